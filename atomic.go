@@ -0,0 +1,83 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StageDir returns the directory a recursive gather into dir writes to, and the function to
+// call with the result of the gather, whose error it returns. With WithAtomicDir, the
+// directory is a new temporary sibling of dir, which the function renames to dir on success,
+// replacing any previous dir, and removes on failure. Otherwise, or when writing to a custom
+// destination, the directory is dir itself.
+func (o *Options) StageDir(dir string) (string, func(error) error, error) {
+	if !o.AtomicDir || o.Destination != nil {
+		return dir, func(err error) error { return err }, nil
+	}
+
+	dir = filepath.Clean(dir)
+	if err := o.MkdirAll(filepath.Dir(dir)); err != nil {
+		return "", nil, fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := os.Chmod(staging, o.DirPermission()); err != nil {
+		_ = os.RemoveAll(staging)
+		return "", nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	finish := func(err error) error {
+		if err == nil {
+			err = ReplacePath(staging, dir)
+		}
+		if err != nil {
+			err = o.discardPartial(staging, dir+PartialSuffix, err)
+		}
+		return err
+	}
+	return staging, finish, nil
+}
+
+// ReplacePath renames staging, a file or a directory, to dir. An existing dir is moved aside
+// first, and removed once staging is in place or moved back if staging cannot be renamed. In
+// between, dir is briefly missing, but never partially updated.
+func ReplacePath(staging, dir string) error {
+	old := ""
+	if _, err := os.Lstat(dir); err == nil {
+		old = staging + ".old"
+		if err := os.Rename(dir, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", dir, err)
+		}
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		if old != "" {
+			_ = os.Rename(old, dir)
+		}
+		return fmt.Errorf("failed to move %s into place: %w", dir, err)
+	}
+	if old != "" {
+		if err := os.RemoveAll(old); err != nil {
+			return fmt.Errorf("failed to remove the previous %s: %w", dir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStageDir tests that the staging directory replaces the destination only on success.
+func TestStageDir(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	o := NewOptions(WithAtomicDir(true))
+
+	// A failed gather keeps the previous directory
+	staging, finish, err := o.StageDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging == dir || filepath.Dir(staging) != parent {
+		t.Fatalf("Expected a sibling staging directory, but got %s", staging)
+	}
+	if err := os.WriteFile(filepath.Join(staging, "new.txt"), []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("failed")
+	if err := finish(failure); err != failure {
+		t.Errorf("Expected the gather error, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); err != nil {
+		t.Errorf("Expected the previous directory to be kept, but got: %v", err)
+	}
+
+	// A successful gather replaces it
+	staging, finish, err = o.StageDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, "new.txt"), []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := finish(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); err != nil {
+		t.Errorf("Expected the new directory in place, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected the previous directory to be replaced, but got: %v", err)
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the destination to be left, but got %d entries", len(entries))
+	}
+}
+
+// TestStageDir_Disabled tests that the destination itself is written without WithAtomicDir.
+func TestStageDir_Disabled(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	staging, finish, err := NewOptions().StageDir(dir)
+	if err != nil || staging != dir {
+		t.Fatalf("Expected %s, but got %s, %v", dir, staging, err)
+	}
+	failure := errors.New("failed")
+	if err := finish(failure); err != failure {
+		t.Errorf("Expected the gather error, but got: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"fmt"
+	"io"
+)
+
+// MinBufferSize and MaxBufferSize bound the buffer size set with WithBufferSize.
+const (
+	MinBufferSize = 4 << 10
+	MaxBufferSize = 64 << 20
+)
+
+// CheckBufferSize returns an error if the buffer size set with WithBufferSize is outside of
+// MinBufferSize and MaxBufferSize. The default buffer size, given as zero, always passes.
+func (o *Options) CheckBufferSize() error {
+	if o.BufferSize != 0 && (o.BufferSize < MinBufferSize || o.BufferSize > MaxBufferSize) {
+		return fmt.Errorf("buffer size of %d bytes is not between %d and %d bytes", o.BufferSize, MinBufferSize, MaxBufferSize)
+	}
+	return nil
+}
+
+// BufferReader returns a reader of r that copies itself in chunks of the buffer size set with
+// WithBufferSize when given to io.Copy, as the savers do, rather than in the default chunks of
+// 32 KiB. Without a buffer size r is returned as is, which keeps the copies the operating
+// system can make without a buffer, such as between two files.
+func (o *Options) BufferReader(r io.Reader) io.Reader {
+	if o.BufferSize == 0 {
+		return r
+	}
+	return &bufferReader{r: r, size: o.BufferSize}
+}
+
+// bufferReader implements io.WriterTo, which io.Copy prefers to any other way of copying.
+type bufferReader struct {
+	r    io.Reader
+	size int
+}
+
+func (br *bufferReader) Read(p []byte) (int, error) {
+	return br.r.Read(p)
+}
+
+// WriteTo copies the data to w with a buffer of the configured size. The reader and writer
+// are wrapped so that neither can bypass the buffer.
+func (br *bufferReader) WriteTo(w io.Writer) (int64, error) {
+	return io.CopyBuffer(struct{ io.Writer }{w}, struct{ io.Reader }{br.r}, make([]byte, br.size))
+}
@@ -0,0 +1,105 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chunkWriter records the data written to it and the size of the largest write.
+type chunkWriter struct {
+	buf     bytes.Buffer
+	largest int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.largest = max(w.largest, len(p))
+	return w.buf.Write(p)
+}
+
+// TestBufferReader tests that data is copied in chunks of the buffer size.
+func TestBufferReader(t *testing.T) {
+	data := strings.Repeat("x", 1<<20)
+
+	testCases := []struct {
+		size    int
+		largest int
+	}{
+		{size: 0, largest: 32 << 10},
+		{size: MinBufferSize, largest: MinBufferSize},
+		{size: 256 << 10, largest: 256 << 10},
+	}
+
+	for _, tc := range testCases {
+		var w chunkWriter
+		o := NewOptions(WithBufferSize(tc.size))
+		// The reader is wrapped so that io.Copy cannot use its WriterTo
+		n, err := io.Copy(&w, o.BufferReader(struct{ io.Reader }{strings.NewReader(data)}))
+		if err != nil || n != int64(len(data)) || w.buf.String() != data {
+			t.Errorf("size %d: expected the data to be copied, but got %d bytes, %v", tc.size, n, err)
+		}
+		if w.largest != tc.largest {
+			t.Errorf("size %d: expected writes of %d bytes, but got %d", tc.size, tc.largest, w.largest)
+		}
+	}
+}
+
+// TestCheckBufferSize tests that buffer sizes outside the bounds are refused.
+func TestCheckBufferSize(t *testing.T) {
+	for _, size := range []int{0, MinBufferSize, 1 << 20, MaxBufferSize} {
+		if err := NewOptions(WithBufferSize(size)).CheckBufferSize(); err != nil {
+			t.Errorf("Expected a buffer size of %d to be allowed, but got: %v", size, err)
+		}
+	}
+	for _, size := range []int{-1, 1, MinBufferSize - 1, MaxBufferSize + 1} {
+		if err := NewOptions(WithBufferSize(size)).CheckBufferSize(); err == nil {
+			t.Errorf("Expected a buffer size of %d to be refused", size)
+		}
+	}
+}
+
+// BenchmarkBufferReader measures copying 64 MiB to a file with different buffer sizes, such
+// as with go test -bench BufferReader. Buffers beyond a few hundred KiB rarely help local
+// copies, but cut the number of reads of a slow network connection.
+func BenchmarkBufferReader(b *testing.B) {
+	data := bytes.Repeat([]byte("x"), 64<<20)
+	for _, size := range []int{0, MinBufferSize, 256 << 10, 4 << 20} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			o := NewOptions(WithBufferSize(size))
+			path := filepath.Join(b.TempDir(), "data")
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				f, err := os.Create(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := io.Copy(f, o.BufferReader(struct{ io.Reader }{bytes.NewReader(data)})); err != nil {
+					b.Fatal(err)
+				}
+				if err := f.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when gathered data does not match the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrSignatureInvalid is returned when a gathered file does not match the detached signature
+// set with WithSignatureFromURL.
+var ErrSignatureInvalid = errors.New("invalid signature")
+
+// checksumAlgorithms maps the supported checksum algorithms to their hash constructors.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// trailerChecksums maps the HTTP trailers carrying the hex encoded checksum of a response body,
+// verified with WithHTTPTrailerChecksum, to their checksum algorithm.
+var trailerChecksums = map[string]string{
+	"X-Checksum-Sha256": "sha256",
+	"X-Checksum-Sha512": "sha512",
+}
+
+// ChecksumVerifier hashes gathered data and compares it with the checksum set with WithChecksum,
+// and with the checksum trailers of the response, if any, with WithHTTPTrailerChecksum.
+// A nil ChecksumVerifier accepts any data.
+type ChecksumVerifier struct {
+	algorithm string
+	expected  []byte
+	hash      hash.Hash
+
+	// resp is the response whose checksum trailers are verified, and trailerHashes the
+	// hashes of its body, by algorithm.
+	resp          *http.Response
+	trailerHashes map[string]hash.Hash
+}
+
+// ChecksumVerifier returns a verifier for the checksum set with WithChecksum, or nil if none is set.
+// It returns an error if the checksum is malformed, so it can be rejected before downloading.
+func (o *Options) ChecksumVerifier() (*ChecksumVerifier, error) {
+	if o.Checksum == "" {
+		return nil, nil
+	}
+	algorithm, sum, ok := strings.Cut(o.Checksum, ":")
+	if !ok {
+		algorithm, sum = "sha256", o.Checksum
+	}
+	newHash, ok := checksumAlgorithms[strings.ToLower(algorithm)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+	expected, err := hex.DecodeString(sum)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum %q: %w", o.Checksum, err)
+	}
+	h := newHash()
+	if len(expected) != h.Size() {
+		return nil, fmt.Errorf("invalid checksum %q: expected %d bytes, got %d", o.Checksum, h.Size(), len(expected))
+	}
+	return &ChecksumVerifier{algorithm: strings.ToLower(algorithm), expected: expected, hash: h}, nil
+}
+
+// TrailerChecksumVerifier returns v extended to verify the data read against the checksum
+// trailers of resp, such as X-Checksum-Sha256, when WithHTTPTrailerChecksum is set, or v as
+// is otherwise. Trailers are only received after the body, so they are checked by Verify once
+// the whole body is read. Responses without checksum trailers are not checked against them.
+func (o *Options) TrailerChecksumVerifier(v *ChecksumVerifier, resp *http.Response) *ChecksumVerifier {
+	if !o.HTTPTrailerChecksum {
+		return v
+	}
+	if v == nil {
+		v = &ChecksumVerifier{}
+	}
+	v.resp = resp
+	v.trailerHashes = make(map[string]hash.Hash, len(trailerChecksums))
+	for _, algorithm := range trailerChecksums {
+		v.trailerHashes[algorithm] = checksumAlgorithms[algorithm]()
+	}
+	return v
+}
+
+// Reader returns a reader hashing the data read from r.
+func (v *ChecksumVerifier) Reader(r io.Reader) io.Reader {
+	if v == nil {
+		return r
+	}
+	writers := make([]io.Writer, 0, 1+len(v.trailerHashes))
+	if v.hash != nil {
+		writers = append(writers, v.hash)
+	}
+	for _, h := range v.trailerHashes {
+		writers = append(writers, h)
+	}
+	return io.TeeReader(r, io.MultiWriter(writers...))
+}
+
+// checksum returns the expected checksum in the form "algorithm:hex".
+func (v *ChecksumVerifier) checksum() string {
+	return v.algorithm + ":" + hex.EncodeToString(v.expected)
+}
+
+// Verify returns an error wrapping ErrChecksumMismatch if the data read does not match the checksum.
+func (v *ChecksumVerifier) Verify() error {
+	if v == nil {
+		return nil
+	}
+	if v.hash != nil {
+		if actual := v.hash.Sum(nil); !bytes.Equal(actual, v.expected) {
+			return fmt.Errorf("%w: expected %s:%x, got %s:%x", ErrChecksumMismatch, v.algorithm, v.expected, v.algorithm, actual)
+		}
+	}
+	return v.verifyTrailers()
+}
+
+// verifyTrailers returns an error wrapping ErrChecksumMismatch if the data read does not
+// match a checksum trailer of the response.
+func (v *ChecksumVerifier) verifyTrailers() error {
+	if v.resp == nil {
+		return nil
+	}
+	names := make([]string, 0, len(trailerChecksums))
+	for name := range trailerChecksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := strings.TrimSpace(v.resp.Trailer.Get(name))
+		if value == "" {
+			continue
+		}
+		algorithm := trailerChecksums[name]
+		expected, err := hex.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("%w: invalid %s trailer %q: %v", ErrChecksumMismatch, name, value, err)
+		}
+		if actual := v.trailerHashes[algorithm].Sum(nil); !bytes.Equal(actual, expected) {
+			return fmt.Errorf("%w: %s trailer expected %s:%x, got %s:%x", ErrChecksumMismatch, name, algorithm, expected, algorithm, actual)
+		}
+	}
+	return nil
+}
+
+// HashOnlyHash returns a new hash of the algorithm set with WithHashOnly, or nil if hash-only
+// mode is not set. It returns an error if the algorithm is not supported.
+func (o *Options) HashOnlyHash() (hash.Hash, error) {
+	if o.HashOnly == "" {
+		return nil, nil
+	}
+	newHash, ok := checksumAlgorithms[strings.ToLower(o.HashOnly)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", o.HashOnly)
+	}
+	return newHash(), nil
+}
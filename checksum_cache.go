@@ -0,0 +1,153 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// checksumEntry is an entry of the checksum cache: the checksum a file matched when it had the
+// given size and modification time.
+type checksumEntry struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	ModTime  int64  `json:"mtime"`
+	Checksum string `json:"checksum"`
+}
+
+// VerifyFile returns an error wrapping ErrChecksumMismatch if the file at path does not match
+// the checksum set with WithChecksum; without a checksum any file passes. With
+// WithChecksumCache, a file that matched the checksum before is trusted without being hashed
+// again, unless its size or modification time changed since.
+func (o *Options) VerifyFile(path string) error {
+	verifier, err := o.ChecksumVerifier()
+	if err != nil || verifier == nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", path, err)
+	}
+	if o.cachedChecksum(path, info) == verifier.checksum() {
+		return nil
+	}
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(io.Discard, verifier.Reader(f)); err != nil {
+		return fmt.Errorf("failed to verify %s: %w", path, err)
+	}
+	if err := verifier.Verify(); err != nil {
+		return err
+	}
+	o.cacheChecksum(path, info, verifier.checksum())
+	return nil
+}
+
+// RecordChecksum records in the checksum cache, if any, that the file at path matches the
+// checksum set with WithChecksum, as verified by a gatherer while writing it, so that
+// VerifyFile trusts it until it changes. Files rewritten by a transform are not recorded.
+func (o *Options) RecordChecksum(path string) {
+	if o.ChecksumCache == "" || o.TransformFunc != nil {
+		return
+	}
+	verifier, err := o.ChecksumVerifier()
+	if err != nil || verifier == nil {
+		return
+	}
+	if info, err := os.Stat(path); err == nil {
+		o.cacheChecksum(path, info, verifier.checksum())
+	}
+}
+
+// cacheEntryPath returns the path of the cache entry of the file at path, named after the
+// hash of its absolute path.
+func (o *Options) cacheEntryPath(path string) (string, string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", false
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(o.ChecksumCache, hex.EncodeToString(sum[:])+".json"), abs, true
+}
+
+// cachedChecksum returns the checksum cached for the file at path, or an empty string if
+// there is none or the file changed since it was cached.
+func (o *Options) cachedChecksum(path string, info os.FileInfo) string {
+	if o.ChecksumCache == "" {
+		return ""
+	}
+	entryPath, abs, ok := o.cacheEntryPath(path)
+	if !ok {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Clean(entryPath))
+	if err != nil {
+		return ""
+	}
+	var entry checksumEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return ""
+	}
+	if entry.Path != abs || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		return ""
+	}
+	return entry.Checksum
+}
+
+// cacheChecksum records the checksum of the file at path, described by info. The cache is
+// only an optimization, so failing to write it is not an error.
+func (o *Options) cacheChecksum(path string, info os.FileInfo, checksum string) {
+	if o.ChecksumCache == "" {
+		return
+	}
+	entryPath, abs, ok := o.cacheEntryPath(path)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(checksumEntry{Path: abs, Size: info.Size(), ModTime: info.ModTime().UnixNano(), Checksum: checksum})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(o.ChecksumCache, 0700); err != nil {
+		return
+	}
+	// Entries are replaced by a rename, so that a concurrent reader never sees half an entry
+	f, err := os.CreateTemp(o.ChecksumCache, ".entry-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), entryPath)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestVerifyFile_Cache tests that files verified before are trusted until their size or
+// modification time changes.
+func TestVerifyFile_Cache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(path, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	o := NewOptions(WithChecksum(helloSHA256), WithChecksumCache(filepath.Join(dir, "cache")))
+	if err := o.VerifyFile(path); err != nil {
+		t.Fatalf("Expected the file to match, but got: %v", err)
+	}
+
+	// Content changed behind the cache's back, with the same size and time, is not hashed
+	if err := os.WriteFile(path, []byte("HELLO WORLD"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.VerifyFile(path); err != nil {
+		t.Errorf("Expected the cached checksum to be trusted, but got: %v", err)
+	}
+	if err := NewOptions(WithChecksum(helloSHA256)).VerifyFile(path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch without a cache, but got: %v", err)
+	}
+
+	// A new modification time invalidates the entry
+	if err := os.Chtimes(path, mtime, mtime.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.VerifyFile(path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch once the file changed, but got: %v", err)
+	}
+
+	// Another checksum is not satisfied by the entry of the first
+	if err := os.WriteFile(path, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.VerifyFile(path); err != nil {
+		t.Fatalf("Expected the file to match, but got: %v", err)
+	}
+	other := NewOptions(WithChecksum("sha256:0000000000000000000000000000000000000000000000000000000000000000"), WithChecksumCache(filepath.Join(dir, "cache")))
+	if err := other.VerifyFile(path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch for another checksum, but got: %v", err)
+	}
+}
+
+// TestRecordChecksum tests that files verified while written are recorded, unless transformed.
+func TestRecordChecksum(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(path, []byte("HELLO WORLD"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cache := WithChecksumCache(filepath.Join(dir, "cache"))
+
+	transformed := NewOptions(WithChecksum(helloSHA256), cache, WithTransform(func(path string, content []byte) ([]byte, error) {
+		return content, nil
+	}))
+	transformed.RecordChecksum(path)
+	if err := transformed.VerifyFile(path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected transformed files not to be recorded, but got: %v", err)
+	}
+
+	o := NewOptions(WithChecksum(helloSHA256), cache)
+	o.RecordChecksum(path)
+	if err := o.VerifyFile(path); err != nil {
+		t.Errorf("Expected the recorded checksum to be trusted, but got: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// helloSHA256 is the SHA-256 checksum of "hello world".
+const helloSHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+// TestChecksumVerifier tests that data is verified against the configured checksum.
+func TestChecksumVerifier(t *testing.T) {
+	testCases := []struct {
+		checksum string
+		matches  bool
+	}{
+		{checksum: helloSHA256, matches: true},
+		{checksum: "sha256:" + helloSHA256, matches: true},
+		{checksum: "SHA256:" + strings.ToUpper(helloSHA256), matches: true},
+		{checksum: "sha256:" + strings.Repeat("0", 64), matches: false},
+	}
+
+	for _, tc := range testCases {
+		v, err := NewOptions(WithChecksum(tc.checksum)).ChecksumVerifier()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.checksum, err)
+		}
+		if _, err := io.ReadAll(v.Reader(strings.NewReader("hello world"))); err != nil {
+			t.Fatal(err)
+		}
+		err = v.Verify()
+		if tc.matches && err != nil {
+			t.Errorf("%s: expected the checksum to match, but got: %v", tc.checksum, err)
+		}
+		if !tc.matches && !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("%s: expected ErrChecksumMismatch, but got: %v", tc.checksum, err)
+		}
+	}
+}
+
+// TestChecksumVerifier_Invalid tests that malformed checksums are rejected.
+func TestChecksumVerifier_Invalid(t *testing.T) {
+	for _, checksum := range []string{"md5:" + helloSHA256, "sha256:xyz", "sha512:" + helloSHA256} {
+		if _, err := NewOptions(WithChecksum(checksum)).ChecksumVerifier(); err == nil {
+			t.Errorf("%s: expected an error, but got nil", checksum)
+		}
+	}
+}
+
+// TestChecksumVerifier_None tests that a nil verifier accepts any data.
+func TestChecksumVerifier_None(t *testing.T) {
+	v, err := NewOptions().ChecksumVerifier()
+	if err != nil || v != nil {
+		t.Fatalf("Expected no verifier, but got %v, %v", v, err)
+	}
+	if err := v.Verify(); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+}
+
+// TestTrailerChecksumVerifier tests that data is verified against the checksum trailers of a
+// response, along with the configured checksum.
+func TestTrailerChecksumVerifier(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []Option
+		trailer http.Header
+		matches bool
+	}{
+		{name: "no trailer", matches: true},
+		{name: "matching", trailer: http.Header{"X-Checksum-Sha256": {helloSHA256}}, matches: true},
+		{name: "matching uppercase", trailer: http.Header{"X-Checksum-Sha256": {strings.ToUpper(helloSHA256)}}, matches: true},
+		{name: "mismatch", trailer: http.Header{"X-Checksum-Sha256": {strings.Repeat("0", 64)}}, matches: false},
+		{name: "sha512 mismatch", trailer: http.Header{"X-Checksum-Sha512": {strings.Repeat("0", 128)}}, matches: false},
+		{name: "invalid", trailer: http.Header{"X-Checksum-Sha256": {"xyz"}}, matches: false},
+		{name: "checksum mismatch", opts: []Option{WithChecksum(strings.Repeat("0", 64))}, trailer: http.Header{"X-Checksum-Sha256": {helloSHA256}}, matches: false},
+	}
+
+	for _, tc := range testCases {
+		o := NewOptions(append(tc.opts, WithHTTPTrailerChecksum(true))...)
+		v, err := o.ChecksumVerifier()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		resp := &http.Response{}
+		v = o.TrailerChecksumVerifier(v, resp)
+		if _, err := io.ReadAll(v.Reader(strings.NewReader("hello world"))); err != nil {
+			t.Fatal(err)
+		}
+		// Trailers are received once the body is read
+		resp.Trailer = tc.trailer
+		err = v.Verify()
+		if tc.matches && err != nil {
+			t.Errorf("%s: expected the checksum to match, but got: %v", tc.name, err)
+		}
+		if !tc.matches && !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("%s: expected ErrChecksumMismatch, but got: %v", tc.name, err)
+		}
+	}
+
+	if v := NewOptions().TrailerChecksumVerifier(nil, &http.Response{}); v != nil {
+		t.Errorf("Expected no verifier without WithHTTPTrailerChecksum, but got %v", v)
+	}
+}
+
+// TestHashOnlyHash tests the hash returned for the hash-only algorithms.
+func TestHashOnlyHash(t *testing.T) {
+	hasher, err := NewOptions(WithHashOnly("SHA256")).HashOnlyHash()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	hasher.Write([]byte("hello world"))
+	if got := hex.EncodeToString(hasher.Sum(nil)); got != helloSHA256 {
+		t.Errorf("Expected %s, but got %s", helloSHA256, got)
+	}
+
+	if _, err := NewOptions(WithHashOnly("md5")).HashOnlyHash(); err == nil {
+		t.Errorf("Expected an error for md5, but got nil")
+	}
+	if hasher, err := NewOptions().HashOnlyHash(); hasher != nil || err != nil {
+		t.Errorf("Expected no hash, but got %v, %v", hasher, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientCache holds the transports shared by gathers with WithClientCache, so that their
+// connections and TLS sessions are reused across gathers.
+var clientCache = struct {
+	sync.Mutex
+	entries map[clientCacheKey]*clientCacheEntry
+}{entries: map[clientCacheKey]*clientCacheEntry{}}
+
+// clientCacheKey identifies a cached transport by host and by the transport settings and
+// credentials of the options, so that transports are never shared between credentials.
+type clientCacheKey struct {
+	host     string
+	settings string
+}
+
+// clientCacheEntry is a cached transport and the time it expires.
+type clientCacheEntry struct {
+	transport *http.Transport
+	expires   time.Time
+}
+
+// cachingTransport sends each request with the cached transport for its host.
+type cachingTransport struct {
+	o        *Options
+	settings string
+}
+
+func (t cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.o.cachedTransport(req.URL.Host, t.settings).RoundTrip(req)
+}
+
+// cachedTransport returns the cached transport for host and settings, building it if there
+// is none or it expired. Expired transports are dropped and their idle connections closed;
+// requests still using them complete normally.
+func (o *Options) cachedTransport(host, settings string) *http.Transport {
+	clientCache.Lock()
+	defer clientCache.Unlock()
+
+	now := time.Now()
+	for key, e := range clientCache.entries {
+		if now.After(e.expires) {
+			e.transport.CloseIdleConnections()
+			delete(clientCache.entries, key)
+		}
+	}
+
+	key := clientCacheKey{host: host, settings: settings}
+	if e, ok := clientCache.entries[key]; ok {
+		return e.transport
+	}
+	t := o.newTransport()
+	clientCache.entries[key] = &clientCacheEntry{transport: t, expires: now.Add(o.ClientCacheTTL)}
+	return t
+}
+
+// transportSettings returns a fingerprint of the options that shape a transport, including
+// the credentials: the client certificates, trusted roots, pinned certificates and API token.
+func (o *Options) transportSettings() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "private=%t http2=%t conns=%d headers=%d insecure=%t roots=%p",
+		o.BlockPrivateNetworks, o.DisableHTTP2, o.MaxConnsPerHost, o.MaxResponseHeaderBytes, o.InsecureSkipTLSVerify, o.RootCAs)
+	for _, cert := range o.ClientCertificates {
+		for _, der := range cert.Certificate {
+			sum := sha256.Sum256(der)
+			b.WriteString(" cert=" + hex.EncodeToString(sum[:]))
+		}
+	}
+	fmt.Fprintf(&b, " resolver=%p", o.Resolver)
+	hosts := make([]string, 0, len(o.HostMapping))
+	for host, target := range o.HostMapping {
+		hosts = append(hosts, host+"="+target)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		b.WriteString(" map=" + host)
+	}
+	for _, pin := range o.PinnedCertFingerprints {
+		b.WriteString(" pin=" + normalizeFingerprint(pin))
+	}
+	if o.GitToken != "" {
+		sum := sha256.Sum256([]byte(o.GitToken))
+		b.WriteString(" token=" + hex.EncodeToString(sum[:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestClientCache tests that cached transports are shared per host and transport settings.
+func TestClientCache(t *testing.T) {
+	o := NewOptions(WithClientCache(time.Minute), WithMaxConnsPerHost(2))
+	if _, ok := o.Transport().(cachingTransport); !ok {
+		t.Fatalf("Expected a caching transport, but got %T", o.Transport())
+	}
+	if o.OwnsTransport(o.Transport()) {
+		t.Errorf("Expected cached transports not to be owned by the options")
+	}
+
+	settings := o.transportSettings()
+	first := o.cachedTransport("cache.example.com", settings)
+	other := NewOptions(WithClientCache(time.Minute), WithMaxConnsPerHost(2))
+	if other.cachedTransport("cache.example.com", other.transportSettings()) != first {
+		t.Errorf("Expected the transport to be shared for the same host and settings")
+	}
+	if o.cachedTransport("other.example.com", settings) == first {
+		t.Errorf("Expected a different transport for a different host")
+	}
+
+	for _, opt := range []Option{WithInsecureSkipTLSVerify(true), WithGitToken("secret"), WithMaxConnsPerHost(3), WithPinnedCertFingerprints([]string{"00"})} {
+		scoped := NewOptions(WithClientCache(time.Minute), WithMaxConnsPerHost(2), opt)
+		if scoped.cachedTransport("cache.example.com", scoped.transportSettings()) == first {
+			t.Errorf("Expected a different transport for different settings: %s", scoped.transportSettings())
+		}
+	}
+}
+
+// TestClientCache_Expires tests that cached transports are replaced once they expire.
+func TestClientCache_Expires(t *testing.T) {
+	o := NewOptions(WithClientCache(time.Millisecond), WithMaxConnsPerHost(2))
+	first := o.cachedTransport("expires.example.com", o.transportSettings())
+	time.Sleep(5 * time.Millisecond)
+	if o.cachedTransport("expires.example.com", o.transportSettings()) == first {
+		t.Errorf("Expected an expired transport to be replaced")
+	}
+}
+
+// TestClientCache_NotNeeded tests that the default transport is used when no dedicated one is needed.
+func TestClientCache_NotNeeded(t *testing.T) {
+	if NewOptions(WithClientCache(time.Minute)).Transport() != http.DefaultTransport {
+		t.Errorf("Expected http.DefaultTransport to be used")
+	}
+}
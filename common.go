@@ -16,6 +16,8 @@ const (
 	GitURI URIType = iota
 	HTTPURI
 	FileURI
+	K8sURI
+	WebDAVURI
 	Unknown
 )
 
@@ -23,7 +25,7 @@ var GetHomeDir = os.UserHomeDir
 
 // String returns the string representation of the URLType
 func (t URIType) String() string {
-	return [...]string{"GitURI", "HTTPURI", "FileURI", "Unknown"}[t]
+	return [...]string{"GitURI", "HTTPURI", "FileURI", "K8sURI", "WebDAVURI", "Unknown"}[t]
 }
 
 // ExpandTilde expands a leading tilde in the file path to the user's home directory
@@ -38,44 +40,132 @@ func ExpandTilde(path string) string {
 	return path
 }
 
-// ClassifyURI classifies the input string as a Git URI, HTTP(S) URI, or file path
+// ambiguousURIPattern matches two path segments, such as "org/repo", which may be
+// a GitHub shorthand as well as a relative file path.
+var ambiguousURIPattern = regexp.MustCompile(`^[\w\-]+(\.[\w\-]+)*/[\w\-]+(\.[\w\-]+)*$`)
+
+// ClassifyURI classifies the input string as a Git URI, HTTP(S) URI, or file path.
+// Inputs are checked in the following order:
+//   - the "git::", "file::" and "http::" forcing prefixes; a "file::" path of the form
+//     "/path/to/repo//subdir" is a Git URI when /path/to/repo is a local git repository
+//   - GitHub and GitLab release assets, see ParseReleaseAsset, which are HTTP(S) URIs
+//   - the github.com and gitlab.com shorthands, and the k8s://, webdav://, webdavs://,
+//     dav+http://, dav+https://, git://, ssh:// and git+ssh:// schemes
+//   - file paths starting with "./", "../", "/", "~/", a drive letter or file://,
+//     which are Git URIs when they end with ".git" or name the subdir of a local git
+//     repository as "/path/to/repo//subdir"
+//   - Git URIs, which include two path segments such as "org/repo", and scp-style
+//     URIs such as "git@host:org/repo.git". Like git, scp-style URIs take everything
+//     after the colon as the path, so a port has to be given with ssh://
+//   - HTTP(S) URIs
 func ClassifyURI(input string) (URIType, error) {
+	return ClassifyURIWithOptions(input)
+}
+
+// ClassifyURIWithOptions classifies the input like ClassifyURI, applying the given options.
+// WithDefaultType decides how ambiguous two segment inputs such as "foo/bar" are classified,
+// and WithForceType replaces the classification altogether.
+func ClassifyURIWithOptions(input string, opts ...Option) (URIType, error) {
+	t, _, err := classifyURI(input, NewOptions(opts...))
+	return t, err
+}
+
+// ExplainClassification classifies the input like ClassifyURIWithOptions and returns the
+// type together with a human-readable explanation of the rule that decided it, such as the
+// prefix or pattern that matched or the scheme that was parsed, to debug unexpected
+// classifications. Errors are returned as part of the explanation.
+func ExplainClassification(input string, opts ...Option) (URIType, string) {
+	t, explanation, err := classifyURI(input, NewOptions(opts...))
+	if err != nil {
+		return t, fmt.Sprintf("%s: %v", explanation, err)
+	}
+	return t, explanation
+}
+
+// Regular expression for Git URIs
+var gitURIPattern = regexp.MustCompile(`^(git@[\w\.\-]+:[\w\.\-]+(/[\w\.\-]+)+(\.git)?|https?://[\w\.\-]+/[\w\.\-]+/[\w\.\-]+(\.git)?|git://[\w\.\-]+/[\w\.\-]+/[\w\.\-]+(\.git)?|[\w\.\-]+/[\w\.\-]+/[\w\.\-]+//.*|file://.*\.git|[\w\.\-]+/[\w\.\-]+(\.git)?)$`)
+
+// Regular expression for HTTP URIs (with or without protocol)
+var httpURIPattern = regexp.MustCompile(`^((http://|https://)[\w\-]+(\.[\w\-]+)+.*)$`)
+
+// Regular expression for file paths
+var filePathPattern = regexp.MustCompile(`^(\./|\../|/|[a-zA-Z]:\\|~\/|file://).*`)
+
+// classifyURI classifies the input and explains the rule that decided its type.
+func classifyURI(input string, o *Options) (URIType, string, error) {
+	// A forced type bypasses the rules below, as long as its gatherer can parse the input
+	if o.ForceType != nil {
+		return *o.ForceType, fmt.Sprintf("the type is forced to %s with WithForceType", *o.ForceType), checkForcedType(input, *o.ForceType)
+	}
+
 	// Check for special prefixes first
 	if strings.HasPrefix(input, "git::") {
-		return GitURI, nil
+		return GitURI, `the input has the forcing prefix "git::"`, nil
 	}
 	if strings.HasPrefix(input, "file::") {
-		return FileURI, nil
+		if isLocalGitSubdir(strings.TrimPrefix(input, "file::")) {
+			return GitURI, `the input has the forcing prefix "file::" and names the subdir of a local git repository as "/path/to/repo//subdir"`, nil
+		}
+		return FileURI, `the input has the forcing prefix "file::"`, nil
 	}
 	if strings.HasPrefix(input, "http::") {
-		return HTTPURI, nil
+		return HTTPURI, `the input has the forcing prefix "http::"`, nil
+	}
+
+	// Release assets are downloaded rather than cloned
+	if asset, ok := ParseReleaseAsset(input); ok {
+		return HTTPURI, fmt.Sprintf("the input names the asset %s of a release of %s on %s", asset.Name, asset.Project, asset.Host), nil
 	}
 
 	if strings.HasPrefix(input, "github.com") || strings.HasPrefix(input, "gitlab.com") {
-		return GitURI, nil
+		return GitURI, "the input starts with the github.com or gitlab.com shorthand", nil
+	}
+
+	// Kubernetes ConfigMaps and Secrets
+	if strings.HasPrefix(input, "k8s://") {
+		return K8sURI, `the input has the scheme "k8s"`, nil
+	}
+
+	// WebDAV servers, whose dav+http(s) schemes must not be taken for HTTP(S)
+	if scheme, ok := webDAVScheme(input); ok {
+		return WebDAVURI, fmt.Sprintf("the input has the WebDAV scheme %q", scheme), nil
+	}
+
+	// The git daemon protocol is only used for git repositories
+	if strings.HasPrefix(input, "git://") {
+		return GitURI, `the input has the scheme "git" of the git daemon protocol`, nil
 	}
 
-	// Regular expression for Git URIs
-	gitURIPattern := regexp.MustCompile(`^(git@[\w\.\-]+:[\w\.\-]+/[\w\.\-]+(\.git)?|https?://[\w\.\-]+/[\w\.\-]+/[\w\.\-]+(\.git)?|git://[\w\.\-]+/[\w\.\-]+/[\w\.\-]+(\.git)?|[\w\.\-]+/[\w\.\-]+/[\w\.\-]+//.*|file://.*\.git|[\w\.\-]+/[\w\.\-]+(\.git)?)$`)
-	// Regular expression for HTTP URIs (with or without protocol)
-	httpURIPattern := regexp.MustCompile(`^((http://|https://)[\w\-]+(\.[\w\-]+)+.*)$`)
-	// Regular expression for file paths
-	filePathPattern := regexp.MustCompile(`^(\./|\../|/|[a-zA-Z]:\\|~\/|file://).*`)
+	// So are SSH URLs, which may give a port as in ssh://git@host:2222/org/repo.git
+	if strings.HasPrefix(input, "ssh://") || strings.HasPrefix(input, "git+ssh://") {
+		return GitURI, `the input has the scheme "ssh" or "git+ssh"`, nil
+	}
 
 	// Check if the input matches the file path pattern first
 	if filePathPattern.MatchString(input) {
 		// Expand the tilde in the file path if it exists
 		input = ExpandTilde(input)
-		// Check if the input ends with ".git" to classify as GitURI
+		// Check if the input ends with ".git" or names a subdir of a local repository to classify as GitURI
 		if strings.HasSuffix(input, ".git") {
-			return GitURI, nil
+			return GitURI, fmt.Sprintf("the input matches the file path pattern %s and ends with \".git\"", filePathPattern), nil
+		}
+		if isLocalGitSubdir(input) {
+			return GitURI, fmt.Sprintf("the input matches the file path pattern %s and names the subdir of a local git repository as \"/path/to/repo//subdir\"", filePathPattern), nil
+		}
+		return FileURI, fmt.Sprintf("the input matches the file path pattern %s", filePathPattern), nil
+	}
+
+	// Break the tie between a GitHub shorthand and a relative file path, if asked to
+	if o.DefaultType != nil && ambiguousURIPattern.MatchString(input) && !strings.HasSuffix(input, ".git") {
+		switch *o.DefaultType {
+		case GitURI, FileURI:
+			return *o.DefaultType, fmt.Sprintf("the input matches the ambiguous two segment pattern %s and the default type is %s", ambiguousURIPattern, *o.DefaultType), nil
 		}
-		return FileURI, nil
 	}
 
 	// Check if the input matches the Git URI pattern
 	if gitURIPattern.MatchString(input) {
-		return GitURI, nil
+		return GitURI, fmt.Sprintf("the input matches the Git URI pattern %s", gitURIPattern), nil
 	}
 
 	// Check if the input matches the HTTP URI pattern
@@ -83,22 +173,109 @@ func ClassifyURI(input string) (URIType, error) {
 		// Parse the input as a URI
 		parsedURI, err := url.Parse(input)
 		if err == nil && (parsedURI.Scheme == "http" || parsedURI.Scheme == "https") {
-			return HTTPURI, nil
+			return HTTPURI, fmt.Sprintf("the input matches the HTTP URI pattern %s and has the scheme %q", httpURIPattern, parsedURI.Scheme), nil
 		}
 	}
 
 	// Check for unsupported schemes
 	parsedURI, err := url.Parse(input)
 	if err == nil && parsedURI.Scheme != "" && parsedURI.Scheme != "http" && parsedURI.Scheme != "https" {
-		return Unknown, fmt.Errorf("unsupported source protocol: %s", parsedURI.Scheme)
+		return Unknown, fmt.Sprintf("the input has the scheme %q, which no rule matches", parsedURI.Scheme), fmt.Errorf("unsupported source protocol: %s", parsedURI.Scheme)
 	}
 
 	// Check if the input contains a dot but lacks a valid scheme
 	if strings.Contains(input, ".") {
-		return Unknown, fmt.Errorf("got %s. HTTP(S) URIs require a scheme (http:// or https://)", input)
+		return Unknown, "the input contains a dot but has no scheme, and no rule matches", fmt.Errorf("got %s. HTTP(S) URIs require a scheme (http:// or https://)", input)
+	}
+
+	return Unknown, "no rule matches the input", nil
+}
+
+// checkForcedType checks that the gatherer of the forced type t can parse the input.
+func checkForcedType(input string, t URIType) error {
+	for _, prefix := range []string{"git::", "file::", "http::"} {
+		input = strings.TrimPrefix(input, prefix)
+	}
+	if input == "" {
+		return fmt.Errorf("cannot gather an empty source as %s", t)
+	}
+
+	switch t {
+	case GitURI:
+		if !strings.Contains(input, "://") {
+			// Shorthands, scp-style URIs and local paths
+			return nil
+		}
+		parsedURI, err := url.Parse(input)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s as a Git URI: %w", input, err)
+		}
+		switch parsedURI.Scheme {
+		case "http", "https", "git", "ssh", "git+ssh", "file":
+			return nil
+		}
+		return fmt.Errorf("cannot parse %s as a Git URI: unsupported scheme %q", input, parsedURI.Scheme)
+	case HTTPURI:
+		parsedURI, err := url.Parse(input)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s as an HTTP(S) URI: %w", input, err)
+		}
+		if parsedURI.Scheme != "http" && parsedURI.Scheme != "https" {
+			return fmt.Errorf("cannot parse %s as an HTTP(S) URI: HTTP(S) URIs require a scheme (http:// or https://)", input)
+		}
+		if parsedURI.Host == "" {
+			return fmt.Errorf("cannot parse %s as an HTTP(S) URI: missing host", input)
+		}
+		return nil
+	case FileURI:
+		if strings.Contains(input, "://") && !strings.HasPrefix(input, "file://") {
+			return fmt.Errorf("cannot parse %s as a file path: only the file:// scheme is supported", input)
+		}
+		return nil
+	case K8sURI:
+		if !strings.HasPrefix(input, "k8s://") {
+			return fmt.Errorf("cannot parse %s as a k8s URI: the scheme k8s:// is required", input)
+		}
+		return nil
+	case WebDAVURI:
+		if _, ok := webDAVScheme(input); !ok {
+			return fmt.Errorf("cannot parse %s as a WebDAV URI: one of the schemes webdav://, webdavs://, dav+http:// or dav+https:// is required", input)
+		}
+		return nil
 	}
+	return fmt.Errorf("cannot force the type %s", t)
+}
+
+// webDAVScheme returns the scheme of input if it is one of the WebDAV schemes.
+func webDAVScheme(input string) (string, bool) {
+	for _, scheme := range []string{"webdav", "webdavs", "dav+http", "dav+https"} {
+		if strings.HasPrefix(input, scheme+"://") {
+			return scheme, true
+		}
+	}
+	return "", false
+}
+
+// IsGitRepository reports whether path is the worktree of a git repository or a bare repository.
+func IsGitRepository(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return true
+	}
+	head, err := os.Stat(filepath.Join(path, "HEAD"))
+	if err != nil || head.IsDir() {
+		return false
+	}
+	objects, err := os.Stat(filepath.Join(path, "objects"))
+	return err == nil && objects.IsDir()
+}
 
-	return Unknown, nil
+// isLocalGitSubdir reports whether the file path input has the form "/path/to/repo//subdir"
+// with /path/to/repo a local git repository, which is exported from the repository at HEAD
+// rather than copied from the filesystem.
+func isLocalGitSubdir(input string) bool {
+	path := ExpandTilde(strings.TrimPrefix(input, "file://"))
+	repo, subdir, ok := strings.Cut(path, "//")
+	return ok && repo != "" && subdir != "" && IsGitRepository(repo)
 }
 
 // ValidateFileDestination validates the d1estination path for saving files
@@ -0,0 +1,66 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ErrUnexpectedContentType is returned when a response has a content type that is not expected.
+var ErrUnexpectedContentType = errors.New("unexpected content type")
+
+// sniffLen is the number of bytes considered by http.DetectContentType.
+const sniffLen = 512
+
+// DetectContentType detects the content type of the data in r from its first 512 bytes
+// using http.DetectContentType. It returns the detected type together with a reader that
+// yields all of the data of r, including the bytes consumed while detecting.
+func DetectContentType(r io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", br, err
+	}
+	return http.DetectContentType(head), br, nil
+}
+
+// CheckContentType checks the media type of the Content-Type header value against the
+// content types expected with WithExpectContentType, which may use wildcards such as
+// "application/*". Any content type is accepted if none are expected.
+func (o *Options) CheckContentType(contentType string) error {
+	if len(o.ExpectContentTypes) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(contentType)
+	}
+	mediaType = strings.ToLower(mediaType)
+	for _, pattern := range o.ExpectContentTypes {
+		if ok, _ := path.Match(strings.ToLower(pattern), mediaType); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is not one of %s", ErrUnexpectedContentType, contentType, strings.Join(o.ExpectContentTypes, ", "))
+}
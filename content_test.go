@@ -0,0 +1,77 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestDetectContentType tests that the content type is detected without losing data.
+func TestDetectContentType(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "<html><body>hello</body></html>", expected: "text/html; charset=utf-8"},
+		{input: "\x1f\x8b\x08\x00\x00\x00\x00\x00", expected: "application/x-gzip"},
+		{input: strings.Repeat("a", 1024), expected: "text/plain; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		actual, r, err := DetectContentType(strings.NewReader(tc.input))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if actual != tc.expected {
+			t.Errorf("Expected DetectContentType to return %s, but got %s", tc.expected, actual)
+		}
+		content, _ := io.ReadAll(r)
+		if string(content) != tc.input {
+			t.Errorf("Expected the reader to return the full input, but got %d bytes", len(content))
+		}
+	}
+}
+
+// TestCheckContentType tests the content types accepted by WithExpectContentType.
+func TestCheckContentType(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		expected    []string
+		allowed     bool
+	}{
+		{contentType: "text/html", allowed: true},
+		{contentType: "application/gzip", expected: []string{"application/gzip"}, allowed: true},
+		{contentType: "Application/GZIP", expected: []string{"application/gzip"}, allowed: true},
+		{contentType: "application/json; charset=utf-8", expected: []string{"application/*"}, allowed: true},
+		{contentType: "text/plain", expected: []string{"application/*", "text/plain"}, allowed: true},
+		{contentType: "text/html; charset=utf-8", expected: []string{"application/*"}},
+		{contentType: "", expected: []string{"application/gzip"}},
+	}
+
+	for _, tc := range testCases {
+		err := NewOptions(WithExpectContentType(tc.expected)).CheckContentType(tc.contentType)
+		if tc.allowed && err != nil {
+			t.Errorf("Expected %q to be allowed by %v, but got: %v", tc.contentType, tc.expected, err)
+		}
+		if !tc.allowed && !errors.Is(err, ErrUnexpectedContentType) {
+			t.Errorf("Expected ErrUnexpectedContentType for %q, but got: %v", tc.contentType, err)
+		}
+	}
+}
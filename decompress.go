@@ -0,0 +1,217 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// ErrUnknownCompression is returned when WithDecompress is set and the data is not in a
+// supported compression format.
+var ErrUnknownCompression = errors.New("unknown compression format")
+
+// compression describes a compression format recognized by its magic bytes.
+type compression struct {
+	name       string
+	magic      []byte
+	extensions []string
+	// shorthands are the extensions of tar archives compressed with the format, such as
+	// ".tgz", which are named ".tar" once decompressed.
+	shorthands []string
+	reader     func(r io.Reader) (io.ReadCloser, error)
+}
+
+// compressions lists the formats supported by WithDecompress.
+var compressions = []compression{
+	{
+		name:       "gzip",
+		magic:      []byte{0x1f, 0x8b},
+		extensions: []string{".gz", ".gzip"},
+		shorthands: []string{".tgz"},
+		reader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:       "bzip2",
+		magic:      []byte("BZh"),
+		extensions: []string{".bz2", ".bzip2"},
+		shorthands: []string{".tbz", ".tbz2"},
+		reader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		name:       "xz",
+		magic:      []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		extensions: []string{".xz"},
+		shorthands: []string{".txz"},
+		reader: func(r io.Reader) (io.ReadCloser, error) {
+			xr, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(xr), nil
+		},
+	},
+	{
+		name:       "zstd",
+		magic:      []byte{0x28, 0xb5, 0x2f, 0xfd},
+		extensions: []string{".zst", ".zstd"},
+		shorthands: []string{".tzst"},
+		reader: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	},
+}
+
+// DecompressReader returns a reader of the decompressed data of r, which is written to path,
+// and the path to write the decompressed data to: path without its compression extension,
+// unless WithDecompressedName or WithKeepCompressionExtension is set. When WithDecompress is
+// not set, r and path are returned as is. The format is detected from the magic bytes of the
+// data, and an error wrapping ErrUnknownCompression is returned when it is not supported.
+// With WithSniffCompression, data in no supported format is returned as is instead, and a
+// warning is logged when the extension of path disagrees with the format detected.
+// The returned reader must be closed.
+func (o *Options) DecompressReader(r io.Reader, path string) (io.ReadCloser, string, error) {
+	if !o.Decompress && !o.SniffCompression {
+		return io.NopCloser(r), path, nil
+	}
+
+	br := bufio.NewReader(r)
+	header, err := br.Peek(6)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", fmt.Errorf("failed to read compression header: %w", err)
+	}
+
+	for _, c := range compressions {
+		if !bytes.HasPrefix(header, c.magic) {
+			continue
+		}
+		if o.SniffCompression && trimCompressionExtension(path, c) == path {
+			o.Log(context.Background(), slog.LevelWarn, "data is compressed, whatever its extension", "path", path, "format", c.name)
+		}
+		decompressedPath, err := o.decompressedPath(path, c)
+		if err != nil {
+			return nil, "", err
+		}
+		dr, err := c.reader(br)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decompress %s data: %w", c.name, err)
+		}
+		return dr, decompressedPath, nil
+	}
+	if o.SniffCompression {
+		if c, ok := compressionExtension(path); ok {
+			o.Log(context.Background(), slog.LevelWarn, "data is not compressed, whatever its extension", "path", path, "format", c.name)
+		}
+		return io.NopCloser(br), path, nil
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrUnknownCompression, path)
+}
+
+// compressionExtension returns the compression format whose extension or shorthand path has.
+func compressionExtension(path string) (compression, bool) {
+	for _, c := range compressions {
+		if trimCompressionExtension(path, c) != path {
+			return c, true
+		}
+	}
+	return compression{}, false
+}
+
+// IsGzipSingleFile reports whether name is that of a single gzip-compressed file, ending with
+// ".gz" but not with ".tar.gz", the extension of a compressed tar archive.
+func IsGzipSingleFile(name string) bool {
+	ext := filepath.Ext(name)
+	return strings.EqualFold(ext, ".gz") && !strings.EqualFold(filepath.Ext(strings.TrimSuffix(name, ext)), ".tar")
+}
+
+// GunzipReader returns a reader of the data of r, which is written to path, decompressed if
+// it is compressed with gzip, and the path to write it to: path without its ".gz" extension,
+// unless WithDecompressedName or WithKeepCompressionExtension is set. Data that is not
+// compressed with gzip is returned as is, with a warning. It serves WithGunzipSingleFile,
+// whatever the options of WithDecompress. The returned reader must be closed.
+func (o *Options) GunzipReader(r io.Reader, path string) (io.ReadCloser, string, error) {
+	gz := compressions[0]
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(gz.magic))
+	if err != nil && err != io.EOF {
+		return nil, "", fmt.Errorf("failed to read compression header: %w", err)
+	}
+	if !bytes.HasPrefix(header, gz.magic) {
+		o.Log(context.Background(), slog.LevelWarn, "data is not compressed with gzip, whatever its extension", "path", path)
+		return io.NopCloser(br), path, nil
+	}
+	decompressedPath, err := o.decompressedPath(path, gz)
+	if err != nil {
+		return nil, "", err
+	}
+	dr, err := gz.reader(br)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decompress %s data: %w", gz.name, err)
+	}
+	return dr, decompressedPath, nil
+}
+
+// decompressedPath returns the path the data of path, compressed with c, is decompressed to.
+func (o *Options) decompressedPath(path string, c compression) (string, error) {
+	switch name := o.DecompressedName; {
+	case name != "":
+		if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return "", fmt.Errorf("invalid decompressed name %q", name)
+		}
+		return filepath.Join(filepath.Dir(path), name), nil
+	case o.KeepCompressionExtension:
+		return path, nil
+	}
+	return trimCompressionExtension(path, c), nil
+}
+
+// trimCompressionExtension removes the extension of path if it is one of the extensions of c,
+// and replaces a shorthand of c such as ".tgz" with ".tar".
+func trimCompressionExtension(path string, c compression) string {
+	ext := filepath.Ext(path)
+	for _, e := range c.extensions {
+		if strings.EqualFold(ext, e) {
+			return strings.TrimSuffix(path, ext)
+		}
+	}
+	for _, e := range c.shorthands {
+		if strings.EqualFold(ext, e) {
+			return strings.TrimSuffix(path, ext) + ".tar"
+		}
+	}
+	return path
+}
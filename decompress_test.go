@@ -0,0 +1,250 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// bzip2Hello is "hello world" compressed with bzip2.
+const bzip2Hello = "425a683931415926535944f7137800000191804000064490802000220334843021b68154278bb9229c2848227b89bc00"
+
+// compress returns "hello world" compressed in the given format.
+func compress(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch format {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "bzip2":
+		data, err := hex.DecodeString(bzip2Hello)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	case "xz":
+		w, err = xz.NewWriter(&buf)
+	case "zstd":
+		w, err = zstd.NewWriter(&buf)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("hello world")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestDecompressReader tests that every supported format is detected by its magic bytes.
+func TestDecompressReader(t *testing.T) {
+	testCases := []struct {
+		format   string
+		path     string
+		expected string
+	}{
+		{format: "gzip", path: "/tmp/foo.txt.gz", expected: "/tmp/foo.txt"},
+		{format: "bzip2", path: "/tmp/foo.txt.bz2", expected: "/tmp/foo.txt"},
+		{format: "xz", path: "/tmp/foo.txt.xz", expected: "/tmp/foo.txt"},
+		{format: "zstd", path: "/tmp/foo.txt.zst", expected: "/tmp/foo.txt"},
+		{format: "gzip", path: "/tmp/foo.tgz", expected: "/tmp/foo.tar"},
+		{format: "bzip2", path: "/tmp/foo.tbz2", expected: "/tmp/foo.tar"},
+		{format: "xz", path: "/tmp/foo.txz", expected: "/tmp/foo.tar"},
+		{format: "zstd", path: "/tmp/foo.tzst", expected: "/tmp/foo.tar"},
+		{format: "gzip", path: "/tmp/download", expected: "/tmp/download"},
+		{format: "zstd", path: "/tmp/foo.txt.gz", expected: "/tmp/foo.txt.gz"},
+	}
+
+	o := NewOptions(WithDecompress(true))
+	for _, tc := range testCases {
+		r, path, err := o.DecompressReader(bytes.NewReader(compress(t, tc.format)), tc.path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.format, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.format, err)
+		}
+		if string(data) != "hello world" {
+			t.Errorf("%s: expected decompressed data, but got %q", tc.format, data)
+		}
+		if path != tc.expected {
+			t.Errorf("%s: expected path %s, but got %s", tc.format, tc.expected, path)
+		}
+	}
+}
+
+// TestDecompressReader_Unknown tests that data in an unknown format is rejected.
+func TestDecompressReader_Unknown(t *testing.T) {
+	_, _, err := NewOptions(WithDecompress(true)).DecompressReader(strings.NewReader("plain text"), "/tmp/foo.gz")
+	if !errors.Is(err, ErrUnknownCompression) {
+		t.Errorf("Expected ErrUnknownCompression, but got: %v", err)
+	}
+}
+
+// TestDecompressReader_Disabled tests that the data is left as is without WithDecompress.
+func TestDecompressReader_Disabled(t *testing.T) {
+	r, path, err := NewOptions().DecompressReader(strings.NewReader("plain text"), "/tmp/foo.gz")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	if string(data) != "plain text" || path != "/tmp/foo.gz" {
+		t.Errorf("Expected the data and path to be unchanged, but got %q and %s", data, path)
+	}
+}
+
+// TestDecompressReader_Name tests that the name of the decompressed file can be chosen.
+func TestDecompressReader_Name(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []Option
+		path     string
+		expected string
+		err      bool
+	}{
+		{name: "decompressed name", opts: []Option{WithDecompressedName("bar.txt")}, path: "/tmp/foo.txt.gz", expected: "/tmp/bar.txt"},
+		{name: "keep extension", opts: []Option{WithKeepCompressionExtension(true)}, path: "/tmp/foo.tgz", expected: "/tmp/foo.tgz"},
+		{name: "name wins", opts: []Option{WithKeepCompressionExtension(true), WithDecompressedName("bar")}, path: "/tmp/foo.gz", expected: "/tmp/bar"},
+		{name: "trimmed", opts: []Option{WithKeepCompressionExtension(false)}, path: "/tmp/foo.txt.gz", expected: "/tmp/foo.txt"},
+		{name: "separator", opts: []Option{WithDecompressedName("../bar")}, path: "/tmp/foo.gz", err: true},
+		{name: "dot dot", opts: []Option{WithDecompressedName("..")}, path: "/tmp/foo.gz", err: true},
+	}
+
+	for _, tc := range testCases {
+		o := NewOptions(append(tc.opts, WithDecompress(true))...)
+		r, path, err := o.DecompressReader(bytes.NewReader(compress(t, "gzip")), tc.path)
+		if tc.err {
+			if err == nil {
+				r.Close()
+				t.Errorf("%s: expected an error, but got path %s", tc.name, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		r.Close()
+		if path != tc.expected {
+			t.Errorf("%s: expected path %s, but got %s", tc.name, tc.expected, path)
+		}
+	}
+}
+
+// TestDecompressReader_Sniff tests that WithSniffCompression decompresses by the magic bytes
+// alone, leaves uncompressed data as is, and warns when the extension disagrees.
+func TestDecompressReader_Sniff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		path     string
+		expected string
+		warning  bool
+	}{
+		{name: "compressed", data: compress(t, "gzip"), path: "/tmp/foo.txt.gz", expected: "/tmp/foo.txt"},
+		{name: "compressed without extension", data: compress(t, "gzip"), path: "/tmp/foo.json", expected: "/tmp/foo.json", warning: true},
+		{name: "other format", data: compress(t, "zstd"), path: "/tmp/foo.gz", expected: "/tmp/foo.gz", warning: true},
+		{name: "not compressed", data: []byte("hello world"), path: "/tmp/foo.txt.gz", expected: "/tmp/foo.txt.gz", warning: true},
+		{name: "plain", data: []byte("hello world"), path: "/tmp/foo.txt", expected: "/tmp/foo.txt"},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		o := NewOptions(WithSniffCompression(true), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+		r, path, err := o.DecompressReader(bytes.NewReader(tc.data), tc.path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil || string(data) != "hello world" {
+			t.Errorf("%s: expected the data to be \"hello world\", but got %q: %v", tc.name, data, err)
+		}
+		if path != tc.expected {
+			t.Errorf("%s: expected path %s, but got %s", tc.name, tc.expected, path)
+		}
+		if warned := strings.Contains(buf.String(), "level=WARN"); warned != tc.warning {
+			t.Errorf("%s: expected a warning %t, but got: %q", tc.name, tc.warning, buf.String())
+		}
+	}
+}
+
+// TestIsGzipSingleFile tests that single gzip files are told apart from compressed tar archives.
+func TestIsGzipSingleFile(t *testing.T) {
+	testCases := map[string]bool{
+		"file.json.gz":   true,
+		"FILE.JSON.GZ":   true,
+		"file.gz":        true,
+		"bundle.tar.gz":  false,
+		"bundle.TAR.gz":  false,
+		"bundle.tgz":     false,
+		"file.json":      false,
+		"file.json.gzip": false,
+	}
+	for name, expected := range testCases {
+		if got := IsGzipSingleFile(name); got != expected {
+			t.Errorf("%s: expected %t, but got %t", name, expected, got)
+		}
+	}
+}
+
+// TestGunzipReader tests that gzip data is decompressed and other data left as is.
+func TestGunzipReader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []Option
+		data     []byte
+		content  string
+		expected string
+	}{
+		{name: "gzip", data: compress(t, "gzip"), content: "hello world", expected: "/tmp/foo.txt"},
+		{name: "kept extension", opts: []Option{WithKeepCompressionExtension(true)}, data: compress(t, "gzip"), content: "hello world", expected: "/tmp/foo.txt.gz"},
+		{name: "other format", data: compress(t, "zstd"), content: string(compress(t, "zstd")), expected: "/tmp/foo.txt.gz"},
+		{name: "plain", data: []byte("hello world"), content: "hello world", expected: "/tmp/foo.txt.gz"},
+	}
+
+	for _, tc := range testCases {
+		r, path, err := NewOptions(tc.opts...).GunzipReader(bytes.NewReader(tc.data), "/tmp/foo.txt.gz")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if path != tc.expected {
+			t.Errorf("%s: expected path %s, but got %s", tc.name, tc.expected, path)
+		}
+		if string(data) != tc.content {
+			t.Errorf("%s: expected %q, but got %q", tc.name, tc.content, data)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxDirDepth is the deepest an entry may lie below the destination of a recursive
+// gather, unless set otherwise with WithMaxDirDepth.
+const DefaultMaxDirDepth = 128
+
+// ErrMaxDepthExceeded is returned when an entry lies deeper below the destination than the
+// depth set with WithMaxDirDepth.
+var ErrMaxDepthExceeded = errors.New("maximum directory depth exceeded")
+
+// CheckDirDepth returns ErrMaxDepthExceeded if rel, the path of an entry relative to the
+// destination, has more elements than the maximum depth. Both slashes and the separator of the
+// platform are accepted.
+func (o *Options) CheckDirDepth(rel string) error {
+	max := o.MaxDirDepth
+	if max == 0 {
+		max = DefaultMaxDirDepth
+	}
+	if max < 0 {
+		return nil
+	}
+	clean := strings.Trim(path.Clean(filepath.ToSlash(rel)), "/")
+	if clean == "." || clean == "" {
+		return nil
+	}
+	if depth := strings.Count(clean, "/") + 1; depth > max {
+		return fmt.Errorf("%w: %s is %d levels deep, more than %d", ErrMaxDepthExceeded, rel, depth, max)
+	}
+	return nil
+}
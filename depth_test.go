@@ -0,0 +1,66 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckDirDepth tests that entries deeper than the maximum depth are refused.
+func TestCheckDirDepth(t *testing.T) {
+	deep := strings.Repeat("d/", DefaultMaxDirDepth) + "file"
+	testCases := []struct {
+		opts     []Option
+		rel      string
+		exceeded bool
+	}{
+		{rel: "."},
+		{rel: "a/b/c"},
+		{rel: deep, exceeded: true},
+		{opts: []Option{WithMaxDirDepth(-1)}, rel: deep},
+		{opts: []Option{WithMaxDirDepth(2)}, rel: "a/b"},
+		{opts: []Option{WithMaxDirDepth(2)}, rel: "a/b/"},
+		{opts: []Option{WithMaxDirDepth(2)}, rel: "a/b/c", exceeded: true},
+		{opts: []Option{WithMaxDirDepth(2)}, rel: filepath.Join("a", "b", "c"), exceeded: true},
+		{opts: []Option{WithMaxDirDepth(2)}, rel: "a/./b/../b/c", exceeded: true},
+	}
+
+	for _, tc := range testCases {
+		err := NewOptions(tc.opts...).CheckDirDepth(tc.rel)
+		if tc.exceeded != errors.Is(err, ErrMaxDepthExceeded) {
+			t.Errorf("Unexpected error for %s: %v", tc.rel, err)
+		}
+	}
+}
+
+// TestExtractArchive_MaxDirDepth tests that archives with entries deeper than the maximum
+// depth are refused.
+func TestExtractArchive_MaxDirDepth(t *testing.T) {
+	data := tarball(t, tarEntry{name: "a/b.txt", content: "b"}, tarEntry{name: "a/b/c.txt", content: "c"})
+
+	err := NewOptions(WithMaxDirDepth(2)).ExtractArchive(bytes.NewReader(data), "foo.tar", t.TempDir())
+	if !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("Expected ErrMaxDepthExceeded, but got: %v", err)
+	}
+	if err := NewOptions(WithMaxDirDepth(3)).ExtractArchive(bytes.NewReader(data), "foo.tar", t.TempDir()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
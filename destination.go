@@ -0,0 +1,89 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrDestinationNotSupported is returned when a gatherer cannot write to a custom Destination.
+var ErrDestinationNotSupported = errors.New("custom destination not supported")
+
+// ErrDestinationIsSymlink is returned when a file would be written through an existing
+// symbolic link at its destination path.
+var ErrDestinationIsSymlink = errors.New("destination is a symbolic link")
+
+// Destination receives the files written by a gather when it is set with WithDestination.
+// The paths passed to Create are the destination paths computed by the gatherer, which allows
+// implementations to map them onto object storage or any other remote store without a local copy.
+// A file saver from the saver package satisfies this interface for the local filesystem.
+type Destination interface {
+	Create(path string) (io.WriteCloser, error)
+}
+
+// WriteDestination copies data to path in the configured Destination and returns the number
+// of bytes written.
+func (o *Options) WriteDestination(path string, data io.Reader) (int64, error) {
+	w, err := o.Destination.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	n, err := io.Copy(w, o.BufferReader(data))
+	if err != nil {
+		_ = w.Close()
+		return n, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := w.Close(); err != nil {
+		return n, fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// CheckDestination returns an error wrapping ErrDestinationNotSupported if the options
+// cannot be combined with the configured Destination.
+func (o *Options) CheckDestination() error {
+	if o.Destination != nil && o.TransformFunc != nil {
+		return fmt.Errorf("%w: transforms require a local destination", ErrDestinationNotSupported)
+	}
+	return nil
+}
+
+// ValidateDestination runs the validator set with WithDestinationValidator, if any, on
+// destination and returns its error as is. Gatherers call it in addition to their own checks.
+func (o *Options) ValidateDestination(destination string) error {
+	if o.DestinationValidator == nil {
+		return nil
+	}
+	return o.DestinationValidator(destination)
+}
+
+// CheckSymlink returns an error wrapping ErrDestinationIsSymlink if path is an existing
+// symbolic link, which writing the file would follow. Links are only refused for local
+// destinations and unless WithSafeDestination(false) is set.
+func (o *Options) CheckSymlink(path string) error {
+	if o.UnsafeDestination || o.Destination != nil {
+		return nil
+	}
+	info, err := os.Lstat(path)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%w: %s", ErrDestinationIsSymlink, path)
+	}
+	return nil
+}
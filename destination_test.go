@@ -0,0 +1,72 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestValidateDestination tests that the validator error is returned as is.
+func TestValidateDestination(t *testing.T) {
+	errOutside := errors.New("destination must be below /workspace")
+	o := NewOptions(WithDestinationValidator(func(destination string) error {
+		if !strings.HasPrefix(destination, "/workspace/") {
+			return errOutside
+		}
+		return nil
+	}))
+
+	if err := o.ValidateDestination("/workspace/foo.txt"); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if err := o.ValidateDestination("/tmp/foo.txt"); err != errOutside {
+		t.Errorf("Expected the validator error, but got: %v", err)
+	}
+	if err := NewOptions().ValidateDestination("/tmp/foo.txt"); err != nil {
+		t.Errorf("Expected no error without a validator, but got: %v", err)
+	}
+}
+
+// TestCheckSymlink tests that existing symbolic links are refused unless unsafe destinations
+// are allowed.
+func TestCheckSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	if err := os.WriteFile(target, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewOptions().CheckSymlink(link); !errors.Is(err, ErrDestinationIsSymlink) {
+		t.Errorf("Expected ErrDestinationIsSymlink, but got: %v", err)
+	}
+	for _, path := range []string{target, filepath.Join(dir, "missing.txt")} {
+		if err := NewOptions().CheckSymlink(path); err != nil {
+			t.Errorf("Expected no error for %s, but got: %v", path, err)
+		}
+	}
+	if err := NewOptions(WithSafeDestination(false)).CheckSymlink(link); err != nil {
+		t.Errorf("Expected links to be allowed, but got: %v", err)
+	}
+}
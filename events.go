@@ -0,0 +1,134 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"io"
+	"time"
+)
+
+// EventPhase is the stage of a gather an Event reports.
+type EventPhase string
+
+const (
+	// EventStarted is sent when a gatherer starts gathering a source.
+	EventStarted EventPhase = "started"
+	// EventProgress is sent as the data of a source is read, with the bytes read so far.
+	EventProgress EventPhase = "progress"
+	// EventRetrying is sent before a failed request is retried. The built-in gatherers do
+	// not retry requests, so they do not send it.
+	EventRetrying EventPhase = "retrying"
+	// EventExtracting is sent when the data of a source is decompressed or extracted.
+	EventExtracting EventPhase = "extracting"
+	// EventDone is sent when a gatherer is done with a source, with the error the gather
+	// failed with, if any.
+	EventDone EventPhase = "done"
+)
+
+// Event describes the progress of a gather, as sent to the channel of WithEventChannel.
+//
+// The events sent depend on the gatherer:
+//   - http: started and done for the source; progress for every file downloaded, with the
+//     URL of the file as source, and extracting when the file is decompressed or extracted.
+//     The files of a directory listing are gathered as sources of their own.
+//   - file: started and done; progress and extracting when copying a single file.
+//   - git and k8s: started and done.
+//   - webdav: started and done for the source; progress for every file downloaded, with the
+//     URL of the file as source.
+type Event struct {
+	// Phase is the stage of the gather.
+	Phase EventPhase
+	// Source is the source being gathered.
+	Source string
+	// Bytes is the number of bytes read so far, for progress events.
+	Bytes int64
+	// Total is the expected number of bytes, for progress events, or -1 if it is unknown.
+	Total int64
+	// Err is the error the gather failed with, for done events.
+	Err error
+}
+
+// Emit sends e to the event channel, if any. The event is dropped if the channel is full,
+// so that a slow consumer never stalls a gather.
+func (o *Options) Emit(e Event) {
+	if o.Events == nil {
+		return
+	}
+	select {
+	case o.Events <- e:
+	default:
+	}
+}
+
+// ProgressReader returns a reader that sends a progress event for source on every read
+// from r and draws a progress bar to the writer of WithProgressWriter, along with a function
+// that finishes the bar once reading is done. Total is the expected number of bytes, or -1 if
+// it is unknown. Without an event channel or a progress writer r is returned as is.
+func (o *Options) ProgressReader(r io.Reader, source string, total int64) (io.Reader, func()) {
+	if o.Events == nil && o.ProgressWriter == nil {
+		return r, func() {}
+	}
+	pr := &progressReader{r: r, o: o, source: source, total: total, emitted: -1}
+	if o.ProgressWriter != nil {
+		pr.bar = &progressBar{w: o.ProgressWriter, name: source, total: total}
+	}
+	return pr, pr.finish
+}
+
+// progressReader counts the bytes read and reports them to the event channel and progress bar.
+type progressReader struct {
+	r       io.Reader
+	o       *Options
+	source  string
+	total   int64
+	read    int64
+	bar     *progressBar
+	emitted int64
+	last    time.Time
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.read += int64(n)
+		pr.emit(err == io.EOF || pr.read == pr.total)
+		pr.bar.update(pr.read)
+	} else if err == io.EOF {
+		pr.emit(true)
+	}
+	return n, err
+}
+
+// emit sends a progress event for the bytes read so far, at most once per interval of
+// WithProgressInterval unless final. No event is sent if no bytes were read since the last.
+func (pr *progressReader) emit(final bool) {
+	if pr.read == pr.emitted {
+		return
+	}
+	if !final && pr.o.ProgressInterval > 0 && time.Since(pr.last) < pr.o.ProgressInterval {
+		return
+	}
+	pr.last = time.Now()
+	pr.emitted = pr.read
+	pr.o.Emit(Event{Phase: EventProgress, Source: pr.source, Bytes: pr.read, Total: pr.total})
+}
+
+// finish sends the final progress event, if it is pending, and finishes the progress bar.
+func (pr *progressReader) finish() {
+	pr.emit(true)
+	pr.bar.finish()
+}
@@ -0,0 +1,104 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEmit tests that events are sent to the channel and dropped when it is full.
+func TestEmit(t *testing.T) {
+	events := make(chan Event, 1)
+	o := NewOptions(WithEventChannel(events))
+
+	o.Emit(Event{Phase: EventStarted, Source: "a"})
+	o.Emit(Event{Phase: EventDone, Source: "a"})
+
+	if e := <-events; e.Phase != EventStarted {
+		t.Errorf("Expected the started event, but got: %v", e)
+	}
+	select {
+	case e := <-events:
+		t.Errorf("Expected the done event to be dropped, but got: %v", e)
+	default:
+	}
+
+	// Without a channel, emitting does nothing
+	NewOptions().Emit(Event{Phase: EventStarted})
+}
+
+// TestProgressReader tests that progress events report the bytes read so far.
+func TestProgressReader(t *testing.T) {
+	events := make(chan Event, 10)
+	o := NewOptions(WithEventChannel(events))
+
+	r, finish := o.ProgressReader(io.LimitReader(strings.NewReader("hello world"), 11), "src", 11)
+	defer finish()
+	buf := make([]byte, 6)
+	for {
+		if _, err := r.Read(buf); err != nil {
+			break
+		}
+	}
+	close(events)
+
+	var last Event
+	count := 0
+	for e := range events {
+		if e.Phase != EventProgress || e.Source != "src" || e.Total != 11 {
+			t.Errorf("Unexpected event: %v", e)
+		}
+		last = e
+		count++
+	}
+	if count != 2 || last.Bytes != 11 {
+		t.Errorf("Expected 2 progress events ending at 11 bytes, but got %d ending at %d", count, last.Bytes)
+	}
+
+	// Without a channel, the reader is returned as is
+	sr := strings.NewReader("")
+	if r, _ := NewOptions().ProgressReader(sr, "src", 0); r != io.Reader(sr) {
+		t.Errorf("Expected the reader to be returned as is")
+	}
+}
+
+// TestProgressReader_Interval tests that progress events are throttled, with a final event for all bytes read.
+func TestProgressReader_Interval(t *testing.T) {
+	events := make(chan Event, 20)
+	o := NewOptions(WithEventChannel(events), WithProgressInterval(time.Hour))
+
+	r, finish := o.ProgressReader(strings.NewReader("hello world"), "src", -1)
+	buf := make([]byte, 1)
+	for {
+		if _, err := r.Read(buf); err != nil {
+			break
+		}
+	}
+	finish()
+	close(events)
+
+	var bytes []int64
+	for e := range events {
+		bytes = append(bytes, e.Bytes)
+	}
+	if len(bytes) != 2 || bytes[0] != 1 || bytes[1] != 11 {
+		t.Errorf("Expected progress events at 1 and 11 bytes, but got %v", bytes)
+	}
+}
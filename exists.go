@@ -0,0 +1,29 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+// IfExists is the policy applied when a destination already exists.
+type IfExists int
+
+const (
+	// IfExistsFail fails the gather of the destination, which is the default.
+	IfExistsFail IfExists = iota
+	// IfExistsSkip keeps the existing destination and does not gather it.
+	IfExistsSkip
+	// IfExistsOverwrite removes the existing destination before gathering it again.
+	IfExistsOverwrite
+)
@@ -0,0 +1,319 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotArchive is returned when WithExtract is set and the data is not a tar archive.
+var ErrNotArchive = errors.New("not a tar archive")
+
+// ErrUnsafeArchive is returned when an archive entry would be written outside the directory
+// it is extracted into.
+var ErrUnsafeArchive = errors.New("unsafe archive entry")
+
+// ErrStripComponents is returned when an archive entry other than a directory has no more path
+// components than the number set with WithStripComponents.
+var ErrStripComponents = errors.New("too few path components to strip")
+
+// tarMagic is found at offset 257 of the header of POSIX and GNU tar archives.
+var tarMagic = []byte("ustar")
+
+// ExtractArchive extracts the tar archive read from r into dir, decompressing it first when it
+// is compressed with one of the formats of WithDecompress. The formats are detected from the
+// magic bytes of the data; name, the file name of the archive, is only consulted to recognize
+// old tar archives without a magic, by an extension such as ".tar" or ".tgz". An error
+// wrapping ErrNotArchive is returned for data of any other kind.
+// Entries are refused with ErrUnsafeArchive when they, or the target of a link, would land
+// outside dir, and with ErrMaxDepthExceeded when they lie too deep below it. Entries left out
+// by WithInclude and WithExclude are skipped. Regular files go to the custom Destination, if
+// any, and links and directories are then skipped.
+func (o *Options) ExtractArchive(r io.Reader, name, dir string) error {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(6)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return fmt.Errorf("failed to read compression header: %w", err)
+	}
+
+	var data io.Reader = br
+	for _, c := range compressions {
+		if !bytes.HasPrefix(header, c.magic) {
+			continue
+		}
+		dr, err := c.reader(br)
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s data: %w", c.name, err)
+		}
+		defer dr.Close()
+		data = dr
+		break
+	}
+
+	tb := bufio.NewReader(data)
+	block, err := tb.Peek(262)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read archive header: %w", err)
+	}
+	if !(len(block) == 262 && bytes.Equal(block[257:], tarMagic)) && !hasTarExtension(name) {
+		return fmt.Errorf("%w: %s", ErrNotArchive, name)
+	}
+	return o.extractTar(tar.NewReader(tb), dir)
+}
+
+// hasTarExtension reports whether name has the extension of a tar archive, compressed or not.
+func hasTarExtension(name string) bool {
+	name = strings.ToLower(name)
+	for _, c := range compressions {
+		name = trimCompressionExtension(name, c)
+	}
+	return strings.HasSuffix(name, ".tar")
+}
+
+// extractTar writes the entries of tr below dir.
+func (o *Options) extractTar(tr *tar.Reader, dir string) error {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if o.Destination == nil {
+		if err := o.MkdirAll(root); err != nil {
+			return fmt.Errorf("failed to create %s: %w", root, err)
+		}
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if !filepath.IsLocal(hdr.Name) {
+			return fmt.Errorf("%w: %s is outside the destination", ErrUnsafeArchive, hdr.Name)
+		}
+		if o.StripComponents > 0 {
+			skip, err := o.stripComponents(hdr)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+		}
+		if o.Selecting() {
+			included, err := o.Included(hdr.Name, hdr.Typeflag == tar.TypeDir)
+			if err != nil {
+				return err
+			}
+			// With include patterns, directories are only created for the files they hold.
+			if !included || hdr.Typeflag == tar.TypeDir && len(o.Include) > 0 {
+				continue
+			}
+		}
+		if err := o.CheckDirDepth(hdr.Name); err != nil {
+			return err
+		}
+		path := filepath.Join(root, hdr.Name)
+		if err := o.ValidateDestination(path); err != nil {
+			return err
+		}
+		if path != root {
+			if err := o.CheckSymlink(path); err != nil {
+				return err
+			}
+		}
+
+		if o.Destination != nil {
+			if hdr.Typeflag == tar.TypeReg {
+				if _, err := o.WriteDestination(filepath.Join(dir, hdr.Name), tr); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// Links created by earlier entries must not lead the entry out of the destination
+		if err := checkInside(root, filepath.Dir(path)); err != nil {
+			return err
+		}
+		if err := o.extractEntry(tr, hdr, root, path); err != nil {
+			return err
+		}
+	}
+}
+
+// stripComponents removes the leading path components of the options from the name of the
+// entry described by hdr, and from the target of a hard link. It returns true for directories
+// left without a name, which are skipped.
+func (o *Options) stripComponents(hdr *tar.Header) (bool, error) {
+	name, ok := stripPath(hdr.Name, o.StripComponents)
+	if !ok {
+		if hdr.Typeflag == tar.TypeDir {
+			return true, nil
+		}
+		return false, fmt.Errorf("%w: %s has no more than %d components", ErrStripComponents, hdr.Name, o.StripComponents)
+	}
+	if hdr.Typeflag == tar.TypeLink {
+		if !filepath.IsLocal(hdr.Linkname) {
+			return false, fmt.Errorf("%w: %s links outside the destination", ErrUnsafeArchive, hdr.Name)
+		}
+		target, ok := stripPath(hdr.Linkname, o.StripComponents)
+		if !ok {
+			return false, fmt.Errorf("%w: %s links to %s, which has no more than %d components", ErrStripComponents, hdr.Name, hdr.Linkname, o.StripComponents)
+		}
+		hdr.Linkname = target
+	}
+	hdr.Name = name
+	return false, nil
+}
+
+// stripPath removes the first n components of the slash-separated name, once cleaned, and
+// returns false if nothing is left.
+func stripPath(name string, n int) (string, bool) {
+	parts := strings.Split(strings.Trim(path.Clean(name), "/"), "/")
+	if parts[0] == "." || len(parts) <= n {
+		return "", false
+	}
+	return strings.Join(parts[n:], "/"), true
+}
+
+// extractEntry writes the entry described by hdr to path. Entries of types other than
+// directories, regular files and links, such as devices, are skipped.
+func (o *Options) extractEntry(tr *tar.Reader, hdr *tar.Header, root, path string) error {
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return o.MkdirAll(path)
+	case tar.TypeReg:
+		if err := o.MkdirAll(filepath.Dir(path)); err != nil {
+			return err
+		}
+		return o.extractFile(tr, path, hdr.FileInfo().Mode().Perm())
+	case tar.TypeSymlink:
+		target := filepath.Join(filepath.Dir(hdr.Name), hdr.Linkname)
+		if filepath.IsAbs(hdr.Linkname) || !filepath.IsLocal(target) {
+			return fmt.Errorf("%w: %s links outside the destination", ErrUnsafeArchive, hdr.Name)
+		}
+		if err := o.MkdirAll(filepath.Dir(path)); err != nil {
+			return err
+		}
+		if err := removeExisting(path); err != nil {
+			return err
+		}
+		return os.Symlink(hdr.Linkname, path)
+	case tar.TypeLink:
+		if !filepath.IsLocal(hdr.Linkname) {
+			return fmt.Errorf("%w: %s links outside the destination", ErrUnsafeArchive, hdr.Name)
+		}
+		target := filepath.Join(root, hdr.Linkname)
+		if err := checkInside(root, target); err != nil {
+			return err
+		}
+		if err := o.MkdirAll(filepath.Dir(path)); err != nil {
+			return err
+		}
+		if err := removeExisting(path); err != nil {
+			return err
+		}
+		return os.Link(target, path)
+	default:
+		return nil
+	}
+}
+
+// extractFile writes the content of a regular file entry to path. Without permission options
+// the permission of the entry is kept; otherwise only its executable bits are.
+func (o *Options) extractFile(r io.Reader, path string, perm os.FileMode) error {
+	if err := removeExisting(path); err != nil {
+		return err
+	}
+	if o.setsPermissions() {
+		if perm&0111 != 0 {
+			perm = o.PreservedPermission(0755)
+		} else {
+			perm = o.FilePermission()
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := io.Copy(f, o.BufferReader(r)); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	return o.Chmod(path, perm)
+}
+
+// removeExisting removes a file or link at path, so that an entry never writes through a
+// link of an earlier entry. Directories are kept.
+func removeExisting(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkInside returns ErrUnsafeArchive if path, once the links of its closest existing
+// ancestor are resolved, is not below root.
+func checkInside(root, path string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+
+	existing, rest := path, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", existing, err)
+	}
+
+	rel, err := filepath.Rel(realRoot, filepath.Join(real, rest))
+	if err != nil || !(rel == "." || filepath.IsLocal(rel)) {
+		return fmt.Errorf("%w: %s is outside the destination", ErrUnsafeArchive, path)
+	}
+	return nil
+}
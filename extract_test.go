@@ -0,0 +1,320 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// bzip2Tarball is a tar archive of dir/hello.txt, holding "hello world", compressed with bzip2.
+const bzip2Tarball = "425a683931415926535971470a4000006ffb80ca8000804001ed00080066649ec0080820005432a6990623464d1a69e504928f51a01a340d01f7571421049d0846138d846fb60810c0c4c864e276112610f9b658b22d864656e7c0b5a71619809edea8aa8b79d09207e2ee48a70a120e28e14800"
+
+// tarEntry is an entry of a test archive.
+type tarEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+// tarball returns a tar archive of the entries.
+func tarball(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: e.mode, Size: int64(len(e.content)), Linkname: e.linkname}
+		if hdr.Typeflag == 0 {
+			hdr.Typeflag = tar.TypeReg
+		}
+		if hdr.Mode == 0 {
+			hdr.Mode = 0644
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// compressTarball returns a tar archive of dir/hello.txt compressed in the given format.
+func compressTarball(t *testing.T, format string) []byte {
+	t.Helper()
+	data := tarball(t, tarEntry{name: "dir/hello.txt", content: "hello world"})
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch format {
+	case "tar":
+		return data
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "bzip2":
+		data, err := hex.DecodeString(bzip2Tarball)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	case "xz":
+		w, err = xz.NewWriter(&buf)
+	case "zstd":
+		w, err = zstd.NewWriter(&buf)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestExtractArchive tests that tar archives are extracted in every supported compression format.
+func TestExtractArchive(t *testing.T) {
+	testCases := []struct {
+		format string
+		name   string
+	}{
+		{format: "tar", name: "foo.tar"},
+		{format: "gzip", name: "foo.tgz"},
+		{format: "bzip2", name: "foo.tar.bz2"},
+		{format: "xz", name: "foo.txz"},
+		{format: "zstd", name: "foo.tar.zst"},
+		{format: "zstd", name: "download"},
+	}
+
+	o := NewOptions(WithExtract(true))
+	for _, tc := range testCases {
+		dir := t.TempDir()
+		if err := o.ExtractArchive(bytes.NewReader(compressTarball(t, tc.format)), tc.name, dir); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "dir", "hello.txt"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if string(data) != "hello world" {
+			t.Errorf("%s: expected \"hello world\", but got %q", tc.name, data)
+		}
+	}
+}
+
+// TestExtractArchive_Entries tests that directories, executables and links are extracted.
+func TestExtractArchive_Entries(t *testing.T) {
+	dir := t.TempDir()
+	data := tarball(t,
+		tarEntry{name: "bin/", typeflag: tar.TypeDir, mode: 0755},
+		tarEntry{name: "bin/run.sh", mode: 0755, content: "#!/bin/sh"},
+		tarEntry{name: "README.md", content: "hello"},
+		tarEntry{name: "docs/README.md", typeflag: tar.TypeSymlink, linkname: "../README.md"},
+		tarEntry{name: "COPY.md", typeflag: tar.TypeLink, linkname: "README.md"},
+	)
+
+	if err := NewOptions().ExtractArchive(bytes.NewReader(data), "foo.tar", dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "bin", "run.sh"))
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected run.sh to be executable, but got: %v, %v", info, err)
+	}
+	for _, name := range []string{"docs/README.md", "COPY.md"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(content) != "hello" {
+			t.Errorf("Expected %s to hold \"hello\", but got: %q, %v", name, content, err)
+		}
+	}
+}
+
+// TestExtractArchive_Unsafe tests that entries escaping the destination are refused.
+func TestExtractArchive_Unsafe(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{name: "parent path", entries: []tarEntry{{name: "../evil.txt", content: "evil"}}},
+		{name: "absolute symlink", entries: []tarEntry{{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc"}}},
+		{name: "escaping symlink", entries: []tarEntry{{name: "link", typeflag: tar.TypeSymlink, linkname: "../.."}}},
+		{name: "escaping hard link", entries: []tarEntry{{name: "link", typeflag: tar.TypeLink, linkname: "../evil.txt"}}},
+		{name: "symlink chain", entries: []tarEntry{
+			{name: "a", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "a/b/evil.txt", content: "evil"},
+		}},
+	}
+
+	for _, tc := range testCases {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "out")
+		err := NewOptions().ExtractArchive(bytes.NewReader(tarball(t, tc.entries...)), "foo.tar", dir)
+		if !errors.Is(err, ErrUnsafeArchive) {
+			t.Errorf("%s: expected ErrUnsafeArchive, but got: %v", tc.name, err)
+		}
+		if _, err := os.Stat(filepath.Join(parent, "evil.txt")); err == nil {
+			t.Errorf("%s: expected nothing to be written outside the destination", tc.name)
+		}
+	}
+}
+
+// TestExtractArchive_Symlink tests that entries are not written through links that already
+// exist in the destination.
+func TestExtractArchive_Symlink(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	secret := filepath.Join(parent, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(dir, "README.md")); err != nil {
+		t.Fatal(err)
+	}
+	data := tarball(t, tarEntry{name: "README.md", content: "evil"})
+
+	err := NewOptions().ExtractArchive(bytes.NewReader(data), "foo.tar", dir)
+	if !errors.Is(err, ErrDestinationIsSymlink) {
+		t.Errorf("Expected ErrDestinationIsSymlink, but got: %v", err)
+	}
+	if content, _ := os.ReadFile(secret); string(content) != "secret" {
+		t.Errorf("Expected the link target to be left alone, but got: %q", content)
+	}
+
+	if err := NewOptions(WithSafeDestination(false)).ExtractArchive(bytes.NewReader(data), "foo.tar", dir); err != nil {
+		t.Errorf("Expected the link to be replaced, but got: %v", err)
+	}
+	if content, _ := os.ReadFile(secret); string(content) != "secret" {
+		t.Errorf("Expected the link to be replaced rather than written through, but got: %q", content)
+	}
+}
+
+// TestExtractArchive_NotArchive tests that data other than tar archives is refused.
+func TestExtractArchive_NotArchive(t *testing.T) {
+	for _, data := range [][]byte{[]byte("hello world"), compress(t, "gzip")} {
+		err := NewOptions().ExtractArchive(bytes.NewReader(data), "foo.txt", t.TempDir())
+		if !errors.Is(err, ErrNotArchive) {
+			t.Errorf("Expected ErrNotArchive, but got: %v", err)
+		}
+	}
+}
+
+// TestExtractArchive_StripComponents tests that the leading components of entries and hard
+// link targets are removed, and that the wrapping directories are skipped.
+func TestExtractArchive_StripComponents(t *testing.T) {
+	dir := t.TempDir()
+	data := tarball(t,
+		tarEntry{name: "./repo-1.2.3/", typeflag: tar.TypeDir, mode: 0755},
+		tarEntry{name: "./repo-1.2.3/src/", typeflag: tar.TypeDir, mode: 0755},
+		tarEntry{name: "./repo-1.2.3/src/main.go", content: "package main"},
+		tarEntry{name: "./repo-1.2.3/src/link.go", typeflag: tar.TypeLink, linkname: "repo-1.2.3/src/main.go"},
+	)
+
+	if err := NewOptions(WithStripComponents(1)).ExtractArchive(bytes.NewReader(data), "foo.tar", dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{"src/main.go", "src/link.go"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(content) != "package main" {
+			t.Errorf("Expected %s to hold \"package main\", but got: %q, %v", name, content, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "repo-1.2.3")); err == nil {
+		t.Errorf("Expected the top-level directory to be stripped")
+	}
+}
+
+// TestExtractArchive_StripComponents_Empty tests that entries left without a path once
+// stripped are refused.
+func TestExtractArchive_StripComponents_Empty(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []tarEntry
+		err     error
+	}{
+		{name: "file", entries: []tarEntry{{name: "repo/README.md", content: "hello"}}, err: ErrStripComponents},
+		{name: "hard link target", entries: []tarEntry{{name: "repo/a/b/link", typeflag: tar.TypeLink, linkname: "repo/README.md"}}, err: ErrStripComponents},
+		{name: "escaping hard link", entries: []tarEntry{{name: "repo/a/b/link", typeflag: tar.TypeLink, linkname: "../../../evil.txt"}}, err: ErrUnsafeArchive},
+	}
+
+	for _, tc := range testCases {
+		err := NewOptions(WithStripComponents(2)).ExtractArchive(bytes.NewReader(tarball(t, tc.entries...)), "foo.tar", t.TempDir())
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected %v, but got: %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+// TestExtractArchive_Include tests that only the included entries are extracted, and that
+// directories are only created for the files they hold.
+func TestExtractArchive_Include(t *testing.T) {
+	dir := t.TempDir()
+	data := tarball(t,
+		tarEntry{name: "docs/", typeflag: tar.TypeDir, mode: 0755},
+		tarEntry{name: "docs/index.md", content: "docs"},
+		tarEntry{name: "src/", typeflag: tar.TypeDir, mode: 0755},
+		tarEntry{name: "src/main.go", content: "package main"},
+		tarEntry{name: "src/main_test.go", content: "package main"},
+	)
+
+	o := NewOptions(WithInclude([]string{"*.go"}), WithExclude([]string{"*_test.go"}))
+	if err := o.ExtractArchive(bytes.NewReader(data), "foo.tar", dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "main.go")); err != nil {
+		t.Errorf("Expected src/main.go to be extracted: %v", err)
+	}
+	for _, name := range []string{"docs", "src/main_test.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("Expected %s to be left out", name)
+		}
+	}
+}
+
+// TestHasTarExtension tests that tar archives are recognized by their extensions.
+func TestHasTarExtension(t *testing.T) {
+	for _, name := range []string{"foo.tar", "foo.TAR.GZ", "foo.tgz", "foo.tbz2", "foo.tar.xz", "foo.txz", "foo.tar.zst", "foo.tzst"} {
+		if !hasTarExtension(name) {
+			t.Errorf("Expected %s to be recognized as a tar archive", name)
+		}
+	}
+	for _, name := range []string{"foo.txt", "foo.gz", "foo.zst", "tar"} {
+		if hasTarExtension(name) {
+			t.Errorf("Expected %s not to be recognized as a tar archive", name)
+		}
+	}
+}
@@ -23,14 +23,18 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	gogather "github.com/enterprise-contract/go-gather"
 	"github.com/enterprise-contract/go-gather/metadata"
 	"github.com/enterprise-contract/go-gather/metadata/file"
 	"github.com/enterprise-contract/go-gather/saver"
@@ -38,36 +42,80 @@ import (
 
 // FileGatherer is a struct that implements the Gatherer interface
 // and provides methods for gathering files and directories.
-type FileGatherer struct{}
+type FileGatherer struct {
+	// FS is the filesystem sources are read from, such as an embed.FS, or nil for the local
+	// filesystem. Destinations are always on the local filesystem.
+	FS fs.FS
+}
 
 // Gather copies a file or directory from the source path to the destination path.
 // It returns the metadata of the gathered file or directory and any error encountered.
-func (f *FileGatherer) Gather(ctx context.Context, source, destination string) (metadata.Metadata, error) {
-	// Parse the source URI
-	src, err := url.Parse(source)
+// The file gatherer does not contact any hosts, so the host options do not apply to it.
+func (f *FileGatherer) Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (m metadata.Metadata, err error) {
+	o := gogather.NewOptions(opts...)
+	o.Emit(gogather.Event{Phase: gogather.EventStarted, Source: source})
+	defer func() { o.Emit(gogather.Event{Phase: gogather.EventDone, Source: source, Err: err}) }()
+	if err := o.CheckDestination(); err != nil {
+		return nil, err
+	}
+	if err := o.CheckBufferSize(); err != nil {
+		return nil, err
+	}
+	sw := metadata.NewStopwatch()
+	ctx = sw.WithContext(ctx)
+	defer func() {
+		if err != nil {
+			return
+		}
+		// Local files are read without credentials
+		switch fm := m.(type) {
+		case *file.FileMetadata:
+			fm.Timing = sw.Timings()
+			fm.Auth = metadata.AuthAnonymous
+		case *file.DirectoryMetadata:
+			fm.Timing = sw.Timings()
+			fm.Auth = metadata.AuthAnonymous
+		}
+	}()
 
+	// Parse the source URI
+	srcPath, err := f.sourcePath(source)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse source URI: %w", err)
+		return nil, err
 	}
 
 	// Determine if we have a file or directory
-	sourceKind, err := os.Stat(src.Path)
+	sourceKind, err := f.stat(srcPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine source kind: %w", err)
 	}
 
 	// If it's a directory, call copyDirectory, otherwise call copyFile
 	if sourceKind.IsDir() {
-		return f.copyDirectory(ctx, src.Path, destination)
+		return f.copyDirectory(ctx, source, destination, o)
 	} else {
-		return f.copyFile(ctx, src.Path, destination)
+		return f.copyFile(ctx, source, destination, o)
 	}
 }
 
-func (f *FileGatherer) copyFile(ctx context.Context, source, destination string) (metadata.Metadata, error) {
-	src, err := url.Parse(source)
+// DestinationName returns the name of the source file or directory, which is the name
+// a gather into a directory would usually be given.
+func (f *FileGatherer) DestinationName(ctx context.Context, source string, opts ...gogather.Option) (string, error) {
+	srcPath, err := f.sourcePath(source)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse source URI: %w", err)
+		return "", err
+	}
+	name := filepath.Base(srcPath)
+	if name == "." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("%s does not name a file", source)
+	}
+	return name, nil
+}
+
+func (f *FileGatherer) copyFile(ctx context.Context, source, destination string, o *gogather.Options) (metadata.Metadata, error) {
+	srcPath, err := f.sourcePath(source)
+	if err != nil {
+		return nil, err
 	}
 	select {
 	case <-ctx.Done():
@@ -76,12 +124,64 @@ func (f *FileGatherer) copyFile(ctx context.Context, source, destination string)
 	}
 
 	// Open the source file.
-	srcFile, err := os.Open(filepath.Clean(src.Path))
+	srcFile, err := f.open(srcPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open source file: %w", err)
 	}
 	defer srcFile.Close()
 
+	// Report the bytes read from the file, if asked to.
+	total := int64(-1)
+	if info, err := srcFile.Stat(); err == nil {
+		total = info.Size()
+	}
+	progress, finish := o.ProgressReader(srcFile, source, total)
+	defer finish()
+
+	// Extract the archive into the destination directory, if asked to.
+	if o.Extract {
+		defer metadata.StopwatchFrom(ctx).Time(metadata.PhaseExtract)()
+		return f.extract(source, progress, filepath.Base(srcPath), destination, o)
+	}
+
+	// Decompress the file, if asked to, dropping the compression extension.
+	decompressed, decompressedPath, err := o.DecompressReader(progress, destination)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressed.Close()
+	if decompressedPath != destination {
+		o.Emit(gogather.Event{Phase: gogather.EventExtracting, Source: source})
+		destination = decompressedPath
+	}
+
+	// Run the caller's destination validator, if any, on the final destination.
+	if err := o.ValidateDestination(destination); err != nil {
+		return nil, err
+	}
+
+	// Detect the content type from the first bytes of the file.
+	detectedType, data, err := gogather.DetectContentType(decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+
+	// Write to the custom destination, if any, hashing the data on the way.
+	if o.Destination != nil {
+		hasher := sha256.New()
+		size, err := o.WriteDestination(destination, io.TeeReader(data, hasher))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save file: %w", err)
+		}
+		return &file.FileMetadata{
+			Size:         size,
+			Path:         destination,
+			Timestamp:    time.Now(),
+			SHA:          hex.EncodeToString(hasher.Sum(nil)),
+			DetectedType: detectedType,
+		}, nil
+	}
+
 	// Parse the destination URI.
 	destFile, err := url.Parse(destination)
 	if err != nil {
@@ -94,9 +194,30 @@ func (f *FileGatherer) copyFile(ctx context.Context, source, destination string)
 		return nil, fmt.Errorf("failed to create saver: %w", err)
 	}
 
-	// Save the file to the destination.
-	if err := saver.Save(ctx, srcFile, destination); err != nil {
-		return nil, fmt.Errorf("failed to save file: %w", err)
+	// Save the file to the destination, with the configured permissions.
+	if err := o.MkdirParents(destFile.Path); err != nil {
+		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	if err := o.CheckSymlink(destFile.Path); err != nil {
+		return nil, err
+	}
+	_, statErr := os.Lstat(destFile.Path)
+	created := os.IsNotExist(statErr)
+	if err := saver.Save(ctx, o.BufferReader(data), destination); err != nil {
+		err = fmt.Errorf("failed to save file: %w", err)
+		// An existing file that could not be replaced is not ours to remove
+		if created {
+			err = o.DiscardPartial(destFile.Path, err)
+		}
+		return nil, err
+	}
+	if err := o.ChmodFile(destFile.Path); err != nil {
+		return nil, err
+	}
+
+	// Apply the transform, if any, before describing the file.
+	if err := o.TransformFile(destFile.Path); err != nil {
+		return nil, o.DiscardPartial(destFile.Path, err)
 	}
 
 	// Get the file info
@@ -112,28 +233,107 @@ func (f *FileGatherer) copyFile(ctx context.Context, source, destination string)
 	}
 
 	return &file.FileMetadata{
-		Size:      info.Size(),
-		Path:      destination,
-		Timestamp: info.ModTime(),
-		SHA:       fileSha,
+		Size:         info.Size(),
+		Path:         destination,
+		Timestamp:    info.ModTime(),
+		SHA:          fileSha,
+		DetectedType: detectedType,
+	}, nil
+}
+
+// extract extracts the archive read from r into the destination directory, removing the
+// directory again if it was created and the archive cannot be extracted.
+func (f *FileGatherer) extract(source string, r io.Reader, name, destination string, o *gogather.Options) (metadata.Metadata, error) {
+	o.Emit(gogather.Event{Phase: gogather.EventExtracting, Source: source})
+	if err := o.ValidateDestination(destination); err != nil {
+		return nil, err
+	}
+
+	dst, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse destination URI: %w", err)
+	}
+	if o.Destination == nil {
+		if err := o.MkdirParents(dst.Path); err != nil {
+			return nil, fmt.Errorf("failed to create destination directory: %w", err)
+		}
+	}
+	// Extract into a staging directory that replaces the destination on success, if asked to.
+	dir, finish, err := o.StageDir(dst.Path)
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Stat(dir)
+	created := o.Destination == nil && os.IsNotExist(statErr)
+
+	fail := func(err error) (metadata.Metadata, error) {
+		if created {
+			err = o.DiscardPartial(dir, err)
+		}
+		return nil, finish(err)
+	}
+
+	if err := o.ExtractArchive(r, name, dir); err != nil {
+		return fail(fmt.Errorf("failed to extract archive: %w", err))
+	}
+	if o.Destination == nil {
+		if err := o.TransformTree(dir); err != nil {
+			return fail(err)
+		}
+	}
+	if err := finish(nil); err != nil {
+		return nil, err
+	}
+
+	return &file.DirectoryMetadata{
+		Path:      dst.Path,
+		Timestamp: time.Now(),
 	}, nil
 }
 
 // copyDirectory copies a directory from the source path to the destination path.
 // It walks through the directory tree, creates the corresponding directories in the destination path,
-// and copies each file in the directory to the destination path.
+// and copies each file in the directory to the destination path, skipping those left out by WithInclude and WithExclude.
 // It limits the number of concurrent operations to 10 to avoid overwhelming system resources.
 // It returns the metadata of the copied directory and any error encountered.
-func (f *FileGatherer) copyDirectory(ctx context.Context, source, destination string) (metadata.Metadata, error) {
-	src, err := url.Parse(source)
+func (f *FileGatherer) copyDirectory(ctx context.Context, source, destination string, o *gogather.Options) (m metadata.Metadata, err error) {
+	srcPath, err := f.sourcePath(source)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse source URI: %w", err)
+		return nil, err
 	}
 	dst, err := url.Parse(destination)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse destination URI: %w", err)
 	}
 
+	// Run the caller's destination validator, if any.
+	if err := o.ValidateDestination(destination); err != nil {
+		return nil, err
+	}
+	if o.Destination == nil {
+		if err := o.MkdirParents(dst.Path); err != nil {
+			return nil, fmt.Errorf("failed to create destination directory: %w", err)
+		}
+	}
+
+	// Copy into a staging directory that replaces the destination on success, if asked to.
+	root, finish, err := o.StageDir(dst.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err = finish(err); err != nil {
+			m = nil
+		}
+	}()
+
+	_, statErr := os.Stat(root)
+	created := os.IsNotExist(statErr)
+
+	// Stop the walk at the first error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	errChan := make(chan error, 100) // Increased buffer size
 	done := make(chan bool)
 	semaphore := make(chan struct{}, 10) // Limit to 10 concurrent operations
@@ -142,7 +342,7 @@ func (f *FileGatherer) copyDirectory(ctx context.Context, source, destination st
 
 	go func() {
 		defer close(done)
-		err = filepath.Walk(src.Path, func(path string, info os.FileInfo, err error) error {
+		walkErr := f.walk(srcPath, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return fmt.Errorf("failed to walk path: %w", err)
 			}
@@ -153,14 +353,40 @@ func (f *FileGatherer) copyDirectory(ctx context.Context, source, destination st
 			default:
 			}
 
-			relPath, err := filepath.Rel(src.Path, path)
+			relPath, err := filepath.Rel(filepath.FromSlash(srcPath), filepath.FromSlash(path))
 			if err != nil {
 				return fmt.Errorf("failed to get relative path: %w", err)
 			}
+			if err := o.CheckDirDepth(relPath); err != nil {
+				return err
+			}
+			included, err := o.Included(relPath, d.IsDir())
+			if err != nil {
+				return err
+			}
+			if !included {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
 
-			destPath := filepath.Join(dst.Path, relPath)
-			if info.IsDir() {
-				if err := os.MkdirAll(destPath, 0755); err != nil {
+			destPath := filepath.Join(root, relPath)
+			if d.IsDir() {
+				// Custom destinations create directories implicitly
+				if o.Destination != nil {
+					return nil
+				}
+				if destPath != root {
+					if err := o.CheckSymlink(destPath); err != nil {
+						return err
+					}
+				}
+				// With include patterns, directories are only created for the files they hold
+				if destPath != root && len(o.Include) > 0 {
+					return nil
+				}
+				if err := o.MkdirAll(destPath); err != nil {
 					return fmt.Errorf("failed to create directory: %w", err)
 				}
 			} else {
@@ -171,48 +397,98 @@ func (f *FileGatherer) copyDirectory(ctx context.Context, source, destination st
 						<-semaphore
 						wg.Done()
 					}()
-					srcFile, err := os.Open(filepath.Clean(path))
+					srcFile, err := f.open(path)
 					if err != nil {
 						errChan <- err
 						return
 					}
 					defer srcFile.Close()
 
+					if o.Destination != nil {
+						if _, err := o.WriteDestination(destPath, srcFile); err != nil {
+							errChan <- err
+						}
+						return
+					}
+
 					saver, err := saver.NewSaver(dst.Scheme)
 					if err != nil {
 						errChan <- err
 						return
 					}
 
-					if err := saver.Save(ctx, srcFile, destPath); err != nil {
+					if err := o.CheckSymlink(destPath); err != nil {
+						errChan <- err
+						return
+					}
+					if len(o.Include) > 0 {
+						if err := o.MkdirAll(filepath.Dir(destPath)); err != nil {
+							errChan <- fmt.Errorf("failed to create directory: %w", err)
+							return
+						}
+					}
+					if err := saver.Save(ctx, o.BufferReader(srcFile), destPath); err != nil {
 						errChan <- err
 						return
 					}
+					if err := o.Chmod(destPath, o.FilePermission()); err != nil {
+						errChan <- err
+					}
 				}()
 			}
 			return nil
 		})
-		if err != nil {
-			errChan <- err
+		if walkErr != nil {
+			errChan <- walkErr
 		}
 
 		wg.Wait()      // Wait for all goroutines to finish
 		close(errChan) // Close the channel safely after all sends are done
 	}()
 
-	// Handle errors and completion
+	// Handle errors and completion, waiting for the copies in flight after an error so that
+	// the staging directory is not finished while they write to it
+	var copyErr error
 	for err := range errChan {
-		if err != nil {
-			return nil, fmt.Errorf("failed to copy directory: %w", err)
+		if err != nil && copyErr == nil {
+			copyErr = err
+			cancel()
 		}
 	}
 	<-done
+	if copyErr != nil {
+		return nil, fmt.Errorf("failed to copy directory: %w", copyErr)
+	}
+
+	// Apply the transform, if any, removing the directory again if we created it.
+	if err := o.TransformTree(root); err != nil {
+		if created {
+			err = o.DiscardPartial(root, err)
+		}
+		return nil, err
+	}
+
 	return &file.DirectoryMetadata{
 		Path:      dst.Path,
 		Timestamp: time.Now(),
 	}, nil
 }
 
+// sourcePath returns the local path named by source. The path of a file:// URL is
+// percent-decoded, so "file:///tmp/my%20folder" names "/tmp/my folder", and "#" and "?"
+// are given as "%23" and "%3F". Plain paths are used as is, so they may contain spaces,
+// "#", "?" and "%" literally.
+func sourcePath(source string) (string, error) {
+	src, err := url.Parse(source)
+	if err != nil && (strings.HasPrefix(source, "file:") || !errors.As(err, new(url.EscapeError))) {
+		return "", fmt.Errorf("failed to parse source URI: %w", err)
+	}
+	if err == nil && src.Scheme == "file" {
+		return src.Path, nil
+	}
+	return source, nil
+}
+
 // getFileSha calculates the SHA256 hash of a file located at the given path.
 // It returns the hexadecimal representation of the hash and any error encountered.
 // If the file cannot be opened or an error occurs while calculating the hash, an empty string and the error are returned.
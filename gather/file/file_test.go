@@ -17,11 +17,21 @@
 package file
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/file"
 )
 
 func TestFileGatherer_Gather(t *testing.T) {
@@ -137,7 +147,7 @@ func TestFileGatherer_copyFile(t *testing.T) {
 	// Test when the source is a file
 	sourceFile := tempFile.Name()
 	destinationFile := filepath.Join(tempDir, "destination_file")
-	_, err = gatherer.copyFile(context.Background(), sourceFile, fmt.Sprintf("%s%s", "file://", destinationFile))
+	_, err = gatherer.copyFile(context.Background(), sourceFile, fmt.Sprintf("%s%s", "file://", destinationFile), gogather.NewOptions())
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -150,7 +160,7 @@ func TestFileGatherer_copyFile(t *testing.T) {
 	// Test when the source is a directory
 	sourceDir := tempDir
 	destinationDir := filepath.Join(tempDir, "destination_dir")
-	_, err = gatherer.copyFile(context.Background(), sourceDir, fmt.Sprintf("%s%s", "file://", destinationDir))
+	_, err = gatherer.copyFile(context.Background(), sourceDir, fmt.Sprintf("%s%s", "file://", destinationDir), gogather.NewOptions())
 	if err == nil {
 		t.Error("expected an error, but got nil")
 	}
@@ -164,7 +174,7 @@ func TestFileGatherer_copyFile_Source_URIParseError(t *testing.T) {
 	// Test when url.Parse returns an error
 	source := ":"
 	destination := "destination_file"
-	_, err := gatherer.copyFile(context.Background(), source, destination)
+	_, err := gatherer.copyFile(context.Background(), source, destination, gogather.NewOptions())
 	if err == nil {
 		t.Error("expected an error, but got nil")
 	}
@@ -182,7 +192,7 @@ func TestFileGatherer_copyFile_OpenSourceFileError(t *testing.T) {
 	// Test when os.Open returns an error
 	source := "nonexistent_file"
 	destination := "destination_file"
-	_, err := gatherer.copyFile(context.Background(), source, destination)
+	_, err := gatherer.copyFile(context.Background(), source, destination, gogather.NewOptions())
 	if err == nil {
 		t.Error("expected an error, but got nil")
 	}
@@ -210,7 +220,7 @@ func TestFileGatherer_copyFile_Destination_URIParseError(t *testing.T) {
 	// Test when url.Parse returns an error
 	source := tempFile.Name()
 	destination := ":"
-	_, err = gatherer.copyFile(context.Background(), source, destination)
+	_, err = gatherer.copyFile(context.Background(), source, destination, gogather.NewOptions())
 	if err == nil {
 		t.Error("expected an error, but got nil")
 	}
@@ -247,7 +257,7 @@ func TestFileGatherer_copyFile_CreateSaverError(t *testing.T) {
 	// Test when saver.NewSaver returns an error
 	source := tempFile.Name()
 	destination := "ftp://destination_file"
-	_, err = gatherer.copyFile(context.Background(), source, destination)
+	_, err = gatherer.copyFile(context.Background(), source, destination, gogather.NewOptions())
 	if err == nil {
 		t.Error("expected an error, but got nil")
 	}
@@ -265,7 +275,7 @@ func TestFileGatherer_copyDirectory_Source_URIParseError(t *testing.T) {
 	// Test when url.Parse returns an error
 	source := ":"
 	destination := "destination_dir"
-	_, err := gatherer.copyDirectory(context.Background(), source, destination)
+	_, err := gatherer.copyDirectory(context.Background(), source, destination, gogather.NewOptions())
 	if err == nil {
 		t.Error("expected an error, but got nil")
 	}
@@ -282,7 +292,7 @@ func TestFileGatherer_copyDirectory_Destination_URIParseError(t *testing.T) {
 	// Test when url.Parse returns an error
 	source := "source_dir"
 	destination := ":"
-	_, err := gatherer.copyDirectory(context.Background(), source, destination)
+	_, err := gatherer.copyDirectory(context.Background(), source, destination, gogather.NewOptions())
 	if err == nil {
 		t.Error("expected an error, but got nil")
 	}
@@ -341,3 +351,494 @@ func TestFileGatherer_getFileSha_OpenFileError(t *testing.T) {
 	}
 
 }
+
+// TestFileGatherer_copyFile_DetectedContentType tests that the content type of the copied file is detected
+func TestFileGatherer_copyFile_DetectedContentType(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	if err := os.WriteFile(source, []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &FileGatherer{}
+	m, err := gatherer.copyFile(context.Background(), source, fmt.Sprintf("%s%s", "file://", filepath.Join(tempDir, "destination_file")), gogather.NewOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.(*file.FileMetadata).DetectedContentType() != "application/x-gzip" {
+		t.Errorf("unexpected detected content type: got %s, want %s", m.(*file.FileMetadata).DetectedContentType(), "application/x-gzip")
+	}
+}
+
+// TestFileGatherer_Gather_Transform tests that the transform is applied to every copied file and that the SHA reflects it
+func TestFileGatherer_Gather_Transform(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(filepath.Join(source, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "sub", "file.txt"), []byte("test content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	transform := gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+		return []byte("transformed"), nil
+	})
+
+	gatherer := &FileGatherer{}
+	destinationDir := filepath.Join(tempDir, "destination_dir")
+	if _, err := gatherer.Gather(context.Background(), source, "file://"+destinationDir, transform); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(destinationDir, "sub", "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "transformed" {
+		t.Errorf("unexpected content: got %s, want %s", content, "transformed")
+	}
+
+	destinationFile := filepath.Join(tempDir, "destination_file")
+	m, err := gatherer.Gather(context.Background(), filepath.Join(source, "sub", "file.txt"), "file://"+destinationFile, transform)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedSha, _ := getFileSha(filepath.Join(destinationDir, "sub", "file.txt"))
+	if m.(*file.FileMetadata).SHA != expectedSha {
+		t.Errorf("unexpected SHA: got %s, want %s", m.(*file.FileMetadata).SHA, expectedSha)
+	}
+}
+
+// TestFileGatherer_Gather_TransformError tests that a failing transform removes the created directory
+func TestFileGatherer_Gather_TransformError(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "file.txt"), []byte("test content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &FileGatherer{}
+	destinationDir := filepath.Join(tempDir, "destination_dir")
+	_, err := gatherer.Gather(context.Background(), source, "file://"+destinationDir, gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+		return nil, fmt.Errorf("bad content")
+	}))
+	if err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+	if _, err := os.Stat(destinationDir); !os.IsNotExist(err) {
+		t.Errorf("expected destination directory to be removed, but got: %v", err)
+	}
+}
+
+// memoryDestination is a gogather.Destination that keeps written files in memory.
+type memoryDestination struct {
+	mu    sync.Mutex
+	files map[string]*bytes.Buffer
+}
+
+type memoryFile struct{ *bytes.Buffer }
+
+func (memoryFile) Close() error { return nil }
+
+func (d *memoryDestination) Create(path string) (io.WriteCloser, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.files == nil {
+		d.files = map[string]*bytes.Buffer{}
+	}
+	d.files[path] = &bytes.Buffer{}
+	return memoryFile{d.files[path]}, nil
+}
+
+// TestFileGatherer_Gather_Destination tests that files and directories are written to a custom destination
+func TestFileGatherer_Gather_Destination(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(filepath.Join(source, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "sub", "file.txt"), []byte("test content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &FileGatherer{}
+	destination := &memoryDestination{}
+	if _, err := gatherer.Gather(context.Background(), source, "file:///bucket/dir", gogather.WithDestination(destination)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if destination.files["/bucket/dir/sub/file.txt"].String() != "test content" {
+		t.Errorf("unexpected files in destination: %v", destination.files)
+	}
+
+	m, err := gatherer.Gather(context.Background(), filepath.Join(source, "sub", "file.txt"), "object", gogather.WithDestination(destination))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedSha, _ := getFileSha(filepath.Join(source, "sub", "file.txt"))
+	if m.(*file.FileMetadata).SHA != expectedSha || m.(*file.FileMetadata).Size != 12 {
+		t.Errorf("unexpected metadata: %v", m.Get())
+	}
+	if destination.files["object"].String() != "test content" {
+		t.Errorf("unexpected files in destination: %v", destination.files)
+	}
+}
+
+func TestFileGatherer_DestinationName(t *testing.T) {
+	f := &FileGatherer{}
+
+	name, err := f.DestinationName(context.Background(), "file:///tmp/dir/foo.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "foo.txt" {
+		t.Errorf("expected foo.txt, but got %s", name)
+	}
+
+	if _, err := f.DestinationName(context.Background(), "/"); err == nil {
+		t.Errorf("expected an error, but got nil")
+	}
+}
+
+func TestFileGatherer_Gather_Decompress(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.txt.gz")
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	fmt.Fprint(gw, "hello world")
+	gw.Close()
+	if err := os.WriteFile(source, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &FileGatherer{}
+	m, err := f.Gather(context.Background(), "file://"+source, "file://"+filepath.Join(dir, "out", "foo.txt.gz"), gogather.WithDecompress(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.(*file.FileMetadata).Path != "file://"+filepath.Join(dir, "out", "foo.txt") {
+		t.Errorf("unexpected path: %s", m.(*file.FileMetadata).Path)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "out", "foo.txt"))
+	if err != nil || string(content) != "hello world" {
+		t.Errorf("expected the decompressed content, but got %q (%v)", content, err)
+	}
+}
+
+// TestFileGatherer_Gather_Events tests that the events of a file copy are sent to the channel.
+func TestFileGatherer_Gather_Events(t *testing.T) {
+	dir := t.TempDir()
+	source := "file://" + filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(filepath.Join(dir, "foo.txt"), []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan gogather.Event, 10)
+
+	f := &FileGatherer{}
+	if _, err := f.Gather(context.Background(), source, "file://"+filepath.Join(dir, "out.txt"), gogather.WithEventChannel(events)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(events)
+
+	var phases []gogather.EventPhase
+	for e := range events {
+		if e.Source != source {
+			t.Errorf("unexpected source: %s", e.Source)
+		}
+		if e.Phase == gogather.EventProgress && (e.Bytes != 11 || e.Total != 11) {
+			t.Errorf("unexpected progress: %d of %d bytes", e.Bytes, e.Total)
+		}
+		phases = append(phases, e.Phase)
+	}
+	expected := []gogather.EventPhase{gogather.EventStarted, gogather.EventProgress, gogather.EventDone}
+	if fmt.Sprint(phases) != fmt.Sprint(expected) {
+		t.Errorf("expected events %v, but got %v", expected, phases)
+	}
+}
+
+// tarGz returns a gzip compressed tar archive holding dir/hello.txt.
+func tarGz(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{Name: "dir/hello.txt", Mode: 0644, Size: 11}); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(tw, "hello world")
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestFileGatherer_Gather_Extract(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.tar.gz")
+	if err := os.WriteFile(source, tarGz(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &FileGatherer{}
+	m, err := f.Gather(context.Background(), "file://"+source, "file://"+filepath.Join(dir, "out"), gogather.WithExtract(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.(*file.DirectoryMetadata).Path != filepath.Join(dir, "out") {
+		t.Errorf("unexpected path: %s", m.(*file.DirectoryMetadata).Path)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "out", "dir", "hello.txt"))
+	if err != nil || string(content) != "hello world" {
+		t.Errorf("expected the extracted content, but got %q (%v)", content, err)
+	}
+
+	// The destination is removed again when the transform fails
+	_, err = f.Gather(context.Background(), "file://"+source, "file://"+filepath.Join(dir, "out2"), gogather.WithExtract(true), gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+		return nil, fmt.Errorf("transform failed")
+	}))
+	if err == nil {
+		t.Error("expected the transform error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out2")); !os.IsNotExist(err) {
+		t.Errorf("expected the destination to be removed, but got: %v", err)
+	}
+}
+
+// TestFileGatherer_Gather_AtomicDir tests that a failed gather leaves the previous directory in place.
+func TestFileGatherer_Gather_AtomicDir(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "file.txt"), []byte("new content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	destinationDir := filepath.Join(tempDir, "destination_dir")
+	if err := os.MkdirAll(destinationDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(destinationDir, "file.txt"), []byte("old content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &FileGatherer{}
+	_, err := gatherer.Gather(context.Background(), source, "file://"+destinationDir, gogather.WithAtomicDir(true),
+		gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+			return nil, fmt.Errorf("bad content")
+		}))
+	if err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+	if content, err := os.ReadFile(filepath.Join(destinationDir, "file.txt")); err != nil || string(content) != "old content" {
+		t.Errorf("expected the previous content to be kept, but got %q, %v", content, err)
+	}
+
+	if _, err := gatherer.Gather(context.Background(), source, "file://"+destinationDir, gogather.WithAtomicDir(true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(destinationDir, "file.txt")); err != nil || string(content) != "new content" {
+		t.Errorf("expected the new content, but got %q, %v", content, err)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 2 {
+		t.Errorf("expected no staging directories to be left, but got %v", entries)
+	}
+}
+
+// TestFileGatherer_Gather_SpecialCharacters tests that sources with spaces, "#", "?" and "%" in
+// their names are found, given as percent-encoded file:// URLs or as plain paths.
+func TestFileGatherer_Gather_SpecialCharacters(t *testing.T) {
+	tempDir := t.TempDir()
+	dir := filepath.Join(tempDir, "my folder #1")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"data file.txt", "notes#draft?.txt", "100%.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "encoded space", source: (&url.URL{Scheme: "file", Path: filepath.Join(dir, "data file.txt")}).String(), want: "data file.txt"},
+		{name: "encoded hash and question mark", source: (&url.URL{Scheme: "file", Path: filepath.Join(dir, "notes#draft?.txt")}).String(), want: "notes#draft?.txt"},
+		{name: "encoded percent", source: (&url.URL{Scheme: "file", Path: filepath.Join(dir, "100%.txt")}).String(), want: "100%.txt"},
+		{name: "plain space", source: filepath.Join(dir, "data file.txt"), want: "data file.txt"},
+		{name: "plain hash and question mark", source: filepath.Join(dir, "notes#draft?.txt"), want: "notes#draft?.txt"},
+		{name: "plain percent", source: filepath.Join(dir, "100%.txt"), want: "100%.txt"},
+	}
+
+	gatherer := &FileGatherer{}
+	for _, tc := range testCases {
+		destination := filepath.Join(t.TempDir(), "out.txt")
+		if _, err := gatherer.Gather(context.Background(), tc.source, "file://"+destination); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if content, err := os.ReadFile(destination); err != nil || string(content) != tc.want {
+			t.Errorf("%s: expected %q, but got %q, %v", tc.name, tc.want, content, err)
+		}
+		if name, err := gatherer.DestinationName(context.Background(), tc.source); err != nil || name != tc.want {
+			t.Errorf("%s: expected the name %q, but got %q, %v", tc.name, tc.want, name, err)
+		}
+	}
+
+	// Directories are copied from encoded URLs as well
+	destination := filepath.Join(t.TempDir(), "copy")
+	source := (&url.URL{Scheme: "file", Path: dir}).String()
+	if _, err := gatherer.Gather(context.Background(), source, "file://"+destination); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(destination, "notes#draft?.txt")); err != nil || string(content) != "notes#draft?.txt" {
+		t.Errorf("expected the directory to be copied, but got %q, %v", content, err)
+	}
+}
+
+// TestFileGatherer_Gather_CreateParents tests that missing parent directories are only created when allowed.
+func TestFileGatherer_Gather_CreateParents(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(source, []byte("content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	destination := filepath.Join(t.TempDir(), "a", "b", "file.txt")
+
+	gatherer := &FileGatherer{}
+	_, err := gatherer.Gather(context.Background(), source, "file://"+destination, gogather.WithCreateParents(false))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(destination)); !os.IsNotExist(err) {
+		t.Errorf("expected no directory to be created, but got: %v", err)
+	}
+
+	if _, err := gatherer.Gather(context.Background(), source, "file://"+destination); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, err := os.ReadFile(destination); err != nil || string(content) != "content" {
+		t.Errorf("expected the file to be copied, but got %q, %v", content, err)
+	}
+}
+
+// TestFileGatherer_Gather_Executable tests that copied files are made executable.
+func TestFileGatherer_Gather_Executable(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(source, []byte("#!/bin/sh\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	destination := filepath.Join(t.TempDir(), "tool")
+
+	gatherer := &FileGatherer{}
+	_, err := gatherer.Gather(context.Background(), source, "file://"+destination, gogather.WithExecutable(true), gogather.WithUmask(022))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected the file to have permission 755, but got %o", info.Mode().Perm())
+	}
+}
+
+// TestFileGatherer_Gather_MaxDirDepth tests that directories with entries deeper than the
+// maximum depth are refused.
+func TestFileGatherer_Gather_MaxDirDepth(t *testing.T) {
+	source := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(source, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "a", "b", "c.txt"), []byte("c"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &FileGatherer{}
+	_, err := gatherer.Gather(context.Background(), source, "file://"+filepath.Join(t.TempDir(), "out"), gogather.WithMaxDirDepth(2))
+	if !errors.Is(err, gogather.ErrMaxDepthExceeded) {
+		t.Errorf("expected ErrMaxDepthExceeded, but got: %v", err)
+	}
+	if _, err := gatherer.Gather(context.Background(), source, "file://"+filepath.Join(t.TempDir(), "out"), gogather.WithMaxDirDepth(3)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestFileGatherer_Gather_IncludeExclude tests that only the included files of a directory are
+// copied, excludes winning, and that excluded directories are not descended into.
+func TestFileGatherer_Gather_IncludeExclude(t *testing.T) {
+	source := t.TempDir()
+	for _, name := range []string{"policy/main.rego", "policy/main_test.rego", "docs/index.md", "vendor/lib.rego"} {
+		path := filepath.Join(source, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	gatherer := &FileGatherer{}
+	_, err := gatherer.Gather(context.Background(), source, "file://"+out,
+		gogather.WithInclude([]string{"*.rego"}), gogather.WithExclude([]string{"*_test.rego", "vendor/"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "policy", "main.rego")); err != nil {
+		t.Errorf("expected policy/main.rego to be copied: %v", err)
+	}
+	for _, name := range []string{"policy/main_test.rego", "docs", "vendor"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(name))); err == nil {
+			t.Errorf("expected %s to be left out", name)
+		}
+	}
+}
+
+// TestFileGatherer_Gather_Symlink tests that files and directories are not copied through
+// links that already exist at their destinations.
+func TestFileGatherer_Gather_Symlink(t *testing.T) {
+	tempDir := t.TempDir()
+	secret := filepath.Join(tempDir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	srcDir := filepath.Join(tempDir, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(srcDir, "foo.txt")
+	if err := os.WriteFile(source, []byte("evil"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(destDir, "foo.txt")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &FileGatherer{}
+	if _, err := gatherer.Gather(context.Background(), source, "file://"+link); !errors.Is(err, gogather.ErrDestinationIsSymlink) {
+		t.Errorf("expected ErrDestinationIsSymlink for a file, but got: %v", err)
+	}
+	if _, err := gatherer.Gather(context.Background(), srcDir, "file://"+destDir); !errors.Is(err, gogather.ErrDestinationIsSymlink) {
+		t.Errorf("expected ErrDestinationIsSymlink for a directory, but got: %v", err)
+	}
+	if content, _ := os.ReadFile(secret); string(content) != "secret" {
+		t.Errorf("expected the link target to be left alone, but got %q", content)
+	}
+
+	if _, err := gatherer.Gather(context.Background(), source, "file://"+link, gogather.WithSafeDestination(false)); err != nil {
+		t.Errorf("expected the link to be followed, but got: %v", err)
+	}
+	if content, _ := os.ReadFile(secret); string(content) != "evil" {
+		t.Errorf("expected the link target to be written, but got %q", content)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// sourcePath returns the path of source in the filesystem of the gatherer. Sources in an
+// fs.FS are slash-separated paths, unrooted and without "." or ".." elements, as fs.ValidPath
+// requires; "." names the root of the filesystem.
+func (f *FileGatherer) sourcePath(source string) (string, error) {
+	if f.FS == nil {
+		return sourcePath(source)
+	}
+	if !fs.ValidPath(source) {
+		return "", fmt.Errorf("invalid source path %q in the filesystem", source)
+	}
+	return source, nil
+}
+
+// stat returns the file info of the source file at path.
+func (f *FileGatherer) stat(path string) (fs.FileInfo, error) {
+	if f.FS == nil {
+		return os.Stat(path)
+	}
+	return fs.Stat(f.FS, path)
+}
+
+// open opens the source file at path for reading.
+func (f *FileGatherer) open(path string) (fs.File, error) {
+	if f.FS == nil {
+		return os.Open(filepath.Clean(path))
+	}
+	return f.FS.Open(path)
+}
+
+// walk walks the source directory tree at root, calling fn for each file or directory. The
+// local filesystem is walked with filepath.Walk, which lists a directory before fn is called
+// for it, so that a destination inside the source is not copied into itself.
+func (f *FileGatherer) walk(root string, fn fs.WalkDirFunc) error {
+	if f.FS == nil {
+		return filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
+			var d fs.DirEntry
+			if info != nil {
+				d = fs.FileInfoToDirEntry(info)
+			}
+			return fn(path, d, err)
+		})
+	}
+	return fs.WalkDir(f.FS, root, fn)
+}
@@ -3,9 +3,15 @@ module github.com/enterprise-contract/go-gather/gather/file
 go 1.21.9
 
 require (
+	github.com/enterprise-contract/go-gather v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata/file v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/saver v0.0.0-20240523073727-ba2c37023242
 )
 
-require github.com/enterprise-contract/go-gather/saver/file v0.0.0-20240523073727-ba2c37023242 // indirect
+require (
+	github.com/enterprise-contract/go-gather/saver/file v0.0.0-20240523073727-ba2c37023242 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/ulikunitz/xz v0.5.12 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+)
@@ -22,37 +22,131 @@ package gather
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 
 	gogather "github.com/enterprise-contract/go-gather"
 	"github.com/enterprise-contract/go-gather/gather/file"
 	"github.com/enterprise-contract/go-gather/gather/git"
 	"github.com/enterprise-contract/go-gather/gather/http"
+	"github.com/enterprise-contract/go-gather/gather/k8s"
+	"github.com/enterprise-contract/go-gather/gather/webdav"
 	"github.com/enterprise-contract/go-gather/metadata"
 )
 
 // Gatherer is an interface that defines the behavior of a gatherer.
 type Gatherer interface {
-	Gather(ctx context.Context, source, destination string) (metadata metadata.Metadata, err error)
+	Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (metadata metadata.Metadata, err error)
+}
+
+// DestinationNamer is implemented by gatherers that can tell the name of the file a gather
+// would produce without performing it.
+type DestinationNamer interface {
+	DestinationName(ctx context.Context, source string, opts ...gogather.Option) (string, error)
 }
 
 // protocolHandlers maps URL schemes to their corresponding Gatherer implementations.
 var protocolHandlers = map[string]Gatherer{
-	"FileURI": &file.FileGatherer{},
-	"GitURI":  &git.GitGatherer{},
-	"HTTPURI": &http.HTTPGatherer{},
+	"FileURI":   &file.FileGatherer{},
+	"GitURI":    &git.GitGatherer{},
+	"HTTPURI":   &http.HTTPGatherer{},
+	"K8sURI":    &k8s.K8sGatherer{},
+	"WebDAVURI": &webdav.WebDAVGatherer{},
 }
 
 // Gather determines the protocol from the source URI and uses the appropriate Gatherer to perform the operation.
+// Without a deadline, the context times out after the default timeout, see SetDefaultTimeout.
+// The lock file set with WithLockFile, if any, is held while gathering.
+// Package URLs are first mapped to sources with the template of WithURLTemplate, if any.
+// An empty destination is derived from the source, such as a directory named after a git
+// repository in the current working directory, see WithDefaultDestinationDir, and
+// ErrAmbiguousDestination is returned if the source names none.
+// With WithCASLayout, destination is a content-addressed store, see CASPath, and the metadata
+// is a *CASMetadata with the checksums of the stored content.
+// The options are passed on to the selected Gatherer.
 // It returns the gathered metadata and an error, if any.
-func Gather(ctx context.Context, source, destination string) (metadata.Metadata, error) {
-	srcProtocol, err := gogather.ClassifyURI(source)
+func Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	source, err := gogather.NewOptions(opts...).ResolvePackageURL(source)
+	if err != nil {
+		return nil, err
+	}
+	srcProtocol, err := gogather.ClassifyURIWithOptions(source, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to classify source URI: %w", err)
 	}
 
 	if gatherer, ok := protocolHandlers[srcProtocol.String()]; ok {
-		return gatherer.Gather(ctx, source, destination)
+		if gogather.NewOptions(opts...).CASLayout {
+			return gatherCAS(ctx, gatherer, source, destination, opts...)
+		}
+		destination, err := defaultDestination(ctx, gatherer, source, destination, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return gatherLocked(ctx, wrap(gatherer), source, destination, opts...)
 	}
 	return nil, fmt.Errorf("unsupported source protocol: %s", srcProtocol)
 }
+
+// gatherLocked gathers source with gatherer while holding the lock file set with
+// WithLockFile, if any.
+func gatherLocked(ctx context.Context, gatherer Gatherer, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
+	release, err := gogather.NewOptions(opts...).AcquireLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return gatherer.Gather(ctx, source, destination, opts...)
+}
+
+// SuggestDestinationName returns the name of the file that gathering source would produce,
+// using the Gatherer that Gather would select. It allows callers to build output paths and
+// detect collisions before gathering. The http gatherer may send a HEAD request to learn the
+// name from the Content-Disposition header when the URL does not contain one.
+func SuggestDestinationName(ctx context.Context, source string, opts ...gogather.Option) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	source, err := gogather.NewOptions(opts...).ResolvePackageURL(source)
+	if err != nil {
+		return "", err
+	}
+	srcProtocol, err := gogather.ClassifyURIWithOptions(source, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to classify source URI: %w", err)
+	}
+
+	gatherer, ok := protocolHandlers[srcProtocol.String()]
+	if !ok {
+		return "", fmt.Errorf("unsupported source protocol: %s", srcProtocol)
+	}
+	namer, ok := gatherer.(DestinationNamer)
+	if !ok {
+		return "", fmt.Errorf("the %s gatherer does not suggest destination names", srcProtocol)
+	}
+	return namer.DestinationName(ctx, source, opts...)
+}
+
+// Close releases the resources, such as pooled connections, held by the registered gatherers.
+// Gatherers that implement io.Closer are closed in protocol order; the others are skipped.
+// All gatherers are closed even if some fail, and their errors are joined.
+func Close() error {
+	protocols := make([]string, 0, len(protocolHandlers))
+	for protocol := range protocolHandlers {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+
+	var errs []error
+	for _, protocol := range protocols {
+		if c, ok := protocolHandlers[protocol].(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close %s gatherer: %w", protocol, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,150 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// maxConcurrentGathers limits the number of sources GatherAll gathers at the same time.
+const maxConcurrentGathers = 10
+
+// GatherAllResult holds the outcome of GatherAll for every source.
+type GatherAllResult struct {
+	// Metadata maps each source that was gathered successfully to its metadata.
+	Metadata map[string]metadata.Metadata
+	// Errors maps each source that failed to its error.
+	Errors map[string]error
+}
+
+// GatherAllError is returned by GatherAll when one or more sources failed.
+type GatherAllError struct {
+	Errors map[string]error
+}
+
+// Error lists the failed sources in a stable order.
+func (e *GatherAllError) Error() string {
+	sources := make([]string, 0, len(e.Errors))
+	for source := range e.Errors {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	messages := make([]string, 0, len(sources))
+	for _, source := range sources {
+		messages = append(messages, fmt.Sprintf("%s: %s", source, e.Errors[source]))
+	}
+	return fmt.Sprintf("failed to gather %d source(s): %s", len(sources), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the failed sources, so errors.Is and errors.As
+// can match any of them.
+func (e *GatherAllError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// GatherAll gathers every source in sources, which maps sources to destinations, concurrently.
+// The result always holds the metadata of the sources that succeeded and the errors of those that
+// failed. If any source failed, a *GatherAllError is returned as well, so callers can decide whether
+// the partial results are still usable.
+// A transport built from the options is shared by all the gathers, so that limits such as
+// WithMaxConnsPerHost apply across the run, and its connections are closed when it is done.
+// Clones of the git gatherer over HTTPS use it as well, so that concurrent clones from the
+// same host reuse its idle connections instead of each setting up its own.
+func GatherAll(ctx context.Context, sources map[string]string, opts ...gogather.Option) (*GatherAllResult, error) {
+	jobs := make(map[string]gatherJob, len(sources))
+	for source, destination := range sources {
+		jobs[source] = gatherJob{source: source, destination: destination}
+	}
+
+	result := &GatherAllResult{}
+	result.Metadata, result.Errors = gatherConcurrently(ctx, jobs, opts...)
+	if len(result.Errors) > 0 {
+		return result, &GatherAllError{Errors: result.Errors}
+	}
+	return result, nil
+}
+
+// gatherJob is a source to gather to a destination.
+type gatherJob struct {
+	source      string
+	destination string
+}
+
+// gatherConcurrently gathers the jobs concurrently and returns the metadata of those that
+// succeeded and the errors of those that failed, keyed like jobs.
+func gatherConcurrently(ctx context.Context, jobs map[string]gatherJob, opts ...gogather.Option) (map[string]metadata.Metadata, map[string]error) {
+	o := gogather.NewOptions(opts...)
+	if transport := o.Transport(); o.OwnsTransport(transport) {
+		if t, ok := transport.(*http.Transport); ok {
+			defer t.CloseIdleConnections()
+		}
+		opts = append(opts[:len(opts):len(opts)], gogather.WithHTTPTransport(transport))
+	}
+
+	metadatas := make(map[string]metadata.Metadata)
+	errs := make(map[string]error)
+
+	// Hold the lock file, if any, once for all the jobs rather than serializing them
+	release, err := o.AcquireLock(ctx)
+	if err != nil {
+		for key := range jobs {
+			errs[key] = err
+		}
+		return metadatas, errs
+	}
+	defer release()
+	opts = append(opts[:len(opts):len(opts)], gogather.WithLockFile(""))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrentGathers)
+
+	for key, job := range jobs {
+		wg.Add(1)
+		go func(key string, job gatherJob) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			m, err := Gather(ctx, job.source, job.destination, opts...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[key] = err
+				return
+			}
+			metadatas[key] = m
+		}(key, job)
+	}
+	wg.Wait()
+
+	return metadatas, errs
+}
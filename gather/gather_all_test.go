@@ -0,0 +1,185 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+func TestGatherAll(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(source, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("AllSucceed", func(t *testing.T) {
+		result, err := GatherAll(ctx, map[string]string{
+			source: "file://" + filepath.Join(dir, "bar.txt"),
+		})
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		if len(result.Metadata) != 1 || len(result.Errors) != 0 {
+			t.Errorf("unexpected result: %v", result)
+		}
+	})
+
+	t.Run("PartialSuccess", func(t *testing.T) {
+		result, err := GatherAll(ctx, map[string]string{
+			source:                       "file://" + filepath.Join(dir, "baz.txt"),
+			"ftp://example.com/file.txt": filepath.Join(dir, "ftp.txt"),
+			"/does/not/exist.txt":        filepath.Join(dir, "missing.txt"),
+		})
+
+		var gatherAllErr *GatherAllError
+		if !errors.As(err, &gatherAllErr) {
+			t.Fatalf("expected a GatherAllError, but got: %v", err)
+		}
+		if len(gatherAllErr.Unwrap()) != 2 {
+			t.Errorf("expected 2 wrapped errors, but got: %d", len(gatherAllErr.Unwrap()))
+		}
+		if _, ok := result.Metadata[source]; !ok {
+			t.Errorf("expected metadata for %s, but got: %v", source, result.Metadata)
+		}
+		if _, ok := result.Errors["ftp://example.com/file.txt"]; !ok {
+			t.Errorf("expected an error for the ftp source, but got: %v", result.Errors)
+		}
+		expectedPrefix := "failed to gather 2 source(s): /does/not/exist.txt: "
+		if err.Error()[:len(expectedPrefix)] != expectedPrefix {
+			t.Errorf("unexpected error message: %s", err)
+		}
+	})
+}
+
+func TestGatherAll_MaxConnsPerHost(t *testing.T) {
+	var mu sync.Mutex
+	var active, maxActive int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "hello world")
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	sources := map[string]string{}
+	for i := 0; i < 5; i++ {
+		sources[fmt.Sprintf("%s/file%d.txt", server.URL, i)] = dir + "/"
+	}
+
+	_, err := GatherAll(context.Background(), sources, gogather.WithMaxConnsPerHost(1))
+	if err != nil {
+		t.Fatalf("expected no error, but got: %s", err)
+	}
+	if maxActive != 1 {
+		t.Errorf("expected at most 1 concurrent connection, but got: %d", maxActive)
+	}
+}
+
+// TestGatherAll_GitConnectionReuse tests that concurrent clones from the same host share the
+// connections of the transport built from the options, within WithMaxConnsPerHost.
+func TestGatherAll_GitConnectionReuse(t *testing.T) {
+	out, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	backend := filepath.Join(strings.TrimSpace(string(out)), "git-http-backend")
+
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo.git")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "README.md"}, {"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+
+	var mu sync.Mutex
+	var opened, open, maxOpen int
+	server := httptest.NewUnstartedServer(&cgi.Handler{
+		Path: backend,
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch state {
+		case http.StateNew:
+			opened++
+			open++
+			maxOpen = max(maxOpen, open)
+		case http.StateClosed, http.StateHijacked:
+			open--
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	sources := map[string]string{}
+	for i := 0; i < 5; i++ {
+		// Each source is a distinct URL of the same repository
+		sources[fmt.Sprintf("git::%s/repo.git?depth=%d", server.URL, i+1)] = filepath.Join(t.TempDir(), "clone")
+	}
+	_, err = GatherAll(context.Background(), sources, gogather.WithRootCAs(pool), gogather.WithMaxConnsPerHost(1))
+	if err != nil {
+		t.Fatalf("expected no error, but got: %s", err)
+	}
+	for _, destination := range sources {
+		if _, err := os.Stat(filepath.Join(destination, "README.md")); err != nil {
+			t.Errorf("expected README.md to be cloned to %s, but got: %s", destination, err)
+		}
+	}
+	if opened != 1 || maxOpen != 1 {
+		t.Errorf("expected the clones to share 1 connection, but %d were opened and %d open at once", opened, maxOpen)
+	}
+}
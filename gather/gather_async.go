@@ -0,0 +1,159 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// asyncEventBuffer is the number of events GatherAsync buffers while tracking progress.
+const asyncEventBuffer = 64
+
+// GatherHandle controls a gather started with GatherAsync.
+type GatherHandle struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	m      metadata.Metadata
+	err    error
+
+	mu       sync.Mutex
+	progress map[string]gogather.Event
+}
+
+// GatherAsync starts gathering source into destination in the background, as Gather does, and
+// returns a handle to follow, wait for or cancel it. An error is returned right away only when
+// a package URL cannot be mapped to a source, no gatherer handles the source or no
+// destination can be derived for an empty one; the errors of the gather itself are returned
+// by Wait. With WithCASLayout, destination is a content-addressed store, as for Gather.
+// The handle tracks progress through the events of the gather. An event channel given with
+// WithEventChannel still receives every event.
+func GatherAsync(ctx context.Context, source, destination string, opts ...gogather.Option) (*GatherHandle, error) {
+	source, err := gogather.NewOptions(opts...).ResolvePackageURL(source)
+	if err != nil {
+		return nil, err
+	}
+	srcProtocol, err := gogather.ClassifyURIWithOptions(source, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to classify source URI: %w", err)
+	}
+	gatherer, ok := protocolHandlers[srcProtocol.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source protocol: %s", srcProtocol)
+	}
+
+	ctx, stop := withDefaultTimeout(ctx)
+	cas := gogather.NewOptions(opts...).CASLayout
+	if !cas {
+		destination, err = defaultDestination(ctx, gatherer, source, destination, opts...)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	h := &GatherHandle{
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		progress: make(map[string]gogather.Event),
+	}
+
+	// Track the events of the gather, passing them on to the caller's channel, if any
+	forward := gogather.NewOptions(opts...).Events
+	events := make(chan gogather.Event, asyncEventBuffer)
+	opts = append(opts[:len(opts):len(opts)], gogather.WithEventChannel(events))
+	tracked := make(chan struct{})
+	go func() {
+		defer close(tracked)
+		for e := range events {
+			h.track(e)
+			if forward != nil {
+				select {
+				case forward <- e:
+				default:
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer close(h.done)
+		defer stop()
+		defer cancel()
+		if cas {
+			h.m, h.err = gatherCAS(ctx, gatherer, source, destination, opts...)
+		} else {
+			h.m, h.err = gatherLocked(ctx, wrap(gatherer), source, destination, opts...)
+		}
+		close(events)
+		<-tracked
+	}()
+	return h, nil
+}
+
+// track records the latest progress event of every file of the gather.
+func (h *GatherHandle) track(e gogather.Event) {
+	if e.Phase != gogather.EventProgress {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.progress[e.Source] = e
+}
+
+// Cancel stops the gather by cancelling its context. Wait returns once the gatherer has
+// stopped. Cancelling a gather that is already done has no effect.
+func (h *GatherHandle) Cancel() {
+	h.cancel()
+}
+
+// Done returns a channel that is closed once the gather is done, so its status can be
+// checked without blocking.
+func (h *GatherHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the gather is done and returns its metadata and error, as Gather does.
+func (h *GatherHandle) Wait() (metadata.Metadata, error) {
+	<-h.done
+	return h.m, h.err
+}
+
+// Progress returns the number of bytes read so far and the expected total, summed over the
+// files of the gather, or -1 as the total while it is unknown. Progress is only reported by
+// the gatherers that send progress events, so it stays at 0 for the others. Events are
+// dropped rather than stalling the gather, so the numbers may lag behind slightly.
+func (h *GatherHandle) Progress() (done, total int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.progress) == 0 {
+		return 0, -1
+	}
+	for _, e := range h.progress {
+		done += e.Bytes
+		if e.Total < 0 || total < 0 {
+			total = -1
+		} else {
+			total += e.Total
+		}
+	}
+	return done, total
+}
@@ -0,0 +1,138 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+func TestGatherAsync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(source, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Wait", func(t *testing.T) {
+		events := make(chan gogather.Event, 10)
+		handle, err := GatherAsync(ctx, source, "file://"+filepath.Join(dir, "bar.txt"), gogather.WithEventChannel(events))
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		m, err := handle.Wait()
+		if err != nil || m == nil {
+			t.Fatalf("expected metadata, but got: %v, %v", m, err)
+		}
+		select {
+		case <-handle.Done():
+		default:
+			t.Error("expected the handle to be done")
+		}
+		if done, total := handle.Progress(); done != 11 || total != 11 {
+			t.Errorf("expected a progress of 11/11, but got: %d/%d", done, total)
+		}
+		if len(events) == 0 {
+			t.Error("expected the events to be passed on to the caller's channel")
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "100")
+			fmt.Fprint(w, "hello")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		handle, err := GatherAsync(ctx, server.URL+"/foo.txt", filepath.Join(dir, "slow.txt"))
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if done, _ := handle.Progress(); done > 0 {
+				break
+			}
+		}
+		if done, total := handle.Progress(); done != 5 || total != 100 {
+			t.Errorf("expected a progress of 5/100, but got: %d/%d", done, total)
+		}
+
+		handle.Cancel()
+		if _, err := handle.Wait(); err == nil {
+			t.Error("expected the cancelled gather to fail")
+		}
+	})
+
+	t.Run("CASLayout", func(t *testing.T) {
+		store := t.TempDir()
+		handle, err := GatherAsync(ctx, source, store, gogather.WithCASLayout(true))
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		m, err := handle.Wait()
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		cm, ok := m.(*CASMetadata)
+		if !ok {
+			t.Fatalf("expected *CASMetadata, but got %T", m)
+		}
+		if _, err := os.Stat(CASPath(store, cm.Hash)); err != nil {
+			t.Errorf("expected the content in the store, but got: %v", err)
+		}
+	})
+
+	t.Run("PackageURL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/lib/1.2.3/lib.jar" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, "jar")
+		}))
+		defer server.Close()
+
+		destination := filepath.Join(dir, "lib.jar")
+		handle, err := GatherAsync(ctx, "pkg:maven/org.example/lib@1.2.3", destination, gogather.WithURLTemplate(server.URL+"/{{.Name}}/{{.Version}}/{{.Name}}.jar"))
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		if _, err := handle.Wait(); err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		if content, err := os.ReadFile(destination); err != nil || string(content) != "jar" {
+			t.Errorf("expected %q, but got %q, %v", "jar", content, err)
+		}
+	})
+
+	t.Run("UnsupportedProtocol", func(t *testing.T) {
+		if _, err := GatherAsync(ctx, "ftp://example.com/file.txt", dir); err == nil {
+			t.Error("expected an error for an unsupported protocol")
+		}
+	})
+}
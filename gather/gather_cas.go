@@ -0,0 +1,236 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// CASAlgorithm is the checksum algorithm content is addressed by in the store layout of
+// WithCASLayout.
+const CASAlgorithm = "sha256"
+
+// CASPath returns the path of the content with hash in the content-addressed store at dest:
+// dest/sha256/<the first two hex digits of hash>/<hash>. The hash may be given with its
+// "sha256:" prefix, as CASMetadata reports it.
+func CASPath(dest, hash string) string {
+	hash = strings.ToLower(strings.TrimPrefix(hash, CASAlgorithm+":"))
+	prefix := hash
+	if len(hash) > 2 {
+		prefix = hash[:2]
+	}
+	return filepath.Join(strings.TrimPrefix(dest, "file://"), CASAlgorithm, prefix, hash)
+}
+
+// CASType is the type of the metadata in the JSON envelopes of metadata.UnmarshalMetadata.
+const CASType = "cas"
+
+func init() {
+	metadata.RegisterType(CASType, func(data []byte) (metadata.Metadata, error) {
+		m := &CASMetadata{}
+		return m, json.Unmarshal(data, m)
+	})
+}
+
+// CASMetadata is the metadata of a gather into a content-addressed store with WithCASLayout.
+type CASMetadata struct {
+	// Gathered is the metadata of the gatherer. The paths it reports are in the temporary
+	// directory the source was gathered into, removed once its files are stored.
+	Gathered metadata.Metadata `json:"gathered"`
+	// Hash is the checksum, "sha256:<hex>", of the content of a source gathered as a single
+	// file, and empty otherwise.
+	Hash string `json:"hash"`
+	// Path is the path of the content of a single file in the store, see CASPath.
+	Path string `json:"casPath"`
+	// Files maps the slash separated paths of the gathered files, relative to the gathered
+	// directory or the name of a single file, to the checksum of their content.
+	Files map[string]string `json:"files"`
+}
+
+// casFields are the fields of CASMetadata in its envelope, with the metadata of the gatherer
+// in its own envelope.
+type casFields struct {
+	Gathered json.RawMessage   `json:"gathered"`
+	Hash     string            `json:"hash"`
+	Path     string            `json:"casPath"`
+	Files    map[string]string `json:"files"`
+}
+
+// MarshalJSON encodes the metadata in an envelope of type "cas", the metadata of the
+// gatherer nested in its own envelope.
+func (m CASMetadata) MarshalJSON() ([]byte, error) {
+	gathered, err := json.Marshal(m.Gathered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s metadata: %w", CASType, err)
+	}
+	return metadata.MarshalEnvelope(CASType, casFields{Gathered: gathered, Hash: m.Hash, Path: m.Path, Files: m.Files})
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "cas". The metadata of the
+// gatherer is decoded with metadata.UnmarshalMetadata.
+func (m *CASMetadata) UnmarshalJSON(data []byte) error {
+	var fields casFields
+	if err := metadata.UnmarshalEnvelope(data, CASType, &fields); err != nil {
+		return err
+	}
+	*m = CASMetadata{Hash: fields.Hash, Path: fields.Path, Files: fields.Files}
+	if len(fields.Gathered) > 0 && string(fields.Gathered) != "null" {
+		gathered, err := metadata.UnmarshalMetadata(fields.Gathered)
+		if err != nil {
+			return err
+		}
+		m.Gathered = gathered
+	}
+	return nil
+}
+
+// Get returns the metadata of the gatherer with the checksums of the stored content.
+func (m *CASMetadata) Get() map[string]any {
+	values := map[string]any{}
+	if m.Gathered != nil {
+		for k, v := range m.Gathered.Get() {
+			values[k] = v
+		}
+	}
+	values["hash"] = m.Hash
+	values["casPath"] = m.Path
+	values["files"] = m.Files
+	return values
+}
+
+// AuthMethod returns how the gatherer authenticated with the source.
+func (m *CASMetadata) AuthMethod() string {
+	if m.Gathered == nil {
+		return metadata.AuthAnonymous
+	}
+	return m.Gathered.AuthMethod()
+}
+
+// gatherCAS gathers source with gatherer, wrapped with the middlewares of Use, into a
+// temporary directory in the content-addressed store at destination, then moves the content
+// of every gathered file to its CASPath, unless the store already has it. Symbolic links and
+// the .git directory of a clone are not stored.
+func gatherCAS(ctx context.Context, gatherer Gatherer, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
+	o := gogather.NewOptions(opts...)
+	if o.Destination != nil {
+		return nil, fmt.Errorf("%w: a content-addressed store is a local directory", gogather.ErrDestinationNotSupported)
+	}
+	if destination == "" {
+		return nil, fmt.Errorf("a content-addressed store needs a destination directory")
+	}
+	root := strings.TrimPrefix(destination, "file://")
+	if err := o.MkdirAll(root); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	// Gather into the store, so that files are moved rather than copied into place
+	staging, err := os.MkdirTemp(root, ".gather-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	staged := filepath.Join(staging, stagedName(ctx, gatherer, source, opts...))
+	m, err := gatherLocked(ctx, wrap(gatherer), source, intoDestination(source, staged, opts...), opts...)
+	if err != nil {
+		return nil, err
+	}
+	// The git gatherer does not record where it cloned, which is the staged destination, and
+	// the file gatherer reports a single file with the file:// prefix of its destination
+	output := strings.TrimPrefix(outputPath(m), "file://")
+	if output == "" {
+		output = staged
+	}
+
+	result := &CASMetadata{Gathered: m, Files: map[string]string{}}
+	err = filepath.WalkDir(output, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case !d.Type().IsRegular():
+			return nil
+		}
+		hash, err := storeContent(o, root, path)
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if path != output {
+			rel, err := filepath.Rel(output, path)
+			if err != nil {
+				return err
+			}
+			name = filepath.ToSlash(rel)
+		} else {
+			result.Hash, result.Path = hash, CASPath(root, hash)
+		}
+		result.Files[name] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", output, err)
+	}
+	return result, nil
+}
+
+// stagedName returns the name to gather source under in the staging directory of a store: the
+// name gatherer suggests for it, if any, so that a single file is reported by its name. The
+// http gatherer may send a HEAD request to learn it.
+func stagedName(ctx context.Context, gatherer Gatherer, source string, opts ...gogather.Option) string {
+	if namer, ok := gatherer.(DestinationNamer); ok {
+		name, err := namer.DestinationName(ctx, source, opts...)
+		if err == nil && filepath.IsLocal(name) && !strings.ContainsAny(name, `/\`) {
+			return name
+		}
+	}
+	return "content"
+}
+
+// storeContent moves the file at path to its CASPath in the store at root, or leaves it to be
+// removed if the store already has its content, and returns its checksum.
+func storeContent(o *gogather.Options, root, path string) (string, error) {
+	h := sha256.New()
+	if err := hashFile(h, path); err != nil {
+		return "", err
+	}
+	hash := CASAlgorithm + ":" + hex.EncodeToString(h.Sum(nil))
+	target := CASPath(root, hash)
+	if _, err := os.Lstat(target); err == nil {
+		return hash, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err := o.MkdirAll(filepath.Dir(target)); err != nil {
+		return "", err
+	}
+	return hash, os.Rename(path, target)
+}
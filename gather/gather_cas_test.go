@@ -0,0 +1,165 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// helloSHA256 is the SHA-256 checksum of "hello world".
+const helloSHA256 = "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+// TestCASPath tests the path of content in a content-addressed store.
+func TestCASPath(t *testing.T) {
+	expected := filepath.Join("/cache", "sha256", "b9", helloSHA256[len("sha256:"):])
+	for _, hash := range []string{helloSHA256, helloSHA256[len("sha256:"):]} {
+		if path := CASPath("/cache", hash); path != expected {
+			t.Errorf("expected %s, but got %s", expected, path)
+		}
+	}
+	if path := CASPath("file:///cache", helloSHA256); path != expected {
+		t.Errorf("expected %s, but got %s", expected, path)
+	}
+}
+
+// TestGather_CASLayout tests that the files of a directory are stored by their content, once
+// for identical content, and that gathering them again writes nothing new.
+func TestGather_CASLayout(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{"a.txt": "hello world", "sub/b.txt": "hello world", "c.txt": "other"})
+	store := t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		m, err := Gather(context.Background(), source, "file://"+store, gogather.WithCASLayout(true))
+		if err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+		cm, ok := m.(*CASMetadata)
+		if !ok {
+			t.Fatalf("expected *CASMetadata, but got %T", m)
+		}
+		if cm.Files["a.txt"] != helloSHA256 || cm.Files["sub/b.txt"] != helloSHA256 || len(cm.Files) != 3 {
+			t.Errorf("unexpected files: %v", cm.Files)
+		}
+		if cm.Hash != "" {
+			t.Errorf("expected no hash for a directory, but got %s", cm.Hash)
+		}
+	}
+
+	content, err := os.ReadFile(CASPath(store, helloSHA256))
+	if err != nil || string(content) != "hello world" {
+		t.Errorf("expected the content in the store, but got %q (%v)", content, err)
+	}
+	entries, err := os.ReadDir(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != CASAlgorithm {
+		t.Errorf("expected only the %s directory in the store, but got %v", CASAlgorithm, entries)
+	}
+	var objects []string
+	_ = filepath.WalkDir(store, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			objects = append(objects, path)
+		}
+		return err
+	})
+	if len(objects) != 2 {
+		t.Errorf("expected 2 objects in the store, but got %v", objects)
+	}
+}
+
+// TestGather_CASLayoutFile tests that the hash of a single file is returned.
+func TestGather_CASLayoutFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello world")
+	}))
+	defer server.Close()
+	store := t.TempDir()
+
+	m, err := Gather(context.Background(), server.URL+"/files/hello.txt", store, gogather.WithCASLayout(true))
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	cm := m.(*CASMetadata)
+	if cm.Hash != helloSHA256 || cm.Path != CASPath(store, helloSHA256) {
+		t.Errorf("unexpected hash %s and path %s", cm.Hash, cm.Path)
+	}
+	if expected := map[string]string{"hello.txt": helloSHA256}; !reflect.DeepEqual(cm.Files, expected) {
+		t.Errorf("expected %v, but got %v", expected, cm.Files)
+	}
+	if _, err := os.Stat(cm.Path); err != nil {
+		t.Errorf("expected the content in the store, but got: %v", err)
+	}
+}
+
+// TestGather_CASLayoutDestinationNotSupported tests that custom destinations are refused.
+func TestGather_CASLayoutDestinationNotSupported(t *testing.T) {
+	_, err := Gather(context.Background(), t.TempDir(), t.TempDir(), gogather.WithCASLayout(true), gogather.WithDestination(&writerDestination{w: io.Discard}))
+	if !errors.Is(err, gogather.ErrDestinationNotSupported) {
+		t.Errorf("expected ErrDestinationNotSupported, but got: %v", err)
+	}
+}
+
+// TestCASMetadata_JSON tests that the metadata is encoded in a "cas" envelope nesting the
+// envelope of the gatherer, and decoded back by metadata.UnmarshalMetadata.
+func TestCASMetadata_JSON(t *testing.T) {
+	m := &CASMetadata{
+		Gathered: httpMetadata.HTTPMetadata{StatusCode: 200, Destination: "/tmp/.gather-1/hello.txt"},
+		Hash:     helloSHA256,
+		Path:     CASPath("/cache", helloSHA256),
+		Files:    map[string]string{"hello.txt": helloSHA256},
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	var envelope struct {
+		Type     string `json:"type"`
+		Metadata struct {
+			Gathered metadata.Envelope `json:"gathered"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Type != CASType || envelope.Metadata.Gathered.Type != httpMetadata.HTTPType {
+		t.Errorf("expected a %s envelope nesting a %s one, but got %s", CASType, httpMetadata.HTTPType, data)
+	}
+
+	decoded, err := metadata.UnmarshalMetadata(data)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, m) {
+		t.Errorf("expected %+v, but got %+v", m, decoded)
+	}
+}
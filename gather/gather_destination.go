@@ -0,0 +1,68 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/gather/file"
+)
+
+// ErrAmbiguousDestination is returned when no destination can be derived for a source
+// gathered with an empty destination.
+var ErrAmbiguousDestination = errors.New("cannot derive a destination")
+
+// defaultDestination returns destination, or when it is empty the destination derived for
+// source: the name gatherer suggests for it, such as the name of a git repository, the last
+// element of a URL path or the base name of a file, below the directory of
+// WithDefaultDestinationDir or else the current working directory. Gathers to a custom
+// destination have no path and are left alone.
+func defaultDestination(ctx context.Context, gatherer Gatherer, source, destination string, opts ...gogather.Option) (string, error) {
+	o := gogather.NewOptions(opts...)
+	if destination != "" || o.Destination != nil {
+		return destination, nil
+	}
+
+	namer, ok := gatherer.(DestinationNamer)
+	if !ok {
+		return "", fmt.Errorf("%w for %s: the gatherer does not suggest destination names", ErrAmbiguousDestination, source)
+	}
+	name, err := namer.DestinationName(ctx, source, opts...)
+	if err != nil {
+		return "", fmt.Errorf("%w for %s: %w", ErrAmbiguousDestination, source, err)
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%w for %s: %q is not a file name", ErrAmbiguousDestination, source, name)
+	}
+
+	dir, err := filepath.Abs(gogather.ExpandTilde(o.DefaultDestinationDir))
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	destination = filepath.Join(dir, name)
+
+	// The file gatherer takes its destination as a file:// URI
+	if _, ok := gatherer.(*file.FileGatherer); ok {
+		destination = "file://" + destination
+	}
+	return destination, nil
+}
@@ -0,0 +1,126 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// TestGather_DefaultDestination tests that an empty destination is derived from the source
+// below the directory of WithDefaultDestinationDir.
+func TestGather_DefaultDestination(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{"data.txt": "file", "dir/a.txt": "dir"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("http"))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name   string
+		source string
+		path   string
+		want   string
+	}{
+		{name: "file", source: filepath.Join(source, "data.txt"), path: "data.txt", want: "file"},
+		{name: "directory", source: filepath.Join(source, "dir"), path: "dir/a.txt", want: "dir"},
+		{name: "http", source: server.URL + "/files/report.json", path: "report.json", want: "http"},
+	}
+
+	for _, tc := range testCases {
+		dir := t.TempDir()
+		if _, err := Gather(context.Background(), tc.source, "", gogather.WithDefaultDestinationDir(dir)); err != nil {
+			t.Errorf("%s: expected no error, but got: %v", tc.name, err)
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(tc.path)))
+		if err != nil || string(content) != tc.want {
+			t.Errorf("%s: expected %s to contain %q, but got %q (%v)", tc.name, tc.path, tc.want, content, err)
+		}
+	}
+}
+
+// TestGather_DefaultDestinationGit tests that a repository is cloned into a directory named
+// after it.
+func TestGather_DefaultDestinationGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := filepath.Join(t.TempDir(), "policy.git")
+	writeFiles(t, repo, map[string]string{"README.md": "hello"})
+	for _, args := range [][]string{{"init", "-q"}, {"add", "README.md"}, {"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+
+	dir := t.TempDir()
+	if _, err := Gather(context.Background(), "git::file://"+repo, "", gogather.WithDefaultDestinationDir(dir)); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "policy", "README.md")); err != nil {
+		t.Errorf("expected the repository to be cloned into policy, but got: %v", err)
+	}
+}
+
+// TestGather_DefaultDestinationWorkingDirectory tests that derived destinations are rooted in
+// the current working directory by default.
+func TestGather_DefaultDestinationWorkingDirectory(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(source, []byte("file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	if _, err := Gather(context.Background(), source, ""); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data.txt")); err != nil {
+		t.Errorf("expected data.txt in the working directory, but got: %v", err)
+	}
+}
+
+// TestGather_DefaultDestinationAmbiguous tests that sources that name no destination are
+// refused.
+func TestGather_DefaultDestinationAmbiguous(t *testing.T) {
+	for _, source := range []string{"file:///", "webdavs://dav.example.com/docs"} {
+		_, err := Gather(context.Background(), source, "", gogather.WithDefaultDestinationDir(t.TempDir()))
+		if !errors.Is(err, ErrAmbiguousDestination) {
+			t.Errorf("%s: expected ErrAmbiguousDestination, but got: %v", source, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// DiffResult lists the changes gathering a source over an existing destination would make.
+// Paths are slash separated and relative to the destination directory, or the name of the
+// destination file for a single file.
+type DiffResult struct {
+	// Added lists the files the destination does not have.
+	Added []string
+	// Modified lists the files whose content or type differs from the destination.
+	Modified []string
+	// Removed lists the files of the destination the source does not have.
+	Removed []string
+}
+
+// Changed reports whether gathering the source would change the destination.
+func (r *DiffResult) Changed() bool {
+	return len(r.Added) > 0 || len(r.Modified) > 0 || len(r.Removed) > 0
+}
+
+// Diff reports which files gathering source into destination, as Gather does, would add,
+// modify or remove, without changing the destination. The source is gathered into a
+// temporary directory, removed on return, and its files are compared with those of the
+// destination by their SHA-256 checksum; symbolic links are compared by their target. The
+// .git directory of a cloned repository is not compared, and neither the lock output of
+// WithLockOutput nor a custom destination set with WithDestination is written to.
+func Diff(ctx context.Context, source, destination string, opts ...gogather.Option) (*DiffResult, error) {
+	if gogather.NewOptions(opts...).Destination != nil {
+		return nil, fmt.Errorf("%w: a diff compares files in a local directory", gogather.ErrDestinationNotSupported)
+	}
+	opts = append(opts[:len(opts):len(opts)], gogather.WithLockOutput(""))
+
+	staging, err := os.MkdirTemp("", "go-gather-diff-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create diff directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	// Stage the gather as the destination would be gathered: keep the scheme, which the file
+	// gatherer requires for directories, and a trailing separator, and create the directory
+	// if the destination is one, so that files are named the same
+	local := strings.TrimPrefix(destination, "file://")
+	stagedLocal := filepath.Join(staging, filepath.Base(local))
+	if info, err := os.Stat(local); err == nil && info.IsDir() {
+		if err := os.Mkdir(stagedLocal, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create diff directory: %w", err)
+		}
+	}
+	staged := stagedLocal
+	if strings.HasSuffix(local, "/") || strings.HasSuffix(local, string(filepath.Separator)) {
+		staged += string(filepath.Separator)
+	}
+	if strings.HasPrefix(destination, "file://") {
+		staged = "file://" + staged
+	}
+	m, err := Gather(ctx, source, staged, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// The git gatherer does not record where it cloned, which is the destination
+	output := outputPath(m)
+	if output == "" {
+		output = stagedLocal
+	}
+	rel, err := filepath.Rel(stagedLocal, output)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("cannot diff the output of %s: %s is not in %s", source, output, stagedLocal)
+	}
+
+	gathered, err := diffEntries(output)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := diffEntries(filepath.Join(local, rel))
+	if err != nil {
+		return nil, err
+	}
+
+	// Name the files relative to the destination, or by the name of a single file
+	prefix := filepath.ToSlash(rel)
+	if prefix == "." {
+		prefix = ""
+	}
+	name := func(p string) string {
+		switch {
+		case p == "." && prefix == "":
+			return filepath.Base(local)
+		case p == ".":
+			return prefix
+		case prefix == "":
+			return p
+		}
+		return prefix + "/" + p
+	}
+
+	result := &DiffResult{}
+	for p, sum := range gathered {
+		old, ok := existing[p]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, name(p))
+		case old != sum:
+			result.Modified = append(result.Modified, name(p))
+		}
+	}
+	for p := range existing {
+		if _, ok := gathered[p]; !ok {
+			result.Removed = append(result.Removed, name(p))
+		}
+	}
+	sort.Strings(result.Added)
+	sort.Strings(result.Modified)
+	sort.Strings(result.Removed)
+	return result, nil
+}
+
+// diffEntries returns what identifies the files below root, keyed by their slash separated
+// path relative to root: the checksum of a regular file or the target of a symbolic link.
+// A root that is a file has the single entry ".", and a missing root has none. The .git
+// directory at root is skipped.
+func diffEntries(root string) (map[string]string, error) {
+	entries := map[string]string{}
+	if _, err := os.Lstat(root); os.IsNotExist(err) {
+		return entries, nil
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.IsDir() && rel == ".git":
+			return filepath.SkipDir
+		case d.IsDir():
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			entries[rel] = "symlink:" + target
+		case d.Type().IsRegular():
+			h := sha256.New()
+			if err := hashFile(h, path); err != nil {
+				return err
+			}
+			entries[rel] = "sha256:" + hex.EncodeToString(h.Sum(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,179 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// writeFiles writes the files, keyed by their slash separated path, below root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestDiff_Directory tests that the files a directory would add, modify and remove are
+// reported, and that the destination is left as is.
+func TestDiff_Directory(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{"same.txt": "same", "changed.txt": "new", "sub/added.txt": "added"})
+	destination := filepath.Join(t.TempDir(), "mirror")
+	writeFiles(t, destination, map[string]string{"same.txt": "same", "changed.txt": "old", "removed.txt": "removed"})
+
+	result, err := Diff(context.Background(), source, "file://"+destination)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	expected := &DiffResult{Added: []string{"sub/added.txt"}, Modified: []string{"changed.txt"}, Removed: []string{"removed.txt"}}
+	if !reflect.DeepEqual(result, expected) || !result.Changed() {
+		t.Errorf("expected %+v, but got %+v", expected, result)
+	}
+	if data, err := os.ReadFile(filepath.Join(destination, "changed.txt")); err != nil || string(data) != "old" {
+		t.Errorf("expected the destination to be unchanged, but got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(destination, "sub")); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be added to the destination, but got: %v", err)
+	}
+
+	// A destination with the same files has no changes
+	result, err = Diff(context.Background(), source, "file://"+source)
+	if err != nil || result.Changed() {
+		t.Errorf("expected no changes, but got %+v, %v", result, err)
+	}
+}
+
+// TestDiff_HTTP tests the diff of a downloaded file, alone or in a destination directory,
+// and of an extracted archive.
+func TestDiff_HTTP(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for name, content := range map[string]string{"policy.rego": "package main", "data.json": "{}"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bundle.tar" {
+			_, _ = w.Write(archive.Bytes())
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"foo.txt": "hello", "bundle/data.json": `{"old": true}`, "bundle/stale.rego": "package stale"})
+
+	testCases := []struct {
+		name        string
+		source      string
+		destination string
+		opts        []gogather.Option
+		expected    *DiffResult
+	}{
+		{name: "unchanged file", source: "/foo.txt", destination: filepath.Join(dir, "foo.txt"), expected: &DiffResult{}},
+		{name: "new file", source: "/foo.txt", destination: filepath.Join(dir, "new.txt"), expected: &DiffResult{Added: []string{"new.txt"}}},
+		{name: "file in directory", source: "/bar.txt", destination: dir, expected: &DiffResult{Added: []string{"bar.txt"}}},
+		{
+			name:        "archive",
+			source:      "/bundle.tar",
+			destination: filepath.Join(dir, "bundle"),
+			opts:        []gogather.Option{gogather.WithExtract(true)},
+			expected:    &DiffResult{Added: []string{"policy.rego"}, Modified: []string{"data.json"}, Removed: []string{"stale.rego"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		result, err := Diff(context.Background(), server.URL+tc.source, tc.destination, tc.opts...)
+		if err != nil {
+			t.Errorf("%s: expected no error, but got: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%s: expected %+v, but got %+v", tc.name, tc.expected, result)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be written, but got: %v", err)
+	}
+}
+
+// TestDiff_Git tests the diff of a cloned repository with an earlier clone, ignoring the
+// .git directory.
+func TestDiff_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := filepath.Join(t.TempDir(), "repo.git")
+	writeFiles(t, repo, map[string]string{"README.md": "hello"})
+	for _, args := range [][]string{{"init", "-q"}, {"add", "README.md"}, {"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+	destination := filepath.Join(t.TempDir(), "clone")
+	if _, err := Gather(context.Background(), "git::file://"+repo, destination); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	writeFiles(t, destination, map[string]string{"README.md": "changed"})
+
+	result, err := Diff(context.Background(), "git::file://"+repo, destination)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	expected := &DiffResult{Modified: []string{"README.md"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %+v, but got %+v", expected, result)
+	}
+}
+
+// TestDiff_DestinationNotSupported tests that custom destinations are refused.
+func TestDiff_DestinationNotSupported(t *testing.T) {
+	_, err := Diff(context.Background(), t.TempDir(), t.TempDir(), gogather.WithDestination(&writerDestination{w: io.Discard}))
+	if !errors.Is(err, gogather.ErrDestinationNotSupported) {
+		t.Errorf("expected ErrDestinationNotSupported, but got: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/gather/file"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// GatherFromFS copies the file or directory at source in fsys, such as an embed.FS holding
+// default bundles, to destination on the local filesystem, as the file gatherer copies local
+// files. source is a slash-separated path in fsys as accepted by fs.ValidPath, "." naming the
+// whole filesystem. destination is a local path or a file:// URI. The options of the file
+// gatherer apply, so files can be extracted, decompressed, transformed and written with the
+// configured permissions.
+func GatherFromFS(ctx context.Context, fsys fs.FS, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
+	if !strings.HasPrefix(destination, "file://") {
+		destination = "file://" + destination
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	return gatherLocked(ctx, wrap(&file.FileGatherer{FS: fsys}), source, destination, opts...)
+}
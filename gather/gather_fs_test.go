@@ -0,0 +1,87 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/file"
+)
+
+// TestGatherFromFS tests that files and directories are copied out of a filesystem.
+func TestGatherFromFS(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"defaults/policy.yaml":      {Data: []byte("policy")},
+		"defaults/data/rules.json":  {Data: []byte("rules")},
+		"defaults/data/extra.json":  {Data: []byte("extra")},
+		"defaults/.hidden/ignore.d": {Data: []byte("hidden")},
+	}
+
+	t.Run("File", func(t *testing.T) {
+		destination := filepath.Join(t.TempDir(), "policy.yaml")
+		m, err := GatherFromFS(ctx, fsys, "defaults/policy.yaml", destination)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fm, ok := m.(*file.FileMetadata); !ok || fm.Size != 6 {
+			t.Errorf("expected the metadata of a 6 byte file, but got %#v", m)
+		}
+		if content, err := os.ReadFile(destination); err != nil || string(content) != "policy" {
+			t.Errorf("expected %q, but got %q, %v", "policy", content, err)
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		destination := filepath.Join(t.TempDir(), "workspace")
+		m, err := GatherFromFS(ctx, fsys, "defaults", "file://"+destination, gogather.WithFilePerm(0600))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := m.(*file.DirectoryMetadata); !ok {
+			t.Errorf("expected directory metadata, but got %#v", m)
+		}
+		for name, want := range map[string]string{"policy.yaml": "policy", "data/rules.json": "rules", "data/extra.json": "extra", ".hidden/ignore.d": "hidden"} {
+			path := filepath.Join(destination, filepath.FromSlash(name))
+			if content, err := os.ReadFile(path); err != nil || string(content) != want {
+				t.Errorf("expected %s to hold %q, but got %q, %v", name, want, content, err)
+			}
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+				t.Errorf("expected %s to have the file permission, but got %v, %v", name, info, err)
+			}
+		}
+	})
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		for _, source := range []string{"/defaults", "../defaults", "defaults/"} {
+			if _, err := GatherFromFS(ctx, fsys, source, t.TempDir()); err == nil {
+				t.Errorf("expected an error for %s, but got nil", source)
+			}
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		if _, err := GatherFromFS(ctx, fsys, "defaults/missing.yaml", filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// GatherIntoError is returned by GatherInto when one or more subpaths failed.
+type GatherIntoError struct {
+	// Errors maps each subpath that failed to its error.
+	Errors map[string]error
+}
+
+// Error lists the failed subpaths in a stable order.
+func (e *GatherIntoError) Error() string {
+	subpaths := make([]string, 0, len(e.Errors))
+	for subpath := range e.Errors {
+		subpaths = append(subpaths, subpath)
+	}
+	sort.Strings(subpaths)
+
+	messages := make([]string, 0, len(subpaths))
+	for _, subpath := range subpaths {
+		messages = append(messages, fmt.Sprintf("%s: %s", subpath, e.Errors[subpath]))
+	}
+	return fmt.Sprintf("failed to gather %d subpath(s): %s", len(subpaths), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the failed subpaths, so errors.Is and errors.As
+// can match any of them.
+func (e *GatherIntoError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// GatherInto composes the local directory destination from several sources, where sources
+// maps subpaths of destination to the source gathered there, for example a git subdir, an
+// http tarball and a local file. The sources are gathered concurrently, as GatherAll does.
+// Subpaths must be relative and must not contain one another, otherwise nothing is gathered.
+// The WithIfExists policy decides what happens to subpaths whose destination already exists.
+// The metadata of the subpaths that were gathered is returned keyed by subpath, along with a
+// *GatherIntoError if any subpath failed.
+func GatherInto(ctx context.Context, destination string, sources map[string]string, opts ...gogather.Option) (map[string]metadata.Metadata, error) {
+	o := gogather.NewOptions(opts...)
+	root := strings.TrimPrefix(destination, "file://")
+	if err := checkSubpaths(sources); err != nil {
+		return nil, err
+	}
+
+	errs := make(map[string]error)
+	jobs := make(map[string]gatherJob, len(sources))
+	for subpath, source := range sources {
+		target := filepath.Join(root, subpath)
+		if _, err := os.Lstat(target); err == nil {
+			switch o.IfExists {
+			case gogather.IfExistsSkip:
+				o.Log(ctx, slog.LevelInfo, "skipping existing destination", "source", source, "destination", target)
+				continue
+			case gogather.IfExistsOverwrite:
+				if err := os.RemoveAll(target); err != nil {
+					errs[subpath] = fmt.Errorf("failed to remove existing destination: %w", err)
+					continue
+				}
+			default:
+				errs[subpath] = fmt.Errorf("destination already exists: %s", target)
+				continue
+			}
+		}
+		if err := o.MkdirAll(filepath.Dir(target)); err != nil {
+			errs[subpath] = fmt.Errorf("failed to create destination directory: %w", err)
+			continue
+		}
+		jobs[subpath] = gatherJob{source: source, destination: intoDestination(source, target, opts...)}
+	}
+
+	metadatas, gatherErrs := gatherConcurrently(ctx, jobs, opts...)
+	for subpath, err := range gatherErrs {
+		errs[subpath] = err
+	}
+	if len(errs) > 0 {
+		return metadatas, &GatherIntoError{Errors: errs}
+	}
+	return metadatas, nil
+}
+
+// checkSubpaths checks that every subpath is a relative path inside the destination and
+// that no subpath contains another one.
+func checkSubpaths(sources map[string]string) error {
+	subpaths := make([]string, 0, len(sources))
+	for subpath := range sources {
+		if !filepath.IsLocal(subpath) || filepath.Clean(subpath) == "." {
+			return fmt.Errorf("subpath %q must be a relative path inside the destination", subpath)
+		}
+		subpaths = append(subpaths, subpath)
+	}
+	sort.Strings(subpaths)
+
+	for i, a := range subpaths {
+		for _, b := range subpaths[i+1:] {
+			ca, cb := filepath.Clean(a), filepath.Clean(b)
+			if ca == cb || strings.HasPrefix(cb, ca+string(filepath.Separator)) || strings.HasPrefix(ca, cb+string(filepath.Separator)) {
+				return fmt.Errorf("subpaths %q and %q overlap", a, b)
+			}
+		}
+	}
+	return nil
+}
+
+// intoDestination returns the destination argument for gathering source to the local path
+// target. The file gatherer expects a file:// URI, the other gatherers a path.
+func intoDestination(source, target string, opts ...gogather.Option) string {
+	if t, err := gogather.ClassifyURIWithOptions(source, opts...); err == nil && t == gogather.FileURI {
+		return "file://" + target
+	}
+	return target
+}
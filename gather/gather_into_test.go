@@ -0,0 +1,111 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+func TestGatherInto(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(source, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "remote")
+	}))
+	defer server.Close()
+
+	t.Run("Compose", func(t *testing.T) {
+		out := t.TempDir()
+		m, err := GatherInto(ctx, out, map[string]string{
+			"config/foo.txt":    source,
+			"downloads/bar.txt": server.URL + "/bar.txt",
+		})
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		if len(m) != 2 || m["config/foo.txt"] == nil || m["downloads/bar.txt"] == nil {
+			t.Errorf("expected metadata keyed by subpath, but got: %v", m)
+		}
+		for _, path := range []string{"config/foo.txt", "downloads/bar.txt"} {
+			if _, err := os.Stat(filepath.Join(out, path)); err != nil {
+				t.Errorf("expected %s to be gathered, but got: %s", path, err)
+			}
+		}
+	})
+
+	t.Run("IfExists", func(t *testing.T) {
+		testCases := []struct {
+			policy   gogather.IfExists
+			expected string
+			failed   bool
+		}{
+			{policy: gogather.IfExistsFail, expected: "existing", failed: true},
+			{policy: gogather.IfExistsSkip, expected: "existing"},
+			{policy: gogather.IfExistsOverwrite, expected: "hello world"},
+		}
+
+		for _, tc := range testCases {
+			out := t.TempDir()
+			existing := filepath.Join(out, "foo.txt")
+			if err := os.WriteFile(existing, []byte("existing"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := GatherInto(ctx, out, map[string]string{"foo.txt": source}, gogather.WithIfExists(tc.policy))
+			var intoErr *GatherIntoError
+			if tc.failed != errors.As(err, &intoErr) {
+				t.Errorf("policy %d: unexpected error: %v", tc.policy, err)
+			}
+			if tc.policy == gogather.IfExistsOverwrite && m["foo.txt"] == nil {
+				t.Errorf("policy %d: expected metadata, but got: %v", tc.policy, m)
+			}
+			content, err := os.ReadFile(existing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tc.expected {
+				t.Errorf("policy %d: expected %q, but got %q", tc.policy, tc.expected, content)
+			}
+		}
+	})
+
+	t.Run("InvalidSubpaths", func(t *testing.T) {
+		for _, sources := range []map[string]string{
+			{"../foo.txt": source},
+			{"/foo.txt": source},
+			{".": source},
+			{"config": source, "config/foo.txt": source},
+		} {
+			if _, err := GatherInto(ctx, t.TempDir(), sources); err == nil {
+				t.Errorf("expected an error for %v", sources)
+			}
+		}
+	})
+}
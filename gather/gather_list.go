@@ -0,0 +1,150 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// ListFileError is returned by GatherFromListFile when entries of the list file are invalid
+// or failed to gather.
+type ListFileError struct {
+	// Path is the path of the list file.
+	Path string
+	// Errors maps the line number of each failed entry to its error.
+	Errors map[int]error
+}
+
+// Error lists the failed entries by line number.
+func (e *ListFileError) Error() string {
+	lines := make([]int, 0, len(e.Errors))
+	for line := range e.Errors {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+
+	messages := make([]string, 0, len(lines))
+	for _, line := range lines {
+		messages = append(messages, fmt.Sprintf("%s:%d: %s", e.Path, line, e.Errors[line]))
+	}
+	return fmt.Sprintf("%d entry(ies) of the list file failed: %s", len(lines), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the failed entries, so errors.Is and errors.As
+// can match any of them.
+func (e *ListFileError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// listEntry is a source read from a list file, with the line it was read from.
+type listEntry struct {
+	line        int
+	source      string
+	destination string
+}
+
+// GatherFromListFile gathers the sources listed in the file at listPath into the destination
+// directory. Each line of the file holds a source, optionally followed by a tab and the name to
+// gather it to; without a name, the one SuggestDestinationName returns is used. Blank lines and
+// lines starting with # are ignored.
+// The valid entries are gathered concurrently with GatherAll. Invalid entries and failed gathers
+// are reported together in a *ListFileError by line number, alongside the result of the entries
+// that were gathered. An error is returned without a result if the list file cannot be read.
+func GatherFromListFile(ctx context.Context, listPath, destination string, opts ...gogather.Option) (*GatherAllResult, error) {
+	entries, lineErrs, err := readListFile(ctx, listPath, destination, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		sources[entry.source] = entry.destination
+	}
+	result, _ := GatherAll(ctx, sources, opts...)
+	for _, entry := range entries {
+		if gatherErr, ok := result.Errors[entry.source]; ok {
+			lineErrs[entry.line] = fmt.Errorf("%s: %w", entry.source, gatherErr)
+		}
+	}
+
+	if len(lineErrs) > 0 {
+		return result, &ListFileError{Path: listPath, Errors: lineErrs}
+	}
+	return result, nil
+}
+
+// readListFile parses the list file into entries with their destinations inside destination.
+// Entries that cannot be parsed or named are returned as errors by line number instead.
+func readListFile(ctx context.Context, listPath, destination string, opts ...gogather.Option) ([]listEntry, map[int]error, error) {
+	f, err := os.Open(filepath.Clean(listPath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open list file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []listEntry
+	lineErrs := make(map[int]error)
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		source, name, _ := strings.Cut(text, "\t")
+		source, name = strings.TrimSpace(source), strings.TrimSpace(name)
+		if first, ok := seen[source]; ok {
+			lineErrs[line] = fmt.Errorf("%s is already listed on line %d", source, first)
+			continue
+		}
+		seen[source] = line
+
+		if name == "" {
+			if name, err = SuggestDestinationName(ctx, source, opts...); err != nil {
+				lineErrs[line] = fmt.Errorf("%s: %w", source, err)
+				continue
+			}
+		}
+		if !filepath.IsLocal(name) {
+			lineErrs[line] = fmt.Errorf("%s: the destination name %q must be a relative path inside the destination", source, name)
+			continue
+		}
+
+		entries = append(entries, listEntry{
+			line:        line,
+			source:      source,
+			destination: strings.TrimSuffix(destination, "/") + "/" + name,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read list file: %w", err)
+	}
+	return entries, lineErrs, nil
+}
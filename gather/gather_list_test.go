@@ -0,0 +1,105 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGatherFromListFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	foo := filepath.Join(dir, "foo.txt")
+	bar := filepath.Join(dir, "bar.txt")
+	for _, path := range []string{foo, bar} {
+		if err := os.WriteFile(path, []byte("hello world"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeList := func(t *testing.T, content string) string {
+		t.Helper()
+		listPath := filepath.Join(t.TempDir(), "sources.list")
+		if err := os.WriteFile(listPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return listPath
+	}
+
+	t.Run("AllSucceed", func(t *testing.T) {
+		out := t.TempDir()
+		listPath := writeList(t, "# sources to gather\n\n"+foo+"\n"+bar+"\tbaz.txt\n")
+
+		result, err := GatherFromListFile(ctx, listPath, "file://"+out)
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err)
+		}
+		if len(result.Metadata) != 2 {
+			t.Errorf("expected metadata for 2 sources, but got: %v", result.Metadata)
+		}
+		for _, name := range []string{"foo.txt", "baz.txt"} {
+			if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+				t.Errorf("expected %s to be gathered, but got: %s", name, err)
+			}
+		}
+	})
+
+	t.Run("LineErrors", func(t *testing.T) {
+		out := t.TempDir()
+		listPath := writeList(t, foo+"\n"+
+			"/does/not/exist.txt\n"+
+			foo+"\tagain.txt\n"+
+			"# "+bar+"\n"+
+			bar+"\t../escape.txt\n")
+
+		result, err := GatherFromListFile(ctx, listPath, "file://"+out)
+
+		var listErr *ListFileError
+		if !errors.As(err, &listErr) {
+			t.Fatalf("expected a ListFileError, but got: %v", err)
+		}
+		for _, line := range []int{2, 3, 5} {
+			if _, ok := listErr.Errors[line]; !ok {
+				t.Errorf("expected an error for line %d, but got: %v", line, listErr.Errors)
+			}
+		}
+		if len(listErr.Errors) != 3 {
+			t.Errorf("expected 3 line errors, but got: %v", listErr.Errors)
+		}
+		if !strings.Contains(err.Error(), listPath+":3: "+foo+" is already listed on line 1") {
+			t.Errorf("unexpected error message: %s", err)
+		}
+		if _, ok := result.Metadata[foo]; !ok {
+			t.Errorf("expected metadata for %s, but got: %v", foo, result.Metadata)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(out), "escape.txt")); err == nil {
+			t.Error("expected the escaping entry not to be gathered")
+		}
+	})
+
+	t.Run("MissingListFile", func(t *testing.T) {
+		result, err := GatherFromListFile(ctx, filepath.Join(dir, "missing.list"), dir)
+		if err == nil || result != nil {
+			t.Errorf("expected an error without a result, but got: %v, %v", result, err)
+		}
+	})
+}
@@ -0,0 +1,304 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	fileMetadata "github.com/enterprise-contract/go-gather/metadata/file"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+	k8sMetadata "github.com/enterprise-contract/go-gather/metadata/k8s"
+	webdavMetadata "github.com/enterprise-contract/go-gather/metadata/webdav"
+)
+
+// RefreshEvent is sent by GatherWithRefresh when a gather changed the content of the
+// destination, or when a refresh failed.
+type RefreshEvent struct {
+	// Metadata is the metadata of the gather that changed the content, nil if it failed.
+	Metadata metadata.Metadata
+	// Err is the error of a failed refresh, which left the previous content in place.
+	Err error
+	// Time is when the gather finished.
+	Time time.Time
+}
+
+// GatherWithRefresh gathers source into destination, as Gather does, and gathers it again
+// every interval to keep the destination fresh, for example as a local mirror. It returns
+// the error of the initial gather, if any; otherwise the first event on the returned channel
+// carries its metadata, and a later event is only sent when a refresh changed the content:
+// the commit of a git repository, the ETag of an HTTP download, or the checksum of the file
+// if the server sent none, the checksum of a copied file or directory, or the resource
+// version of a Kubernetes object. Failed refreshes send an event with the error and the loop
+// carries on.
+//
+// Refreshes are gathered into a temporary sibling of the output of the initial gather, which
+// replaces it only when the content changed, so a failed or unchanged refresh leaves it as
+// is. Cancelling ctx stops the loop, interrupting the refresh in progress, if any, and closes
+// the channel; no gathered file is removed. Events are not dropped, so the channel must be
+// read. Gathers to a custom destination set with WithDestination are refreshed in place.
+func GatherWithRefresh(ctx context.Context, source, destination string, interval time.Duration, opts ...gogather.Option) (<-chan RefreshEvent, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid refresh interval: %s", interval)
+	}
+
+	m, err := Gather(ctx, source, destination, opts...)
+	if err != nil {
+		return nil, err
+	}
+	version := contentVersion(m)
+	output := outputPath(m)
+	if gogather.NewOptions(opts...).Destination != nil {
+		output = ""
+	}
+
+	events := make(chan RefreshEvent, 1)
+	events <- RefreshEvent{Metadata: m, Time: time.Now()}
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			var event RefreshEvent
+			changed, m, err := refresh(ctx, source, destination, output, version, opts)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				event = RefreshEvent{Err: err, Time: time.Now()}
+			case changed:
+				version = contentVersion(m)
+				event = RefreshEvent{Metadata: m, Time: time.Now()}
+			default:
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// refresh gathers source again and reports whether its content differs from version. When
+// the path of the output is known, source is gathered next to it, and the output is only
+// replaced when the content changed.
+func refresh(ctx context.Context, source, destination, output, version string, opts []gogather.Option) (bool, metadata.Metadata, error) {
+	if output == "" {
+		m, err := Gather(ctx, source, destination, opts...)
+		if err != nil {
+			return false, nil, err
+		}
+		return contentVersion(m) != version, m, nil
+	}
+
+	staging, err := os.MkdirTemp(filepath.Dir(output), "."+filepath.Base(output)+".refresh-")
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to create refresh directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	// Keep the scheme of the destination, which the file gatherer requires for directories
+	staged := filepath.Join(staging, filepath.Base(output))
+	if strings.HasPrefix(destination, "file://") {
+		staged = "file://" + staged
+	}
+	m, err := Gather(ctx, source, staged, opts...)
+	if err != nil {
+		return false, nil, err
+	}
+	if contentVersion(m) == version {
+		return false, m, nil
+	}
+	if err := gogather.ReplacePath(outputPath(m), output); err != nil {
+		return false, nil, err
+	}
+	return true, rebaseMetadata(m, outputPath(m), output), nil
+}
+
+// contentVersion returns what identifies the content gathered with the metadata m, which
+// changes when the content does.
+func contentVersion(m metadata.Metadata) string {
+	switch m := m.(type) {
+	case *gitMetadata.GitMetadata:
+		return m.SHA
+	case *fileMetadata.FileMetadata:
+		return m.SHA
+	case *fileMetadata.DirectoryMetadata:
+		return treeVersion(m.Path)
+	case k8sMetadata.K8sMetadata:
+		return m.ResourceVersion
+	case httpMetadata.HTTPMetadata:
+		return httpVersion(m)
+	case *httpMetadata.HTTPIndexMetadata:
+		versions := make([]string, 0, len(m.Files))
+		for _, f := range m.Files {
+			versions = append(versions, f.RelativePath+"="+httpVersion(f))
+		}
+		sort.Strings(versions)
+		return strings.Join(versions, "\n")
+	case *webdavMetadata.WebDAVMetadata:
+		versions := make([]string, 0, len(m.Files))
+		for _, f := range m.Files {
+			versions = append(versions, fmt.Sprintf("%s=%s %d", f.RelativePath, f.ETag, f.Size))
+		}
+		sort.Strings(versions)
+		return strings.Join(versions, "\n")
+	}
+	values := m.Get()
+	delete(values, "timestamp")
+	return fmt.Sprint(values)
+}
+
+// treeVersion returns the checksum of the names, modes and contents of the files below root.
+func treeVersion(root string) string {
+	h := sha256.New()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %s\n", filepath.ToSlash(rel), info.Mode())
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(h, target)
+		case info.Mode().IsRegular():
+			return hashFile(h, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// hashFile writes the content of the file at path to h.
+func hashFile(h io.Writer, path string) error {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// httpVersion returns the ETag of an HTTP download, or the checksum of the downloaded data.
+func httpVersion(m httpMetadata.HTTPMetadata) string {
+	if etag := http.Header(m.Headers).Get("ETag"); etag != "" {
+		return "etag:" + etag
+	}
+	if m.Checksum != "" {
+		return m.Checksum
+	}
+	h := sha256.New()
+	if err := hashFile(h, m.Destination); err != nil {
+		return fmt.Sprint(m.BytesWritten)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// outputPath returns the path of the file or directory written by the gather described by
+// m, or "" if it is not known.
+func outputPath(m metadata.Metadata) string {
+	switch m := m.(type) {
+	case *gitMetadata.GitMetadata:
+		return m.Path
+	case *fileMetadata.FileMetadata:
+		return m.Path
+	case *fileMetadata.DirectoryMetadata:
+		return m.Path
+	case httpMetadata.HTTPMetadata:
+		return m.Destination
+	case *httpMetadata.HTTPIndexMetadata:
+		return m.Destination
+	case k8sMetadata.K8sMetadata:
+		return m.Path
+	case *webdavMetadata.WebDAVMetadata:
+		return m.Destination
+	}
+	return ""
+}
+
+// rebaseMetadata returns m with the paths below from, where a refresh was gathered, moved
+// below to, where it was moved.
+func rebaseMetadata(m metadata.Metadata, from, to string) metadata.Metadata {
+	rebase := func(path string) string {
+		if rel, err := filepath.Rel(from, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join(to, rel)
+		}
+		return path
+	}
+	switch m := m.(type) {
+	case *gitMetadata.GitMetadata:
+		m.Path = rebase(m.Path)
+	case *fileMetadata.FileMetadata:
+		m.Path = rebase(m.Path)
+	case *fileMetadata.DirectoryMetadata:
+		m.Path = rebase(m.Path)
+	case httpMetadata.HTTPMetadata:
+		m.Destination = rebase(m.Destination)
+		return m
+	case *httpMetadata.HTTPIndexMetadata:
+		m.Destination = rebase(m.Destination)
+		for i := range m.Files {
+			m.Files[i].Destination = rebase(m.Files[i].Destination)
+		}
+	case k8sMetadata.K8sMetadata:
+		m.Path = rebase(m.Path)
+		return m
+	case *webdavMetadata.WebDAVMetadata:
+		m.Destination = rebase(m.Destination)
+		for i := range m.Files {
+			m.Files[i].Destination = rebase(m.Files[i].Destination)
+		}
+	}
+	return m
+}
@@ -0,0 +1,140 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/enterprise-contract/go-gather/metadata/file"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// TestGatherWithRefresh tests that events are only sent when the content changed, and that
+// cancelling the context stops the refreshes and keeps the files.
+func TestGatherWithRefresh(t *testing.T) {
+	var mu sync.Mutex
+	etag, content := `"v1"`, "hello"
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "foo.txt")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := GatherWithRefresh(ctx, server.URL+"/foo.txt", destination, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	event := <-events
+	if m, ok := event.Metadata.(httpMetadata.HTTPMetadata); event.Err != nil || !ok || m.Destination != destination {
+		t.Fatalf("expected the metadata of the initial gather, but got: %#v", event)
+	}
+
+	// Wait for refreshes of the unchanged content, which send no event
+	for requests.Load() < 4 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("expected no event for unchanged content, but got: %#v", event)
+	default:
+	}
+
+	mu.Lock()
+	etag, content = `"v2"`, "hello world"
+	mu.Unlock()
+	select {
+	case event := <-events:
+		if m, ok := event.Metadata.(httpMetadata.HTTPMetadata); event.Err != nil || !ok || m.Destination != destination {
+			t.Errorf("expected the metadata of the changed content, but got: %#v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected an event for the changed content")
+	}
+	if data, err := os.ReadFile(destination); err != nil || string(data) != "hello world" {
+		t.Errorf("expected the refreshed content, but got %q, %v", data, err)
+	}
+
+	cancel()
+	for range events {
+	}
+	if data, err := os.ReadFile(destination); err != nil || string(data) != "hello world" {
+		t.Errorf("expected the file to be kept, but got %q, %v", data, err)
+	}
+	if entries, err := os.ReadDir(filepath.Dir(destination)); err != nil || len(entries) != 1 {
+		t.Errorf("expected no refresh directory to be left, but got %v, %v", entries, err)
+	}
+}
+
+// TestGatherWithRefresh_Directory tests that a refresh of a directory replaces it when a file changed.
+func TestGatherWithRefresh_Directory(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "foo.txt"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	destination := filepath.Join(t.TempDir(), "mirror")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := GatherWithRefresh(ctx, source, "file://"+destination, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if event := <-events; event.Err != nil {
+		t.Fatalf("expected no error, but got: %v", event.Err)
+	}
+
+	// The same size, but another content
+	if err := os.WriteFile(filepath.Join(source, "foo.txt"), []byte("world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-events:
+		if m, ok := event.Metadata.(*file.DirectoryMetadata); event.Err != nil || !ok || m.Path != destination {
+			t.Errorf("expected the metadata of the changed directory, but got: %#v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected an event for the changed directory")
+	}
+	if data, err := os.ReadFile(filepath.Join(destination, "foo.txt")); err != nil || string(data) != "world" {
+		t.Errorf("expected the refreshed content, but got %q, %v", data, err)
+	}
+}
+
+// TestGatherWithRefresh_Errors tests that the error of the initial gather and invalid intervals are returned.
+func TestGatherWithRefresh_Errors(t *testing.T) {
+	if _, err := GatherWithRefresh(context.Background(), "/does/not/exist", t.TempDir(), time.Second); err == nil {
+		t.Error("expected the error of the initial gather")
+	}
+	if _, err := GatherWithRefresh(context.Background(), "/does/not/exist", t.TempDir(), 0); err == nil {
+		t.Error("expected an error for an invalid interval")
+	}
+}
@@ -18,13 +18,19 @@ package gather
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	gogather "github.com/enterprise-contract/go-gather"
 	"github.com/enterprise-contract/go-gather/metadata"
+	"github.com/enterprise-contract/go-gather/metadata/file"
 	"github.com/enterprise-contract/go-gather/metadata/git"
 )
 
@@ -93,6 +99,34 @@ func TestGather(t *testing.T) {
 		}
 	})
 
+	t.Run("ForceType", func(t *testing.T) {
+		// A relative two segment path is classified as a GitHub shorthand
+		dir, err := os.MkdirTemp(".", "force")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		source := filepath.Base(dir) + "/foo.txt"
+		_ = os.WriteFile(source, []byte("hello world"), 0600)
+		destination := filepath.Join(t.TempDir(), "foo.txt")
+
+		m, err := Gather(ctx, source, "file://"+destination, gogather.WithForceType(gogather.FileURI))
+		if err != nil {
+			t.Fatalf("expected no error, but got: %s", err.Error())
+		}
+		if _, ok := m.(*file.FileMetadata); !ok {
+			t.Errorf("expected file metadata, but got %#v", m)
+		}
+		if content, err := os.ReadFile(destination); err != nil || string(content) != "hello world" {
+			t.Errorf("expected %q, but got %q, %v", "hello world", content, err)
+		}
+
+		_, err = Gather(ctx, source, "file://"+destination, gogather.WithForceType(gogather.HTTPURI))
+		if err == nil {
+			t.Error("expected an error for a source the http gatherer cannot parse, but got nil")
+		}
+	})
+
 	t.Run("CustomGatherer", func(t *testing.T) {
 		source := "custom_source"
 		destination := "custom_destination"
@@ -107,10 +141,65 @@ func TestGather(t *testing.T) {
 
 type mockGatherer struct{}
 
-func (m *mockGatherer) Gather(ctx context.Context, source, destination string) (metadata.Metadata, error) {
+func (m *mockGatherer) Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
 	// Mock implementation
 	return &git.GitMetadata{}, nil
 }
+
+type mockClosingGatherer struct {
+	mockGatherer
+	closed bool
+	err    error
+}
+
+func (m *mockClosingGatherer) Close() error {
+	m.closed = true
+	return m.err
+}
+
+func TestClose(t *testing.T) {
+	handlers := protocolHandlers
+	defer func() { protocolHandlers = handlers }()
+
+	closing := &mockClosingGatherer{}
+	failing := &mockClosingGatherer{err: errors.New("boom")}
+	protocolHandlers = map[string]Gatherer{
+		"A": closing,
+		"B": &mockGatherer{},
+		"C": failing,
+	}
+
+	err := Close()
+	if !closing.closed || !failing.closed {
+		t.Errorf("expected all closers to be closed")
+	}
+	expectedErrorMessage := "failed to close C gatherer: boom"
+	if err == nil || err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message: %s, but got: %v", expectedErrorMessage, err)
+	}
+}
+
+func TestSuggestDestinationName(t *testing.T) {
+	name, err := SuggestDestinationName(context.Background(), "https://example.com/foo.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "foo.txt" {
+		t.Errorf("expected foo.txt, but got %s", name)
+	}
+
+	name, err = SuggestDestinationName(context.Background(), "git::https://github.com/git-fixtures/basic.git")
+	if err != nil || name != "basic" {
+		t.Errorf("expected basic, but got %s (%v)", name, err)
+	}
+
+	_, err = SuggestDestinationName(context.Background(), "webdavs://dav.example.com/docs")
+	expectedErrorMessage := "the WebDAVURI gatherer does not suggest destination names"
+	if err == nil || err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message: %s, but got: %v", expectedErrorMessage, err)
+	}
+}
+
 func TestExpandTilde(t *testing.T) {
 	homeDir, _ := os.UserHomeDir()
 
@@ -140,3 +229,61 @@ func TestExpandTilde(t *testing.T) {
 		}
 	})
 }
+
+// TestGather_PackageURL tests that package URLs are gathered from the URL of the template.
+func TestGather_PackageURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/example/lib/1.2.3/lib-1.2.3.jar" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "jar")
+	}))
+	defer server.Close()
+
+	template := server.URL + `/{{.Namespace | replace "." "/"}}/{{.Name}}/{{.Version}}/{{.Name}}-{{.Version}}.jar`
+	destination := filepath.Join(t.TempDir(), "lib.jar")
+	_, err := Gather(context.Background(), "pkg:maven/org.example/lib@1.2.3", destination, gogather.WithURLTemplate(template))
+	if err != nil {
+		t.Fatalf("expected no error, but got: %s", err)
+	}
+	if content, err := os.ReadFile(destination); err != nil || string(content) != "jar" {
+		t.Errorf("expected %q, but got %q, %v", "jar", content, err)
+	}
+}
+
+// TestGather_LockFile tests that gathers wait for the lock file, and that the sources of
+// GatherAll are gathered under a single lock.
+func TestGather_LockFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(source, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lockFile := filepath.Join(dir, ".lock")
+
+	release, err := gogather.NewOptions(gogather.WithLockFile(lockFile)).AcquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	_, err = Gather(context.Background(), source, "file://"+filepath.Join(dir, "bar.txt"), gogather.WithLockFile(lockFile), gogather.WithLockTimeout(10*time.Millisecond))
+	if !errors.Is(err, gogather.ErrLockTimeout) {
+		t.Errorf("expected ErrLockTimeout, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bar.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written while locked, but got: %v", err)
+	}
+	release()
+
+	sources := map[string]string{}
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("foo%d.txt", i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("hello"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		sources[filepath.Join(dir, name)] = "file://" + filepath.Join(dir, "out", name)
+	}
+	if _, err := GatherAll(context.Background(), sources, gogather.WithLockFile(lockFile), gogather.WithLockTimeout(time.Second)); err != nil {
+		t.Errorf("expected no error, but got: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	fileMetadata "github.com/enterprise-contract/go-gather/metadata/file"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// ErrNotSingleFile is returned by GatherToWriter for sources that do not gather a single file.
+var ErrNotSingleFile = errors.New("the source does not gather a single file")
+
+// GatherToWriter gathers the single file named by source and streams its bytes to w, without
+// a destination path. It supports the file, http and k8s gatherers; git repositories,
+// directories, directory listings, manifests and extracted archives cannot be expressed as
+// a single stream and return ErrNotSingleFile.
+func GatherToWriter(ctx context.Context, source string, w io.Writer, opts ...gogather.Option) (metadata.Metadata, error) {
+	o := gogather.NewOptions(opts...)
+	if o.Extract {
+		return nil, fmt.Errorf("%w: archives are extracted into a directory", ErrNotSingleFile)
+	}
+
+	srcProtocol, err := gogather.ClassifyURIWithOptions(source, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to classify source URI: %w", err)
+	}
+	switch srcProtocol {
+	case gogather.GitURI:
+		return nil, fmt.Errorf("%w: git repositories are cloned into a directory", ErrNotSingleFile)
+	case gogather.FileURI:
+		if src, err := url.Parse(source); err == nil {
+			if info, err := os.Stat(gogather.ExpandTilde(src.Path)); err == nil && info.IsDir() {
+				return nil, fmt.Errorf("%w: %s is a directory", ErrNotSingleFile, source)
+			}
+		}
+	case gogather.HTTPURI:
+		if o.HTTPAutoIndex && strings.HasSuffix(source, "/") {
+			return nil, fmt.Errorf("%w: directory listings gather several files", ErrNotSingleFile)
+		}
+	}
+
+	d := &writerDestination{w: w}
+	m, err := Gather(ctx, source, "", append(opts[:len(opts):len(opts)], gogather.WithDestination(d))...)
+	if err != nil {
+		return nil, err
+	}
+	switch m.(type) {
+	case *fileMetadata.DirectoryMetadata, *httpMetadata.HTTPIndexMetadata:
+		return nil, fmt.Errorf("%w: %s gathered several files", ErrNotSingleFile, source)
+	}
+	if !d.created {
+		return nil, fmt.Errorf("%w: %s gathered no file", ErrNotSingleFile, source)
+	}
+	return m, nil
+}
+
+// writerDestination is a gogather.Destination that writes the only file of a gather to w.
+type writerDestination struct {
+	mu      sync.Mutex
+	w       io.Writer
+	created bool
+}
+
+func (d *writerDestination) Create(path string) (io.WriteCloser, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.created {
+		return nil, fmt.Errorf("%w: %s is a second file", ErrNotSingleFile, path)
+	}
+	d.created = true
+	return nopWriteCloser{d.w}, nil
+}
+
+// nopWriteCloser is a writer whose Close does nothing, as the caller owns the writer.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,77 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGatherToWriter(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.txt")
+	if err := os.WriteFile(source, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "remote")
+	}))
+	defer server.Close()
+
+	for source, expected := range map[string]string{source: "hello world", server.URL + "/bar.txt": "remote"} {
+		var buf bytes.Buffer
+		m, err := GatherToWriter(ctx, source, &buf)
+		if err != nil {
+			t.Fatalf("expected no error for %s, but got: %s", source, err)
+		}
+		if m == nil {
+			t.Errorf("expected metadata for %s, but got nil", source)
+		}
+		if buf.String() != expected {
+			t.Errorf("expected %q to be written for %s, but got %q", expected, source, buf.String())
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no files to be written, but got %v", entries)
+	}
+}
+
+func TestGatherToWriter_NotSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "foo.txt"), []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{dir, "git::https://example.com/org/repo.git"} {
+		var buf bytes.Buffer
+		_, err := GatherToWriter(context.Background(), source, &buf)
+		if !errors.Is(err, ErrNotSingleFile) {
+			t.Errorf("expected ErrNotSingleFile for %s, but got: %v", source, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected nothing to be written for %s, but got %q", source, buf.String())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	gitUrls "github.com/whilp/git-urls"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// errArchiveUnsupported is returned when the remote does not export the requested path as an
+// archive, so that it is read from a clone instead.
+var errArchiveUnsupported = errors.New("the remote does not support archives")
+
+// archiveRepositoryPath exports the directory at path to the destination with git archive,
+// so that only the requested tree is transferred and written, and returns the metadata.
+// It returns errArchiveUnsupported if git is not installed, the transport cannot serve
+// archives, as with http, or the remote sends no archive, for example because path is a file.
+// Archives carry no history, so the metadata has no commits.
+func archiveRepositoryPath(ctx context.Context, o *gogather.Options, path, destination string, cloneOpts *git.CloneOptions) (metadata.Metadata, error) {
+	u, err := gitUrls.Parse(cloneOpts.URL)
+	if err != nil || (u.Scheme != "file" && u.Scheme != "ssh" && u.Scheme != "git") {
+		return nil, errArchiveUnsupported
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errArchiveUnsupported
+	}
+	config, err := gitConfigArgs(o.GitExtraArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, sha, err := remoteCommit(ctx, config, cloneOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The process is killed if the archive cannot be extracted
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	// #nosec G204 -- the arguments are passed to git without a shell
+	args := append(config, "archive", "--format=tar", "--remote="+cloneOpts.URL, ref.String()+":"+path)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errArchiveUnsupported
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errArchiveUnsupported
+	}
+
+	// A remote refusing the archive sends nothing, and the path is read from a clone instead
+	archive := bufio.NewReader(stdout)
+	if _, err := archive.Peek(1); err != nil {
+		_ = cmd.Wait()
+		return nil, errArchiveUnsupported
+	}
+	stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseExtract)
+	err = o.ExtractArchive(archive, "archive.tar", destination)
+	stop()
+	if err != nil {
+		cancel()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("error extracting archive: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("error exporting archive: %w", err)
+	}
+
+	return &gitMetadata.GitMetadata{
+		Ref:    ref.String(),
+		SHA:    sha,
+		Method: "archive",
+	}, nil
+}
+
+// remoteCommit lists the refs of the remote with git ls-remote and returns the ref the clone
+// options check out, or the branch HEAD points to, and the commit it points to. The config
+// arguments are passed to git before the command.
+func remoteCommit(ctx context.Context, config []string, cloneOpts *git.CloneOptions) (plumbing.ReferenceName, string, error) {
+	// #nosec G204 -- the arguments are passed to git without a shell
+	cmd := exec.CommandContext(ctx, "git", append(config, "ls-remote", "--symref", cloneOpts.URL)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("error listing remote refs: %w", err)
+	}
+
+	name := cloneOpts.ReferenceName
+	refs := map[plumbing.ReferenceName]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		value, ref, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if target, ok := strings.CutPrefix(value, "ref: "); ok {
+			if ref == "HEAD" && cloneOpts.ReferenceName == "" {
+				name = plumbing.ReferenceName(target)
+			}
+			continue
+		}
+		refs[plumbing.ReferenceName(ref)] = value
+	}
+	if name == "" {
+		return "", "", errArchiveUnsupported
+	}
+
+	// Annotated tags are peeled to the commit they point to
+	if sha, ok := refs[name+"^{}"]; ok {
+		return name, sha, nil
+	}
+	if sha, ok := refs[name]; ok {
+		return name, sha, nil
+	}
+	return "", "", fmt.Errorf("ref %s does not exist in the repository", name)
+}
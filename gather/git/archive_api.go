@@ -0,0 +1,270 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	gitUrls "github.com/whilp/git-urls"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// The base URLs of the GitHub and GitLab archive endpoints, which tests replace.
+var (
+	githubArchiveURL = "https://github.com"
+	gitlabArchiveURL = "https://gitlab.com"
+)
+
+// archiveAPIURL returns the URL of the tarball of the ref the clone options check out, or
+// of the commit, served by the archive endpoint of GitHub or GitLab, along with the header
+// authenticating the request. It returns errArchiveUnsupported for other remotes, and for
+// the default branch of GitLab repositories, which has no archive URL.
+func archiveAPIURL(o *gogather.Options, cloneOpts *git.CloneOptions) (string, http.Header, error) {
+	u, err := gitUrls.Parse(cloneOpts.URL)
+	if err != nil {
+		return "", nil, errArchiveUnsupported
+	}
+	project := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	ref := o.GitCommit
+	if ref == "" && cloneOpts.ReferenceName != "" {
+		ref = cloneOpts.ReferenceName.String()
+	}
+
+	header := http.Header{}
+	switch strings.ToLower(u.Hostname()) {
+	case "github.com":
+		if strings.Count(project, "/") != 1 {
+			return "", nil, errArchiveUnsupported
+		}
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if o.GitToken != "" {
+			header.Set("Authorization", "Bearer "+o.GitToken)
+		}
+		return fmt.Sprintf("%s/%s/archive/%s.tar.gz", githubArchiveURL, project, ref), header, nil
+	case "gitlab.com":
+		if !strings.Contains(project, "/") || ref == "" {
+			return "", nil, errArchiveUnsupported
+		}
+		// GitLab names refs by their short name
+		ref = strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+		if o.GitToken != "" {
+			header.Set("PRIVATE-TOKEN", o.GitToken)
+		}
+		name := path.Base(project) + "-" + strings.ReplaceAll(ref, "/", "-") + ".tar.gz"
+		return fmt.Sprintf("%s/%s/-/archive/%s/%s", gitlabArchiveURL, project, url.PathEscape(ref), name), header, nil
+	}
+	return "", nil, errArchiveUnsupported
+}
+
+// archiveAPIRepositoryPath downloads the tarball of the ref or commit to check out from the
+// archive endpoint of GitHub or GitLab and extracts the file or directory at subdir, or the
+// whole repository when subdir is empty, to the destination. It needs neither git nor a clone,
+// but the tarball carries no history, so the metadata has no commits. It returns
+// errArchiveUnsupported for other remotes and when the endpoint has no tarball for the ref,
+// for example for a commit it does not expose, so that a clone is used instead.
+func archiveAPIRepositoryPath(ctx context.Context, o *gogather.Options, subdir, destination string, cloneOpts *git.CloneOptions) (metadata.Metadata, error) {
+	archiveURL, header, err := archiveAPIURL(o, cloneOpts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(archiveURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing archive URL: %w", err)
+	}
+	if err := o.CheckScheme(u.Scheme); err != nil {
+		return nil, err
+	}
+	if err := o.CheckHost(ctx, u.Host); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", archiveURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header = header
+	req.Header.Set("User-Agent", "Go-Gather")
+
+	// The transport configured for the gather is found in the context
+	c := &http.Client{Transport: contextRoundTripper{}, CheckRedirect: checkRedirect}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading archive: %w", o.ResponseError(err))
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errArchiveUnsupported
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("response code error for %s: %d", archiveURL, resp.StatusCode)
+	}
+
+	gz, err := gzip.NewReader(o.LimitReader(resp.Body))
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive: %w", err)
+	}
+	defer gz.Close()
+
+	// The entries are renamed from the top-level directory of the tarball to the destination,
+	// and extracted into its parent, so that a single file is written like from a clone
+	subdir = strings.Trim(filepath.ToSlash(filepath.Clean(subdir)), "/")
+	if subdir == "." {
+		subdir = ""
+	}
+	destination = filepath.Clean(destination)
+	parent := filepath.Dir(destination)
+	file, err := filepath.Rel(parent, fileDestination(destination, path.Base(subdir)))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving destination: %w", err)
+	}
+	names := archiveNames{subdir: subdir, dir: filepath.Base(destination), file: filepath.ToSlash(file), o: o}
+
+	pr, pw := io.Pipe()
+	sha := make(chan string, 1)
+	go func() {
+		commit, err := names.strip(tar.NewReader(gz), pw)
+		sha <- commit
+		pw.CloseWithError(err)
+	}()
+	stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseExtract)
+	// The entries are selected by strip, relative to the destination rather than its parent
+	extract := *o
+	extract.Include, extract.Exclude = nil, nil
+	err = extract.ExtractArchive(pr, "archive.tar", parent)
+	stop()
+	pr.CloseWithError(errors.New("extraction stopped"))
+	commit := <-sha
+	if err != nil {
+		return nil, fmt.Errorf("error extracting archive: %w", err)
+	}
+
+	// The default branch and commits are recorded as HEAD, like detached checkouts
+	m := &gitMetadata.GitMetadata{
+		Ref:    cloneOpts.ReferenceName.String(),
+		SHA:    commit,
+		Method: "archive-api",
+	}
+	if m.Ref == "" || o.GitCommit != "" {
+		m.Ref = plumbing.HEAD.String()
+	}
+	if m.SHA == "" {
+		m.SHA = o.GitCommit
+	}
+	return m, nil
+}
+
+// archiveNames renames the entries of a GitHub or GitLab tarball, which are below a top-level
+// directory named after the repository and ref.
+type archiveNames struct {
+	// subdir is the slash separated path of the file or directory to keep, or empty for all.
+	subdir string
+	// dir is the name the directory at subdir is renamed to.
+	dir string
+	// file is the slash separated path a file at subdir is renamed to.
+	file string
+	// o selects the entries below the directory with WithInclude and WithExclude.
+	o *gogather.Options
+}
+
+// strip copies the entries of tr at or below subdir of the top-level directory to w, renamed,
+// leaving out those the options do not include.
+// It returns the commit recorded by git archive in the global header of the tarball, if any,
+// and an error if no entry is found at subdir.
+func (n archiveNames) strip(tr *tar.Reader, w io.Writer) (string, error) {
+	tw := tar.NewWriter(w)
+	var commit string
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return commit, fmt.Errorf("error reading archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			commit = hdr.PAXRecords["comment"]
+			continue
+		}
+
+		name, ok := n.rename(hdr.Name)
+		if !ok {
+			continue
+		}
+		if rel, below := strings.CutPrefix(name, n.dir+"/"); below && n.o != nil {
+			included, err := n.o.Included(rel, hdr.Typeflag == tar.TypeDir)
+			if err != nil {
+				return commit, err
+			}
+			if !included || hdr.Typeflag == tar.TypeDir && len(n.o.Include) > 0 {
+				continue
+			}
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			if hdr.Linkname, ok = n.rename(hdr.Linkname); !ok {
+				return commit, fmt.Errorf("%w: %s links outside of %s", gogather.ErrUnsafeArchive, hdr.Name, n.subdir)
+			}
+		}
+		found = true
+		hdr.Name = name
+		if err := tw.WriteHeader(hdr); err != nil {
+			return commit, fmt.Errorf("error writing archive: %w", err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return commit, fmt.Errorf("error writing archive: %w", err)
+		}
+	}
+	if !found {
+		return commit, fmt.Errorf("path %s does not exist in the repository", n.subdir)
+	}
+	return commit, tw.Close()
+}
+
+// rename returns the new name of the tarball entry called name, and reports whether it is
+// at or below subdir.
+func (n archiveNames) rename(name string) (string, bool) {
+	_, rest, ok := strings.Cut(strings.TrimPrefix(name, "./"), "/")
+	if !ok {
+		return "", false
+	}
+	if n.subdir != "" {
+		if rest == n.subdir {
+			return n.file, true
+		}
+		if rest, ok = strings.CutPrefix(rest, n.subdir+"/"); !ok {
+			return "", false
+		}
+	}
+	return path.Join(n.dir, rest), true
+}
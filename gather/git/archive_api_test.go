@@ -0,0 +1,212 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// archiveSHA is the commit recorded in the tarballs of newArchiveServer.
+const archiveSHA = "0123456789abcdef0123456789abcdef01234567"
+
+// newTarball returns a gzipped tarball like the ones of GitHub and GitLab, with the files
+// below the top directory and the commit in the global header.
+func newTarball(t *testing.T, top string, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": archiveSHA}}))
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: top + "/", Mode: 0755}))
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: top + "/sub/", Mode: 0755}))
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: top + "/" + name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// newArchiveServer returns a server of the GitHub and GitLab archive endpoints, which it
+// replaces for the duration of the test.
+func newArchiveServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	files := map[string]string{"README.md": "readme", "sub/a.txt": "a"}
+	tarballs := map[string][]byte{
+		"/org/repo/archive/refs/tags/v1.tar.gz":           newTarball(t, "repo-1", files),
+		"/org/repo/archive/HEAD.tar.gz":                   newTarball(t, "repo-main", files),
+		"/org/repo/archive/0123456.tar.gz":                newTarball(t, "repo-0123456", files),
+		"/group/sub/repo/-/archive/main/repo-main.tar.gz": newTarball(t, "repo-main-0123456", files),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tarball, ok := tarballs[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(tarball)
+	}))
+	t.Cleanup(server.Close)
+
+	github, gitlab := githubArchiveURL, gitlabArchiveURL
+	githubArchiveURL, gitlabArchiveURL = server.URL, server.URL
+	t.Cleanup(func() { githubArchiveURL, gitlabArchiveURL = github, gitlab })
+	return server
+}
+
+// TestGather_ArchiveAPI tests that GitHub and GitLab repositories are downloaded as tarballs.
+func TestGather_ArchiveAPI(t *testing.T) {
+	newArchiveServer(t)
+
+	testCases := []struct {
+		name     string
+		source   string
+		opts     []gogather.Option
+		file     string
+		files    map[string]string
+		expected gitMetadata.GitMetadata
+	}{
+		{
+			name:     "tag",
+			source:   "https://github.com/org/repo.git",
+			opts:     []gogather.Option{gogather.WithGitTag("v1")},
+			files:    map[string]string{"README.md": "readme", "sub/a.txt": "a"},
+			expected: gitMetadata.GitMetadata{Ref: "refs/tags/v1", SHA: archiveSHA, Method: "archive-api"},
+		},
+		{
+			name:     "default branch",
+			source:   "github.com/org/repo",
+			files:    map[string]string{"README.md": "readme", "sub/a.txt": "a"},
+			expected: gitMetadata.GitMetadata{Ref: "HEAD", SHA: archiveSHA, Method: "archive-api"},
+		},
+		{
+			name:     "commit",
+			source:   "github.com/org/repo",
+			opts:     []gogather.Option{gogather.WithGitCommit("0123456")},
+			files:    map[string]string{"README.md": "readme", "sub/a.txt": "a"},
+			expected: gitMetadata.GitMetadata{Ref: "HEAD", SHA: archiveSHA, Method: "archive-api"},
+		},
+		{
+			name:     "subdir",
+			source:   "github.com/org/repo//sub",
+			opts:     []gogather.Option{gogather.WithGitTag("v1")},
+			files:    map[string]string{"a.txt": "a"},
+			expected: gitMetadata.GitMetadata{Ref: "refs/tags/v1", SHA: archiveSHA, Method: "archive-api"},
+		},
+		{
+			name:     "file",
+			source:   "github.com/org/repo//sub/a.txt",
+			opts:     []gogather.Option{gogather.WithGitTag("v1")},
+			file:     "copy.txt",
+			files:    map[string]string{"copy.txt": "a"},
+			expected: gitMetadata.GitMetadata{Ref: "refs/tags/v1", SHA: archiveSHA, Method: "archive-api"},
+		},
+		{
+			name:     "GitLab subgroup",
+			source:   "https://gitlab.com/group/sub/repo.git",
+			opts:     []gogather.Option{gogather.WithGitBranch("main")},
+			files:    map[string]string{"README.md": "readme", "sub/a.txt": "a"},
+			expected: gitMetadata.GitMetadata{Ref: "refs/heads/main", SHA: archiveSHA, Method: "archive-api"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			destination := filepath.Join(root, "out")
+			if tc.file != "" {
+				destination = filepath.Join(root, tc.file)
+			}
+			gatherer := &GitGatherer{}
+			m, err := gatherer.Gather(context.Background(), tc.source, destination, append(tc.opts, gogather.WithGitUseArchiveAPI(true))...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			gm, ok := m.(*gitMetadata.GitMetadata)
+			if assert.True(t, ok) {
+				assert.Equal(t, tc.expected.Ref, gm.Ref)
+				assert.Equal(t, tc.expected.SHA, gm.SHA)
+				assert.Equal(t, tc.expected.Method, gm.Method)
+			}
+			dir := destination
+			if tc.file != "" {
+				dir = root
+			}
+			for name, content := range tc.files {
+				actual, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+				assert.NoError(t, err, name)
+				assert.Equal(t, content, string(actual), name)
+			}
+			_, err = os.Stat(filepath.Join(dir, ".git"))
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+// TestArchiveAPIRepositoryPath_Unsupported tests that remotes and refs without a tarball are
+// left to be cloned.
+func TestArchiveAPIRepositoryPath_Unsupported(t *testing.T) {
+	newArchiveServer(t)
+
+	testCases := []struct {
+		name      string
+		url       string
+		reference plumbing.ReferenceName
+		opts      []gogather.Option
+	}{
+		{name: "other host", url: "https://example.com/org/repo.git"},
+		{name: "ssh remote on other host", url: "git@example.com:org/repo.git"},
+		{name: "GitHub without a repository", url: "https://github.com/org"},
+		{name: "GitLab default branch", url: "https://gitlab.com/group/repo.git"},
+		{name: "missing commit", url: "https://github.com/org/repo.git", opts: []gogather.Option{gogather.WithGitCommit("abcdef0")}},
+		{name: "missing tag", url: "https://github.com/org/repo.git", reference: plumbing.NewTagReferenceName("v2")},
+	}
+
+	for _, tc := range testCases {
+		o := gogather.NewOptions(tc.opts...)
+		cloneOpts := &git.CloneOptions{URL: tc.url, ReferenceName: tc.reference}
+		_, err := archiveAPIRepositoryPath(context.Background(), o, "", t.TempDir(), cloneOpts)
+		assert.ErrorIs(t, err, errArchiveUnsupported, tc.name)
+	}
+}
+
+// TestArchiveAPIRepositoryPath_MissingPath tests that a path missing from the tarball is refused.
+func TestArchiveAPIRepositoryPath_MissingPath(t *testing.T) {
+	newArchiveServer(t)
+
+	cloneOpts := &git.CloneOptions{URL: "https://github.com/org/repo.git", ReferenceName: plumbing.NewTagReferenceName("v1")}
+	_, err := archiveAPIRepositoryPath(context.Background(), gogather.NewOptions(), "missing", filepath.Join(t.TempDir(), "out"), cloneOpts)
+	assert.ErrorContains(t, err, "path missing does not exist in the repository")
+}
@@ -0,0 +1,97 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// TestArchiveRepositoryPath tests that a subdirectory is exported with git archive.
+func TestArchiveRepositoryPath(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := createTestRepository(t, map[string]string{"sub/a.txt": "a", "sub/dir/b.txt": "b", "other.txt": "other"})
+	r, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTag("v1.0.0", head.Hash(), &git.CreateTagOptions{
+		Message: "v1.0.0",
+		Tagger:  &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		ref  plumbing.ReferenceName
+	}{
+		{name: "HEAD"},
+		{name: "branch", ref: head.Name()},
+		{name: "annotated tag", ref: "refs/tags/v1.0.0"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "export")
+			cloneOpts := &git.CloneOptions{URL: "file://" + repoPath, ReferenceName: tc.ref}
+			m, err := archiveRepositoryPath(context.Background(), gogather.NewOptions(), "/sub/", destination, cloneOpts)
+			assert.NoError(t, err)
+			assert.FileExists(t, filepath.Join(destination, "a.txt"))
+			assert.FileExists(t, filepath.Join(destination, "dir", "b.txt"))
+			assert.NoFileExists(t, filepath.Join(destination, "other.txt"))
+
+			gm := m.(*gitMetadata.GitMetadata)
+			assert.Equal(t, "archive", gm.Method)
+			assert.Equal(t, head.Hash().String(), gm.SHA)
+			assert.Empty(t, gm.Commits)
+		})
+	}
+}
+
+// TestArchiveRepositoryPath_Unsupported tests that files and https remotes fall back to a clone.
+func TestArchiveRepositoryPath_Unsupported(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := createTestRepository(t, map[string]string{"sub/a.txt": "a"})
+	o := gogather.NewOptions()
+
+	_, err := archiveRepositoryPath(context.Background(), o, "sub/a.txt", t.TempDir(), &git.CloneOptions{URL: "file://" + repoPath})
+	assert.ErrorIs(t, err, errArchiveUnsupported)
+	_, err = archiveRepositoryPath(context.Background(), o, "sub", t.TempDir(), &git.CloneOptions{URL: "https://example.com/repo.git"})
+	assert.ErrorIs(t, err, errArchiveUnsupported)
+
+	_, err = archiveRepositoryPath(context.Background(), o, "sub", t.TempDir(), &git.CloneOptions{URL: "file://" + repoPath, ReferenceName: "refs/heads/missing"})
+	assert.ErrorContains(t, err, "refs/heads/missing does not exist")
+}
@@ -0,0 +1,89 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrGitArgNotAllowed is returned when an argument given with WithGitExtraArgs is not on the
+// allowlist of gitConfigPrefixes.
+var ErrGitArgNotAllowed = errors.New("git argument not allowed")
+
+// gitConfigPrefixes are the configuration keys, or key prefixes ending with a dot, that may be
+// set with WithGitExtraArgs, in lower case. They tune the transfer only: keys that run
+// commands, such as core.sshCommand or remote.<name>.uploadpack, that change the allowed
+// protocols, the credentials, the headers or the proxy used, or that turn off TLS
+// verification, such as http.sslVerify, are refused.
+var gitConfigPrefixes = []string{
+	"http.postbuffer",
+	"http.lowspeedlimit",
+	"http.lowspeedtime",
+	"http.maxrequests",
+	"pack.",
+	"core.compression",
+	"core.bigfilethreshold",
+	"protocol.version",
+	"transfer.fsckobjects",
+	"fetch.fsckobjects",
+}
+
+// gitConfigArgs validates the extra arguments of the options and returns them as the global
+// "-c key=value" arguments of the git commands run by the gatherer. The arguments may be given
+// as "-c key=value", "--config key=value" or "--config=key=value", with the setting in a single
+// argument. They are passed to git without a shell, so values need no quoting or escaping.
+func gitConfigArgs(args []string) ([]string, error) {
+	var config []string
+	for i := 0; i < len(args); i++ {
+		setting, ok := strings.CutPrefix(args[i], "--config=")
+		if !ok {
+			if args[i] != "-c" && args[i] != "--config" {
+				return nil, fmt.Errorf("%w: %s", ErrGitArgNotAllowed, args[i])
+			}
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("%w: %s needs a key=value setting", ErrGitArgNotAllowed, args[i])
+			}
+			i++
+			setting = args[i]
+		}
+
+		key, _, ok := strings.Cut(setting, "=")
+		if !ok || !allowedGitConfig(key) {
+			return nil, fmt.Errorf("%w: the setting %s", ErrGitArgNotAllowed, setting)
+		}
+		config = append(config, "-c", setting)
+	}
+	return config, nil
+}
+
+// allowedGitConfig reports whether the configuration key may be set, comparing it without
+// regard to case like git does.
+func allowedGitConfig(key string) bool {
+	key = strings.ToLower(key)
+	for _, allowed := range gitConfigPrefixes {
+		if strings.HasSuffix(allowed, ".") {
+			if strings.HasPrefix(key, allowed) && len(key) > len(allowed) {
+				return true
+			}
+		} else if key == allowed {
+			return true
+		}
+	}
+	return false
+}
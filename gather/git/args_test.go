@@ -0,0 +1,79 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// TestGitConfigArgs tests that only allowlisted settings are turned into git config arguments
+func TestGitConfigArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []string
+		refused  bool
+	}{
+		{name: "none"},
+		{name: "short", args: []string{"-c", "http.postBuffer=524288000"}, expected: []string{"-c", "http.postBuffer=524288000"}},
+		{name: "long", args: []string{"--config", "pack.threads=2"}, expected: []string{"-c", "pack.threads=2"}},
+		{name: "long with equals", args: []string{"--config=core.compression=0"}, expected: []string{"-c", "core.compression=0"}},
+		{name: "case insensitive", args: []string{"-c", "Protocol.Version=2"}, expected: []string{"-c", "Protocol.Version=2"}},
+		{name: "low speed", args: []string{"-c", "http.lowSpeedLimit=1000", "-c", "http.lowSpeedTime=60"}, expected: []string{"-c", "http.lowSpeedLimit=1000", "-c", "http.lowSpeedTime=60"}},
+		{name: "value with spaces", args: []string{"-c", "pack.threads=a b"}, expected: []string{"-c", "pack.threads=a b"}},
+		{name: "upload pack", args: []string{"--upload-pack=touch /tmp/pwned"}, refused: true},
+		{name: "ssh command", args: []string{"-c", "core.sshCommand=touch /tmp/pwned"}, refused: true},
+		{name: "protocol allow", args: []string{"-c", "protocol.ext.allow=always"}, refused: true},
+		{name: "prefix only", args: []string{"-c", "pack.=x"}, refused: true},
+		{name: "ssl verify", args: []string{"-c", "http.sslVerify=false"}, refused: true},
+		{name: "proxy", args: []string{"-c", "http.proxy=http://proxy.example.com"}, refused: true},
+		{name: "extra header", args: []string{"-c", "http.extraHeader=Authorization: Bearer x"}, refused: true},
+		{name: "url specific", args: []string{"-c", "http.https://example.com.sslVerify=false"}, refused: true},
+		{name: "cookie file", args: []string{"-c", "http.cookieFile=/tmp/cookies"}, refused: true},
+		{name: "no value", args: []string{"-c", "http.postBuffer"}, refused: true},
+		{name: "missing setting", args: []string{"-c"}, refused: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := gitConfigArgs(tc.args)
+			if tc.refused {
+				assert.ErrorIs(t, err, ErrGitArgNotAllowed)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, config)
+		})
+	}
+}
+
+// TestGather_GitExtraArgs tests that disallowed extra arguments fail the gather
+func TestGather_GitExtraArgs(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"sub/a.txt": "a"})
+	gatherer := &GitGatherer{}
+
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, t.TempDir(), gogather.WithGitExtraArgs([]string{"--upload-pack=evil"}))
+	assert.ErrorIs(t, err, ErrGitArgNotAllowed)
+
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath+"//sub", t.TempDir(), gogather.WithGitExtraArgs([]string{"-c", "core.compression=0"}))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,93 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	gitUrls "github.com/whilp/git-urls"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// authMethod returns how the gather of the remote src, described by m, authenticated. Archive
+// API snapshots are fetched with the token of WithGitToken, if any, partial clones and git
+// archive exports with the git binary, and everything else with go-git, which authenticates
+// SSH connections through the SSH agent and sends the credentials of the URL, if any, with
+// basic authentication.
+func authMethod(ctx context.Context, o *gogather.Options, src string, m *gitMetadata.GitMetadata) string {
+	u, err := gitUrls.Parse(src)
+	if err != nil {
+		return metadata.AuthAnonymous
+	}
+	_, hasPassword := u.User.Password()
+
+	switch {
+	case m.Method == "archive-api":
+		if o.GitToken != "" {
+			return metadata.AuthBearer
+		}
+		return metadata.AuthAnonymous
+	case m.Method == "archive" || m.Filter != "":
+		return gitBinaryAuthMethod(ctx, o, u.Scheme, hasPassword)
+	case u.Scheme == "ssh":
+		return metadata.AuthSSHAgent
+	case hasPassword:
+		return metadata.AuthBasic
+	case (u.Scheme == "http" || u.Scheme == "https") && len(o.ClientCertificates) > 0:
+		return metadata.AuthClientCertificate
+	default:
+		return metadata.AuthAnonymous
+	}
+}
+
+// gitBinaryAuthMethod returns how the git binary authenticates with a remote of the given
+// scheme: ssh uses the SSH agent when one is running and the keys of the user otherwise, and
+// over http credentials not given in the URL come from the credential helpers git is
+// configured with, if any.
+func gitBinaryAuthMethod(ctx context.Context, o *gogather.Options, scheme string, hasPassword bool) string {
+	switch {
+	case scheme == "ssh":
+		if os.Getenv("SSH_AUTH_SOCK") != "" {
+			return metadata.AuthSSHAgent
+		}
+		return metadata.AuthSSHKey
+	case hasPassword:
+		return metadata.AuthBasic
+	case (scheme == "http" || scheme == "https") && hasCredentialHelper(ctx, o):
+		return metadata.AuthCredentialHelper
+	default:
+		return metadata.AuthAnonymous
+	}
+}
+
+// hasCredentialHelper reports whether git is configured with a credential helper, by the
+// configuration files or the extra arguments of the options.
+func hasCredentialHelper(ctx context.Context, o *gogather.Options) bool {
+	config, err := gitConfigArgs(o.GitExtraArgs)
+	if err != nil {
+		return false
+	}
+	// #nosec G204 -- the arguments are passed to git without a shell
+	out, err := exec.CommandContext(ctx, "git", append(config, "config", "--get-all", "credential.helper")...).Output()
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
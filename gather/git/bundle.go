@@ -0,0 +1,364 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	gitUrls "github.com/whilp/git-urls"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// bundleHeader is the first line of a version 2 git bundle.
+const bundleHeader = "# v2 git bundle\n"
+
+// bundlePackWindow is the number of objects the packfile of a bundle looks back for deltas.
+const bundlePackWindow = 10
+
+// bundleRepository fetches the refs described by the options and cloneOpts into memory and
+// writes them, with HEAD, to a git bundle file at destination, returning the metadata.
+// The history is limited to the depth of cloneOpts or the commits after since, if any.
+func bundleRepository(ctx context.Context, o *gogather.Options, destination string, cloneOpts *git.CloneOptions, since time.Time) (*gitMetadata.GitMetadata, error) {
+	refSpecs := bundleRefSpecs(o, cloneOpts)
+	r, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating repository: %w", err)
+	}
+	_, err = r.CreateRemote(&config.RemoteConfig{
+		Name:  cloneOpts.RemoteName,
+		URLs:  []string{cloneOpts.URL},
+		Fetch: refSpecs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating remote: %w", err)
+	}
+	err = r.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: cloneOpts.RemoteName,
+		RefSpecs:   refSpecs,
+		Depth:      cloneOpts.Depth,
+		Auth:       cloneOpts.Auth,
+		Progress:   cloneOpts.Progress,
+		Tags:       git.NoTags,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+
+	// Point HEAD at the commit a clone of the bundle checks out
+	if err := setBundleHead(ctx, o, r, cloneOpts); err != nil {
+		return nil, err
+	}
+	if !since.IsZero() {
+		if err := deepenSince(ctx, r, cloneOpts, refSpecs, since); err != nil {
+			return nil, err
+		}
+	}
+
+	refs, err := bundleRefs(r)
+	if err != nil {
+		return nil, err
+	}
+	objects, prerequisites, err := bundleObjects(r, refs)
+	if err != nil {
+		return nil, err
+	}
+	dst := fileDestination(destination, bundleName(cloneOpts.URL))
+	size, err := writeBundle(o, r, dst, refs, objects, prerequisites)
+	if err != nil {
+		return nil, fmt.Errorf("error writing bundle: %w", err)
+	}
+	o.Log(ctx, gogather.LevelTrace, "wrote bundle", "path", dst, "refs", len(refs), "objects", len(objects), "prerequisites", len(prerequisites))
+
+	m, err := repositoryMetadata(r, cloneOpts.ReferenceName, since)
+	if err != nil {
+		return nil, err
+	}
+	m.Method = "bundle"
+	m.Path = dst
+	m.Size = size
+	for _, ref := range refs {
+		m.Refs = append(m.Refs, ref.Name().String())
+	}
+	if o.GitCommit != "" {
+		m.Ref = plumbing.HEAD.String()
+	}
+	return m, nil
+}
+
+// bundleRefSpecs returns the refspecs fetching the refs of a bundle under their own names:
+// the refs of the options, all refs for a mirror, the reference name of cloneOpts, or else
+// all branches and tags. The reference name of cloneOpts is set to the first ref of the
+// options, if any.
+func bundleRefSpecs(o *gogather.Options, cloneOpts *git.CloneOptions) []config.RefSpec {
+	switch {
+	case len(o.GitRefs) > 0:
+		refSpecs := make([]config.RefSpec, len(o.GitRefs))
+		for i, ref := range o.GitRefs {
+			refSpecs[i] = refSpec(refName(ref), cloneOpts.RemoteName, true)
+		}
+		cloneOpts.ReferenceName = refName(o.GitRefs[0])
+		return refSpecs
+	case cloneOpts.Mirror:
+		return []config.RefSpec{"+refs/*:refs/*"}
+	case cloneOpts.ReferenceName != "":
+		return []config.RefSpec{refSpec(cloneOpts.ReferenceName, cloneOpts.RemoteName, true)}
+	}
+	return []config.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"}
+}
+
+// setBundleHead points HEAD of r at the commit of the options, if any, or else at the commit
+// of the reference name of cloneOpts, or of the branch HEAD of the remote points to.
+func setBundleHead(ctx context.Context, o *gogather.Options, r *git.Repository, cloneOpts *git.CloneOptions) error {
+	var hash plumbing.Hash
+	if o.GitCommit != "" {
+		h, err := checkedOutCommit(o, r)
+		if err != nil {
+			return err
+		}
+		hash = h
+	} else {
+		name := cloneOpts.ReferenceName
+		if name == "" {
+			stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseResolve)
+			head, err := remoteHead(ctx, cloneOpts.URL)
+			stop()
+			if err != nil {
+				return err
+			}
+			name = head
+		}
+		h, err := r.ResolveRevision(plumbing.Revision(name))
+		if err != nil {
+			return fmt.Errorf("ref %s not found in the repository: %w", name, err)
+		}
+		hash = *h
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hash)); err != nil {
+		return fmt.Errorf("error setting HEAD: %w", err)
+	}
+	return nil
+}
+
+// bundleRefs returns the refs of r to bundle: HEAD followed by the fetched refs, by name.
+func bundleRefs(r *git.Repository) ([]*plumbing.Reference, error) {
+	iter, err := r.References()
+	if err != nil {
+		return nil, fmt.Errorf("error listing refs: %w", err)
+	}
+	var refs []*plumbing.Reference
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference && ref.Name() != plumbing.HEAD {
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing refs: %w", err)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name() < refs[j].Name() })
+
+	head, err := r.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD: %w", err)
+	}
+	return append([]*plumbing.Reference{head}, refs...), nil
+}
+
+// bundleObjects returns the hashes of the objects of r reachable from refs, and the
+// prerequisites of the bundle: the parents of the shallow commits, which the bundle needs
+// but does not hold. Submodule commits are not bundled.
+func bundleObjects(r *git.Repository, refs []*plumbing.Reference) (objects, prerequisites []plumbing.Hash, err error) {
+	shallows, err := r.Storer.Shallow()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading shallow commits: %w", err)
+	}
+	missing := map[plumbing.Hash]bool{}
+	for _, h := range shallows {
+		c, err := r.CommitObject(h)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting commit %s: %w", h, err)
+		}
+		for _, p := range c.ParentHashes {
+			if !missing[p] {
+				missing[p] = true
+				prerequisites = append(prerequisites, p)
+			}
+		}
+	}
+	sort.Slice(prerequisites, func(i, j int) bool { return prerequisites[i].String() < prerequisites[j].String() })
+
+	seen := map[plumbing.Hash]bool{}
+	add := func(h plumbing.Hash) bool {
+		if seen[h] {
+			return false
+		}
+		seen[h] = true
+		objects = append(objects, h)
+		return true
+	}
+	var addTree func(tree *object.Tree) error
+	addTree = func(tree *object.Tree) error {
+		if !add(tree.Hash) {
+			return nil
+		}
+		for _, e := range tree.Entries {
+			switch e.Mode {
+			case filemode.Submodule:
+			case filemode.Dir:
+				subtree, err := r.TreeObject(e.Hash)
+				if err != nil {
+					return fmt.Errorf("error getting tree %s: %w", e.Hash, err)
+				}
+				if err := addTree(subtree); err != nil {
+					return err
+				}
+			default:
+				add(e.Hash)
+			}
+		}
+		return nil
+	}
+
+	for _, ref := range refs {
+		// Bundle annotated tags with the commits they point to
+		hash := ref.Hash()
+		for {
+			tag, err := r.TagObject(hash)
+			if err != nil {
+				break
+			}
+			add(hash)
+			hash = tag.Target
+		}
+		commit, err := r.CommitObject(hash)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting commit of %s: %w", ref.Name(), err)
+		}
+
+		// Walk the history down to the shallow commits, whose parents were not fetched
+		err = object.NewCommitPreorderIter(commit, seen, prerequisites).ForEach(func(c *object.Commit) error {
+			add(c.Hash)
+			tree, err := c.Tree()
+			if err != nil {
+				return fmt.Errorf("error getting tree of %s: %w", c.Hash, err)
+			}
+			return addTree(tree)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return objects, prerequisites, nil
+}
+
+// writeBundle writes a version 2 git bundle of refs, holding the objects and needing the
+// prerequisites, to a temporary file renamed to dst once complete, and returns its size.
+func writeBundle(o *gogather.Options, r *git.Repository, dst string, refs []*plumbing.Reference, objects, prerequisites []plumbing.Hash) (int64, error) {
+	if err := o.MkdirAll(filepath.Dir(dst)); err != nil {
+		return 0, err
+	}
+	if err := o.CheckSymlink(dst); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w := bufio.NewWriter(tmp)
+	if _, err := w.WriteString(bundleHeader); err != nil {
+		return 0, err
+	}
+	for _, h := range prerequisites {
+		if _, err := fmt.Fprintf(w, "-%s\n", h); err != nil {
+			return 0, err
+		}
+	}
+	for _, ref := range refs {
+		if _, err := fmt.Fprintf(w, "%s %s\n", ref.Hash(), ref.Name()); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.WriteByte('\n'); err != nil {
+		return 0, err
+	}
+	if _, err := packfile.NewEncoder(w, r.Storer, false).Encode(objects, bundlePackWindow); err != nil {
+		return 0, fmt.Errorf("error encoding packfile: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), o.FilePermission()); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// bundleName returns the file name of the bundle of the repository at remote: its name, or
+// "repository" if it has none, with the .bundle extension.
+func bundleName(remote string) string {
+	name := repositoryName(remote)
+	if name == "" {
+		name = "repository"
+	}
+	return name + ".bundle"
+}
+
+// repositoryName returns the name of the repository at remote, as git clone names it: the
+// last element of its path, without the .git suffix. It is empty if the path has none.
+func repositoryName(remote string) string {
+	u, err := gitUrls.Parse(remote)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
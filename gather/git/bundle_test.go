@@ -0,0 +1,116 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// TestGather_GitBundle tests that the requested refs are written to a bundle that git can
+// clone from, and that the refs of the bundle are reported in the metadata.
+func TestGather_GitBundle(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath, first := createTwoCommitRepository(t)
+
+	testCases := []struct {
+		name   string
+		query  string
+		opts   []gogather.Option
+		refs   []string
+		readme string
+	}{
+		{name: "all refs", refs: []string{"HEAD", "refs/heads/feature", "refs/heads/master", "refs/tags/v1.0.0"}, readme: "changed"},
+		{name: "branch", query: "?ref=feature", refs: []string{"HEAD", "refs/heads/feature"}, readme: "hello"},
+		{name: "git refs", opts: []gogather.Option{gogather.WithGitRefs([]string{"refs/tags/v1.0.0", "master"})}, refs: []string{"HEAD", "refs/heads/master", "refs/tags/v1.0.0"}, readme: "hello"},
+		{name: "commit", opts: []gogather.Option{gogather.WithGitCommit(first.String())}, refs: []string{"HEAD", "refs/heads/feature", "refs/heads/master", "refs/tags/v1.0.0"}, readme: "hello"},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := append([]gogather.Option{gogather.WithGitBundle(true)}, tc.opts...)
+			m, err := gatherer.Gather(context.Background(), "file://"+repoPath+tc.query, dir+"/", opts...)
+			assert.NoError(t, err)
+
+			gm := m.(*gitMetadata.GitMetadata)
+			assert.Equal(t, "bundle", gm.Method)
+			assert.Equal(t, filepath.Join(dir, "repo.bundle"), gm.Path)
+			assert.Equal(t, tc.refs, gm.Refs)
+			info, err := os.Stat(gm.Path)
+			assert.NoError(t, err)
+			assert.Equal(t, info.Size(), gm.Size)
+
+			clone := filepath.Join(dir, "clone")
+			out, err := exec.Command("git", "clone", "-q", gm.Path, clone).CombinedOutput()
+			assert.NoError(t, err, string(out))
+			content, err := os.ReadFile(filepath.Join(clone, "README.md"))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.readme, string(content))
+		})
+	}
+}
+
+// TestGather_GitBundleDepth tests that a bundle of a limited history lists the parents of its
+// oldest commits as prerequisites.
+func TestGather_GitBundleDepth(t *testing.T) {
+	repoPath, first := createTwoCommitRepository(t)
+	destination := filepath.Join(t.TempDir(), "out.bundle")
+
+	gatherer := &GitGatherer{}
+	m, err := gatherer.Gather(context.Background(), "file://"+repoPath+"?ref=master&depth=1", destination, gogather.WithGitBundle(true))
+	assert.NoError(t, err)
+	assert.Equal(t, destination, m.(*gitMetadata.GitMetadata).Path)
+
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	header, _, _ := strings.Cut(string(content), "\n\n")
+	lines := strings.Split(header, "\n")
+	assert.Equal(t, strings.TrimSuffix(bundleHeader, "\n"), lines[0])
+	assert.Equal(t, "-"+first.String(), lines[1])
+}
+
+// TestGather_GitBundleInvalidOptions tests that a bundle cannot be combined with a
+// subdirectory or a since ref.
+func TestGather_GitBundleInvalidOptions(t *testing.T) {
+	repoPath, first := createTwoCommitRepository(t)
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath+"//docs", t.TempDir(), gogather.WithGitBundle(true))
+	assert.ErrorContains(t, err, "a bundle cannot be combined")
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath, t.TempDir(), gogather.WithGitBundle(true), gogather.WithGitSince(first.String()))
+	assert.ErrorContains(t, err, "a bundle cannot be combined")
+}
+
+// TestBundleName tests that bundles are named after the repository.
+func TestBundleName(t *testing.T) {
+	assert.Equal(t, "repo.bundle", bundleName("https://example.com/org/repo.git"))
+	assert.Equal(t, "repo.bundle", bundleName("file:///tmp/repo/"))
+	assert.Equal(t, "repository.bundle", bundleName("file:///"))
+}
@@ -0,0 +1,113 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// checkRefOptions checks that at most one of the branch, tag and commit of the options is
+// set, and that it is not combined with ref, the ref of the URL, or with options it cannot
+// be checked out with.
+func checkRefOptions(o *gogather.Options, ref string) error {
+	set := 0
+	for _, name := range []string{o.GitBranch, o.GitTag, o.GitCommit} {
+		if name != "" {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		return nil
+	case set > 1:
+		return fmt.Errorf("only one of a git branch, tag or commit can be set")
+	case ref != "":
+		return fmt.Errorf("a git branch, tag or commit cannot be combined with a ref in the URL")
+	case len(o.GitRefs) > 0:
+		return fmt.Errorf("a git branch, tag or commit cannot be combined with git refs")
+	case o.GitCommit != "" && o.GitMirror:
+		return fmt.Errorf("a git commit cannot be checked out in a mirror")
+	case o.GitCommit != "" && !isCommitHash(o.GitCommit):
+		return fmt.Errorf("invalid git commit %q: expected a commit hash", o.GitCommit)
+	}
+	return nil
+}
+
+// isCommitHash reports whether s is a full or abbreviated commit hash.
+func isCommitHash(s string) bool {
+	if len(s) < 4 || len(s) > 64 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkedOutCommit returns the hash of the commit the clone r is checked out at: the commit
+// of the options, if any, or else the commit HEAD points to.
+func checkedOutCommit(o *gogather.Options, r *git.Repository) (plumbing.Hash, error) {
+	if o.GitCommit != "" {
+		hash, err := r.ResolveRevision(plumbing.Revision(o.GitCommit))
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("commit %s not found in the repository: %w", o.GitCommit, err)
+		}
+		return *hash, nil
+	}
+	head, err := r.Head()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("error getting HEAD: %w", err)
+	}
+	return head.Hash(), nil
+}
+
+// checkoutCommit checks out the commit of the options, if any, in the worktree of r, leaving
+// HEAD detached at it.
+func checkoutCommit(o *gogather.Options, r *git.Repository) error {
+	if o.GitCommit == "" {
+		return nil
+	}
+	hash, err := checkedOutCommit(o, r)
+	if err != nil {
+		return err
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Hash: hash, Force: true}); err != nil {
+		return fmt.Errorf("error checking out %s: %w", o.GitCommit, err)
+	}
+	return nil
+}
+
+// commitMetadata records the commit of the options, if any, as the checked out commit of m,
+// for clones without a worktree, whose HEAD stays at the default branch.
+func commitMetadata(o *gogather.Options, m *gitMetadata.GitMetadata, hash plumbing.Hash) {
+	if o.GitCommit != "" {
+		m.SHA = hash.String()
+		m.Ref = plumbing.HEAD.String()
+	}
+}
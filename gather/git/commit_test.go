@@ -0,0 +1,157 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// createTwoCommitRepository creates a test repository with a feature branch and a v1.0.0 tag
+// on its first commit, and a second commit changing README.md on master. It returns the path
+// of the repository and the hash of the first commit.
+func createTwoCommitRepository(t *testing.T) (string, plumbing.Hash) {
+	t.Helper()
+	repoPath := createBranchedRepository(t)
+	r, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "README.md"), []byte("changed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Commit("Change README", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repoPath, head.Hash()
+}
+
+// TestGather_GitBranchAndTag tests that branches are checked out as tracking branches and tags
+// as a detached HEAD.
+func TestGather_GitBranchAndTag(t *testing.T) {
+	repoPath, _ := createTwoCommitRepository(t)
+	gatherer := &GitGatherer{}
+
+	destination := filepath.Join(t.TempDir(), "clone")
+	m, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithGitBranch("feature"))
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/heads/feature", m.(*gitMetadata.GitMetadata).Ref)
+	content, err := os.ReadFile(filepath.Join(destination, "README.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+	r, err := git.PlainOpen(destination)
+	assert.NoError(t, err)
+	branch, err := r.Branch("feature")
+	assert.NoError(t, err)
+	assert.Equal(t, "origin", branch.Remote)
+
+	destination = filepath.Join(t.TempDir(), "clone")
+	m, err = gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithGitTag("v1.0.0"))
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/tags/v1.0.0", m.(*gitMetadata.GitMetadata).Ref)
+	content, err = os.ReadFile(filepath.Join(destination, "README.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+// TestGather_GitCommit tests that a commit given by its full or abbreviated hash is checked out
+// as a detached HEAD, for whole clones and for paths of the repository alike.
+func TestGather_GitCommit(t *testing.T) {
+	repoPath, first := createTwoCommitRepository(t)
+	gatherer := &GitGatherer{}
+
+	for _, sha := range []string{first.String(), first.String()[:8]} {
+		destination := filepath.Join(t.TempDir(), "clone")
+		m, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithGitCommit(sha))
+		assert.NoError(t, err)
+		assert.Equal(t, first.String(), m.(*gitMetadata.GitMetadata).SHA)
+		assert.Equal(t, "HEAD", m.(*gitMetadata.GitMetadata).Ref)
+		content, err := os.ReadFile(filepath.Join(destination, "README.md"))
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(content))
+
+		r, err := git.PlainOpen(destination)
+		assert.NoError(t, err)
+		head, err := r.Head()
+		assert.NoError(t, err)
+		assert.Equal(t, plumbing.HEAD, head.Name())
+	}
+
+	destination := filepath.Join(t.TempDir(), "README.md")
+	m, err := gatherer.Gather(context.Background(), "file://"+repoPath+"//README.md", destination, gogather.WithGitCommit(first.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, first.String(), m.(*gitMetadata.GitMetadata).SHA)
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+// TestGather_GitRefOptionsConflicts tests that branches, tags and commits are exclusive and
+// that invalid or unknown commits are refused.
+func TestGather_GitRefOptionsConflicts(t *testing.T) {
+	repoPath, _ := createTwoCommitRepository(t)
+	source := "file://" + repoPath
+
+	testCases := []struct {
+		name   string
+		source string
+		opts   []gogather.Option
+		msg    string
+	}{
+		{name: "branch and tag", opts: []gogather.Option{gogather.WithGitBranch("feature"), gogather.WithGitTag("v1.0.0")}, msg: "only one of a git branch, tag or commit can be set"},
+		{name: "tag and commit", opts: []gogather.Option{gogather.WithGitTag("v1.0.0"), gogather.WithGitCommit("abcdef12")}, msg: "only one of a git branch, tag or commit can be set"},
+		{name: "ref in URL", source: source + "?ref=feature", opts: []gogather.Option{gogather.WithGitBranch("feature")}, msg: "cannot be combined with a ref in the URL"},
+		{name: "git refs", opts: []gogather.Option{gogather.WithGitTag("v1.0.0"), gogather.WithGitRefs([]string{"feature"})}, msg: "cannot be combined with git refs"},
+		{name: "mirror", opts: []gogather.Option{gogather.WithGitCommit("abcdef12"), gogather.WithGitMirror(true)}, msg: "a git commit cannot be checked out in a mirror"},
+		{name: "invalid commit", opts: []gogather.Option{gogather.WithGitCommit("main")}, msg: `invalid git commit "main"`},
+		{name: "unknown commit", opts: []gogather.Option{gogather.WithGitCommit("0123456789abcdef0123456789abcdef01234567")}, msg: "commit 0123456789abcdef0123456789abcdef01234567 not found"},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		if tc.source == "" {
+			tc.source = source
+		}
+		_, err := gatherer.Gather(context.Background(), tc.source, filepath.Join(t.TempDir(), "clone"), tc.opts...)
+		assert.ErrorContains(t, err, tc.msg, tc.name)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// diffRepositoryPath fetches a git repository without checking it out and writes the files
+// that changed between the since ref of the options and the checked out ref to the
+// destination. Renamed files are written under their new name, and their old name is
+// reported as deleted along with the removed files. If path is not empty, only the changes
+// below it are written, relative to it.
+func diffRepositoryPath(ctx context.Context, o *gogather.Options, path, destination string, cloneOpts *git.CloneOptions) (metadata.Metadata, error) {
+	r, err := git.CloneContext(ctx, memory.NewStorage(), nil, cloneOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error cloning repository: %w", err)
+	}
+
+	hash, err := checkedOutCommit(o, r)
+	if err != nil {
+		return nil, err
+	}
+	to, err := commitTree(r, hash)
+	if err != nil {
+		return nil, err
+	}
+	since, err := resolveSince(r, cloneOpts.RemoteName, o.GitSince)
+	if err != nil {
+		return nil, err
+	}
+	from, err := commitTree(r, since)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, from, to, &object.DiffTreeOptions{DetectRenames: true})
+	if err != nil {
+		return nil, fmt.Errorf("error comparing with %s: %w", o.GitSince, err)
+	}
+
+	path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	if path == "." {
+		path = ""
+	}
+	var changed, deleted []string
+	if err := o.MkdirAll(destination); err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			if rel, ok := diffPath(path, change.From.Name); ok {
+				deleted = append(deleted, rel)
+			}
+		}
+		if change.To.Name == "" {
+			continue
+		}
+		rel, ok := diffPath(path, change.To.Name)
+		if !ok {
+			continue
+		}
+		if err := o.CheckDirDepth(rel); err != nil {
+			return nil, err
+		}
+		included, err := o.Included(rel, false)
+		if err != nil {
+			return nil, err
+		}
+		if !included {
+			continue
+		}
+		file, err := to.TreeEntryFile(&change.To.TreeEntry)
+		if err != nil {
+			return nil, fmt.Errorf("error getting file %s: %w", change.To.Name, err)
+		}
+		if err := writeFile(o, file, filepath.Join(destination, filepath.FromSlash(rel))); err != nil {
+			return nil, fmt.Errorf("error writing file: %w", err)
+		}
+		changed = append(changed, rel)
+	}
+
+	m, err := repositoryMetadata(r, cloneOpts.ReferenceName, o.GitShallowSince)
+	if err != nil {
+		return nil, err
+	}
+	m.Method = "diff"
+	m.Changed = changed
+	m.Deleted = deleted
+	commitMetadata(o, m, hash)
+	return m, nil
+}
+
+// diffPath returns the path of a changed file relative to the requested path, and false if
+// the file is not below it. A requested path naming the file itself yields its base name.
+func diffPath(root, name string) (string, bool) {
+	switch {
+	case root == "":
+		return name, true
+	case name == root:
+		return path.Base(name), true
+	case strings.HasPrefix(name, root+"/"):
+		return strings.TrimPrefix(name, root+"/"), true
+	}
+	return "", false
+}
+
+// resolveSince resolves the since ref to a commit, trying it as a revision first and then
+// as a branch of the remote, which is only known as a remote tracking branch after a clone.
+func resolveSince(r *git.Repository, remote, since string) (plumbing.Hash, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(since))
+	if err != nil {
+		hash, err = r.ResolveRevision(plumbing.Revision(plumbing.NewRemoteReferenceName(remote, since)))
+	}
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("error resolving %s: %w", since, err)
+	}
+	return *hash, nil
+}
+
+// commitTree returns the tree of the commit with the given hash.
+func commitTree(r *git.Repository, hash plumbing.Hash) (*object.Tree, error) {
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting tree of commit %s: %w", hash, err)
+	}
+	return tree, nil
+}
@@ -0,0 +1,163 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// createChangedRepository creates a local git repository with a v1 tag followed by a commit
+// that modifies, adds, removes and renames files, and returns its path.
+func createChangedRepository(t *testing.T) string {
+	t.Helper()
+	repoPath := createTestRepository(t, map[string]string{
+		"a.txt":      "a",
+		"b.txt":      "b",
+		"old.txt":    "renamed content",
+		"docs/c.txt": "c",
+	})
+	r, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTag("v1", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("a2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "d.txt"), []byte("d"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(repoPath, "old.txt"), filepath.Join(repoPath, "docs", "new.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(repoPath, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Commit("Change files", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repoPath
+}
+
+// TestGather_GitSince tests that only the files changed since a ref are written
+func TestGather_GitSince(t *testing.T) {
+	repoPath := createChangedRepository(t)
+
+	testCases := []struct {
+		name    string
+		source  string
+		since   string
+		files   map[string]string
+		changed []string
+		deleted []string
+	}{
+		{
+			name:    "repository",
+			source:  "file://" + repoPath,
+			since:   "v1",
+			files:   map[string]string{"a.txt": "a2", "d.txt": "d", "docs/new.txt": "renamed content"},
+			changed: []string{"a.txt", "d.txt", "docs/new.txt"},
+			deleted: []string{"b.txt", "old.txt"},
+		},
+		{
+			name:    "subdirectory",
+			source:  "file://" + repoPath + "//docs",
+			since:   "v1",
+			files:   map[string]string{"new.txt": "renamed content"},
+			changed: []string{"new.txt"},
+		},
+		{
+			name:   "unchanged",
+			source: "file://" + repoPath,
+			since:  "master",
+		},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "changes")
+			m, err := gatherer.Gather(context.Background(), tc.source, destination, gogather.WithGitSince(tc.since))
+			assert.NoError(t, err)
+
+			gm, ok := m.(*gitMetadata.GitMetadata)
+			if !ok {
+				t.Fatalf("unexpected metadata type: %T", m)
+			}
+			assert.Equal(t, "diff", gm.Method)
+			assert.ElementsMatch(t, tc.changed, gm.Changed)
+			assert.ElementsMatch(t, tc.deleted, gm.Deleted)
+
+			var files []string
+			err = filepath.WalkDir(destination, func(path string, d os.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, _ := filepath.Rel(destination, path)
+				files = append(files, filepath.ToSlash(rel))
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Len(t, files, len(tc.files))
+			for name, content := range tc.files {
+				data, err := os.ReadFile(filepath.Join(destination, filepath.FromSlash(name)))
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(data))
+			}
+		})
+	}
+}
+
+// TestGather_GitSince_Errors tests that unknown since refs and limited histories are refused
+func TestGather_GitSince_Errors(t *testing.T) {
+	repoPath := createChangedRepository(t)
+	gatherer := &GitGatherer{}
+
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, t.TempDir(), gogather.WithGitSince("v9"))
+	assert.ErrorContains(t, err, "error resolving v9")
+
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath+"?depth=1", t.TempDir(), gogather.WithGitSince("v1"))
+	assert.ErrorContains(t, err, "cannot be combined")
+}
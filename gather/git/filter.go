@@ -0,0 +1,112 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// gitFilterPattern matches the partial clone filter specs accepted by WithGitFilter.
+var gitFilterPattern = regexp.MustCompile(`^(blob:none|blob:limit=[0-9]+[kmg]?|tree:[0-9]+|object:type=(blob|tree|commit|tag))$`)
+
+// checkFilter returns an error if filter, the filter spec of the options, is not empty and
+// not a supported partial clone filter.
+func checkFilter(filter string) error {
+	if filter != "" && !gitFilterPattern.MatchString(filter) {
+		return fmt.Errorf("unsupported git filter %q: expected blob:none, blob:limit=<n>[kmg], tree:<depth> or object:type=<type>", filter)
+	}
+	return nil
+}
+
+// filteredClone clones the remote of cloneOpts into destination with git clone and the filter
+// of the options, which go-git cannot pass to the remote, and checks out the commit of the
+// options, if any. The objects left out are fetched by git as they are needed, so the
+// clone is opened with go-git for its commits only.
+func filteredClone(ctx context.Context, o *gogather.Options, destination string, cloneOpts *git.CloneOptions) (*git.Repository, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("a git filter needs git to be installed: %w", err)
+	}
+	config, err := gitConfigArgs(o.GitExtraArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(config, "clone", "--filter="+o.GitFilter, "--origin", cloneOpts.RemoteName)
+	if o.ProgressWriter != nil {
+		args = append(args, "--progress")
+	} else {
+		args = append(args, "--quiet")
+	}
+	switch name := cloneOpts.ReferenceName; {
+	case name.IsBranch() || name.IsTag():
+		args = append(args, "--branch", name.Short())
+	case name != "":
+		return nil, fmt.Errorf("a git filter cannot be combined with ref %s, which is not a branch or a tag", name)
+	}
+	if cloneOpts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(cloneOpts.Depth))
+	}
+	if cloneOpts.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if cloneOpts.Mirror {
+		args = append(args, "--mirror")
+	}
+	args = append(args, "--", cloneOpts.URL, destination)
+	if err := runGit(ctx, o, "", args...); err != nil {
+		return nil, fmt.Errorf("error cloning repository: %w", err)
+	}
+
+	if o.GitCommit != "" && !cloneOpts.Mirror {
+		if err := runGit(ctx, o, destination, append(config, "checkout", "--quiet", "--detach", o.GitCommit)...); err != nil {
+			return nil, fmt.Errorf("error checking out %s: %w", o.GitCommit, err)
+		}
+	}
+	return git.PlainOpen(destination)
+}
+
+// runGit runs git with args in dir, or the working directory if dir is empty, writing its
+// progress to the progress writer of the options, if any. The error carries what git printed.
+func runGit(ctx context.Context, o *gogather.Options, dir string, args ...string) error {
+	// #nosec G204 -- the arguments are passed to git without a shell
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if o.ProgressWriter != nil {
+		cmd.Stderr = o.ProgressWriter
+	}
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// TestCheckFilter tests that only supported filter specs are accepted.
+func TestCheckFilter(t *testing.T) {
+	for _, filter := range []string{"", "blob:none", "blob:limit=1024", "blob:limit=1m", "tree:0", "tree:3", "object:type=blob"} {
+		assert.NoError(t, checkFilter(filter), filter)
+	}
+	for _, filter := range []string{"blob", "blob:limit=", "blob:limit=1t", "tree:x", "object:type=file", "sparse:oid=HEAD:filter", "combine:blob:none+tree:0", "--upload-pack=sh"} {
+		assert.ErrorContains(t, checkFilter(filter), "unsupported git filter", filter)
+	}
+}
+
+// TestGather_GitFilter tests that a partial clone is checked out and its filter reported.
+func TestGather_GitFilter(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath, first := createTwoCommitRepository(t)
+	out, err := exec.Command("git", "-C", repoPath, "config", "uploadpack.allowFilter", "true").CombinedOutput()
+	assert.NoError(t, err, string(out))
+
+	testCases := []struct {
+		name   string
+		opts   []gogather.Option
+		sha    string
+		readme string
+	}{
+		{name: "branch", readme: "changed"},
+		{name: "commit", opts: []gogather.Option{gogather.WithGitCommit(first.String())}, sha: first.String(), readme: "hello"},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "clone")
+			opts := append([]gogather.Option{gogather.WithGitFilter("blob:none")}, tc.opts...)
+			m, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, opts...)
+			assert.NoError(t, err)
+
+			gm := m.(*gitMetadata.GitMetadata)
+			assert.Equal(t, "blob:none", gm.Filter)
+			assert.Len(t, gm.Commits, 2)
+			if tc.sha != "" {
+				assert.Equal(t, tc.sha, gm.SHA)
+			}
+			content, err := os.ReadFile(filepath.Join(destination, "README.md"))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.readme, string(content))
+
+			// The clone fetches the blobs it leaves out from its remote
+			out, err := exec.Command("git", "-C", destination, "config", "remote.origin.promisor").Output()
+			assert.NoError(t, err)
+			assert.Equal(t, "true\n", string(out))
+		})
+	}
+}
+
+// TestGather_GitFilterInvalidOptions tests that filters are validated and cannot be combined
+// with options cloning with go-git.
+func TestGather_GitFilterInvalidOptions(t *testing.T) {
+	repoPath, _ := createTwoCommitRepository(t)
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, t.TempDir(), gogather.WithGitFilter("blob:all"))
+	assert.ErrorContains(t, err, "unsupported git filter")
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath+"//docs", t.TempDir(), gogather.WithGitFilter("blob:none"))
+	assert.ErrorContains(t, err, "a git filter cannot be combined")
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath, t.TempDir(), gogather.WithGitFilter("blob:none"), gogather.WithGitRefs([]string{"master"}))
+	assert.ErrorContains(t, err, "a git filter cannot be combined")
+}
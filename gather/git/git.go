@@ -17,23 +17,45 @@
 // Package git provides methods for gathering git repositories.
 // This package implements the Gatherer interface and provides methods for cloning git repositories,
 // retrieving commit metadata, and authenticating SSH connections.
+//
+// Repositories can be cloned over https://, ssh://, file:// and the git daemon protocol (git://).
+// The git daemon protocol is read-only, unencrypted and unauthenticated: the contents of the
+// repository are not protected in transit, so prefer https:// or ssh:// on untrusted networks.
+//
+// A subdirectory of a repository reached over ssh://, file:// or git:// is exported with
+// git archive when git is installed, writing only the requested tree. Otherwise, and over
+// https://, the repository objects are fetched into memory and the subdirectory is written
+// from them.
+//
+// With WithGitSince, only the files changed since a ref are written, for incremental builds,
+// and the removed files are reported in the metadata.
+//
+// With WithGitBundle, the fetched history is written to a git bundle file, for offline
+// transfer, rather than checked out.
 package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
 	gitUrls "github.com/whilp/git-urls"
 
 	gogather "github.com/enterprise-contract/go-gather"
@@ -66,18 +88,201 @@ func (r *RealSSHAuthenticator) NewSSHAgentAuth(user string) (transport.AuthMetho
 
 // Gather clones a Git repository from the given source URI into the specified destination directory,
 // and returns the metadata of the cloned repository.
-func (g *GitGatherer) Gather(ctx context.Context, source, destination string) (metadata.Metadata, error) {
-	src, ref, subdir, depth, err := processUrl(source)
+func (g *GitGatherer) Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (m metadata.Metadata, err error) {
+	o := gogather.NewOptions(opts...)
+	o.Emit(gogather.Event{Phase: gogather.EventStarted, Source: source})
+	defer func() { o.Emit(gogather.Event{Phase: gogather.EventDone, Source: source, Err: err}) }()
+	if o.Destination != nil {
+		return nil, fmt.Errorf("%w: the git gatherer clones into a local directory", gogather.ErrDestinationNotSupported)
+	}
+
+	// Run the caller's destination validator, if any
+	if err := o.ValidateDestination(destination); err != nil {
+		return nil, err
+	}
+
+	src, ref, subdir, depth, pr, err := processUrl(source)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process URL: %w", err)
 	}
 
+	// Look up the commit the lock input pins the source to, for sources tracking a branch
+	tracking := pr == "" && o.GitTag == "" && o.GitCommit == "" && len(o.GitRefs) == 0 && !o.GitMirror
+	var pin pinEntry
+	if tracking && o.LockInput != "" {
+		if pin, _, err = readPin(o.LockInput, source); err != nil {
+			return nil, err
+		}
+	}
+
+	// Refuse extra git arguments that are not allowed, and unsupported filters, before
+	// contacting the remote
+	if _, err := gitConfigArgs(o.GitExtraArgs); err != nil {
+		return nil, err
+	}
+	if err := checkFilter(o.GitFilter); err != nil {
+		return nil, err
+	}
+
+	// Apply the insteadOf rules of the git configuration, if asked to
+	if o.GitConfigRewrites {
+		src, err = rewriteURL(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite URL: %w", err)
+		}
+	}
+
+	// Check that the remote host may be contacted
+	if err := checkRemoteHost(ctx, o, src); err != nil {
+		return nil, err
+	}
+	ctx, release := withTransportConfig(ctx, o)
+	defer release()
+	sw := metadata.NewStopwatch()
+	ctx = sw.WithContext(ctx)
+
+	// Check out the head of the pull request of the URL, if any, as the single ref fetched
+	var pull int
+	if pr != "" {
+		var name plumbing.ReferenceName
+		if pull, name, err = resolvePullRequest(ctx, o, src, ref, subdir, pr); err != nil {
+			return nil, err
+		}
+		o.GitRefs = []string{name.String()}
+	}
+
+	// Resolve the commit without cloning or writing to the destination, if asked to
+	if o.GitMetadataOnly {
+		o.Log(ctx, gogather.LevelTrace, "resolving repository commit", "url", src, "ref", ref)
+		gm, err := metadataOnly(ctx, o, src, ref, pin.SHA)
+		if err != nil {
+			return nil, err
+		}
+		gm.Timing = sw.Timings()
+		gm.Auth = authMethod(ctx, o, src, gm)
+		gm.PullRequest = pull
+		if tracking {
+			if err := lockCommit(o, source, pin, gm); err != nil {
+				return nil, err
+			}
+		}
+		return gm, nil
+	}
+
+	// Clone into a staging directory that replaces the destination on success, if asked to.
+	// Bundles are written to a temporary file renamed into place instead.
+	finish := func(err error) error { return err }
+	if !o.GitBundle {
+		if destination, finish, err = o.StageDir(destination); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err = finish(err); err != nil {
+			m = nil
+		}
+	}()
+
+	_, statErr := os.Stat(destination)
+	created := os.IsNotExist(statErr)
+
+	o.Log(ctx, gogather.LevelTrace, "cloning repository", "url", src, "ref", ref, "subdir", subdir, "depth", depth)
+	m, err = g.gather(ctx, o, src, ref, subdir, depth, pin.SHA, destination)
+	if err != nil {
+		return nil, err
+	}
+	o.Log(ctx, slog.LevelInfo, "cloned repository", "url", src, "destination", destination)
+
+	// Apply the transform, if any, removing the destination again if we created it. A
+	// bundle holds no files to transform.
+	if !o.GitBundle {
+		if err := o.TransformTree(destination); err != nil {
+			if created {
+				_ = os.RemoveAll(destination)
+			}
+			return nil, err
+		}
+	}
+	if gm, ok := m.(*gitMetadata.GitMetadata); ok {
+		gm.Timing = sw.Timings()
+		gm.Auth = authMethod(ctx, o, src, gm)
+		gm.PullRequest = pull
+	}
+
+	if gm, ok := m.(*gitMetadata.GitMetadata); ok && tracking {
+		if err := lockCommit(o, source, pin, gm); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// DestinationName returns the name a gather of source into a directory would usually be
+// given: the name of the repository, as git clone names it, or the last element of the
+// subdirectory of the source, or with WithGitBundle the name of the bundle file.
+func (g *GitGatherer) DestinationName(ctx context.Context, source string, opts ...gogather.Option) (string, error) {
+	src, _, subdir, _, _, err := processUrl(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to process URL: %w", err)
+	}
+	if subdir := strings.Trim(subdir, "/"); subdir != "" {
+		return path.Base(subdir), nil
+	}
+	name := repositoryName(src)
+	if name == "" {
+		return "", fmt.Errorf("%s does not name a repository", source)
+	}
+	if gogather.NewOptions(opts...).GitBundle {
+		return bundleName(src), nil
+	}
+	return name, nil
+}
+
+// gather clones the repository described by the processed source URL into destination,
+// checking out the pinned commit, if any, instead of the tip of a branch.
+func (g *GitGatherer) gather(ctx context.Context, o *gogather.Options, src, ref, subdir, depth, pinned, destination string) (metadata.Metadata, error) {
 	cloneOpts := &git.CloneOptions{
-		URL: src,
+		URL:        src,
+		RemoteName: git.DefaultRemoteName,
+		Mirror:     o.GitMirror,
+		Progress:   o.ProgressWriter,
+	}
+	if o.GitRemoteName != "" {
+		cloneOpts.RemoteName = o.GitRemoteName
 	}
 
-	if ref != "" {
-		cloneOpts.ReferenceName = plumbing.ReferenceName("refs/heads/" + ref)
+	// Fetching several refs and mirroring produce a repository rather than files
+	if subdir != "" && (len(o.GitRefs) > 0 || o.GitMirror) {
+		return nil, fmt.Errorf("a subdirectory cannot be combined with git refs or a mirror")
+	}
+	// Filtered clones are made by git, writing a worktree or a mirror
+	if o.GitFilter != "" && (subdir != "" || len(o.GitRefs) > 0 || o.GitSince != "" || !o.GitShallowSince.IsZero() || o.GitBundle || o.GitUseArchiveAPI) {
+		return nil, fmt.Errorf("a git filter cannot be combined with a subdirectory, git refs, a since ref, a shallow since date, a bundle or the archive API")
+	}
+	name, err := referenceName(ctx, o, src, ref)
+	if err != nil {
+		return nil, err
+	}
+	cloneOpts.ReferenceName = name
+	if pinned != "" && !cloneOpts.ReferenceName.IsTag() {
+		o.GitCommit = pinned
+	}
+
+	// Fetch the requested branch or tag alone, if asked to, naming the branch HEAD of the
+	// remote points to when none is requested, so that later fetches keep to it
+	if o.GitSingleBranch {
+		if len(o.GitRefs) > 0 || o.GitMirror {
+			return nil, fmt.Errorf("a single branch cannot be combined with git refs or a mirror")
+		}
+		if cloneOpts.ReferenceName == "" {
+			stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseResolve)
+			cloneOpts.ReferenceName, err = remoteHead(ctx, src)
+			stop()
+			if err != nil {
+				return nil, err
+			}
+		}
+		cloneOpts.SingleBranch = true
 	}
 
 	if depth != "" {
@@ -88,71 +293,235 @@ func (g *GitGatherer) Gather(ctx context.Context, source, destination string) (m
 		cloneOpts.Depth = depth
 	}
 
-	// If we don't have a subdir, clone the repository and return the metadata
-	if subdir == "" {
-		r, err := git.PlainClone(destination, false, cloneOpts)
-		if err != nil {
-			return nil, fmt.Errorf("error cloning repository: %w", err)
+	// Limit the history by date, starting from a shallow clone that is deepened as needed
+	since := o.GitShallowSince
+	if !since.IsZero() {
+		if cloneOpts.Depth != 0 {
+			return nil, fmt.Errorf("a depth cannot be combined with a shallow since date")
 		}
+		cloneOpts.Depth = shallowSinceDepth
+	}
 
-		// Get the commit history
-		commits, err := r.CommitObjects()
-		if err != nil {
-			return nil, fmt.Errorf("error getting commit history: %w", err)
+	// Clone a pinned tag without its history, unless a depth or date is given explicitly
+	if o.GitAutoDepth && depth == "" && since.IsZero() && cloneOpts.ReferenceName.IsTag() {
+		cloneOpts.Depth = 1
+	}
+
+	// Write a bundle of the fetched history instead of a worktree, if asked to
+	if o.GitBundle {
+		if subdir != "" || o.GitSince != "" {
+			return nil, fmt.Errorf("a bundle cannot be combined with a subdirectory or a since ref")
 		}
+		return bundleRepository(ctx, o, destination, cloneOpts, since)
+	}
 
-		// Safely accumulate commits into the metadata structure
-		m := &gitMetadata.GitMetadata{}
-		err = commits.ForEach(func(c *object.Commit) error {
-			m.Commits = append(m.Commits, *c)
-			return nil
-		})
-		if err != nil {
-			return nil, fmt.Errorf("error accumulating commits: %w", err)
+	// Write only the files changed since the given ref, if asked to. The ref must be
+	// reachable, so the history cannot be limited.
+	if o.GitSince != "" {
+		if cloneOpts.Depth != 0 || !since.IsZero() || len(o.GitRefs) > 0 || o.GitMirror {
+			return nil, fmt.Errorf("a since ref cannot be combined with a depth, a shallow since date, git refs or a mirror")
 		}
+		return diffRepositoryPath(ctx, o, subdir, destination, cloneOpts)
+	}
 
-		return m, nil
+	// Download a snapshot from the GitHub or GitLab archive endpoint instead of cloning, if
+	// asked to. Snapshots carry no history, so a clone is used when the history is limited by
+	// date, and are made of a single ref, so a clone is used for several refs or a mirror.
+	if o.GitUseArchiveAPI && since.IsZero() && len(o.GitRefs) == 0 && !o.GitMirror {
+		m, err := archiveAPIRepositoryPath(ctx, o, subdir, destination, cloneOpts)
+		if !errors.Is(err, errArchiveUnsupported) {
+			return m, err
+		}
+	}
 
+	// If we have a subdir, export it as an archive, or clone the repository and copy the
+	// subdir to the destination if the remote cannot export it. Archives carry no history,
+	// so a clone is used when the history is limited by date, and are only made of refs, so
+	// a clone is used for a commit as well.
+	if subdir != "" {
+		if since.IsZero() && o.GitCommit == "" {
+			m, err := archiveRepositoryPath(ctx, o, subdir, destination, cloneOpts)
+			if !errors.Is(err, errArchiveUnsupported) {
+				return m, err
+			}
+		}
+		return cloneRepositoryPath(ctx, o, subdir, destination, cloneOpts)
+	}
+
+	// Otherwise clone the repository, or fetch the requested refs, and return the metadata
+	var r *git.Repository
+	var refSpecs []config.RefSpec
+	switch {
+	case len(o.GitRefs) > 0:
+		r, refSpecs, err = fetchRefs(ctx, o.GitRefs, destination, cloneOpts)
+	case o.GitFilter != "":
+		// The commit, if any, is checked out by git, which fetches the blobs it needs
+		r, err = filteredClone(ctx, o, destination, cloneOpts)
+	default:
+		r, err = cloneOrResume(ctx, o, destination, cloneOpts)
+		refSpecs = cloneRefSpecs(cloneOpts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error cloning repository: %w", err)
+	}
+	if !since.IsZero() {
+		if err := deepenSince(ctx, r, cloneOpts, refSpecs, since); err != nil {
+			return nil, err
+		}
+	}
+	if o.GitFilter == "" {
+		if err := checkoutCommit(o, r); err != nil {
+			return nil, err
+		}
+	}
+	if !o.GitMirror && len(o.GitRefs) == 0 {
+		if err := checkTreeDepth(o, r); err != nil {
+			return nil, err
+		}
+		// The checkout is written by go-git, so the files left out are removed afterwards
+		if err := o.FilterTree(destination); err != nil {
+			return nil, err
+		}
 	}
 
-	// If we have a subdir, clone the repository and copy the subdir to the destination
-	return cloneRepositoryPath(ctx, subdir, destination, cloneOpts)
+	m, err := repositoryMetadata(r, cloneOpts.ReferenceName, since)
+	if err != nil {
+		return nil, err
+	}
+	m.Method = "checkout"
+	if o.GitMirror {
+		m.Method = "mirror"
+	}
+	m.Filter = o.GitFilter
+
+	// Strip the .git directory for a clean export, if asked to
+	if o.StripGitDir {
+		if err := os.RemoveAll(filepath.Join(destination, git.GitDirName)); err != nil {
+			return nil, fmt.Errorf("error removing .git directory: %w", err)
+		}
+	}
+
+	// Apply the configured permissions, if any, to the checked out files
+	if err := o.ChmodTree(destination); err != nil {
+		return nil, fmt.Errorf("error changing permissions: %w", err)
+	}
+	return m, nil
 }
 
-// cloneRepositoryPath clones a git repository, copies the specified subdirectory to the destination, and returns the metadata.
-func cloneRepositoryPath(ctx context.Context, path, destination string, cloneOpts *git.CloneOptions) (metadata.Metadata, error) {
-	// create a temporary directory to clone the repository into
-	tmpDir, err := os.MkdirTemp("", "git-repo-")
-	if err != nil {
-		return nil, fmt.Errorf("error creating temporary directory: %w", err)
+// referenceName checks ref, the ref of the URL, against the options and returns the name
+// of the reference to clone, resolving a ref constraint or the kind of ref against the
+// remote if asked to. It is empty when neither the URL nor the options name a ref.
+func referenceName(ctx context.Context, o *gogather.Options, src, ref string) (plumbing.ReferenceName, error) {
+	if ref != "" && len(o.GitRefs) > 0 {
+		return "", fmt.Errorf("a ref cannot be combined with git refs")
+	}
+	if err := checkRefOptions(o, ref); err != nil {
+		return "", err
 	}
-	defer os.RemoveAll(tmpDir)
 
-	// Clone the repository into the temporary directory
-	r, err := git.PlainCloneContext(ctx, tmpDir, false, cloneOpts)
+	switch {
+	case ref != "" && o.GitRefResolver:
+		// Resolve "latest" or a semver constraint to the best matching tag
+		constraint, err := parseRefConstraint(ref)
+		if err != nil {
+			return "", err
+		}
+		stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseResolve)
+		defer stop()
+		return resolveTag(ctx, src, constraint)
+	case ref != "" && o.GitAutoDepth:
+		// Find whether the ref names a branch or a tag, which needs no history
+		stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseResolve)
+		defer stop()
+		return resolveRefName(ctx, src, ref)
+	case ref != "":
+		return plumbing.ReferenceName("refs/heads/" + ref), nil
+	case o.GitBranch != "":
+		return plumbing.NewBranchReferenceName(o.GitBranch), nil
+	case o.GitTag != "":
+		return plumbing.NewTagReferenceName(o.GitTag), nil
+	}
+	return "", nil
+}
+
+// cloneRepositoryPath fetches a git repository without checking it out, writes the file or
+// directory at the specified path to the destination, and returns the metadata.
+// A single file is written straight from its blob, a directory from its tree.
+// The history is limited to the commits after the shallow since date of the options, if any.
+func cloneRepositoryPath(ctx context.Context, o *gogather.Options, path, destination string, cloneOpts *git.CloneOptions) (metadata.Metadata, error) {
+	since := o.GitShallowSince
+	// Only the commit checked out is needed unless a commit of the history is, so that large
+	// repositories are not held in memory whole
+	if cloneOpts.Depth == 0 && o.GitCommit == "" && since.IsZero() {
+		shallow := *cloneOpts
+		shallow.Depth = 1
+		cloneOpts = &shallow
+	}
+	// Fetch the repository objects into memory, without a worktree
+	r, err := git.CloneContext(ctx, memory.NewStorage(), nil, cloneOpts)
 	if err != nil {
 		return nil, fmt.Errorf("error cloning repository: %w", err)
 	}
+	if !since.IsZero() {
+		if err := deepenSince(ctx, r, cloneOpts, cloneRefSpecs(cloneOpts), since); err != nil {
+			return nil, err
+		}
+	}
 
-	// Get the worktree
-	w, err := r.Worktree()
+	hash, err := checkedOutCommit(o, r)
 	if err != nil {
-		return nil, fmt.Errorf("error getting worktree: %w", err)
+		return nil, err
+	}
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD tree: %w", err)
 	}
 
 	// Check if the path exists in the repository
-	_, err = w.Filesystem.Stat(path)
+	path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	entry, err := tree.FindEntry(path)
 	if err != nil {
 		return nil, fmt.Errorf("path %s does not exist in the repository", path)
 	}
 
-	path = filepath.Join(tmpDir, path)
+	var method string
+	if entry.Mode.IsFile() {
+		file, err := tree.TreeEntryFile(entry)
+		if err != nil {
+			return nil, fmt.Errorf("error getting file %s: %w", path, err)
+		}
+		if err := writeFile(o, file, fileDestination(destination, file.Name)); err != nil {
+			return nil, fmt.Errorf("error writing file: %w", err)
+		}
+		method = "blob"
+	} else {
+		subtree, err := tree.Tree(path)
+		if err != nil {
+			return nil, fmt.Errorf("error getting directory %s: %w", path, err)
+		}
+		if err := writeTree(o, subtree, destination); err != nil {
+			return nil, fmt.Errorf("error copying directory: %w", err)
+		}
+		method = "tree"
+	}
 
-	err = copyDir(path, destination)
+	m, err := repositoryMetadata(r, cloneOpts.ReferenceName, since)
 	if err != nil {
-		return nil, fmt.Errorf("error copying directory: %w", err)
+		return nil, err
 	}
+	m.Method = method
+	commitMetadata(o, m, hash)
+	return m, nil
+}
 
+// repositoryMetadata returns the metadata of a cloned repository.
+// The ref is the reference that was requested, if any, otherwise the ref HEAD points to is reported.
+// If since is not zero, only the commits after it are included.
+func repositoryMetadata(r *git.Repository, ref plumbing.ReferenceName, since time.Time) (*gitMetadata.GitMetadata, error) {
 	// Get the commit history
 	commits, err := r.CommitObjects()
 	if err != nil {
@@ -168,84 +537,158 @@ func cloneRepositoryPath(ctx context.Context, path, destination string, cloneOpt
 	if err != nil {
 		return nil, fmt.Errorf("error accumulating commits: %w", err)
 	}
+	if !since.IsZero() {
+		m.Commits = commitsSince(m.Commits, since)
+	}
+
+	// Record the checked out ref and commit
+	head, err := r.Head()
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD: %w", err)
+	}
+	m.SHA = head.Hash().String()
+	m.Ref = head.Name().String()
+	if ref != "" {
+		m.Ref = ref.String()
+	}
 
 	return m, nil
 }
 
-// copyDir copies the contents of the src directory to dst directory
-func copyDir(src string, dst string) error {
-	src = filepath.Clean(src)
-	dst = filepath.Clean(dst)
-
-	srcInfo, err := os.Stat(src)
+// checkRemoteHost checks the host of the remote URL against the options.
+// Local repositories have no host and are not checked.
+func checkRemoteHost(ctx context.Context, o *gogather.Options, remote string) error {
+	u, err := gitUrls.Parse(remote)
 	if err != nil {
-		return fmt.Errorf("error getting source directory info: %w", err)
+		return fmt.Errorf("failed to parse remote URL: %w", err)
+	}
+	if err := o.CheckScheme(u.Scheme); err != nil {
+		return err
 	}
+	if u.Scheme == "file" || u.Host == "" {
+		return nil
+	}
+	return o.CheckHost(ctx, u.Host)
+}
 
-	if !srcInfo.IsDir() {
-		return fmt.Errorf("%s is not a directory", src)
+// fileDestination returns the path a single file called name is written to. Like the http
+// gatherer, destinations ending with a slash or without an extension are treated as directories.
+func fileDestination(destination, name string) string {
+	if strings.HasSuffix(destination, "/") || filepath.Ext(destination) == "" {
+		return filepath.Join(destination, name)
 	}
+	return destination
+}
 
-	_, err = os.Stat(dst)
-	if err != nil {
-		if os.IsNotExist(err) {
-			err = os.MkdirAll(dst, srcInfo.Mode())
-			if err != nil {
-				return err
-			}
-		} else {
+// writeTree writes the files of tree below dst, leaving out those the options do not include.
+func writeTree(o *gogather.Options, tree *object.Tree, dst string) error {
+	if err := o.MkdirAll(dst); err != nil {
+		return err
+	}
+	return tree.Files().ForEach(func(f *object.File) error {
+		if err := o.CheckDirDepth(f.Name); err != nil {
 			return err
 		}
-	}
+		if included, err := o.Included(f.Name, false); err != nil || !included {
+			return err
+		}
+		return writeFile(o, f, filepath.Join(dst, filepath.FromSlash(f.Name)))
+	})
+}
 
-	entries, err := os.ReadDir(src)
+// checkTreeDepth returns ErrMaxDepthExceeded if an entry of the tree of the commit checked out
+// in r lies deeper than the maximum depth of the options. Checkouts are written by go-git, so
+// the tree is only checked once it is written.
+func checkTreeDepth(o *gogather.Options, r *git.Repository) error {
+	hash, err := checkedOutCommit(o, r)
 	if err != nil {
 		return err
 	}
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return fmt.Errorf("error getting commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("error getting tree of %s: %w", hash, err)
+	}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, _, err := walker.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error walking tree of %s: %w", hash, err)
+		}
+		if err := o.CheckDirDepth(name); err != nil {
+			return err
+		}
+	}
+}
 
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
+// writeFile writes the contents of the file to dst, keeping executable bits and symlinks.
+func writeFile(o *gogather.Options, f *object.File, dst string) error {
+	if err := o.MkdirAll(filepath.Dir(dst)); err != nil {
+		return err
+	}
 
-		if entry.IsDir() {
-			err = copyDir(srcPath, dstPath)
-			if err != nil {
-				return err
-			}
-		} else {
-			err = copyFile(srcPath, dstPath)
-			if err != nil {
-				return err
-			}
+	if f.Mode == filemode.Symlink {
+		target, err := f.Contents()
+		if err != nil {
+			return err
 		}
+		return os.Symlink(target, dst)
 	}
-	return nil
-}
 
-// copyFile copies a file from src to dst
-func copyFile(src string, dst string) error {
-	srcFile, err := os.Open(src)
+	perm := o.FilePermission()
+	if f.Mode == filemode.Executable {
+		perm = o.PreservedPermission(0755)
+	}
+
+	r, err := f.Reader()
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
+	defer r.Close()
 
-	dstFile, err := os.Create(dst)
+	if err := o.CheckSymlink(dst); err != nil {
+		return err
+	}
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
 	if err != nil {
 		return err
 	}
 	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
-	if err != nil {
+	if _, err := io.Copy(dstFile, r); err != nil {
 		return err
 	}
+	return o.Chmod(dst, perm)
+}
+
+// localRepositoryPath returns the absolute path of a source given as a local path, with
+// its forcing prefix and file:// scheme removed and the tilde expanded. A "//subdir" suffix
+// is kept as is. It returns false for sources that are not local paths.
+func localRepositoryPath(rawURL string) (string, bool) {
+	rawURL = strings.TrimPrefix(strings.TrimPrefix(rawURL, "git::"), "file::")
+	path, isFileURL := strings.CutPrefix(rawURL, "file://")
+	if !isFileURL && !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "./") &&
+		!strings.HasPrefix(path, "../") && !strings.HasPrefix(path, "~/") {
+		return "", false
+	}
 
-	srcInfo, err := os.Stat(src)
+	// Resolve the repository path alone, as cleaning it would merge the subdir separator
+	repo, subdir, hasSubdir := strings.Cut(gogather.ExpandTilde(path), "//")
+	repo, err := filepath.Abs(repo)
 	if err != nil {
-		return err
+		return "", false
 	}
-	return os.Chmod(dst, srcInfo.Mode())
+	if hasSubdir {
+		return repo + "//" + subdir, true
+	}
+	return repo, true
 }
 
 // extractSubdirFromQuery extracts the value of the key from the query parameters and extracts a subdir, if present.
@@ -288,12 +731,17 @@ func getCloneOptions(source string, auth SSHAuthenticator) (*git.CloneOptions, e
 	return cloneOpts, nil
 }
 
-// processUrl processes the raw URL and returns the source URL, ref, subdir, and depth.
-func processUrl(rawURL string) (src, ref, subdir, depth string, err error) {
+// processUrl processes the raw URL and returns the source URL, ref, subdir, depth, and pull request.
+func processUrl(rawURL string) (src, ref, subdir, depth, pr string, err error) {
+	// Clone repositories given as local paths with the file transport
+	if path, ok := localRepositoryPath(rawURL); ok {
+		rawURL = "file://" + path
+	}
+
 	// Check if the URL is a git URL and if it is not a SSH URL, convert it to HTTPS
 	t, err := gogather.ClassifyURI(rawURL)
 	if err != nil {
-		return src, ref, subdir, depth, fmt.Errorf("failed to classify URI: %w", err)
+		return src, ref, subdir, depth, pr, fmt.Errorf("failed to classify URI: %w", err)
 	}
 	if t == gogather.GitURI && !strings.Contains(rawURL, "git@") && !strings.Contains(rawURL, "://") {
 		rawURL = "https://" + rawURL
@@ -304,22 +752,28 @@ func processUrl(rawURL string) (src, ref, subdir, depth string, err error) {
 		rawURL = strings.Split(rawURL, "::")[1]
 	}
 
+	// git+ssh:// is another name for ssh://, the only one the SSH transport knows
+	if strings.HasPrefix(rawURL, "git+ssh://") {
+		rawURL = "ssh://" + strings.TrimPrefix(rawURL, "git+ssh://")
+	}
+
 	// Parse the raw URL with the gitUrls package. This will format the URL correctly
 	parsedURL, err := gitUrls.Parse(rawURL)
 	if err != nil {
-		return src, ref, subdir, depth, fmt.Errorf("failed to parse URL: %w", err)
+		return src, ref, subdir, depth, pr, fmt.Errorf("failed to parse URL: %w", err)
 	}
 
 	// Parse the URL again with the url package to extract the query parameters, etc.
 	u, err := url.Parse(parsedURL.String())
 	if err != nil {
-		return src, ref, subdir, depth, fmt.Errorf("failed to reparse URL: %w", err)
+		return src, ref, subdir, depth, pr, fmt.Errorf("failed to reparse URL: %w", err)
 	}
 
 	// Extract the ref, subdir, and depth from the query parameters
 	q := u.Query()
 	ref = extractSubdirFromQuery(q, "ref", &subdir)
 	depth = extractSubdirFromQuery(q, "depth", &subdir)
+	pr = extractSubdirFromQuery(q, "pr", &subdir)
 	u.RawQuery = q.Encode()
 
 	// If the path contains "//", split it to get the actual path and subdir
@@ -329,11 +783,11 @@ func processUrl(rawURL string) (src, ref, subdir, depth string, err error) {
 		subdir = parts[1]
 	}
 
-	// If the path does not end with ".git", append it
-	if !strings.HasSuffix(u.Path, ".git") {
+	// If the path does not end with ".git" and is not a local repository, append it
+	if !strings.HasSuffix(u.Path, ".git") && !(u.Scheme == "file" && gogather.IsGitRepository(u.Path)) {
 		u.Path += ".git"
 	}
 
-	// Return the URL, ref, subdir, and depth
-	return u.String(), ref, subdir, depth, nil
+	// Return the URL, ref, subdir, depth, and pull request
+	return u.String(), ref, subdir, depth, pr, nil
 }
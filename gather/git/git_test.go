@@ -19,19 +19,24 @@ package git
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	gogather "github.com/enterprise-contract/go-gather"
 	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
 )
 
@@ -110,6 +115,35 @@ func TestGetGitCloneOptions_SSHAuthError(t *testing.T) {
 	mockAuth.AssertExpectations(t)
 }
 
+// TestProcessUrl_SSHPort tests that the port of SSH URLs is passed through to the SSH transport.
+func TestProcessUrl_SSHPort(t *testing.T) {
+	testCases := []struct {
+		source string
+		url    string
+		port   int
+		subdir string
+	}{
+		{source: "ssh://git@example.com:2222/org/repo.git", url: "ssh://git@example.com:2222/org/repo.git", port: 2222},
+		{source: "ssh://git@example.com:2222/org/repo//policies", url: "ssh://git@example.com:2222/org/repo.git", port: 2222, subdir: "policies"},
+		{source: "git+ssh://git@example.com:2222/org/repo.git", url: "ssh://git@example.com:2222/org/repo.git", port: 2222},
+		{source: "git::git+ssh://example.com:2222/org/repo", url: "ssh://example.com:2222/org/repo.git", port: 2222},
+		// Like git, scp-style URLs take everything after the colon as the path
+		{source: "git@example.com:2222/org/repo.git", url: "ssh://git@example.com/2222/org/repo.git"},
+	}
+
+	for _, tc := range testCases {
+		src, _, subdir, _, _, err := processUrl(tc.source)
+		assert.NoError(t, err, tc.source)
+		assert.Equal(t, tc.url, src, tc.source)
+		assert.Equal(t, tc.subdir, subdir, tc.source)
+
+		endpoint, err := transport.NewEndpoint(src)
+		assert.NoError(t, err, tc.source)
+		assert.Equal(t, "ssh", endpoint.Protocol, tc.source)
+		assert.Equal(t, tc.port, endpoint.Port, tc.source)
+	}
+}
+
 // TestGatherSuccess tests the successful gathering of a git repository
 func TestGatherSuccess(t *testing.T) {
 	// Create a temporary directory for the repository
@@ -240,7 +274,7 @@ func TestCloneRepositoryPath(t *testing.T) {
 	}
 
 	// Clone the repository path
-	metadata, err := cloneRepositoryPath(context.Background(), filepath.Base(subdir), destination, cloneOpts)
+	metadata, err := cloneRepositoryPath(context.Background(), gogather.NewOptions(), filepath.Base(subdir), destination, cloneOpts)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -269,3 +303,370 @@ func TestCloneRepositoryPath(t *testing.T) {
 		t.Fatalf("unexpected commit hash in metadata: %s", gitMetadata.Commits[0].Hash.String())
 	}
 }
+
+// TestCloneRepositoryPath_Shallow tests that only the commit checked out is fetched for a path,
+// unless a depth or a commit of the history is asked for.
+func TestCloneRepositoryPath_Shallow(t *testing.T) {
+	repoPath := createHistoryRepository(t, 3)
+	r, err := git.PlainOpen(repoPath)
+	assert.NoError(t, err)
+	commits, err := r.Log(&git.LogOptions{})
+	assert.NoError(t, err)
+	var first plumbing.Hash
+	assert.NoError(t, commits.ForEach(func(c *object.Commit) error {
+		first = c.Hash
+		return nil
+	}))
+
+	testCases := []struct {
+		name    string
+		opts    []gogather.Option
+		depth   int
+		commits int
+	}{
+		{name: "default", commits: 1},
+		{name: "depth", depth: 2, commits: 2},
+		{name: "commit", opts: []gogather.Option{gogather.WithGitCommit(first.String())}, commits: 3},
+	}
+
+	for _, tc := range testCases {
+		cloneOpts := &git.CloneOptions{URL: "file://" + repoPath, Depth: tc.depth}
+		m, err := cloneRepositoryPath(context.Background(), gogather.NewOptions(tc.opts...), "CHANGELOG.md", filepath.Join(t.TempDir(), "CHANGELOG.md"), cloneOpts)
+		assert.NoError(t, err, tc.name)
+		assert.Len(t, m.(*gitMetadata.GitMetadata).Commits, tc.commits, tc.name)
+		assert.Equal(t, tc.depth, cloneOpts.Depth, tc.name)
+	}
+}
+
+// TestGather_HostNotAllowed tests that the remote host is checked before cloning
+func TestGather_HostNotAllowed(t *testing.T) {
+	gatherer := &GitGatherer{}
+
+	_, err := gatherer.Gather(context.Background(), "git::https://github.com/git-fixtures/basic.git", t.TempDir(), gogather.WithDeniedHosts([]string{"github.com"}))
+
+	assert.ErrorIs(t, err, gogather.ErrHostNotAllowed)
+}
+
+// TestGather_InsecureTransport tests that git:// and http:// remotes are refused when insecure transports are not allowed
+func TestGather_InsecureTransport(t *testing.T) {
+	gatherer := &GitGatherer{}
+
+	for _, source := range []string{"git://example.com/org/repo.git", "git::http://example.com/org/repo.git"} {
+		_, err := gatherer.Gather(context.Background(), source, t.TempDir(), gogather.WithInsecureHTTPAllowed(false))
+		assert.ErrorIs(t, err, gogather.ErrInsecureTransport, source)
+	}
+}
+
+// createTestRepository creates a local git repository named repo.git containing the given files
+// and returns its path.
+func createTestRepository(t *testing.T, files map[string]string) string {
+	t.Helper()
+	repoPath := filepath.Join(t.TempDir(), "repo.git")
+	r, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add("."); err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repoPath
+}
+
+// TestGather_Transform tests that the transform is applied to the files of the cloned repository
+func TestGather_Transform(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	destination := filepath.Join(t.TempDir(), "clone")
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+		return append(content, '!'), nil
+	}))
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(destination, "README.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello!", string(content))
+
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath, destination+"2", gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+		return nil, fmt.Errorf("bad content")
+	}))
+	assert.ErrorContains(t, err, "bad content")
+	assert.NoDirExists(t, destination+"2")
+}
+
+// TestGather_GitDaemon tests cloning over the git daemon protocol from a local git daemon
+func TestGather_GitDaemon(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	daemon := exec.Command("git", "daemon", "--export-all", "--reuseaddr", "--listen=127.0.0.1",
+		fmt.Sprintf("--port=%d", port), "--base-path="+filepath.Dir(repoPath), filepath.Dir(repoPath))
+	if err := daemon.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = daemon.Process.Kill()
+		_ = daemon.Wait()
+	}()
+
+	// Wait for the daemon to accept connections
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	for i := 0; i < 50; i++ {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	destination := filepath.Join(t.TempDir(), "clone")
+	gatherer := &GitGatherer{}
+	_, err = gatherer.Gather(context.Background(), fmt.Sprintf("git://%s/repo.git", address), destination)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "README.md"))
+}
+
+type discardDestination struct{}
+
+func (discardDestination) Create(path string) (io.WriteCloser, error) {
+	return nopWriteCloser{io.Discard}, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// TestGather_DestinationNotSupported tests that custom destinations are refused
+func TestGather_DestinationNotSupported(t *testing.T) {
+	gatherer := &GitGatherer{}
+
+	_, err := gatherer.Gather(context.Background(), "git::https://github.com/git-fixtures/basic.git", t.TempDir(), gogather.WithDestination(discardDestination{}))
+
+	assert.ErrorIs(t, err, gogather.ErrDestinationNotSupported)
+}
+
+// TestGather_SingleFile tests that a single file is written from its blob, without a checkout
+func TestGather_SingleFile(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{
+		"README.md":             "hello",
+		"policies/policy.yaml":  "rules: []",
+		"policies/sub/sub.yaml": "sub: true",
+	})
+	gatherer := &GitGatherer{}
+
+	testCases := []struct {
+		source      string
+		destination string
+		method      string
+		files       map[string]string
+	}{
+		{source: "//policies/policy.yaml", destination: "out", method: "blob", files: map[string]string{"out/policy.yaml": "rules: []"}},
+		{source: "//policies/policy.yaml", destination: "renamed.yaml", method: "blob", files: map[string]string{"renamed.yaml": "rules: []"}},
+		{source: "//policies", destination: "dir", method: "archive", files: map[string]string{"dir/policy.yaml": "rules: []", "dir/sub/sub.yaml": "sub: true"}},
+		{source: "", destination: "clone", method: "checkout", files: map[string]string{"clone/README.md": "hello"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+"_"+tc.destination, func(t *testing.T) {
+			dir := t.TempDir()
+			m, err := gatherer.Gather(context.Background(), "file://"+repoPath+tc.source, filepath.Join(dir, tc.destination))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.method, m.(*gitMetadata.GitMetadata).Method)
+
+			for name, expected := range tc.files {
+				content, err := os.ReadFile(filepath.Join(dir, name))
+				assert.NoError(t, err)
+				assert.Equal(t, expected, string(content))
+			}
+		})
+	}
+}
+
+// TestGather_KeepGitDir tests that the .git directory is kept by default and stripped on request
+func TestGather_KeepGitDir(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	gatherer := &GitGatherer{}
+
+	kept := filepath.Join(t.TempDir(), "kept")
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, kept)
+	assert.NoError(t, err)
+	assert.DirExists(t, filepath.Join(kept, ".git"))
+
+	stripped := filepath.Join(t.TempDir(), "stripped")
+	m, err := gatherer.Gather(context.Background(), "file://"+repoPath, stripped, gogather.WithKeepGitDir(false))
+	assert.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(stripped, ".git"))
+	assert.FileExists(t, filepath.Join(stripped, "README.md"))
+	assert.Len(t, m.(*gitMetadata.GitMetadata).Commits, 1)
+}
+
+// TestGather_DestinationValidator tests that the validator runs before cloning
+func TestGather_DestinationValidator(t *testing.T) {
+	gatherer := &GitGatherer{}
+	errRejected := fmt.Errorf("rejected")
+
+	_, err := gatherer.Gather(context.Background(), "git::https://github.invalid/org/repo.git", t.TempDir(), gogather.WithDestinationValidator(func(string) error {
+		return errRejected
+	}))
+
+	assert.Equal(t, errRejected, err)
+}
+
+// TestGather_Umask tests that the umask is applied to checked out files, keeping executable bits
+func TestGather_Umask(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello", "docs/guide.md": "guide"})
+	destination := filepath.Join(t.TempDir(), "clone")
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithUmask(077))
+	assert.NoError(t, err)
+
+	for path, perm := range map[string]os.FileMode{"README.md": 0600, "docs": 0700, "docs/guide.md": 0600} {
+		info, err := os.Stat(filepath.Join(destination, path))
+		assert.NoError(t, err)
+		assert.Equal(t, perm, info.Mode().Perm(), path)
+	}
+}
+
+// TestGather_LocalSubdir tests that the subdir of a local repository given as a file path is exported at HEAD
+func TestGather_LocalSubdir(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := os.Rename(createTestRepository(t, map[string]string{"sub/README.md": "hello", "other.txt": "other"}), repoPath); err != nil {
+		t.Fatal(err)
+	}
+	// Uncommitted changes are not part of the export
+	if err := os.WriteFile(filepath.Join(repoPath, "sub", "draft.md"), []byte("draft"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &GitGatherer{}
+	for _, source := range []string{repoPath + "//sub", "file://" + repoPath + "//sub", "file::" + repoPath + "//sub"} {
+		t.Run(source, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "export")
+			_, err := gatherer.Gather(context.Background(), source, destination)
+			assert.NoError(t, err)
+			assert.FileExists(t, filepath.Join(destination, "README.md"))
+			assert.NoFileExists(t, filepath.Join(destination, "draft.md"))
+			assert.NoFileExists(t, filepath.Join(destination, "other.txt"))
+		})
+	}
+}
+
+// TestGather_AtomicDir tests that a clone replaces the previous destination directory.
+func TestGather_AtomicDir(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	destination := filepath.Join(t.TempDir(), "clone")
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(destination, "stale.txt"), []byte("stale"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithAtomicDir(true))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "README.md"))
+	assert.NoFileExists(t, filepath.Join(destination, "stale.txt"))
+
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath+"//missing", destination, gogather.WithAtomicDir(true))
+	assert.Error(t, err)
+	assert.FileExists(t, filepath.Join(destination, "README.md"))
+	entries, err := os.ReadDir(filepath.Dir(destination))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// TestGitGatherer_Gather_MaxDirDepth tests that checkouts and subdirectories with entries
+// deeper than the maximum depth are refused.
+func TestGitGatherer_Gather_MaxDirDepth(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"sub/a/b.txt": "b", "top.txt": "top"})
+	gatherer := &GitGatherer{}
+
+	for _, source := range []string{"file://" + repoPath, "file://" + repoPath + "//sub"} {
+		_, err := gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "clone"), gogather.WithMaxDirDepth(1))
+		assert.ErrorIs(t, err, gogather.ErrMaxDepthExceeded, source)
+
+		_, err = gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "clone"), gogather.WithMaxDirDepth(3))
+		assert.NoError(t, err, source)
+	}
+}
+
+// TestGitGatherer_Gather_IncludeExclude tests that only the included files of checkouts and
+// subdirectories are written, excludes winning, and that the clone is kept.
+func TestGitGatherer_Gather_IncludeExclude(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{
+		"sub/policy/main.rego":      "main",
+		"sub/policy/main_test.rego": "test",
+		"sub/README.md":             "readme",
+	})
+	gatherer := &GitGatherer{}
+	opts := []gogather.Option{gogather.WithInclude([]string{"*.rego"}), gogather.WithExclude([]string{"*_test.rego"})}
+
+	destination := filepath.Join(t.TempDir(), "clone")
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, opts...)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "sub", "policy", "main.rego"))
+	assert.NoFileExists(t, filepath.Join(destination, "sub", "policy", "main_test.rego"))
+	assert.NoFileExists(t, filepath.Join(destination, "sub", "README.md"))
+	assert.DirExists(t, filepath.Join(destination, ".git"))
+
+	destination = filepath.Join(t.TempDir(), "export")
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath+"//sub", destination, opts...)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "policy", "main.rego"))
+	assert.NoFileExists(t, filepath.Join(destination, "policy", "main_test.rego"))
+	assert.NoFileExists(t, filepath.Join(destination, "README.md"))
+}
+
+// TestGitGatherer_DestinationName tests that destinations are named after the repository or
+// the subdirectory of the source.
+func TestGitGatherer_DestinationName(t *testing.T) {
+	testCases := []struct {
+		source string
+		opts   []gogather.Option
+		want   string
+	}{
+		{source: "https://github.com/org/repo.git", want: "repo"},
+		{source: "git::https://github.com/org/repo.git?ref=main", want: "repo"},
+		{source: "git@github.com:org/repo.git", want: "repo"},
+		{source: "https://github.com/org/repo.git//policy/lib", want: "lib"},
+		{source: "https://github.com/org/repo.git", opts: []gogather.Option{gogather.WithGitBundle(true)}, want: "repo.bundle"},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		name, err := gatherer.DestinationName(context.Background(), tc.source, tc.opts...)
+		assert.NoError(t, err, tc.source)
+		assert.Equal(t, tc.want, name, tc.source)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/enterprise-contract/go-gather/gather/git
 go 1.21.9
 
 require (
+	github.com/Masterminds/semver/v3 v3.2.1
 	github.com/enterprise-contract/go-gather v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata/git v0.0.0-20240523073727-ba2c37023242
@@ -24,11 +25,13 @@ require (
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/jbenet/go-context v0.0.0-20150711004518-d14ea06fba99 // indirect
 	github.com/kevinburke/ssh_config v1.2.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/pjbgf/sha1cd v0.3.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/sergi/go-diff v1.3.2-0.20230802210424-5b0b94c5c0d3 // indirect
 	github.com/skeema/knownhosts v1.2.2 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
+	github.com/ulikunitz/xz v0.5.12 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
 	golang.org/x/crypto v0.23.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
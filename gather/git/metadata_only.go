@@ -0,0 +1,164 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// metadataRef is the local ref the commit of a metadata only gather is fetched into.
+const metadataRef = plumbing.ReferenceName("refs/go-gather/metadata")
+
+// metadataOnly resolves the commit of the repository at src described by ref, the ref of the
+// URL, and the options, taking the pinned commit, if any, instead of the tip of a branch.
+// The ref is resolved with the refs the remote advertises, and only its commit,
+// without history, is fetched into memory to record it in the metadata. Nothing is written
+// to disk.
+func metadataOnly(ctx context.Context, o *gogather.Options, src, ref, pinned string) (*gitMetadata.GitMetadata, error) {
+	if len(o.GitRefs) > 1 || o.GitMirror || o.GitSince != "" {
+		return nil, fmt.Errorf("metadata only cannot be combined with several git refs, a mirror or a since ref")
+	}
+	name, err := referenceName(ctx, o, src, ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.GitRefs) == 1 {
+		name = refName(o.GitRefs[0])
+	}
+	if pinned != "" && !name.IsTag() {
+		o.GitCommit = pinned
+	}
+
+	remoteName := git.DefaultRemoteName
+	if o.GitRemoteName != "" {
+		remoteName = o.GitRemoteName
+	}
+	r, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating repository: %w", err)
+	}
+	remote, err := r.CreateRemote(&config.RemoteConfig{Name: remoteName, URLs: []string{src}})
+	if err != nil {
+		return nil, fmt.Errorf("error creating remote: %w", err)
+	}
+
+	// Resolve the commit: the commit of the options, or the commit the ref points to
+	m := &gitMetadata.GitMetadata{Method: "ls-remote"}
+	var spec config.RefSpec
+	if o.GitCommit != "" {
+		if !plumbing.IsHash(o.GitCommit) {
+			return nil, fmt.Errorf("invalid git commit %q: a full commit hash is needed without a clone", o.GitCommit)
+		}
+		m.Ref, m.SHA = plumbing.HEAD.String(), strings.ToLower(o.GitCommit)
+		spec = config.RefSpec(m.SHA + ":" + metadataRef.String())
+	} else {
+		if name == "" {
+			name = plumbing.HEAD
+		}
+		stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseResolve)
+		target, hash, err := listCommit(ctx, remote, name)
+		stop()
+		if err != nil {
+			return nil, err
+		}
+		m.Ref, m.SHA = name.String(), hash.String()
+		spec = config.RefSpec("+" + target.String() + ":" + metadataRef.String())
+	}
+
+	// Fetch the commit alone for its author, committer and message. Remotes that do not
+	// serve commits by hash leave the metadata without it.
+	err = remote.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: []config.RefSpec{spec},
+		Depth:    1,
+		Tags:     git.NoTags,
+		Progress: o.ProgressWriter,
+	})
+	if errors.Is(err, git.ErrExactSHA1NotSupported) {
+		o.Log(ctx, slog.LevelDebug, "remote does not serve commits by hash", "url", src, "commit", m.SHA)
+		return m, nil
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("error fetching commit: %w", err)
+	}
+	commit, err := fetchedCommit(r)
+	if err != nil {
+		return nil, err
+	}
+	// The ref may have moved since it was listed: report the commit fetched
+	m.SHA = commit.Hash.String()
+	m.Commits = []object.Commit{*commit}
+	return m, nil
+}
+
+// listCommit lists the refs of remote and returns the ref to fetch for name, the target of
+// a symbolic ref such as HEAD, and the commit it points to, peeling annotated tags.
+func listCommit(ctx context.Context, remote *git.Remote, name plumbing.ReferenceName) (plumbing.ReferenceName, plumbing.Hash, error) {
+	refs, err := remote.ListContext(ctx, &git.ListOptions{PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return "", plumbing.ZeroHash, fmt.Errorf("error listing remote refs: %w", err)
+	}
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, r := range refs {
+		byName[r.Name()] = r
+	}
+
+	ref, ok := byName[name]
+	if ok && ref.Type() == plumbing.SymbolicReference {
+		ref, ok = byName[ref.Target()]
+	}
+	if !ok {
+		return "", plumbing.ZeroHash, fmt.Errorf("ref %s not found in %s", name, remote.Config().URLs[0])
+	}
+	if peeled, ok := byName[ref.Name()+"^{}"]; ok {
+		return ref.Name(), peeled.Hash(), nil
+	}
+	return ref.Name(), ref.Hash(), nil
+}
+
+// fetchedCommit returns the commit fetched into metadataRef of r, peeling an annotated tag.
+func fetchedCommit(r *git.Repository) (*object.Commit, error) {
+	ref, err := r.Reference(metadataRef, true)
+	if err != nil {
+		return nil, fmt.Errorf("error getting fetched commit: %w", err)
+	}
+	if tag, err := r.TagObject(ref.Hash()); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return nil, fmt.Errorf("error getting commit of tag %s: %w", tag.Name, err)
+		}
+		return commit, nil
+	}
+	commit, err := r.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("error getting fetched commit: %w", err)
+	}
+	return commit, nil
+}
@@ -0,0 +1,125 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// TestGather_GitMetadataOnly tests that the commit of a ref is resolved and recorded without
+// writing to the destination.
+func TestGather_GitMetadataOnly(t *testing.T) {
+	repoPath, first := createTwoCommitRepository(t)
+	r, err := git.PlainOpen(repoPath)
+	assert.NoError(t, err)
+	head, err := r.Head()
+	assert.NoError(t, err)
+	_, err = r.CreateTag("v2.0.0", head.Hash(), &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "Test User", Email: "test@example.com"},
+		Message: "Release v2.0.0",
+	})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		query   string
+		opts    []gogather.Option
+		ref     string
+		sha     string
+		message string
+	}{
+		{name: "no ref", ref: "HEAD", sha: head.Hash().String(), message: "Change README"},
+		{name: "branch", query: "?ref=feature", ref: "refs/heads/feature", sha: first.String(), message: "Initial commit"},
+		{name: "tag", opts: []gogather.Option{gogather.WithGitTag("v1.0.0")}, ref: "refs/tags/v1.0.0", sha: first.String(), message: "Initial commit"},
+		{name: "annotated tag", opts: []gogather.Option{gogather.WithGitTag("v2.0.0")}, ref: "refs/tags/v2.0.0", sha: head.Hash().String(), message: "Change README"},
+		{name: "commit", opts: []gogather.Option{gogather.WithGitCommit(first.String())}, ref: "HEAD", sha: first.String()},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "clone")
+			opts := append([]gogather.Option{gogather.WithGitMetadataOnly(true)}, tc.opts...)
+			m, err := gatherer.Gather(context.Background(), "file://"+repoPath+tc.query, destination, opts...)
+			assert.NoError(t, err)
+
+			gm := m.(*gitMetadata.GitMetadata)
+			assert.Equal(t, "ls-remote", gm.Method)
+			assert.Equal(t, tc.ref, gm.Ref)
+			assert.Equal(t, tc.sha, gm.SHA)
+			if tc.message != "" {
+				assert.Len(t, gm.Commits, 1)
+				assert.Equal(t, tc.message, gm.Commits[0].Message)
+			}
+			_, err = os.Stat(destination)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+// TestGather_GitMetadataOnlyLock tests that the commit resolved for a branch is recorded in
+// the lock output, and that the commit of the lock input is reported.
+func TestGather_GitMetadataOnlyLock(t *testing.T) {
+	repoPath, first := createTwoCommitRepository(t)
+	source := "file://" + repoPath
+	lockFile := filepath.Join(t.TempDir(), "git.lock")
+	assert.NoError(t, os.WriteFile(lockFile, []byte(`{"`+source+`": {"ref": "refs/heads/master", "sha": "`+first.String()+`"}}`), 0600))
+	gatherer := &GitGatherer{}
+
+	m, err := gatherer.Gather(context.Background(), source, t.TempDir(), gogather.WithGitMetadataOnly(true), gogather.WithLockInput(lockFile), gogather.WithLockOutput(lockFile))
+	assert.NoError(t, err)
+	assert.Equal(t, first.String(), m.(*gitMetadata.GitMetadata).SHA)
+
+	pins, err := readPins(lockFile)
+	assert.NoError(t, err)
+	assert.Equal(t, pinEntry{Ref: "refs/heads/master", SHA: first.String()}, pins[source])
+}
+
+// TestGather_GitMetadataOnlyErrors tests that refs that cannot be resolved without a clone
+// are refused.
+func TestGather_GitMetadataOnlyErrors(t *testing.T) {
+	repoPath, first := createTwoCommitRepository(t)
+	testCases := []struct {
+		name  string
+		query string
+		opts  []gogather.Option
+		err   string
+	}{
+		{name: "missing branch", query: "?ref=missing", err: "ref refs/heads/missing not found"},
+		{name: "abbreviated commit", opts: []gogather.Option{gogather.WithGitCommit(first.String()[:7])}, err: "a full commit hash is needed"},
+		{name: "mirror", opts: []gogather.Option{gogather.WithGitMirror(true)}, err: "metadata only cannot be combined"},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]gogather.Option{gogather.WithGitMetadataOnly(true)}, tc.opts...)
+			_, err := gatherer.Gather(context.Background(), "file://"+repoPath+tc.query, t.TempDir(), opts...)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// pinEntry is the entry of a source in a lock file: the ref gathered and the commit it was at.
+type pinEntry struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+// pinMu serializes the updates of lock files within the process.
+var pinMu sync.Mutex
+
+// readPins reads the entries of the lock file at path, keyed by source. A missing file has
+// no entries.
+func readPins(path string) (map[string]pinEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]pinEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading lock file: %w", err)
+	}
+	pins := map[string]pinEntry{}
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("error parsing lock file %s: %w", path, err)
+	}
+	return pins, nil
+}
+
+// readPin returns the entry of source in the lock file at path, if any.
+func readPin(path, source string) (pinEntry, bool, error) {
+	pins, err := readPins(path)
+	if err != nil {
+		return pinEntry{}, false, err
+	}
+	pin, ok := pins[source]
+	if ok && !isCommitHash(pin.SHA) {
+		return pinEntry{}, false, fmt.Errorf("invalid commit %q for %s in lock file %s", pin.SHA, source, path)
+	}
+	return pin, ok, nil
+}
+
+// writePin records the entry of source in the lock file at path, keeping the entries of
+// other sources. The file is replaced atomically.
+func writePin(path, source string, pin pinEntry) error {
+	pinMu.Lock()
+	defer pinMu.Unlock()
+
+	pins, err := readPins(path)
+	if err != nil {
+		return err
+	}
+	pins[source] = pin
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding lock file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating lock file directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("error creating lock file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing lock file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing lock file: %w", err)
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return fmt.Errorf("error writing lock file: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("error replacing lock file: %w", err)
+	}
+	return nil
+}
+
+// lockCommit records the commit of gm, gathered for a source tracking a branch, in the lock
+// output of the options, if any, keeping the branch of a pinned source. Tags are not recorded.
+func lockCommit(o *gogather.Options, source string, pin pinEntry, gm *gitMetadata.GitMetadata) error {
+	if o.LockOutput == "" {
+		return nil
+	}
+	entry := pinEntry{Ref: gm.Ref, SHA: gm.SHA}
+	if o.GitCommit != "" && pin.Ref != "" {
+		entry.Ref = pin.Ref
+	}
+	if plumbing.ReferenceName(entry.Ref).IsTag() {
+		return nil
+	}
+	return writePin(o.LockOutput, source, entry)
+}
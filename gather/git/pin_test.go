@@ -0,0 +1,101 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// TestGather_LockOutput tests that the commit checked out for a branch is recorded in the
+// lock output, alongside the entries of other sources, and that tags are not recorded.
+func TestGather_LockOutput(t *testing.T) {
+	repoPath, _ := createTwoCommitRepository(t)
+	source := "file://" + repoPath
+	lockFile := filepath.Join(t.TempDir(), "git.lock")
+	assert.NoError(t, os.WriteFile(lockFile, []byte(`{"other": {"ref": "refs/heads/main", "sha": "0123456789abcdef"}}`), 0600))
+	gatherer := &GitGatherer{}
+
+	m, err := gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "clone"), gogather.WithLockOutput(lockFile))
+	assert.NoError(t, err)
+	gm := m.(*gitMetadata.GitMetadata)
+
+	pins, err := readPins(lockFile)
+	assert.NoError(t, err)
+	assert.Equal(t, pinEntry{Ref: gm.Ref, SHA: gm.SHA}, pins[source])
+	assert.Equal(t, "0123456789abcdef", pins["other"].SHA)
+
+	_, err = gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "clone"), gogather.WithLockOutput(lockFile), gogather.WithGitTag("v1.0.0"))
+	assert.NoError(t, err)
+	pins, err = readPins(lockFile)
+	assert.NoError(t, err)
+	assert.Equal(t, pinEntry{Ref: gm.Ref, SHA: gm.SHA}, pins[source])
+	assert.Len(t, pins, 2)
+}
+
+// TestGather_LockInput tests that a branch is checked out at the commit recorded in the lock
+// input, and that the recorded branch is kept when the lock output is the same file.
+func TestGather_LockInput(t *testing.T) {
+	repoPath, first := createTwoCommitRepository(t)
+	source := "file://" + repoPath
+	lockFile := filepath.Join(t.TempDir(), "git.lock")
+	assert.NoError(t, writePin(lockFile, source, pinEntry{Ref: "refs/heads/master", SHA: first.String()}))
+	gatherer := &GitGatherer{}
+
+	destination := filepath.Join(t.TempDir(), "clone")
+	m, err := gatherer.Gather(context.Background(), source, destination, gogather.WithLockInput(lockFile), gogather.WithLockOutput(lockFile))
+	assert.NoError(t, err)
+	assert.Equal(t, first.String(), m.(*gitMetadata.GitMetadata).SHA)
+	content, err := os.ReadFile(filepath.Join(destination, "README.md"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, "changed", string(content))
+
+	pins, err := readPins(lockFile)
+	assert.NoError(t, err)
+	assert.Equal(t, pinEntry{Ref: "refs/heads/master", SHA: first.String()}, pins[source])
+
+	// Without the lock input, the tip of the branch is checked out and recorded
+	destination = filepath.Join(t.TempDir(), "clone")
+	m, err = gatherer.Gather(context.Background(), source, destination, gogather.WithLockOutput(lockFile))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.String(), m.(*gitMetadata.GitMetadata).SHA)
+	pins, err = readPins(lockFile)
+	assert.NoError(t, err)
+	assert.Equal(t, m.(*gitMetadata.GitMetadata).SHA, pins[source].SHA)
+}
+
+// TestGather_LockInput_Invalid tests that a lock input that cannot be parsed, or recording
+// something other than a commit hash, is refused.
+func TestGather_LockInput_Invalid(t *testing.T) {
+	repoPath, _ := createTwoCommitRepository(t)
+	source := "file://" + repoPath
+	gatherer := &GitGatherer{}
+
+	for _, content := range []string{"not json", `{"` + source + `": {"ref": "refs/heads/master", "sha": "main"}}`} {
+		lockFile := filepath.Join(t.TempDir(), "git.lock")
+		assert.NoError(t, os.WriteFile(lockFile, []byte(content), 0600))
+		_, err := gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "clone"), gogather.WithLockInput(lockFile))
+		assert.Error(t, err)
+	}
+}
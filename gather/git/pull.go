@@ -0,0 +1,84 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	gitUrls "github.com/whilp/git-urls"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// parsePullRequest parses pr, the "?pr=" query parameter of a source, as the number of a pull
+// request.
+func parsePullRequest(pr string) (int, error) {
+	n, err := strconv.Atoi(pr)
+	if err != nil || n <= 0 || strings.TrimLeft(pr, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid pull request %q: expected a positive number", pr)
+	}
+	return n, nil
+}
+
+// pullRequestRef returns the head ref of pull request n of the repository at src. GitLab,
+// recognized by a host named gitlab.com or starting with "gitlab.", keeps the heads of merge
+// requests in refs/merge-requests/<n>/head; GitHub and the forges following it, such as Gitea,
+// in refs/pull/<n>/head.
+func pullRequestRef(src string, n int) plumbing.ReferenceName {
+	if u, err := gitUrls.Parse(src); err == nil {
+		host := strings.ToLower(u.Hostname())
+		if host == "gitlab.com" || strings.HasPrefix(host, "gitlab.") {
+			return plumbing.ReferenceName(fmt.Sprintf("refs/merge-requests/%d/head", n))
+		}
+	}
+	return plumbing.ReferenceName(fmt.Sprintf("refs/pull/%d/head", n))
+}
+
+// resolvePullRequest checks that pull request pr of the repository at src can be checked out
+// with the options, ref and subdir of the source, and returns the number of the pull request
+// and its head ref, listed by the remote. The head ref is fetched alone, like a ref given to
+// WithGitRefs.
+func resolvePullRequest(ctx context.Context, o *gogather.Options, src, ref, subdir, pr string) (int, plumbing.ReferenceName, error) {
+	n, err := parsePullRequest(pr)
+	if err != nil {
+		return 0, "", err
+	}
+	if ref != "" || subdir != "" || len(o.GitRefs) > 0 || o.GitBranch != "" || o.GitTag != "" || o.GitCommit != "" || o.GitMirror {
+		return 0, "", fmt.Errorf("a pull request cannot be combined with a ref, a subdirectory, git refs, a git branch, tag or commit or a mirror")
+	}
+
+	name := pullRequestRef(src, n)
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{src},
+	})
+	stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseResolve)
+	_, _, err = listCommit(ctx, remote, name)
+	stop()
+	if err != nil {
+		return 0, "", fmt.Errorf("pull request %d not found: %w", n, err)
+	}
+	return n, name, nil
+}
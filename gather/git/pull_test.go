@@ -0,0 +1,173 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// createPullRequest commits a file to the repository at repoPath on a commit reachable only
+// from the head ref of pull request n, as GitHub keeps them, and returns the commit.
+func createPullRequest(t *testing.T, repoPath string, n int, name string) plumbing.Hash {
+	t.Helper()
+	r, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, name), []byte(name), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := w.Commit("Pull request", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(pullRequestRef("", n), hash)); err != nil {
+		t.Fatal(err)
+	}
+	// Move the branch back, leaving the commit to the pull request ref
+	if err := r.Storer.SetReference(plumbing.NewHashReference(head.Name(), head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// TestParsePullRequest tests that only positive numbers are taken as pull requests.
+func TestParsePullRequest(t *testing.T) {
+	testCases := []struct {
+		pr   string
+		want int
+		err  bool
+	}{
+		{pr: "123", want: 123},
+		{pr: "0", err: true},
+		{pr: "-1", err: true},
+		{pr: "+1", err: true},
+		{pr: "12a", err: true},
+		{pr: "main", err: true},
+	}
+
+	for _, tc := range testCases {
+		n, err := parsePullRequest(tc.pr)
+		if tc.err {
+			assert.ErrorContains(t, err, "invalid pull request", tc.pr)
+			continue
+		}
+		assert.NoError(t, err, tc.pr)
+		assert.Equal(t, tc.want, n, tc.pr)
+	}
+}
+
+// TestPullRequestRef tests that GitLab hosts use merge request refs and others pull request refs.
+func TestPullRequestRef(t *testing.T) {
+	testCases := []struct {
+		src  string
+		want plumbing.ReferenceName
+	}{
+		{src: "https://github.com/org/repo.git", want: "refs/pull/7/head"},
+		{src: "git@github.com:org/repo.git", want: "refs/pull/7/head"},
+		{src: "https://gitlab.com/group/sub/repo.git", want: "refs/merge-requests/7/head"},
+		{src: "ssh://git@gitlab.example.com/group/repo.git", want: "refs/merge-requests/7/head"},
+		{src: "https://codeberg.org/org/repo.git", want: "refs/pull/7/head"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, pullRequestRef(tc.src, 7), tc.src)
+	}
+}
+
+// TestProcessUrl_PullRequest tests that the pull request is taken from the query of the URL.
+func TestProcessUrl_PullRequest(t *testing.T) {
+	src, ref, _, _, pr, err := processUrl("git::https://github.com/org/repo?pr=123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.com/org/repo.git", src)
+	assert.Empty(t, ref)
+	assert.Equal(t, "123", pr)
+}
+
+// TestGitGatherer_Gather_PullRequest tests that the head of a pull request is checked out and
+// recorded in the metadata, with or without a clone.
+func TestGitGatherer_Gather_PullRequest(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "readme"})
+	hash := createPullRequest(t, repoPath, 123, "change.txt")
+	gatherer := &GitGatherer{}
+
+	destination := filepath.Join(t.TempDir(), "clone")
+	m, err := gatherer.Gather(context.Background(), "file://"+repoPath+"?pr=123", destination)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "change.txt"))
+	gm := m.(*gitMetadata.GitMetadata)
+	assert.Equal(t, hash.String(), gm.SHA)
+	assert.Equal(t, "refs/pull/123/head", gm.Ref)
+	assert.Equal(t, 123, gm.PullRequest)
+
+	m, err = gatherer.Gather(context.Background(), "file://"+repoPath+"?pr=123", filepath.Join(t.TempDir(), "none"), gogather.WithGitMetadataOnly(true))
+	assert.NoError(t, err)
+	gm = m.(*gitMetadata.GitMetadata)
+	assert.Equal(t, hash.String(), gm.SHA)
+	assert.Equal(t, 123, gm.PullRequest)
+}
+
+// TestGitGatherer_Gather_PullRequestErrors tests that invalid and missing pull requests, and
+// pull requests combined with other refs, are refused.
+func TestGitGatherer_Gather_PullRequestErrors(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "readme"})
+	createPullRequest(t, repoPath, 1, "change.txt")
+	gatherer := &GitGatherer{}
+
+	testCases := []struct {
+		name   string
+		source string
+		opts   []gogather.Option
+		err    string
+	}{
+		{name: "invalid", source: "?pr=abc", err: "invalid pull request"},
+		{name: "missing", source: "?pr=2", err: "pull request 2 not found"},
+		{name: "ref", source: "?pr=1&ref=main", err: "a pull request cannot be combined"},
+		{name: "subdir", source: "//docs?pr=1", err: "a pull request cannot be combined"},
+		{name: "tag", source: "?pr=1", opts: []gogather.Option{gogather.WithGitTag("v1")}, err: "a pull request cannot be combined"},
+	}
+
+	for _, tc := range testCases {
+		_, err := gatherer.Gather(context.Background(), "file://"+repoPath+tc.source, filepath.Join(t.TempDir(), "clone"), tc.opts...)
+		assert.ErrorContains(t, err, tc.err, tc.name)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// latestRef is the ref selecting the highest semver tag of a repository.
+const latestRef = "latest"
+
+// parseRefConstraint parses a ref of the form "latest" or a semver constraint such as "^1.2".
+// A nil constraint, returned for "latest", matches every release version.
+func parseRefConstraint(ref string) (*semver.Constraints, error) {
+	if ref == latestRef {
+		return nil, nil
+	}
+	c, err := semver.NewConstraint(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ref constraint %q: %w", ref, err)
+	}
+	return c, nil
+}
+
+// resolveTag lists the tags of the remote repository and returns the name of the
+// highest semver tag satisfying the constraint. Tags that are not semver versions are ignored.
+func resolveTag(ctx context.Context, remoteURL string, constraint *semver.Constraints) (plumbing.ReferenceName, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{remoteURL},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error listing remote refs: %w", err)
+	}
+
+	var best *semver.Version
+	var bestName plumbing.ReferenceName
+	for _, r := range refs {
+		if !r.Name().IsTag() {
+			continue
+		}
+		v, err := semver.NewVersion(r.Name().Short())
+		if err != nil {
+			continue
+		}
+		if constraint == nil && v.Prerelease() != "" {
+			continue
+		}
+		if constraint != nil && !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, bestName = v, r.Name()
+		}
+	}
+	if best == nil {
+		if constraint == nil {
+			return "", fmt.Errorf("no semver tags found in %s", remoteURL)
+		}
+		return "", fmt.Errorf("no tag in %s satisfies %s", remoteURL, strings.TrimSpace(constraint.String()))
+	}
+	return bestName, nil
+}
+
+// resolveRefName lists the refs of the remote repository and returns the full name of ref:
+// the branch of that name, or else the tag of that name. Refs that are neither are taken
+// as branches, which the clone then fails to find.
+func resolveRefName(ctx context.Context, remoteURL, ref string) (plumbing.ReferenceName, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{remoteURL},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error listing remote refs: %w", err)
+	}
+
+	branch, tag := plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)
+	found := branch
+	for _, r := range refs {
+		switch r.Name() {
+		case branch:
+			return branch, nil
+		case tag:
+			found = tag
+		}
+	}
+	return found, nil
+}
+
+// remoteHead lists the refs of the remote repository and returns the branch its HEAD points to.
+func remoteHead(ctx context.Context, remoteURL string) (plumbing.ReferenceName, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{remoteURL},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error listing remote refs: %w", err)
+	}
+	for _, r := range refs {
+		if r.Name() == plumbing.HEAD && r.Type() == plumbing.SymbolicReference {
+			return r.Target(), nil
+		}
+	}
+	return "", fmt.Errorf("the HEAD of %s does not point to a branch", remoteURL)
+}
+
+// refName returns the full name of a ref given to WithGitRefs. Names without the refs/
+// prefix are branches.
+func refName(ref string) plumbing.ReferenceName {
+	if strings.HasPrefix(ref, "refs/") {
+		return plumbing.ReferenceName(ref)
+	}
+	return plumbing.NewBranchReferenceName(ref)
+}
+
+// refSpec returns the refspec fetching ref from the remote. Like git clone, branches are
+// fetched as remote-tracking branches unless the clone is a mirror; other refs keep their name.
+func refSpec(ref plumbing.ReferenceName, remoteName string, mirror bool) config.RefSpec {
+	if ref.IsBranch() && !mirror {
+		return config.RefSpec(fmt.Sprintf("+%s:%s", ref, plumbing.NewRemoteReferenceName(remoteName, ref.Short())))
+	}
+	return config.RefSpec(fmt.Sprintf("+%s:%s", ref, ref))
+}
+
+// cloneRefSpecs returns the refspecs a clone with cloneOpts fetches.
+func cloneRefSpecs(cloneOpts *git.CloneOptions) []config.RefSpec {
+	if cloneOpts.Mirror {
+		return []config.RefSpec{config.RefSpec("+refs/*:refs/*")}
+	}
+	if cloneOpts.SingleBranch && cloneOpts.ReferenceName != "" {
+		return []config.RefSpec{refSpec(cloneOpts.ReferenceName, cloneOpts.RemoteName, false)}
+	}
+	refSpecs := []config.RefSpec{config.RefSpec(fmt.Sprintf(config.DefaultFetchRefSpec, cloneOpts.RemoteName))}
+	if cloneOpts.ReferenceName != "" && !cloneOpts.ReferenceName.IsBranch() {
+		refSpecs = append(refSpecs, refSpec(cloneOpts.ReferenceName, cloneOpts.RemoteName, false))
+	}
+	return refSpecs
+}
+
+// fetchRefs initializes a repository at destination and fetches refs from the remote of
+// cloneOpts in a single fetch. Unless the repository is a mirror, the first ref is checked
+// out, a branch as a local branch tracking the remote one. The reference name of cloneOpts is
+// set to the first ref, and the refspecs fetched are returned.
+func fetchRefs(ctx context.Context, refs []string, destination string, cloneOpts *git.CloneOptions) (*git.Repository, []config.RefSpec, error) {
+	names := make([]plumbing.ReferenceName, len(refs))
+	refSpecs := make([]config.RefSpec, len(refs))
+	for i, ref := range refs {
+		names[i] = refName(ref)
+		refSpecs[i] = refSpec(names[i], cloneOpts.RemoteName, cloneOpts.Mirror)
+	}
+	first := names[0]
+	cloneOpts.ReferenceName = first
+
+	r, err := git.PlainInit(destination, cloneOpts.Mirror)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = r.CreateRemote(&config.RemoteConfig{
+		Name:   cloneOpts.RemoteName,
+		URLs:   []string{cloneOpts.URL},
+		Fetch:  refSpecs,
+		Mirror: cloneOpts.Mirror,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = r.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: cloneOpts.RemoteName,
+		RefSpecs:   refSpecs,
+		Depth:      cloneOpts.Depth,
+		Auth:       cloneOpts.Auth,
+		Progress:   cloneOpts.Progress,
+		Tags:       git.NoTags,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, nil, err
+	}
+
+	// Point HEAD at the first ref, checking it out unless the repository is a mirror
+	if err := checkoutRef(r, first, cloneOpts.RemoteName, cloneOpts.Mirror); err != nil {
+		return nil, nil, err
+	}
+	return r, refSpecs, nil
+}
+
+// checkoutRef points HEAD of r at the fetched ref and, unless the repository is a mirror,
+// checks it out, a branch as a local branch tracking the one of the remote. Files left in the
+// worktree are overwritten.
+func checkoutRef(r *git.Repository, ref plumbing.ReferenceName, remoteName string, mirror bool) error {
+	if mirror {
+		head := plumbing.NewSymbolicReference(plumbing.HEAD, ref)
+		if !ref.IsBranch() {
+			hash, err := r.ResolveRevision(plumbing.Revision(ref))
+			if err != nil {
+				return fmt.Errorf("error resolving %s: %w", ref, err)
+			}
+			head = plumbing.NewHashReference(plumbing.HEAD, *hash)
+		}
+		return r.Storer.SetReference(head)
+	}
+
+	checkoutOpts := &git.CheckoutOptions{Force: true}
+	if ref.IsBranch() {
+		tracking, err := r.Reference(plumbing.NewRemoteReferenceName(remoteName, ref.Short()), true)
+		if err != nil {
+			return fmt.Errorf("error resolving %s: %w", ref, err)
+		}
+		if err := r.Storer.SetReference(plumbing.NewHashReference(ref, tracking.Hash())); err != nil {
+			return err
+		}
+		err = r.CreateBranch(&config.Branch{Name: ref.Short(), Remote: remoteName, Merge: ref})
+		if err != nil && !errors.Is(err, git.ErrBranchExists) {
+			return err
+		}
+		checkoutOpts.Branch = ref
+	} else {
+		hash, err := r.ResolveRevision(plumbing.Revision(ref))
+		if err != nil {
+			return fmt.Errorf("error resolving %s: %w", ref, err)
+		}
+		checkoutOpts.Hash = *hash
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := w.Checkout(checkoutOpts); err != nil {
+		return fmt.Errorf("error checking out %s: %w", ref, err)
+	}
+	return nil
+}
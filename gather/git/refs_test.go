@@ -0,0 +1,298 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// createTaggedRepository creates a test repository with the given tags on its only commit.
+func createTaggedRepository(t *testing.T, tags ...string) string {
+	t.Helper()
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	r, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range tags {
+		if _, err := r.CreateTag(tag, head.Hash(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repoPath
+}
+
+// TestGather_RefResolver tests that semver constraints and "latest" resolve to the best matching tag
+func TestGather_RefResolver(t *testing.T) {
+	repoPath := createTaggedRepository(t, "v1.0.0", "v1.2.3", "v2.0.0", "v2.1.0-rc.1", "not-a-version")
+
+	testCases := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: "^1.0", expected: "refs/tags/v1.2.3"},
+		{ref: "~1.0", expected: "refs/tags/v1.0.0"},
+		{ref: ">= 1.0, < 3", expected: "refs/tags/v2.0.0"},
+		{ref: "latest", expected: "refs/tags/v2.0.0"},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.ref, func(t *testing.T) {
+			source := "file://" + repoPath + "?ref=" + url.QueryEscape(tc.ref)
+			m, err := gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "clone"), gogather.WithGitRefResolver(true))
+			assert.NoError(t, err)
+
+			gm, ok := m.(*gitMetadata.GitMetadata)
+			if !ok {
+				t.Fatalf("unexpected metadata type: %T", m)
+			}
+			assert.Equal(t, tc.expected, gm.Ref)
+			assert.Len(t, gm.SHA, 40)
+		})
+	}
+}
+
+// TestGather_RefResolverNoMatch tests that an error is returned when no tag satisfies the constraint
+func TestGather_RefResolverNoMatch(t *testing.T) {
+	repoPath := createTaggedRepository(t, "v1.0.0")
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath+"?ref="+url.QueryEscape("^2.0"), t.TempDir(), gogather.WithGitRefResolver(true))
+
+	assert.ErrorContains(t, err, "no tag in")
+}
+
+// TestGather_RefResolverInvalidConstraint tests that invalid constraints are rejected before cloning
+func TestGather_RefResolverInvalidConstraint(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "clone")
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "git::https://github.invalid/org/repo.git?ref=not-a-constraint", destination, gogather.WithGitRefResolver(true))
+
+	assert.ErrorContains(t, err, "invalid ref constraint")
+	assert.NoDirExists(t, destination)
+}
+
+// createBranchedRepository creates a test repository with a feature branch and a v1.0.0 tag
+// on its only commit, besides the master branch.
+func createBranchedRepository(t *testing.T) string {
+	t.Helper()
+	repoPath := createTaggedRepository(t, "v1.0.0")
+	r, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference("refs/heads/feature", head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+	return repoPath
+}
+
+// refNames returns the names of the references of the repository at path.
+func refNames(t *testing.T, path string) []string {
+	t.Helper()
+	r, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := r.References()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name() != plumbing.HEAD {
+			names = append(names, ref.Name().String())
+		}
+		return nil
+	})
+	return names
+}
+
+// TestGather_GitRefs tests that the listed refs are fetched and the first one is checked out
+func TestGather_GitRefs(t *testing.T) {
+	repoPath := createBranchedRepository(t)
+	destination := filepath.Join(t.TempDir(), "clone")
+
+	gatherer := &GitGatherer{}
+	m, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination,
+		gogather.WithGitRefs([]string{"feature", "refs/tags/v1.0.0"}), gogather.WithGitRemoteName("upstream"))
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/heads/feature", m.(*gitMetadata.GitMetadata).Ref)
+	assert.Equal(t, "checkout", m.(*gitMetadata.GitMetadata).Method)
+	assert.FileExists(t, filepath.Join(destination, "README.md"))
+	assert.ElementsMatch(t, []string{"refs/heads/feature", "refs/remotes/upstream/feature", "refs/tags/v1.0.0"}, refNames(t, destination))
+
+	r, err := git.PlainOpen(destination)
+	assert.NoError(t, err)
+	head, err := r.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, plumbing.ReferenceName("refs/heads/feature"), head.Name())
+	remote, err := r.Remote("upstream")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"file://" + repoPath}, remote.Config().URLs)
+}
+
+// TestGather_GitRefsTag tests that a tag listed first is checked out as a detached HEAD
+func TestGather_GitRefsTag(t *testing.T) {
+	repoPath := createBranchedRepository(t)
+	destination := filepath.Join(t.TempDir(), "clone")
+
+	gatherer := &GitGatherer{}
+	m, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithGitRefs([]string{"refs/tags/v1.0.0"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/tags/v1.0.0", m.(*gitMetadata.GitMetadata).Ref)
+	assert.FileExists(t, filepath.Join(destination, "README.md"))
+}
+
+// TestGather_GitMirror tests that mirrors are bare and contain the refs of the remote
+func TestGather_GitMirror(t *testing.T) {
+	repoPath := createBranchedRepository(t)
+
+	testCases := []struct {
+		name     string
+		refs     []string
+		expected []string
+	}{
+		{name: "all refs", expected: []string{"refs/heads/master", "refs/heads/feature", "refs/tags/v1.0.0"}},
+		{name: "listed refs", refs: []string{"feature"}, expected: []string{"refs/heads/feature"}},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "mirror.git")
+			m, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithGitMirror(true), gogather.WithGitRefs(tc.refs))
+			assert.NoError(t, err)
+			assert.Equal(t, "mirror", m.(*gitMetadata.GitMetadata).Method)
+			assert.NoFileExists(t, filepath.Join(destination, "README.md"))
+			assert.FileExists(t, filepath.Join(destination, "HEAD"))
+			assert.ElementsMatch(t, tc.expected, refNames(t, destination))
+		})
+	}
+}
+
+// TestGather_GitSingleBranch tests that only the requested branch, or the branch HEAD of the
+// remote points to, is fetched, and that later fetches keep to it
+func TestGather_GitSingleBranch(t *testing.T) {
+	repoPath, _ := createTwoCommitRepository(t)
+
+	testCases := []struct {
+		name     string
+		query    string
+		ref      string
+		expected []string
+	}{
+		{name: "branch", query: "?ref=feature&depth=1", ref: "refs/heads/feature", expected: []string{"refs/heads/feature", "refs/remotes/origin/feature", "refs/tags/v1.0.0"}},
+		{name: "remote HEAD", ref: "refs/heads/master", expected: []string{"refs/heads/master", "refs/remotes/origin/master", "refs/tags/v1.0.0"}},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "clone")
+			m, err := gatherer.Gather(context.Background(), "file://"+repoPath+tc.query, destination, gogather.WithGitSingleBranch(true))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ref, m.(*gitMetadata.GitMetadata).Ref)
+			assert.ElementsMatch(t, tc.expected, refNames(t, destination))
+
+			r, err := git.PlainOpen(destination)
+			assert.NoError(t, err)
+			remote, err := r.Remote(git.DefaultRemoteName)
+			assert.NoError(t, err)
+			assert.Len(t, remote.Config().Fetch, 1)
+			assert.Contains(t, remote.Config().Fetch[0].String(), tc.ref)
+		})
+	}
+
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, filepath.Join(t.TempDir(), "clone"), gogather.WithGitSingleBranch(true), gogather.WithGitMirror(true))
+	assert.ErrorContains(t, err, "a single branch cannot be combined with git refs or a mirror")
+}
+
+// TestGather_GitRefsConflicts tests that refs and mirrors are rejected with a ref or subdirectory in the source
+func TestGather_GitRefsConflicts(t *testing.T) {
+	gatherer := &GitGatherer{}
+
+	_, err := gatherer.Gather(context.Background(), "file:///repo.git?ref=main", filepath.Join(t.TempDir(), "clone"), gogather.WithGitRefs([]string{"feature"}))
+	assert.ErrorContains(t, err, "a ref cannot be combined with git refs")
+
+	_, err = gatherer.Gather(context.Background(), "file:///repo.git//docs", filepath.Join(t.TempDir(), "clone"), gogather.WithGitMirror(true))
+	assert.ErrorContains(t, err, "a subdirectory cannot be combined with git refs or a mirror")
+}
+
+// TestGather_GitAutoDepth tests that tags are cloned without history and branches with it
+func TestGather_GitAutoDepth(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := createHistoryRepository(t, 5)
+	r, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		query   string
+		ref     string
+		commits int
+	}{
+		{name: "tag", query: "?ref=v1.0.0", ref: "refs/tags/v1.0.0", commits: 1},
+		{name: "tag with depth", query: "?ref=v1.0.0&depth=3", ref: "refs/tags/v1.0.0", commits: 3},
+		{name: "branch", query: "?ref=master", ref: "refs/heads/master", commits: 5},
+		{name: "no ref", ref: "HEAD", commits: 5},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := gatherer.Gather(context.Background(), "file://"+repoPath+tc.query, filepath.Join(t.TempDir(), "clone"), gogather.WithGitAutoDepth(true))
+			assert.NoError(t, err)
+
+			gm := m.(*gitMetadata.GitMetadata)
+			assert.Equal(t, tc.ref, gm.Ref)
+			assert.Len(t, gm.Commits, tc.commits)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// errForeignRepository is returned when the repository at the destination is not a clone of the remote.
+var errForeignRepository = errors.New("the destination contains a repository of another remote")
+
+// cloneOrResume clones the repository of cloneOpts into destination or, with WithGitResume,
+// resumes the interrupted clone of the same remote found there. A clone that cannot be
+// resumed is removed and cloned again.
+func cloneOrResume(ctx context.Context, o *gogather.Options, destination string, cloneOpts *git.CloneOptions) (*git.Repository, error) {
+	if !o.GitResume {
+		return git.PlainCloneContext(ctx, destination, cloneOpts.Mirror, cloneOpts)
+	}
+
+	r, err := git.PlainOpen(destination)
+	switch {
+	case errors.Is(err, git.ErrRepositoryNotExists):
+		// Nothing to resume
+	case err != nil:
+		o.Log(ctx, slog.LevelWarn, "cannot open the git repository to resume, cloning instead", "destination", destination, "error", err)
+	default:
+		err := resumeClone(ctx, r, cloneOpts)
+		if err == nil {
+			o.Log(ctx, slog.LevelInfo, "resumed git clone", "destination", destination, "url", cloneOpts.URL)
+			return r, nil
+		}
+		if errors.Is(err, errForeignRepository) {
+			return nil, err
+		}
+		o.Log(ctx, slog.LevelWarn, "cannot resume git clone, cloning again", "destination", destination, "url", cloneOpts.URL, "error", err)
+		if err := os.RemoveAll(destination); err != nil {
+			return nil, fmt.Errorf("error removing the clone to resume: %w", err)
+		}
+	}
+	return git.PlainCloneContext(ctx, destination, cloneOpts.Mirror, cloneOpts)
+}
+
+// resumeClone completes the clone r of the remote of cloneOpts: it fetches what is missing and
+// checks out the ref of cloneOpts or, when there is none, the one HEAD of the remote points to.
+func resumeClone(ctx context.Context, r *git.Repository, cloneOpts *git.CloneOptions) error {
+	remote, err := r.Remote(cloneOpts.RemoteName)
+	if errors.Is(err, git.ErrRemoteNotFound) {
+		return fmt.Errorf("%w: no remote %s", errForeignRepository, cloneOpts.RemoteName)
+	}
+	if err != nil {
+		return err
+	}
+	if urls := remote.Config().URLs; len(urls) == 0 || urls[0] != cloneOpts.URL {
+		return fmt.Errorf("%w: remote %s is %v", errForeignRepository, cloneOpts.RemoteName, urls)
+	}
+
+	// Find the ref to check out, as the clone may have been interrupted before recording it
+	ref := cloneOpts.ReferenceName
+	if ref == "" {
+		refs, err := remote.ListContext(ctx, &git.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error listing remote refs: %w", err)
+		}
+		for _, r := range refs {
+			if r.Name() == plumbing.HEAD && r.Type() == plumbing.SymbolicReference {
+				ref = r.Target()
+			}
+		}
+		if ref == "" {
+			return fmt.Errorf("the HEAD of the remote does not point to a branch")
+		}
+	}
+
+	err = r.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: cloneOpts.RemoteName,
+		RefSpecs:   cloneRefSpecs(cloneOpts),
+		Depth:      cloneOpts.Depth,
+		Auth:       cloneOpts.Auth,
+		Progress:   cloneOpts.Progress,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("error fetching: %w", err)
+	}
+	return checkoutRef(r, ref, cloneOpts.RemoteName, cloneOpts.Mirror)
+}
@@ -0,0 +1,116 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// initPartialClone initializes a repository at destination with the remote origin pointing
+// to url, as an interrupted clone leaves it before any object is fetched.
+func initPartialClone(t *testing.T, destination, url string) *git.Repository {
+	t.Helper()
+	r, err := git.PlainInit(destination, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{url}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// TestGather_GitResume tests that interrupted clones are completed
+func TestGather_GitResume(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	source := "file://" + repoPath
+
+	testCases := []struct {
+		name    string
+		partial func(t *testing.T, destination string)
+	}{
+		{name: "initialized", partial: func(t *testing.T, destination string) {
+			initPartialClone(t, destination, source)
+		}},
+		{name: "not checked out", partial: func(t *testing.T, destination string) {
+			_, err := git.PlainClone(destination, false, &git.CloneOptions{URL: source, NoCheckout: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "clone")
+			tc.partial(t, destination)
+			var log bytes.Buffer
+
+			m, err := gatherer.Gather(context.Background(), source, destination, gogather.WithGitResume(true), gogather.WithLogger(slog.New(slog.NewTextHandler(&log, nil))))
+			assert.NoError(t, err)
+			assert.Equal(t, "refs/heads/master", m.(*gitMetadata.GitMetadata).Ref)
+			assert.FileExists(t, filepath.Join(destination, "README.md"))
+			assert.Contains(t, log.String(), "resumed git clone")
+		})
+	}
+}
+
+// TestGather_GitResumeFallback tests that a clone that cannot be resumed is cloned again
+func TestGather_GitResumeFallback(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	source := "file://" + repoPath
+	destination := filepath.Join(t.TempDir(), "clone")
+
+	// Malformed packed refs break the fetch
+	initPartialClone(t, destination, source)
+	if err := os.WriteFile(filepath.Join(destination, ".git", "packed-refs"), []byte("not a ref\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), source, destination, gogather.WithGitResume(true), gogather.WithLogger(slog.New(slog.NewTextHandler(&log, nil))))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "README.md"))
+	assert.Contains(t, log.String(), "cannot resume git clone, cloning again")
+}
+
+// TestGather_GitResumeForeign tests that a repository of another remote is left as is
+func TestGather_GitResumeForeign(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	destination := filepath.Join(t.TempDir(), "clone")
+	initPartialClone(t, destination, "https://example.com/other.git")
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithGitResume(true))
+	assert.ErrorContains(t, err, "the destination contains a repository of another remote")
+	assert.DirExists(t, filepath.Join(destination, ".git"))
+}
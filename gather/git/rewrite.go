@@ -0,0 +1,83 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+// rewriteURL applies the url.<base>.insteadOf rules of the system and global git
+// configuration to remote, using the longest matching prefix like native git does.
+// The pushInsteadOf rules only apply to pushes and are therefore ignored.
+func rewriteURL(remote string) (string, error) {
+	var paths []string
+	for _, scope := range []config.Scope{config.SystemScope, config.GlobalScope} {
+		p, err := config.Paths(scope)
+		if err != nil {
+			return "", fmt.Errorf("error locating git config: %w", err)
+		}
+		paths = append(paths, p...)
+	}
+
+	var base, insteadOf string
+	for _, path := range paths {
+		cfg, err := readGitConfig(path)
+		if err != nil {
+			return "", err
+		}
+		for _, s := range cfg.Sections {
+			if !s.IsName("url") {
+				continue
+			}
+			for _, ss := range s.Subsections {
+				for _, prefix := range ss.Options.GetAll("insteadOf") {
+					if strings.HasPrefix(remote, prefix) && len(prefix) > len(insteadOf) {
+						base, insteadOf = ss.Name, prefix
+					}
+				}
+			}
+		}
+	}
+
+	if insteadOf == "" {
+		return remote, nil
+	}
+	return base + remote[len(insteadOf):], nil
+}
+
+// readGitConfig reads the git configuration file at path, which may not exist.
+func readGitConfig(path string) (*format.Config, error) {
+	cfg := format.New()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening git config: %w", err)
+	}
+	defer f.Close()
+
+	if err := format.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("error reading git config %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,81 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// setGitConfig points the global git configuration at a file with the given content.
+func setGitConfig(t *testing.T, content string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRewriteURL tests that the longest matching insteadOf rule is applied.
+func TestRewriteURL(t *testing.T) {
+	setGitConfig(t, `[url "https://proxy.example.com/"]
+	insteadOf = https://github.com/
+[url "https://mirror.example.com/org/"]
+	insteadOf = https://github.com/org/
+	insteadOf = git@github.com:org/
+[url "https://push.example.com/"]
+	pushInsteadOf = https://gitlab.com/
+`)
+
+	testCases := []struct {
+		remote   string
+		expected string
+	}{
+		{remote: "https://github.com/other/repo.git", expected: "https://proxy.example.com/other/repo.git"},
+		{remote: "https://github.com/org/repo.git", expected: "https://mirror.example.com/org/repo.git"},
+		{remote: "git@github.com:org/repo.git", expected: "https://mirror.example.com/org/repo.git"},
+		{remote: "https://gitlab.com/org/repo.git", expected: "https://gitlab.com/org/repo.git"},
+	}
+
+	for _, tc := range testCases {
+		actual, err := rewriteURL(tc.remote)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, actual, tc.remote)
+	}
+}
+
+// TestGather_GitConfigRewrites tests that a configured rewrite redirects the clone to the proxy URL.
+func TestGather_GitConfigRewrites(t *testing.T) {
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+	setGitConfig(t, `[url "file://`+filepath.Dir(repoPath)+`/"]
+	insteadOf = https://github.invalid/org/
+`)
+	destination := filepath.Join(t.TempDir(), "clone")
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "git::https://github.invalid/org/repo.git", destination, gogather.WithGitConfigRewrites(true))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "README.md"))
+}
@@ -0,0 +1,155 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// shallowSinceDepth is the depth of the first fetch of a clone limited by date.
+// go-git can only limit history by depth, so the depth is doubled until the history
+// reaches the date.
+const shallowSinceDepth = 16
+
+// deepenSince fetches more history for refSpecs into the shallow clone r until every path of
+// it reaches back to since or the whole history has been fetched, then cuts the history at the
+// oldest commits after since.
+func deepenSince(ctx context.Context, r *git.Repository, cloneOpts *git.CloneOptions, refSpecs []config.RefSpec, since time.Time) error {
+	for depth := cloneOpts.Depth; ; {
+		reached, err := historyReaches(r, since)
+		if err != nil {
+			return err
+		}
+		if reached {
+			break
+		}
+
+		depth *= 2
+		err = r.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: cloneOpts.RemoteName,
+			RefSpecs:   refSpecs,
+			Depth:      depth,
+			Auth:       cloneOpts.Auth,
+			Progress:   cloneOpts.Progress,
+			Tags:       git.NoTags,
+		})
+		if errors.Is(err, git.NoErrAlreadyUpToDate) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error deepening history: %w", err)
+		}
+	}
+
+	return cutHistory(r, since)
+}
+
+// historyReaches reports whether the history of the shallow clone r reaches back to since,
+// that is whether every shallow commit is older than since. A complete history has no
+// shallow commits and always reaches it.
+func historyReaches(r *git.Repository, since time.Time) (bool, error) {
+	shallows, err := r.Storer.Shallow()
+	if err != nil {
+		return false, fmt.Errorf("error reading shallow commits: %w", err)
+	}
+	for _, h := range shallows {
+		c, err := r.CommitObject(h)
+		if err != nil {
+			return false, fmt.Errorf("error getting shallow commit %s: %w", h, err)
+		}
+		if !c.Committer.When.Before(since) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// cutHistory makes the commits after since whose parents are older, or were not fetched,
+// the shallow commits of r, so that the history of the clone starts after since.
+func cutHistory(r *git.Repository, since time.Time) error {
+	head, err := headCommit(r)
+	if err != nil {
+		return fmt.Errorf("error getting HEAD commit: %w", err)
+	}
+	if head.Committer.When.Before(since) {
+		return fmt.Errorf("no commits after %s", since.Format(time.RFC3339))
+	}
+
+	commits, err := r.CommitObjects()
+	if err != nil {
+		return fmt.Errorf("error getting commit history: %w", err)
+	}
+
+	var shallows []plumbing.Hash
+	seen := map[plumbing.Hash]bool{}
+	err = commits.ForEach(func(c *object.Commit) error {
+		if c.Committer.When.Before(since) || seen[c.Hash] {
+			return nil
+		}
+		seen[c.Hash] = true
+		for _, h := range c.ParentHashes {
+			parent, err := r.CommitObject(h)
+			if errors.Is(err, plumbing.ErrObjectNotFound) || (err == nil && parent.Committer.When.Before(since)) {
+				shallows = append(shallows, c.Hash)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("error getting commit %s: %w", h, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.Storer.SetShallow(shallows); err != nil {
+		return fmt.Errorf("error writing shallow commits: %w", err)
+	}
+	return nil
+}
+
+// headCommit returns the commit HEAD points to.
+func headCommit(r *git.Repository) (*object.Commit, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	return r.CommitObject(head.Hash())
+}
+
+// commitsSince returns the commits committed after since. Commits fetched again when the
+// history was deepened are only returned once.
+func commitsSince(commits []object.Commit, since time.Time) []object.Commit {
+	var result []object.Commit
+	seen := map[plumbing.Hash]bool{}
+	for _, c := range commits {
+		if !c.Committer.When.Before(since) && !seen[c.Hash] {
+			seen[c.Hash] = true
+			result = append(result, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	gitMetadata "github.com/enterprise-contract/go-gather/metadata/git"
+)
+
+// testHistoryStart is the date of the first commit of the repositories created by createHistoryRepository.
+var testHistoryStart = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// createHistoryRepository creates a local git repository with n commits, one per day from
+// testHistoryStart, and returns its path.
+func createHistoryRepository(t *testing.T, n int) string {
+	t.Helper()
+	repoPath := filepath.Join(t.TempDir(), "repo.git")
+	r, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := os.WriteFile(filepath.Join(repoPath, "CHANGELOG.md"), []byte(fmt.Sprintf("change %d\n", i)), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Add("CHANGELOG.md"); err != nil {
+			t.Fatal(err)
+		}
+		when := testHistoryStart.AddDate(0, 0, i)
+		_, err = w.Commit(fmt.Sprintf("Change %d", i), &git.CommitOptions{
+			Author:    &object.Signature{Name: "Test User", Email: "test@example.com", When: when},
+			Committer: &object.Signature{Name: "Test User", Email: "test@example.com", When: when},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repoPath
+}
+
+// TestGather_ShallowSince tests that only the history after the date is cloned
+func TestGather_ShallowSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := createHistoryRepository(t, 60)
+	since := testHistoryStart.AddDate(0, 0, 20)
+
+	testCases := []struct {
+		name   string
+		source string
+		method string
+	}{
+		{name: "checkout", source: "file://" + repoPath, method: "checkout"},
+		{name: "file", source: "file://" + repoPath + "//CHANGELOG.md", method: "blob"},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "clone")
+			m, err := gatherer.Gather(context.Background(), tc.source, destination, gogather.WithGitShallowSince(since))
+			assert.NoError(t, err)
+
+			gm, ok := m.(*gitMetadata.GitMetadata)
+			if !ok {
+				t.Fatalf("unexpected metadata type: %T", m)
+			}
+			assert.Equal(t, tc.method, gm.Method)
+			assert.Len(t, gm.Commits, 40)
+			for _, c := range gm.Commits {
+				assert.False(t, c.Committer.When.Before(since), c.Message)
+			}
+		})
+	}
+
+	// Native git stops the history of the clone at the date too
+	destination := filepath.Join(t.TempDir(), "clone")
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath, destination, gogather.WithGitShallowSince(since))
+	assert.NoError(t, err)
+	out, err := exec.Command("git", "-C", destination, "rev-list", "--count", "HEAD").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "40", strings.TrimSpace(string(out)))
+}
+
+// TestGather_ShallowSinceErrors tests that conflicting options and dates without commits are rejected
+func TestGather_ShallowSinceErrors(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := createHistoryRepository(t, 3)
+
+	gatherer := &GitGatherer{}
+	_, err := gatherer.Gather(context.Background(), "file://"+repoPath+"?depth=1", filepath.Join(t.TempDir(), "clone"), gogather.WithGitShallowSince(testHistoryStart))
+	assert.ErrorContains(t, err, "a depth cannot be combined with a shallow since date")
+
+	_, err = gatherer.Gather(context.Background(), "file://"+repoPath, filepath.Join(t.TempDir(), "clone"), gogather.WithGitShallowSince(testHistoryStart.AddDate(1, 0, 0)))
+	assert.ErrorContains(t, err, "no commits after")
+}
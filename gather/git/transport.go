@@ -0,0 +1,83 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// transportConfigKey is the context key holding the transportConfig of a gather.
+type transportConfigKey struct{}
+
+// transportConfig carries the options of a gather to the shared HTTP transport.
+type transportConfig struct {
+	options   *gogather.Options
+	transport http.RoundTripper
+}
+
+// installTransport replaces the go-git HTTP(S) transports with one that applies the
+// options stored in the request context. Requests without options behave as before.
+var installTransport = sync.OnceFunc(func() {
+	c := &http.Client{
+		Transport:     contextRoundTripper{},
+		CheckRedirect: checkRedirect,
+	}
+	client.InstallProtocol("http", githttp.NewClient(c))
+	client.InstallProtocol("https", githttp.NewClient(c))
+})
+
+// withTransportConfig returns a context carrying the transport configuration for o,
+// and a function releasing the resources held by it.
+func withTransportConfig(ctx context.Context, o *gogather.Options) (context.Context, func()) {
+	installTransport()
+	cfg := &transportConfig{options: o, transport: o.Transport()}
+	release := func() {
+		if t, ok := cfg.transport.(*http.Transport); ok && o.OwnsTransport(cfg.transport) {
+			t.CloseIdleConnections()
+		}
+	}
+	return context.WithValue(ctx, transportConfigKey{}, cfg), release
+}
+
+// contextRoundTripper dispatches each request to the transport found in its context.
+type contextRoundTripper struct{}
+
+func (contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if cfg, ok := req.Context().Value(transportConfigKey{}).(*transportConfig); ok {
+		return cfg.transport.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// checkRedirect applies the options found in the request context to redirects.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if cfg, ok := req.Context().Value(transportConfigKey{}).(*transportConfig); ok {
+		return cfg.options.CheckRedirect(req, via)
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// TestGather_TLS tests that the TLS options apply to clones over HTTPS
+func TestGather_TLS(t *testing.T) {
+	out, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	backend := filepath.Join(strings.TrimSpace(string(out)), "git-http-backend")
+	repoPath := createTestRepository(t, map[string]string{"README.md": "hello"})
+
+	server := httptest.NewTLSServer(&cgi.Handler{
+		Path: backend,
+		Env:  []string{"GIT_PROJECT_ROOT=" + filepath.Dir(repoPath), "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	defer server.Close()
+	source := server.URL + "/repo.git"
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	testCases := []struct {
+		name        string
+		opts        []gogather.Option
+		expectedErr string
+	}{
+		{name: "untrusted", expectedErr: "certificate"},
+		{name: "root CAs", opts: []gogather.Option{gogather.WithRootCAs(pool)}},
+		{name: "insecure", opts: []gogather.Option{gogather.WithInsecureSkipTLSVerify(true)}},
+	}
+
+	gatherer := &GitGatherer{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "clone")
+			_, err := gatherer.Gather(context.Background(), source, destination, tc.opts...)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.FileExists(t, filepath.Join(destination, "README.md"))
+		})
+	}
+}
@@ -7,19 +7,24 @@ require (
 	github.com/enterprise-contract/go-gather/gather/file v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/gather/git v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/gather/http v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/gather/k8s v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/gather/webdav v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/metadata/file v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata/git v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/metadata/http v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/metadata/k8s v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/metadata/webdav v0.0.0-20240523073727-ba2c37023242
 )
 
 require (
 	dario.cat/mergo v1.0.0 // indirect
+	github.com/Masterminds/semver/v3 v3.2.1 // indirect
 	github.com/Microsoft/go-winio v0.6.2 // indirect
 	github.com/ProtonMail/go-crypto v1.0.0 // indirect
 	github.com/cloudflare/circl v1.3.8 // indirect
 	github.com/cyphar/filepath-securejoin v0.2.5 // indirect
 	github.com/emirpasic/gods v1.18.1 // indirect
-	github.com/enterprise-contract/go-gather/metadata/file v0.0.0-20240523073727-ba2c37023242 // indirect
-	github.com/enterprise-contract/go-gather/metadata/http v0.0.0-20240523073727-ba2c37023242 // indirect
 	github.com/enterprise-contract/go-gather/saver v0.0.0-20240523073727-ba2c37023242 // indirect
 	github.com/enterprise-contract/go-gather/saver/file v0.0.0-20240523073727-ba2c37023242 // indirect
 	github.com/go-git/gcfg v1.5.1-0.20230307220236-3a3c6141e376 // indirect
@@ -28,13 +33,16 @@ require (
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/jbenet/go-context v0.0.0-20150711004518-d14ea06fba99 // indirect
 	github.com/kevinburke/ssh_config v1.2.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/pjbgf/sha1cd v0.3.0 // indirect
 	github.com/sergi/go-diff v1.3.2-0.20230802210424-5b0b94c5c0d3 // indirect
 	github.com/skeema/knownhosts v1.2.2 // indirect
+	github.com/ulikunitz/xz v0.5.12 // indirect
 	github.com/whilp/git-urls v1.0.0 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
 	golang.org/x/crypto v0.23.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/sys v0.20.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
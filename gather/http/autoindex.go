@@ -0,0 +1,199 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// gatherIndex downloads the files linked from an autoindex directory listing, as generated
+// by Apache and nginx, into the destination directory, descending into subdirectories.
+// rel is the slash separated path of the listing below the first one, which the files and
+// subdirectories left out by WithInclude and WithExclude are matched under.
+func (h *HTTPGatherer) gatherIndex(ctx context.Context, o *gogather.Options, src *url.URL, destination, rel string, opts []gogather.Option) (*httpMetadata.HTTPIndexMetadata, error) {
+	links, err := h.listIndex(ctx, o, src)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &httpMetadata.HTTPIndexMetadata{Destination: destination}
+	for _, link := range links {
+		name := path.Base(link.Path)
+		dir := strings.HasSuffix(link.Path, "/")
+		if err := o.CheckDirDepth(path.Join(rel, name)); err != nil {
+			return nil, err
+		}
+		included, err := o.Included(path.Join(rel, name), dir)
+		if err != nil {
+			return nil, err
+		}
+		if !included {
+			continue
+		}
+		if dir {
+			sub, err := h.gatherIndex(ctx, o, link, filepath.Join(destination, name), path.Join(rel, name), opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range sub.Files {
+				file.RelativePath = name + "/" + file.RelativePath
+				m.Files = append(m.Files, file)
+			}
+			continue
+		}
+
+		fm, err := h.Gather(ctx, link.String(), destination+"/", opts...)
+		if err != nil {
+			return nil, fmt.Errorf("error gathering %s: %w", link, err)
+		}
+		file := fm.(httpMetadata.HTTPMetadata)
+		file.RelativePath = relativePath(destination, file.Destination)
+		m.Files = append(m.Files, file)
+	}
+	return m, nil
+}
+
+// listIndex fetches the directory listing at src and returns the links to its entries.
+// Only links to direct children of src are returned, so parent directory links,
+// sorting links and links to other hosts are not followed.
+func (h *HTTPGatherer) listIndex(ctx context.Context, o *gogather.Options, src *url.URL) ([]*url.URL, error) {
+	if err := o.CheckScheme(src.Scheme); err != nil {
+		return nil, err
+	}
+	if err := o.CheckHost(ctx, src.Host); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", src.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Go-Gather")
+
+	c := h.client(o)
+	defer h.closeIdle(o, c)
+	traceRequest(o, req)
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading directory listing: %w", o.ResponseError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response code error: %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	detectedType, body, err := gogather.DetectContentType(o.LimitReader(resp.Body))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if !strings.HasPrefix(contentType, "text/html") && !strings.HasPrefix(detectedType, "text/html") {
+		return nil, fmt.Errorf("%s is not a directory listing", src)
+	}
+
+	// Links are relative to the listing that was served, which may have been redirected to
+	return parseIndexLinks(resp.Request.URL, body)
+}
+
+// parseIndexLinks returns the links of the HTML page r, served from listing, that point to
+// direct children of the directory of the listing. The first <base href> tag of the page
+// replaces the listing as the base that links are resolved against, unless it points outside
+// the directory of the listing, such as to another host, in which case it is ignored.
+func parseIndexLinks(listing *url.URL, r io.Reader) ([]*url.URL, error) {
+	base := indexDirectory(listing)
+	hasBase := false
+	var links []*url.URL
+	seen := map[string]bool{}
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return links, nil
+			}
+			return nil, fmt.Errorf("error parsing directory listing: %w", z.Err())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" && (string(name) != "base" || hasBase) {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				if string(name) == "base" {
+					if b, err := listing.Parse(string(val)); err == nil && withinIndex(base, b) {
+						base = indexDirectory(b)
+					}
+					hasBase = true
+					continue
+				}
+				link, ok := indexLink(base, string(val))
+				if ok && !seen[link.Path] {
+					seen[link.Path] = true
+					links = append(links, link)
+				}
+			}
+		}
+	}
+}
+
+// withinIndex reports whether u is on the host of the listing directory dir and at or below it.
+func withinIndex(dir, u *url.URL) bool {
+	return u.Scheme == dir.Scheme && u.Host == dir.Host && strings.HasPrefix(indexDirectory(u).Path, dir.Path)
+}
+
+// indexDirectory returns the URL of the directory of u, which is u itself when its path
+// ends with a slash.
+func indexDirectory(u *url.URL) *url.URL {
+	return u.ResolveReference(&url.URL{Path: "./"})
+}
+
+// indexLink resolves href against base and reports whether it points to a direct child of base.
+// Query-only and fragment-only links, such as the sorting links of Apache, are not followed.
+func indexLink(base *url.URL, href string) (*url.URL, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+		return nil, false
+	}
+	link, err := base.Parse(href)
+	if err != nil || link.RawQuery != "" || link.Scheme != base.Scheme || link.Host != base.Host {
+		return nil, false
+	}
+	link.Fragment = ""
+
+	rest, ok := strings.CutPrefix(link.Path, base.Path)
+	if !ok || rest == "" || rest == "/" || strings.Contains(strings.TrimSuffix(rest, "/"), "/") {
+		return nil, false
+	}
+	return link, true
+}
@@ -0,0 +1,276 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	h "net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// apacheIndex is a directory listing in the format of Apache mod_autoindex.
+const apacheIndex = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">
+<html><head><title>Index of /files</title></head><body>
+<h1>Index of /files</h1>
+<table>
+<tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th></tr>
+<tr><td><a href="/">Parent Directory</a></td></tr>
+<tr><td><a href="a.txt">a.txt</a></td><td>2024-05-01 10:00</td></tr>
+<tr><td><a href="sub/">sub/</a></td><td>2024-05-01 10:00</td></tr>
+<tr><td><a href="https://elsewhere.example.com/c.txt">elsewhere</a></td></tr>
+</table></body></html>`
+
+// nginxIndex is a directory listing in the format of the nginx autoindex module.
+const nginxIndex = `<html>
+<head><title>Index of /files/sub/</title></head>
+<body>
+<h1>Index of /files/sub/</h1><hr><pre><a href="../">../</a>
+<a href="b%20c.txt">b c.txt</a>                                         01-May-2024 10:00      3
+</pre><hr></body>
+</html>`
+
+// newIndexServer returns a server listing /files/ in the Apache format and /files/sub/ in the nginx format.
+func newIndexServer() *httptest.Server {
+	mux := h.NewServeMux()
+	mux.HandleFunc("/files/", func(w h.ResponseWriter, r *h.Request) {
+		switch r.URL.Path {
+		case "/files/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, apacheIndex)
+		case "/files/sub/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, nginxIndex)
+		case "/files/a.txt":
+			fmt.Fprint(w, "aaa")
+		case "/files/sub/b c.txt":
+			fmt.Fprint(w, "bbb")
+		default:
+			h.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("/moved/", func(w h.ResponseWriter, r *h.Request) {
+		// A relative redirect, which is resolved against the requested URL
+		w.Header().Set("Location", "../files/")
+		w.WriteHeader(h.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/", func(w h.ResponseWriter, r *h.Request) {
+		h.Error(w, "parent directory must not be followed", h.StatusForbidden)
+	})
+	return httptest.NewServer(mux)
+}
+
+// TestHTTPGatherer_Gather_AutoIndex tests that the files of Apache and nginx listings are downloaded recursively.
+func TestHTTPGatherer_Gather_AutoIndex(t *testing.T) {
+	server := newIndexServer()
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "out")
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), server.URL+"/files/", destination, gogather.WithHTTPAutoIndex(true))
+	assert.NoError(t, err)
+
+	im, ok := m.(*http.HTTPIndexMetadata)
+	if !ok {
+		t.Fatalf("unexpected metadata type: %T", m)
+	}
+	assert.Len(t, im.Files, 2)
+	assert.Equal(t, []string{"a.txt", "sub/b c.txt"}, im.Paths())
+
+	content, err := os.ReadFile(filepath.Join(destination, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "aaa", string(content))
+
+	content, err = os.ReadFile(filepath.Join(destination, "sub", "b c.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bbb", string(content))
+}
+
+// TestHTTPGatherer_Gather_AutoIndexExclude tests that excluded files and subdirectories of a
+// listing are not downloaded.
+func TestHTTPGatherer_Gather_AutoIndexExclude(t *testing.T) {
+	server := newIndexServer()
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "out")
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), server.URL+"/files/", destination, gogather.WithHTTPAutoIndex(true), gogather.WithExclude([]string{"/sub/"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, m.(*http.HTTPIndexMetadata).Paths())
+	assert.NoDirExists(t, filepath.Join(destination, "sub"))
+
+	m, err = gatherer.Gather(context.Background(), server.URL+"/files/", filepath.Join(t.TempDir(), "out"), gogather.WithHTTPAutoIndex(true), gogather.WithInclude([]string{"sub/*.txt"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sub/b c.txt"}, m.(*http.HTTPIndexMetadata).Paths())
+}
+
+// TestHTTPGatherer_Gather_AutoIndexDepth tests that listings nested deeper than the maximum
+// directory depth, such as a listing linking to itself endlessly, fail the gather.
+func TestHTTPGatherer_Gather_AutoIndexDepth(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="d/">d/</a></body></html>`)
+	}))
+	defer server.Close()
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), server.URL+"/files/", filepath.Join(t.TempDir(), "out"), gogather.WithHTTPAutoIndex(true), gogather.WithMaxDirDepth(3))
+	assert.ErrorIs(t, err, gogather.ErrMaxDepthExceeded)
+	assert.Equal(t, 4, requests)
+}
+
+// TestHTTPGatherer_Gather_AutoIndexRedirect tests that the links of a redirected listing are
+// resolved against the URL it was redirected to.
+func TestHTTPGatherer_Gather_AutoIndexRedirect(t *testing.T) {
+	server := newIndexServer()
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "out")
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), server.URL+"/moved/", destination, gogather.WithHTTPAutoIndex(true))
+	assert.NoError(t, err)
+
+	im, ok := m.(*http.HTTPIndexMetadata)
+	if !ok {
+		t.Fatalf("unexpected metadata type: %T", m)
+	}
+	assert.Equal(t, []string{"a.txt", "sub/b c.txt"}, im.Paths())
+}
+
+// TestHTTPGatherer_Gather_AutoIndexNotListing tests that a non-HTML page is not treated as a listing.
+func TestHTTPGatherer_Gather_AutoIndexNotListing(t *testing.T) {
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		fmt.Fprint(w, "\x00\x01\x02")
+	}))
+	defer server.Close()
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), server.URL+"/files/", t.TempDir(), gogather.WithHTTPAutoIndex(true))
+	assert.ErrorContains(t, err, "is not a directory listing")
+}
+
+// TestIndexLink tests which links of a listing are followed.
+func TestIndexLink(t *testing.T) {
+	base, _ := url.Parse("https://example.com/files/")
+	testCases := []struct {
+		href     string
+		expected string
+	}{
+		{href: "a.txt", expected: "https://example.com/files/a.txt"},
+		{href: "sub/", expected: "https://example.com/files/sub/"},
+		{href: "/files/b.txt", expected: "https://example.com/files/b.txt"},
+		{href: "a.txt#top", expected: "https://example.com/files/a.txt"},
+		{href: "../"},
+		{href: "/"},
+		{href: "./"},
+		{href: "?C=N;O=D"},
+		{href: "#top"},
+		{href: "sub/deeper/c.txt"},
+		{href: "https://other.example.com/files/a.txt"},
+	}
+
+	for _, tc := range testCases {
+		link, ok := indexLink(base, tc.href)
+		if tc.expected == "" {
+			assert.False(t, ok, tc.href)
+			continue
+		}
+		if assert.True(t, ok, tc.href) {
+			assert.Equal(t, tc.expected, link.String())
+		}
+	}
+}
+
+// TestParseIndexLinks tests that absolute and relative links of a listing are resolved
+// against the listing URL, or against its <base href> tag.
+func TestParseIndexLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		listing  string
+		page     string
+		expected []string
+	}{
+		{
+			name:     "relative",
+			listing:  "https://example.com/files/",
+			page:     `<a href="a.txt">a</a> <a href="./sub/">sub</a> <a href="../">up</a> <a href="?C=M">sort</a> <a href="#top">top</a>`,
+			expected: []string{"https://example.com/files/a.txt", "https://example.com/files/sub/"},
+		},
+		{
+			name:     "absolute",
+			listing:  "https://example.com/files/",
+			page:     `<a href="https://example.com/files/a.txt">a</a> <a href="/files/sub/">sub</a> <a href="https://example.com/other/b.txt">b</a>`,
+			expected: []string{"https://example.com/files/a.txt", "https://example.com/files/sub/"},
+		},
+		{
+			name:     "listing page",
+			listing:  "https://example.com/files/index.html",
+			page:     `<a href="a.txt">a</a> <a href="index.html?C=N">sort</a>`,
+			expected: []string{"https://example.com/files/a.txt"},
+		},
+		{
+			name:     "base href",
+			listing:  "https://example.com/mirror/",
+			page:     `<html><head><base href="/mirror/files/" /><base href="/mirror/ignored/"></head><body><a href="a.txt">a</a> <a href="/mirror/b.txt">b</a></body></html>`,
+			expected: []string{"https://example.com/mirror/files/a.txt"},
+		},
+		{
+			name:     "relative base href",
+			listing:  "https://example.com/mirror/index.html",
+			page:     `<base href="files/index.html"><a href="a.txt">a</a> <a href="sub/">sub</a>`,
+			expected: []string{"https://example.com/mirror/files/a.txt", "https://example.com/mirror/files/sub/"},
+		},
+		{
+			name:     "base href outside the listing",
+			listing:  "https://example.com/mirror/",
+			page:     `<base href="../files/"><base href="/mirror/files/"><a href="a.txt">a</a> <a href="/files/b.txt">b</a>`,
+			expected: []string{"https://example.com/mirror/a.txt"},
+		},
+		{
+			name:     "base href on another host",
+			listing:  "https://example.com/mirror/",
+			page:     `<base href="https://other.example.com/mirror/"><a href="a.txt">a</a>`,
+			expected: []string{"https://example.com/mirror/a.txt"},
+		},
+	}
+
+	for _, tc := range testCases {
+		listing, err := url.Parse(tc.listing)
+		assert.NoError(t, err)
+		links, err := parseIndexLinks(listing, strings.NewReader(tc.page))
+		assert.NoError(t, err, tc.name)
+
+		var actual []string
+		for _, link := range links {
+			actual = append(actual, link.String())
+		}
+		assert.Equal(t, tc.expected, actual, tc.name)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// extract extracts the archive read from body into the destination directory. The whole body
+// is read, so that the archive is verified against the checksum, and the directory is removed
+// again if it was created and the archive cannot be extracted or verified.
+func (h *HTTPGatherer) extract(o *gogather.Options, src *url.URL, resp *http.Response, body io.Reader, name, destination string, verifier *gogather.ChecksumVerifier) (metadata.Metadata, error) {
+	o.Emit(gogather.Event{Phase: gogather.EventExtracting, Source: src.String()})
+	if o.Destination == nil {
+		if err := o.MkdirParents(localPath(destination)); err != nil {
+			return nil, fmt.Errorf("error creating destination directory: %w", err)
+		}
+	}
+
+	// Extract into a staging directory that replaces the destination on success, if asked to
+	dir, finish, err := o.StageDir(localPath(destination))
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Stat(dir)
+	created := o.Destination == nil && os.IsNotExist(statErr)
+	fail := func(err error) (metadata.Metadata, error) {
+		if created {
+			err = o.DiscardPartial(dir, err)
+		}
+		return nil, finish(err)
+	}
+
+	counted := &countingReader{r: body}
+	if err := o.ExtractArchive(counted, name, dir); err != nil {
+		return fail(fmt.Errorf("error extracting archive: %w", err))
+	}
+	if _, err := io.Copy(io.Discard, counted); err != nil {
+		return fail(fmt.Errorf("error reading response body: %w", err))
+	}
+	if err := verifier.Verify(); err != nil {
+		return fail(err)
+	}
+	if o.Destination == nil {
+		if err := o.TransformTree(dir); err != nil {
+			return fail(err)
+		}
+	}
+	if err := finish(nil); err != nil {
+		return nil, err
+	}
+
+	return httpMetadata.HTTPMetadata{
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		Destination:   destination,
+		Headers:       resp.Header,
+		ContentType:   resp.Header.Get("Content-Type"),
+		BytesWritten:  counted.n,
+	}, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
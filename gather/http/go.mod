@@ -3,19 +3,26 @@ module github.com/enterprise-contract/go-gather/gather/http
 go 1.22.2
 
 require (
+	github.com/ProtonMail/go-crypto v1.0.0
 	github.com/enterprise-contract/go-gather v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/metadata/http v0.0.0-20240523073727-ba2c37023242
 	github.com/enterprise-contract/go-gather/saver v0.0.0-20240523073727-ba2c37023242
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/crypto v0.23.0
+	golang.org/x/net v0.25.0
 )
 
 require (
+	github.com/cloudflare/circl v1.3.8 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/enterprise-contract/go-gather/saver/file v0.0.0-20240523073727-ba2c37023242 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.12.0 // indirect
+	github.com/ulikunitz/xz v0.5.12 // indirect
+	golang.org/x/sys v0.20.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
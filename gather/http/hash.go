@@ -0,0 +1,56 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// hashOnly computes the checksum of body with hasher, writing nothing, and returns the
+// metadata of the response with the checksum.
+func (h *HTTPGatherer) hashOnly(o *gogather.Options, resp *http.Response, body io.Reader, hasher hash.Hash, verifier *gogather.ChecksumVerifier) (metadata.Metadata, error) {
+	detectedType, body, err := gogather.DetectContentType(body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	n, err := io.Copy(hasher, o.BufferReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if err := verifier.Verify(); err != nil {
+		return nil, err
+	}
+
+	return httpMetadata.HTTPMetadata{
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		Headers:       resp.Header,
+		ContentType:   resp.Header.Get("Content-Type"),
+		DetectedType:  detectedType,
+		BytesWritten:  n,
+		Checksum:      strings.ToLower(o.HashOnly) + ":" + hex.EncodeToString(hasher.Sum(nil)),
+	}, nil
+}
@@ -31,6 +31,10 @@
 //	}
 //	fmt.Println("Downloaded file metadata:", metadata)
 //
+// GitHub and GitLab release assets, such as "github.com/org/repo/releases/download/v1.2.3/asset.tar.gz",
+// are resolved with the API of their host, authenticated with WithGitToken, and the metadata reports
+// the release tag and asset ID. See gogather.ParseReleaseAsset for the supported forms.
+//
 // Note: The Gather method uses the http.Client's default timeout of 15 seconds for the HTTP requests.
 // You can customize the timeout by modifying the http.Client's Timeout field before calling the Gather method.
 package http
@@ -38,8 +42,13 @@ package http
 import (
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -62,95 +71,636 @@ func NewHTTPGatherer() *HTTPGatherer {
 	}
 }
 
-func (h *HTTPGatherer) Gather(ctx context.Context, source, destination string) (metadata.Metadata, error) {
+func (h *HTTPGatherer) Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (m metadata.Metadata, err error) {
+	o := gogather.NewOptions(opts...)
+	o.Emit(gogather.Event{Phase: gogather.EventStarted, Source: source})
+	defer func() { o.Emit(gogather.Event{Phase: gogather.EventDone, Source: source, Err: err}) }()
+	sw := metadata.NewStopwatch()
+	ctx = sw.WithContext(ctx)
+	auth := metadata.AuthAnonymous
+	defer func() {
+		if err == nil {
+			m = withAuth(withTimings(m, sw.Timings()), auth)
+		}
+	}()
+
+	// Resolve GitHub and GitLab release assets to their download URL
+	var r *release
+	if asset, ok := gogather.ParseReleaseAsset(source); ok {
+		stop := sw.Time(metadata.PhaseResolve)
+		r, err = h.resolveRelease(ctx, o, asset)
+		stop()
+		if err != nil {
+			return nil, err
+		}
+		ctx = withRequestHeader(ctx, r.url, r.header)
+	}
 
 	// Parse source
 	src, err := url.Parse(source)
+	if r != nil {
+		src, err = url.Parse(r.url)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error parsing source URI: %w", err)
 	}
+	auth = authMethod(o, src, r)
 
 	// Check if the source scheme is provided
 	if src.Scheme == "" {
 		return nil, fmt.Errorf("no source scheme provided")
 	}
 
-	// Get the source filename
-	sourceFileName := filepath.Base(src.Path)
-
-	// Check if the source filename is provided
-	if sourceFileName == "" {
-		return nil, fmt.Errorf("specify a path to a file to download")
+	// Download the files of a directory listing, if asked to
+	if o.HTTPAutoIndex && strings.HasSuffix(src.Path, "/") {
+		if err := o.CheckDestination(); err != nil {
+			return nil, err
+		}
+		return h.gatherIndex(ctx, o, src, destination, "", opts)
 	}
 
-	// Check if the destination has a trailing slash.
-	// If it does, append the source filename to the destination path.
-	if strings.HasSuffix(destination, "/") {
-		destination = filepath.Join(destination, sourceFileName)
-	} else {
-		// If it doesn't, append the source filename to the destination path.
-		if filepath.Ext(destination) == "" {
-			destination = filepath.Join(destination, "/", sourceFileName)
+	// Get the source filename, unless it is only known from the response
+	if err := o.CheckDestination(); err != nil {
+		return nil, err
+	}
+	root := destination
+	sourceFileName := urlFileName(src)
+	if r != nil {
+		sourceFileName = r.name
+	}
+	if o.HashOnly != "" {
+		// Nothing is written in hash-only mode
+	} else if o.Extract && !gunzipFile(o, sourceFileName) {
+		// Archives are extracted into the destination directory itself
+		if err := o.ValidateDestination(destination); err != nil {
+			return nil, err
+		}
+	} else if sourceFileName != "" {
+		destination, err = destinationPath(o, destination, sourceFileName)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	// Validate the destination path
-	err = gogather.ValidateFileDestination(destination)
+	m, err = h.download(ctx, o, opts, src, root, sourceFileName, destination)
+	if err != nil || r == nil {
+		return m, err
+	}
+	return r.metadata(m), nil
+}
+
+// download downloads src to destination and returns its metadata. If name is empty,
+// the file is named after the Content-Disposition header and destination is the directory
+// it is written to. Manifests are gathered into root instead.
+func (h *HTTPGatherer) download(ctx context.Context, o *gogather.Options, opts []gogather.Option, src *url.URL, root, name, destination string) (m metadata.Metadata, err error) {
+	// Reject a malformed checksum or buffer size before downloading anything
+	if err := o.CheckBufferSize(); err != nil {
+		return nil, err
+	}
+	verifier, err := o.ChecksumVerifier()
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := o.HashOnlyHash()
 	if err != nil {
-		return nil, fmt.Errorf("error validating destination: %w", err)
+		return nil, err
+	}
+
+	// Check that the source may be contacted
+	if err := o.CheckScheme(src.Scheme); err != nil {
+		return nil, err
+	}
+	if err := o.CheckHost(ctx, src.Host); err != nil {
+		return nil, err
+	}
+
+	// The request is cancelled if the response headers or the body stall for longer than the
+	// idle timeout
+	cancel := context.CancelFunc(func() {})
+	if o.IdleTimeout > 0 {
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+	}
+
+	// A transport built for this gather is not reused, so its connections are closed
+	// once the gather is done.
+	c := h.client(o)
+	defer h.closeIdle(o, c)
+
+	// Validate the source with a HEAD request first, if asked to
+	etag, err := h.preflight(ctx, o, c, src)
+	if err != nil {
+		return nil, err
+	}
+
+	// Download the detached signature, if any, before the file it signs
+	sig, err := h.fetchSignature(ctx, o, c, src)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find whether the server supports ranges, if asked to, and report it in the metadata
+	rangeSupported := h.probeRange(ctx, o, c, src)
+	if o.RangeProbe {
+		defer func() {
+			if err == nil {
+				m = withRangeSupport(m, rangeSupported)
+			}
+		}()
 	}
 
 	// Create a new HTTP request
-	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", src.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
+	for key, values := range requestHeader(ctx) {
+		req.Header[key] = values
+	}
 	req.Header.Set("User-Agent", "Go-Gather")
+	requireContentLength(o, req)
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	// Request only the missing bytes when resuming a partial download, unless the probe
+	// found the server does not support ranges. The If-Range header makes the server send the
+	// whole file instead when the remote file changed since the partial file was written.
+	offset, modTime := resumeOffset(o, name, destination)
+	if offset > 0 && o.RangeProbe && !rangeSupported {
+		o.Log(ctx, slog.LevelInfo, "server does not support ranges, restarting the download", "source", src.String(), "destination", destination)
+		offset = 0
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", modTime.UTC().Format(http.TimeFormat))
+	}
 
 	// Send the HTTP request
-	resp, err := h.Client.Do(req)
+	traceRequest(o, req)
+	wait := o.IdleTimeoutWait(cancel)
+	resp, err := c.Do(req)
+	if idleErr := wait(); idleErr != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("error downloading file: %w", idleErr)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error downloading file: %w", err)
+		return nil, fmt.Errorf("error downloading file: %w", o.ResponseError(err))
 	}
 	defer resp.Body.Close()
 
 	// Check if the response was successful
-	if resp.StatusCode != http.StatusOK {
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return h.rangeNotSatisfiable(ctx, o, opts, src, root, name, destination, resp, offset, sig)
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case resp.StatusCode != http.StatusOK:
 		return nil, fmt.Errorf("response code error: %d", resp.StatusCode)
 	}
+
+	// Refuse files announced larger than the maximum size
+	size := resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent && size >= 0 {
+		size += offset
+	}
+	if err := o.CheckSize(size); err != nil {
+		return nil, err
+	}
+	if err := checkContentLength(o, resp); err != nil {
+		return nil, err
+	}
+
+	// Refuse responses that do not have an expected content type, such as an error page
+	if err := o.CheckContentType(resp.Header.Get("Content-Type")); err != nil {
+		return nil, err
+	}
+
+	// Gather the files listed by a manifest, if the source is one
+	if isManifest(o, src, resp) {
+		return h.gatherManifest(ctx, src, o.LimitReader(resp.Body), root, opts)
+	}
+
+	// Name the file after the Content-Disposition header if the URL does not name it
+	if name == "" && (o.Extract || hasher != nil) {
+		name = contentDispositionName(resp.Header)
+		// A single gzip-compressed file is written as a file rather than extracted
+		if hasher == nil && gunzipFile(o, name) {
+			destination, err = destinationPath(o, destination, name)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else if name == "" {
+		name = contentDispositionName(resp.Header)
+		if name == "" {
+			return nil, fmt.Errorf("specify a path to a file to download")
+		}
+		destination, err = destinationPath(o, destination, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the following pages of a paginated source after the first one, if asked to
+	var respBody io.Reader = resp.Body
+	contentLength := resp.ContentLength
+	if o.Paginate != nil {
+		respBody, contentLength = newPages(ctx, o, c, resp), -1
+	}
+
+	body, stop := o.IdleTimeoutReader(o.LimitReader(respBody), cancel)
+	defer stop()
+	progress, finish := o.ProgressReader(body, src.String(), contentLength)
+	defer finish()
+
+	// Verify the body against the checksum trailers of the response as well, if asked to
+	if resp.StatusCode == http.StatusOK && o.Paginate == nil {
+		verifier = o.TrailerChecksumVerifier(verifier, resp)
+	}
+	body = verifier.Reader(progress)
+
+	// Only compute the checksum of the body, if asked to, discarding the data
+	if hasher != nil {
+		return h.hashOnly(o, resp, body, hasher, verifier)
+	}
+
+	// Append the remaining bytes to the partial file when resuming
+	if resp.StatusCode == http.StatusPartialContent {
+		return h.appendPartial(o, resp, body, offset, destination, verifier, sig)
+	}
+
+	// Extract the archive into the destination directory, if asked to
+	gunzip := gunzipFile(o, name)
+	if o.Extract && !gunzip {
+		defer metadata.StopwatchFrom(ctx).Time(metadata.PhaseExtract)()
+		return h.extract(o, src, resp, body, name, destination, verifier)
+	}
+
+	// Decompress the body, if asked to, dropping the compression extension
+	decompress := o.DecompressReader
+	if gunzip {
+		decompress = o.GunzipReader
+	}
+	decompressed, decompressedPath, err := decompress(body, destination)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressed.Close()
+	if decompressedPath != destination {
+		o.Emit(gogather.Event{Phase: gogather.EventExtracting, Source: src.String()})
+		destination = decompressedPath
+		if err := validateDestination(o, destination); err != nil {
+			return nil, err
+		}
+	}
+
+	// Detect the content type from the first bytes of the body
+	detectedType, body, err := gogather.DetectContentType(decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	// Save the downloaded file
+	destination, written, err := h.save(ctx, o, o.BufferReader(body), name, destination, lastModified(o, resp), verifier, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Log(ctx, slog.LevelInfo, "downloaded file", "source", src.String(), "destination", destination, "size", written)
+
+	// Return the metadata of the downloaded file
+	return httpMetadata.HTTPMetadata{
+		StatusCode:    resp.StatusCode,
+		ContentLength: contentLength,
+		Destination:   destination,
+		Headers:       resp.Header,
+		ContentType:   resp.Header.Get("Content-Type"),
+		DetectedType:  detectedType,
+		BytesWritten:  written,
+	}, nil
+}
+
+// save writes the downloaded data to the destination and returns the path it was written to,
+// along with the size of the written file. A file kept for a later resume is given modTime.
+// The downloaded data is verified against the checksum and signature before any transform is
+// applied, and removed if it does not match.
+func (h *HTTPGatherer) save(ctx context.Context, o *gogather.Options, body io.Reader, name, destination string, modTime time.Time, verifier *gogather.ChecksumVerifier, sig *signature) (string, int64, error) {
+	// Write to the custom destination, if any
+	if o.Destination != nil {
+		size, err := o.WriteDestination(destination, body)
+		if err != nil {
+			return "", 0, fmt.Errorf("error saving file: %w", err)
+		}
+		if err := verifier.Verify(); err != nil {
+			return "", 0, err
+		}
+		return destination, size, nil
+	}
+
 	// Determine the destination type
 	scheme, err := gogather.ClassifyURI(destination)
 	if err != nil {
-		return nil, fmt.Errorf("error determining destination type: %w", err)
+		return "", 0, fmt.Errorf("error determining destination type: %w", err)
 	}
 
 	// Create a new saver based on the destination scheme
 	s, err := saver.NewSaver(scheme.String())
 	if err != nil {
-		return nil, fmt.Errorf("error creating saver: %w", err)
+		return "", 0, fmt.Errorf("error creating saver: %w", err)
 	}
 
-	// Save the downloaded file
-	err = s.Save(ctx, resp.Body, destination)
+	// Create the missing parent directories with the configured permission
+	if err := o.MkdirParents(localPath(destination)); err != nil {
+		return "", 0, fmt.Errorf("error creating destination directory: %w", err)
+	}
+	if err := o.CheckSymlink(localPath(destination)); err != nil {
+		return "", 0, err
+	}
+
+	err = s.Save(ctx, body, destination)
+	if err != nil && strings.Contains(err.Error(), "is a directory") {
+		destination = filepath.Join(destination, name)
+		if err := o.CheckSymlink(localPath(destination)); err != nil {
+			return "", 0, err
+		}
+		err = s.Save(ctx, body, destination)
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "is a directory") {
-			destination = filepath.Join(destination, filepath.Base(src.Path))
-			err = s.Save(ctx, resp.Body, destination)
-			if err != nil {
-				return nil, fmt.Errorf("error saving file: %w", err)
+		err = fmt.Errorf("error saving file: %w", err)
+		// A file left for a later gather to resume is kept in place
+		if !o.HTTPResume {
+			err = o.DiscardPartial(localPath(destination), err)
+		}
+		setModTime(localPath(destination), modTime)
+		return "", 0, err
+	}
+
+	// Verify the checksum and signature, if any, of the downloaded data
+	if err := verifier.Verify(); err != nil {
+		return "", 0, o.DiscardPartial(localPath(destination), err)
+	}
+	if err := sig.Verify(localPath(destination)); err != nil {
+		return "", 0, o.DiscardPartial(localPath(destination), err)
+	}
+	if err := o.ChmodFile(localPath(destination)); err != nil {
+		return "", 0, o.DiscardPartial(localPath(destination), err)
+	}
+
+	// Apply the transform, if any, to the downloaded file
+	if err := o.TransformFile(destination); err != nil {
+		return "", 0, o.DiscardPartial(localPath(destination), err)
+	}
+	setModTime(localPath(destination), modTime)
+	o.RecordChecksum(localPath(destination))
+	info, err := os.Stat(localPath(destination))
+	if err != nil {
+		return "", 0, fmt.Errorf("error getting file info: %w", err)
+	}
+	return destination, info.Size(), nil
+}
+
+// DestinationName returns the name of the file a gather of source would produce: the last
+// element of the URL path or, when the URL does not name a file, the file name of the
+// Content-Disposition header returned for a HEAD request.
+func (h *HTTPGatherer) DestinationName(ctx context.Context, source string, opts ...gogather.Option) (string, error) {
+	o := gogather.NewOptions(opts...)
+
+	// Release assets are named after the asset, which the API knows if a pattern is given
+	if asset, ok := gogather.ParseReleaseAsset(source); ok {
+		if !strings.ContainsAny(asset.Name, "*?[\\") {
+			return asset.Name, nil
+		}
+		r, err := h.resolveRelease(ctx, o, asset)
+		if err != nil {
+			return "", err
+		}
+		return r.name, nil
+	}
+
+	src, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("error parsing source URI: %w", err)
+	}
+	if name := urlFileName(src); name != "" {
+		return name, nil
+	}
+
+	if err := o.CheckScheme(src.Scheme); err != nil {
+		return "", err
+	}
+	if err := o.CheckHost(ctx, src.Host); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "HEAD", source, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Go-Gather")
+
+	c := h.client(o)
+	defer h.closeIdle(o, c)
+	traceRequest(o, req)
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting file: %w", o.ResponseError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("response code error: %d", resp.StatusCode)
+	}
+
+	name := contentDispositionName(resp.Header)
+	if name == "" {
+		return "", fmt.Errorf("%s does not name a file", source)
+	}
+	return name, nil
+}
+
+// gunzipFile reports whether the file called name is decompressed with WithGunzipSingleFile.
+func gunzipFile(o *gogather.Options, name string) bool {
+	return o.GunzipSingleFile && gogather.IsGzipSingleFile(name)
+}
+
+// destinationPath returns the path the file called name is written to, and validates it.
+func destinationPath(o *gogather.Options, destination, name string) (string, error) {
+	// Check if the destination has a trailing slash.
+	// If it does, append the source filename to the destination path.
+	if strings.HasSuffix(destination, "/") {
+		destination = filepath.Join(destination, name)
+	} else {
+		// If it doesn't, append the source filename to the destination path.
+		if filepath.Ext(destination) == "" {
+			destination = filepath.Join(destination, "/", name)
+		}
+	}
+
+	if err := validateDestination(o, destination); err != nil {
+		return "", err
+	}
+	return destination, nil
+}
+
+// validateDestination checks that the file may be written to destination, unless it is
+// written to a custom destination or resumed from an existing file, then runs the caller's
+// validator, if any.
+func validateDestination(o *gogather.Options, destination string) error {
+	if o.Destination == nil && !o.HTTPResume {
+		if err := gogather.ValidateFileDestination(destination); err != nil {
+			return fmt.Errorf("error validating destination: %w", err)
+		}
+	}
+	return o.ValidateDestination(destination)
+}
+
+// requireContentLength asks for responses without a content encoding when a content length is
+// required, as the transport drops the length of the responses it decompresses, unless the
+// caller set an Accept-Encoding header.
+func requireContentLength(o *gogather.Options, req *http.Request) {
+	if o.ContentLengthRequired && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+}
+
+// checkContentLength refuses resp if it has no content length while one is required, and
+// otherwise makes its body fail when it is not as long as declared.
+func checkContentLength(o *gogather.Options, resp *http.Response) error {
+	if err := o.CheckContentLength(resp.ContentLength); err != nil {
+		return err
+	}
+	resp.Body = lengthBody{Reader: o.LengthReader(resp.Body, resp.ContentLength), Closer: resp.Body}
+	return nil
+}
+
+// lengthBody is a response body whose length is checked while it is read.
+type lengthBody struct {
+	io.Reader
+	io.Closer
+}
+
+// localPath returns the local path of a destination, which may be a file:// URL.
+func localPath(destination string) string {
+	if u, err := url.Parse(destination); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return destination
+}
+
+// relativePath returns the path of the file gathered to destination relative to root, with
+// forward slashes on all platforms.
+func relativePath(root, destination string) string {
+	rel, err := filepath.Rel(filepath.Clean(localPath(root)), filepath.Clean(localPath(destination)))
+	if err != nil {
+		return filepath.ToSlash(filepath.Base(destination))
+	}
+	return filepath.ToSlash(rel)
+}
+
+// urlFileName returns the last element of the URL path, or an empty string if there is none.
+func urlFileName(src *url.URL) string {
+	name := path.Base(src.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// contentDispositionName returns the file name of the Content-Disposition header, without
+// any directories, or an empty string if there is none.
+func contentDispositionName(header http.Header) string {
+	_, params, err := mime.ParseMediaType(header.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	name := filepath.Base(params["filename"])
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// closeIdle closes the idle connections of c if its transport was built for a single gather.
+func (h *HTTPGatherer) closeIdle(o *gogather.Options, c *http.Client) {
+	if h.Client.Transport == nil && o.OwnsTransport(unwrapSigning(c.Transport)) {
+		c.CloseIdleConnections()
+	}
+}
+
+// client returns a copy of the gatherer's http.Client configured according to o.
+// The transport is only replaced when the gatherer's client does not already have one.
+// With WithRequestSigner, the transport is wrapped to sign every request, see signingTransport.
+func (h *HTTPGatherer) client(o *gogather.Options) *http.Client {
+	c := h.Client
+	if c.Transport == nil {
+		c.Transport = o.Transport()
+	}
+	if o.RequestSigner != nil {
+		c.Transport = signingTransport{next: c.Transport, sign: o.RequestSigner}
+	}
+	checkRedirect := c.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if checkRedirect != nil {
+			if err := checkRedirect(req, via); err != nil {
+				return err
 			}
-		} else {
-			return nil, fmt.Errorf("error saving file: %w", err)
 		}
+		return o.CheckRedirect(req, via)
 	}
+	return &c
+}
 
-	// Return the metadata of the downloaded file
-	m := httpMetadata.HTTPMetadata{
-		StatusCode:    resp.StatusCode,
-		ContentLength: resp.ContentLength,
-		Destination:   destination,
-		Headers:       resp.Header,
+// withTimings returns m with the timings of the gather that produced it.
+func withTimings(m metadata.Metadata, t metadata.Timings) metadata.Metadata {
+	switch hm := m.(type) {
+	case httpMetadata.HTTPMetadata:
+		hm.Timing = t
+		return hm
+	case *httpMetadata.HTTPIndexMetadata:
+		hm.Timing = t
+	}
+	return m
+}
+
+// withAuth returns m with the authentication method of the gather that produced it.
+func withAuth(m metadata.Metadata, auth string) metadata.Metadata {
+	switch hm := m.(type) {
+	case httpMetadata.HTTPMetadata:
+		hm.Auth = auth
+		return hm
+	case *httpMetadata.HTTPIndexMetadata:
+		hm.Auth = auth
+	}
+	return m
+}
+
+// authMethod returns how the requests for src authenticate under o: signed with the request
+// signer, if any, with the credentials of the URL, which http.Client sends with basic
+// authentication, with the token of WithGitToken for release assets, or with the client
+// certificate of the options.
+func authMethod(o *gogather.Options, src *url.URL, r *release) string {
+	switch {
+	case o.RequestSigner != nil:
+		return metadata.AuthRequestSigner
+	case src.User != nil:
+		return metadata.AuthBasic
+	case r != nil && o.GitToken != "":
+		return metadata.AuthBearer
+	case len(o.ClientCertificates) > 0:
+		return metadata.AuthClientCertificate
+	default:
+		return metadata.AuthAnonymous
 	}
-	return m, nil
+}
+
+// traceRequest logs req at trace level before it is sent.
+func traceRequest(o *gogather.Options, req *http.Request) {
+	o.Log(req.Context(), gogather.LevelTrace, "sending request", "method", req.Method, "url", req.URL.String())
+}
+
+// Close closes the idle connections kept by the gatherer's http.Client.
+func (h *HTTPGatherer) Close() error {
+	h.Client.CloseIdleConnections()
+	return nil
 }
@@ -17,17 +17,27 @@
 package http
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	h "net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
 	"github.com/enterprise-contract/go-gather/metadata/http"
 )
 
@@ -308,3 +318,958 @@ func TestHTTPGatherer_Gather_ClassifyURI_Error(t *testing.T) {
 	}
 	assert.EqualError(t, err, "error determining destination type: unsupported source protocol: foo")
 }
+
+// TestHTTPGatherer_Gather_HostNotAllowed tests that the source host is checked before the request is made.
+func TestHTTPGatherer_Gather_HostNotAllowed(t *testing.T) {
+	requested := false
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		requested = true
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+
+	_, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.bar", mockServer.URL), t.TempDir(), gogather.WithBlockPrivateNetworks(true))
+	assert.ErrorIs(t, err, gogather.ErrHostNotAllowed)
+	assert.False(t, requested)
+}
+
+// TestHTTPGatherer_Gather_RedirectNotAllowed tests that redirect targets are checked against the allowed hosts.
+func TestHTTPGatherer_Gather_RedirectNotAllowed(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		h.Redirect(w, r, strings.Replace("http://"+r.Host+"/other.bar", "127.0.0.1", "localhost", 1), h.StatusFound)
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+
+	_, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.bar", mockServer.URL), t.TempDir(), gogather.WithAllowedHosts([]string{"127.0.0.1"}))
+	assert.ErrorIs(t, err, gogather.ErrHostNotAllowed)
+}
+
+// TestHTTPGatherer_Gather_AuthMethod tests that the metadata reports the credentials of the
+// URL as basic authentication, and requests without credentials as anonymous.
+func TestHTTPGatherer_Gather_AuthMethod(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if user, password, ok := r.BasicAuth(); ok && (user != "alice" || password != "s3cret") {
+			w.WriteHeader(h.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Hello, World!")
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.bar", t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, metadata.AuthAnonymous, m.AuthMethod())
+
+	source := strings.Replace(mockServer.URL, "http://", "http://alice:s3cret@", 1) + "/foo.bar"
+	m, err = gatherer.Gather(context.Background(), source, t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, metadata.AuthBasic, m.AuthMethod())
+}
+
+// TestHTTPGatherer_Gather_DetectedContentType tests that the content type is detected when the server does not send one.
+func TestHTTPGatherer_Gather_DetectedContentType(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		w.Header()["Content-Type"] = nil
+		fmt.Fprint(w, "<html><body>Hello, World!</body></html>")
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+
+	m, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.bar", mockServer.URL), t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, "", m.(http.HTTPMetadata).ContentType)
+	assert.Equal(t, "text/html; charset=utf-8", m.(http.HTTPMetadata).DetectedContentType())
+}
+
+// TestHTTPGatherer_Gather_Transform tests that the transform is applied and that a failing transform removes the file.
+func TestHTTPGatherer_Gather_Transform(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	tempDir := t.TempDir()
+
+	_, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.bar", mockServer.URL), tempDir, gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+		return []byte(strings.ToUpper(string(content))), nil
+	}))
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(tempDir, "foo.bar"))
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO, WORLD!", string(content))
+
+	_, err = gatherer.Gather(context.Background(), fmt.Sprintf("%s/baz.bar", mockServer.URL), tempDir, gogather.WithTransform(func(path string, content []byte) ([]byte, error) {
+		return nil, fmt.Errorf("bad content")
+	}))
+	assert.ErrorContains(t, err, "bad content")
+	assert.NoFileExists(t, filepath.Join(tempDir, "baz.bar"))
+}
+
+// memoryDestination is a gogather.Destination that keeps written files in memory.
+type memoryDestination struct {
+	mu    sync.Mutex
+	files map[string]*bytes.Buffer
+}
+
+type memoryFile struct{ *bytes.Buffer }
+
+func (memoryFile) Close() error { return nil }
+
+func (d *memoryDestination) Create(path string) (io.WriteCloser, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.files == nil {
+		d.files = map[string]*bytes.Buffer{}
+	}
+	d.files[path] = &bytes.Buffer{}
+	return memoryFile{d.files[path]}, nil
+}
+
+// TestHTTPGatherer_Gather_Destination tests that the file is written to a custom destination.
+func TestHTTPGatherer_Gather_Destination(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	destination := &memoryDestination{}
+
+	m, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.bar", mockServer.URL), "bucket/prefix/", gogather.WithDestination(destination))
+	assert.NoError(t, err)
+	assert.Equal(t, "bucket/prefix/foo.bar", m.(http.HTTPMetadata).Destination)
+	assert.Equal(t, "Hello, World!", destination.files["bucket/prefix/foo.bar"].String())
+	assert.NoDirExists(t, "bucket")
+}
+
+// TestHTTPGatherer_Close tests that Close closes the idle connections of the client.
+func TestHTTPGatherer_Close(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	mockServer := httptest.NewUnstartedServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	}))
+	mockServer.Config.ConnState = func(c net.Conn, state h.ConnState) {
+		if state == h.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	mockServer.Start()
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	gatherer.Client.Transport = &h.Transport{}
+
+	_, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.txt", mockServer.URL), t.TempDir())
+	assert.NoError(t, err)
+
+	assert.NoError(t, gatherer.Close())
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the idle connection to be closed")
+	}
+}
+
+// TestHTTPGatherer_Gather_IdleTimeout tests that a stalled download is aborted.
+func TestHTTPGatherer_Gather_IdleTimeout(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "a")
+		w.(h.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.txt", mockServer.URL), t.TempDir(), gogather.WithIdleTimeout(100*time.Millisecond))
+
+	assert.ErrorIs(t, err, gogather.ErrIdleTimeout)
+}
+
+// TestHTTPGatherer_Gather_IdleTimeoutHeaders tests that a download whose server never sends
+// the response headers is aborted.
+func TestHTTPGatherer_Gather_IdleTimeoutHeaders(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	start := time.Now()
+	_, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.txt", mockServer.URL), t.TempDir(), gogather.WithIdleTimeout(100*time.Millisecond))
+
+	assert.ErrorIs(t, err, gogather.ErrIdleTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestHTTPGatherer_DestinationName tests that the name is taken from the URL path or the Content-Disposition header.
+func TestHTTPGatherer_DestinationName(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		assert.Equal(t, "HEAD", r.Method)
+		w.Header().Set("Content-Disposition", `attachment; filename="../report.csv"`)
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+
+	name, err := gatherer.DestinationName(context.Background(), "https://example.com/files/foo.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo.tar.gz", name)
+
+	name, err = gatherer.DestinationName(context.Background(), mockServer.URL+"/")
+	assert.NoError(t, err)
+	assert.Equal(t, "report.csv", name)
+}
+
+// TestHTTPGatherer_Gather_ContentDisposition tests that a URL without a file name is saved under the Content-Disposition name.
+func TestHTTPGatherer_Gather_ContentDisposition(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="report.csv"`)
+		fmt.Fprint(w, "a,b")
+	}))
+	defer mockServer.Close()
+	destination := t.TempDir()
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), mockServer.URL+"/", destination+"/")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(destination, "report.csv"), m.(http.HTTPMetadata).Destination)
+}
+
+// TestHTTPGatherer_Gather_Decompress tests that a gzip file is decompressed and its extension dropped.
+func TestHTTPGatherer_Gather_Decompress(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		gw := gzip.NewWriter(w)
+		fmt.Fprint(gw, "Hello, World!")
+		gw.Close()
+	}))
+	defer mockServer.Close()
+	destination := t.TempDir()
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.txt.gz", mockServer.URL), destination+"/", gogather.WithDecompress(true))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(destination, "foo.txt"), m.(http.HTTPMetadata).Destination)
+
+	content, err := os.ReadFile(filepath.Join(destination, "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, World!", string(content))
+
+	// The name of the decompressed file can be chosen or kept
+	m, err = gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.txt.gz", mockServer.URL), destination+"/", gogather.WithDecompress(true), gogather.WithDecompressedName("bar.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(destination, "bar.txt"), m.(http.HTTPMetadata).Destination)
+	m, err = gatherer.Gather(context.Background(), fmt.Sprintf("%s/foo.txt.gz", mockServer.URL), destination+"/", gogather.WithDecompress(true), gogather.WithKeepCompressionExtension(true))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(destination, "foo.txt.gz"), m.(http.HTTPMetadata).Destination)
+	content, err = os.ReadFile(filepath.Join(destination, "foo.txt.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, World!", string(content))
+}
+
+// TestHTTPGatherer_Gather_GunzipSingleFile tests that a single gzip-compressed file named
+// *.gz is decompressed to its final name, also with WithExtract, which still extracts tar
+// archives.
+func TestHTTPGatherer_Gather_GunzipSingleFile(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "hello.txt", Mode: 0644, Size: 11}))
+	fmt.Fprint(tw, "hello world")
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	archive := buf.Bytes()
+
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		switch r.URL.Path {
+		case "/bundle.tar.gz":
+			_, _ = w.Write(archive)
+		case "/plain.json.gz":
+			fmt.Fprint(w, `{"plain": true}`)
+		default:
+			gw := gzip.NewWriter(w)
+			fmt.Fprint(gw, `{"hello": "world"}`)
+			gw.Close()
+		}
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	testCases := []struct {
+		name     string
+		source   string
+		opts     []gogather.Option
+		file     string
+		expected string
+	}{
+		{name: "gzip file", source: "/file.json.gz", file: "file.json", expected: `{"hello": "world"}`},
+		{name: "with extract", source: "/file.json.gz", opts: []gogather.Option{gogather.WithExtract(true)}, file: "file.json", expected: `{"hello": "world"}`},
+		{name: "not compressed", source: "/plain.json.gz", file: "plain.json.gz", expected: `{"plain": true}`},
+		{name: "tar archive", source: "/bundle.tar.gz", opts: []gogather.Option{gogather.WithExtract(true)}, file: "hello.txt", expected: "hello world"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "out")
+			opts := append([]gogather.Option{gogather.WithGunzipSingleFile(true)}, tc.opts...)
+			_, err := gatherer.Gather(context.Background(), mockServer.URL+tc.source, destination, opts...)
+			assert.NoError(t, err)
+			content, err := os.ReadFile(filepath.Join(destination, tc.file))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(content))
+		})
+	}
+
+	// Without the option, the file is written as downloaded
+	destination := t.TempDir()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/file.json.gz", destination+"/")
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "file.json.gz"))
+}
+
+// TestHTTPGatherer_Gather_Checksum tests that the downloaded file is verified against the checksum.
+func TestHTTPGatherer_Gather_Checksum(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	destination := t.TempDir()
+	gatherer := NewHTTPGatherer()
+
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/ok.txt", destination+"/", gogather.WithChecksum("sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "ok.txt"))
+
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/bad.txt", destination+"/", gogather.WithChecksum("sha256:"+strings.Repeat("0", 64)))
+	assert.ErrorIs(t, err, gogather.ErrChecksumMismatch)
+	assert.NoFileExists(t, filepath.Join(destination, "bad.txt"))
+}
+
+// TestHTTPGatherer_Gather_TrailerChecksum tests that downloads are verified against the
+// checksum trailers sent after the body, whether announced or not.
+func TestHTTPGatherer_Gather_TrailerChecksum(t *testing.T) {
+	const helloSHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		checksum := helloSHA256
+		if r.URL.Path == "/bad.txt" {
+			checksum = strings.Repeat("0", 64)
+		}
+		if r.URL.Query().Has("announced") {
+			w.Header().Set("Trailer", "X-Checksum-Sha256")
+			fmt.Fprint(w, "hello world")
+			w.Header().Set("X-Checksum-Sha256", checksum)
+			return
+		}
+		// Flush the body so that it is chunked and can be followed by trailers
+		fmt.Fprint(w, "hello world")
+		w.(h.Flusher).Flush()
+		w.Header().Set(h.TrailerPrefix+"X-Checksum-Sha256", checksum)
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	for _, query := range []string{"", "?announced"} {
+		destination := t.TempDir()
+		_, err := gatherer.Gather(context.Background(), mockServer.URL+"/ok.txt"+query, destination+"/ok.txt", gogather.WithHTTPTrailerChecksum(true))
+		assert.NoError(t, err)
+		assert.FileExists(t, filepath.Join(destination, "ok.txt"))
+
+		_, err = gatherer.Gather(context.Background(), mockServer.URL+"/bad.txt"+query, destination+"/bad.txt", gogather.WithHTTPTrailerChecksum(true))
+		assert.ErrorIs(t, err, gogather.ErrChecksumMismatch)
+		assert.NoFileExists(t, filepath.Join(destination, "bad.txt"))
+	}
+
+	// Trailers are ignored unless asked for
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/bad.txt", t.TempDir()+"/bad.txt")
+	assert.NoError(t, err)
+}
+
+// TestHTTPGatherer_Gather_DestinationValidator tests that the validator runs on the computed destination.
+func TestHTTPGatherer_Gather_DestinationValidator(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		t.Error("expected no request to be made")
+	}))
+	defer mockServer.Close()
+	destination := t.TempDir()
+	errRejected := fmt.Errorf("rejected")
+
+	var validated string
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination+"/", gogather.WithDestinationValidator(func(dest string) error {
+		validated = dest
+		return errRejected
+	}))
+	assert.Equal(t, errRejected, err)
+	assert.Equal(t, filepath.Join(destination, "foo.txt"), validated)
+}
+
+// TestHTTPGatherer_Gather_Events tests that the events of a download are sent to the channel.
+func TestHTTPGatherer_Gather_Events(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Path == "/missing.txt" {
+			w.WriteHeader(h.StatusNotFound)
+			return
+		}
+		gw := gzip.NewWriter(w)
+		fmt.Fprint(gw, "Hello, World!")
+		gw.Close()
+	}))
+	defer mockServer.Close()
+	destination := t.TempDir()
+	events := make(chan gogather.Event, 100)
+	source := mockServer.URL + "/foo.txt.gz"
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), source, destination+"/", gogather.WithDecompress(true), gogather.WithEventChannel(events))
+	assert.NoError(t, err)
+
+	var phases []gogather.EventPhase
+	var progress gogather.Event
+	for len(events) > 0 {
+		e := <-events
+		assert.Equal(t, source, e.Source)
+		if e.Phase == gogather.EventProgress {
+			progress = e
+		}
+		phases = append(phases, e.Phase)
+	}
+	assert.Equal(t, gogather.EventStarted, phases[0])
+	assert.Contains(t, phases, gogather.EventExtracting)
+	assert.Equal(t, gogather.EventDone, phases[len(phases)-1])
+	assert.Equal(t, progress.Total, progress.Bytes)
+	assert.Greater(t, progress.Bytes, int64(0))
+
+	// A failed gather reports its error with the done event
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/missing.txt", destination+"/", gogather.WithEventChannel(events))
+	assert.Error(t, err)
+	assert.Equal(t, gogather.EventStarted, (<-events).Phase)
+	done := <-events
+	assert.Equal(t, gogather.EventDone, done.Phase)
+	assert.Equal(t, err, done.Err)
+}
+
+// TestHTTPGatherer_Gather_Permissions tests that downloaded files and created directories get the configured permissions.
+func TestHTTPGatherer_Gather_Permissions(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	destination := filepath.Join(t.TempDir(), "shared", "foo.txt")
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination,
+		gogather.WithFilePerm(0666), gogather.WithDirPerm(0777), gogather.WithUmask(002))
+	assert.NoError(t, err)
+
+	info, err := os.Stat(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0664), info.Mode().Perm())
+	info, err = os.Stat(filepath.Dir(destination))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0775), info.Mode().Perm())
+}
+
+// TestHTTPGatherer_Gather_Query tests that the query of the source is sent verbatim and
+// does not name the downloaded file.
+func TestHTTPGatherer_Gather_Query(t *testing.T) {
+	query := "token=abc%2Fdef&v=2&next=//mirror.example.com/repo.git&q=a+b"
+	var received string
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		received = r.URL.RawQuery
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	destination := t.TempDir()
+
+	source := mockServer.URL + "/foo.txt?" + query
+	uriType, err := gogather.ClassifyURI(source)
+	assert.NoError(t, err)
+	assert.Equal(t, gogather.HTTPURI, uriType)
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), source, destination+"/")
+	assert.NoError(t, err)
+	assert.Equal(t, query, received)
+	assert.Equal(t, filepath.Join(destination, "foo.txt"), m.(http.HTTPMetadata).Destination)
+}
+
+// TestHTTPGatherer_Gather_Executable tests that downloaded files are made executable.
+func TestHTTPGatherer_Gather_Executable(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "#!/bin/sh\n")
+	}))
+	defer mockServer.Close()
+	destination := filepath.Join(t.TempDir(), "tool.sh")
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/tool.sh", destination,
+		gogather.WithExecutable(true), gogather.WithFilePerm(0640))
+	assert.NoError(t, err)
+
+	info, err := os.Stat(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+}
+
+// TestHTTPGatherer_Gather_ProgressWriter tests that a progress bar is drawn for the download.
+func TestHTTPGatherer_Gather_ProgressWriter(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	var out bytes.Buffer
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", t.TempDir()+"/", gogather.WithProgressWriter(&out))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out.String(), "\r"+mockServer.URL+"/foo.txt ["), out.String())
+	assert.True(t, strings.HasSuffix(out.String(), "100% 11 B/11 B\n"), out.String())
+}
+
+// TestHTTPGatherer_Gather_MaxBytes tests that files larger than the maximum size are refused.
+func TestHTTPGatherer_Gather_MaxBytes(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Path == "/chunked.txt" {
+			w.(h.Flusher).Flush()
+		}
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	for _, name := range []string{"foo.txt", "chunked.txt"} {
+		_, err := gatherer.Gather(context.Background(), mockServer.URL+"/"+name, filepath.Join(t.TempDir(), name), gogather.WithMaxBytes(5))
+		assert.ErrorIs(t, err, gogather.ErrTooLarge, name)
+	}
+
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"), gogather.WithMaxBytes(11))
+	assert.NoError(t, err)
+}
+
+// TestHTTPGatherer_Gather_MaxResponseHeaderBytes tests that responses with too large headers are refused.
+func TestHTTPGatherer_Gather_MaxResponseHeaderBytes(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Path == "/huge.txt" {
+			w.Header().Set("X-Padding", strings.Repeat("a", 8192))
+		}
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+	opt := gogather.WithMaxResponseHeaderBytes(4096)
+
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/huge.txt", filepath.Join(t.TempDir(), "huge.txt"), opt)
+	assert.ErrorIs(t, err, gogather.ErrResponseTooLarge)
+	assert.NotErrorIs(t, err, gogather.ErrTooLarge)
+
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"), opt)
+	assert.NoError(t, err)
+}
+
+// TestHTTPGatherer_Gather_Extract tests that a downloaded archive is extracted into the destination directory.
+func TestHTTPGatherer_Gather_Extract(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "dir/hello.txt", Mode: 0644, Size: 11}))
+	fmt.Fprint(tw, "hello world")
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	archive := buf.Bytes()
+
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	destination := filepath.Join(t.TempDir(), "out")
+	m, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.tgz", destination, gogather.WithExtract(true))
+	assert.NoError(t, err)
+	assert.Equal(t, destination, m.(http.HTTPMetadata).Destination)
+	content, err := os.ReadFile(filepath.Join(destination, "dir", "hello.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+
+	// The extracted files are removed again if the archive does not match the checksum
+	destination = filepath.Join(t.TempDir(), "out")
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.tgz", destination, gogather.WithExtract(true),
+		gogather.WithChecksum("sha256:0000000000000000000000000000000000000000000000000000000000000000"))
+	assert.ErrorIs(t, err, gogather.ErrChecksumMismatch)
+	assert.NoDirExists(t, destination)
+
+	// Anything else is refused
+	notArchive := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "hello world")
+	}))
+	defer notArchive.Close()
+	_, err = gatherer.Gather(context.Background(), notArchive.URL+"/foo.txt", filepath.Join(t.TempDir(), "out"), gogather.WithExtract(true))
+	assert.ErrorIs(t, err, gogather.ErrNotArchive)
+}
+
+// TestHTTPGatherer_Gather_InsecureHTTP tests that plain http sources and redirects are refused when not allowed.
+func TestHTTPGatherer_Gather_InsecureHTTP(t *testing.T) {
+	plain := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "hello world")
+	}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(h.RedirectHandler(plain.URL+"/foo.txt", h.StatusFound))
+	defer secure.Close()
+	gatherer := NewHTTPGatherer()
+
+	_, err := gatherer.Gather(context.Background(), plain.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"), gogather.WithInsecureHTTPAllowed(false))
+	assert.ErrorIs(t, err, gogather.ErrInsecureTransport)
+
+	_, err = gatherer.Gather(context.Background(), secure.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"),
+		gogather.WithInsecureHTTPAllowed(false), gogather.WithInsecureSkipTLSVerify(true))
+	assert.ErrorIs(t, err, gogather.ErrInsecureTransport)
+
+	_, err = gatherer.Gather(context.Background(), plain.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"))
+	assert.NoError(t, err)
+}
+
+// TestHTTPGatherer_Gather_Size tests that the size of the written file is known with and without a Content-Length.
+func TestHTTPGatherer_Gather_Size(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Path == "/chunked.txt" {
+			fmt.Fprint(w, "hello ")
+			w.(h.Flusher).Flush()
+		}
+		fmt.Fprint(w, "world")
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	testCases := []struct {
+		name         string
+		size         int64
+		declaredSize int64
+	}{
+		{name: "length.txt", size: 5, declaredSize: 5},
+		{name: "chunked.txt", size: 11, declaredSize: -1},
+	}
+
+	for _, tc := range testCases {
+		m, err := gatherer.Gather(context.Background(), mockServer.URL+"/"+tc.name, filepath.Join(t.TempDir(), tc.name))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.size, m.(http.HTTPMetadata).Size(), tc.name)
+		assert.Equal(t, tc.declaredSize, m.(http.HTTPMetadata).DeclaredSize(), tc.name)
+	}
+}
+
+// TestHTTPGatherer_Gather_HashOnly tests that hash-only mode returns the checksum without writing a file.
+func TestHTTPGatherer_Gather_HashOnly(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+	dir := t.TempDir()
+
+	m, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", dir, gogather.WithHashOnly("sha256"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", m.(http.HTTPMetadata).Checksum)
+	assert.Equal(t, int64(11), m.(http.HTTPMetadata).Size())
+	assert.Empty(t, m.(http.HTTPMetadata).Destination)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", dir, gogather.WithHashOnly("sha256"),
+		gogather.WithChecksum("sha256:"+strings.Repeat("0", 64)))
+	assert.ErrorIs(t, err, gogather.ErrChecksumMismatch)
+
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", dir, gogather.WithHashOnly("md5"))
+	assert.Error(t, err)
+}
+
+// TestHTTPGatherer_Gather_ExpectContentType tests that responses of unexpected content types are refused.
+func TestHTTPGatherer_Gather_ExpectContentType(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Path == "/login.tar.gz" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<html><body>Please log in</body></html>")
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		fmt.Fprint(w, "data")
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+	opt := gogather.WithExpectContentType([]string{"application/*"})
+
+	dir := t.TempDir()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/login.tar.gz", dir, opt)
+	assert.ErrorIs(t, err, gogather.ErrUnexpectedContentType)
+	assert.NoFileExists(t, filepath.Join(dir, "login.tar.gz"))
+
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/archive.tar.gz", dir, opt)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "archive.tar.gz"))
+}
+
+// TestHTTPGatherer_Gather_ClientCache tests that repeated gathers from a host reuse connections with WithClientCache.
+func TestHTTPGatherer_Gather_ClientCache(t *testing.T) {
+	var mu sync.Mutex
+	conns := 0
+	mockServer := httptest.NewUnstartedServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "data")
+	}))
+	mockServer.Config.ConnState = func(c net.Conn, state h.ConnState) {
+		if state == h.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	mockServer.Start()
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		_, err := gatherer.Gather(context.Background(), mockServer.URL+"/"+name, t.TempDir(),
+			gogather.WithClientCache(time.Minute), gogather.WithMaxConnsPerHost(4))
+		assert.NoError(t, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, conns)
+}
+
+// TestHTTPGatherer_Gather_LogLevel tests that requests and redirects are only logged at trace level.
+func TestHTTPGatherer_Gather_LogLevel(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Path == "/old.txt" {
+			h.Redirect(w, r, "/foo.txt", h.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: gogather.LevelTrace}))
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/old.txt", filepath.Join(t.TempDir(), "foo.txt"),
+		gogather.WithLogger(logger), gogather.WithLogLevel(gogather.LevelTrace))
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `msg="sending request" method=GET url=`+mockServer.URL+"/old.txt")
+	assert.Contains(t, buf.String(), `msg="following redirect" url=`+mockServer.URL+"/foo.txt")
+	assert.Contains(t, buf.String(), `msg="downloaded file"`)
+
+	buf.Reset()
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/old.txt", filepath.Join(t.TempDir(), "foo.txt"),
+		gogather.WithLogger(logger), gogather.WithLogLevel(slog.LevelInfo))
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "sending request")
+	assert.NotContains(t, buf.String(), "following redirect")
+	assert.Contains(t, buf.String(), `msg="downloaded file"`)
+}
+
+// TestHTTPGatherer_Gather_Timings tests that the phases of a gather are timed in the metadata.
+func TestHTTPGatherer_Gather_Timings(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "hello.txt", Mode: 0644, Size: 11}))
+	fmt.Fprint(tw, "hello world")
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if strings.HasSuffix(r.URL.Path, ".tgz") {
+			_, _ = w.Write(buf.Bytes())
+			return
+		}
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	// A fresh transport makes a new connection for each gather
+	gatherer := &HTTPGatherer{Client: h.Client{Transport: &h.Transport{}}}
+
+	m, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", t.TempDir())
+	assert.NoError(t, err)
+	timings := m.(http.HTTPMetadata).Timings()
+	assert.Positive(t, timings.Connect)
+	assert.Positive(t, timings.Transfer)
+	assert.Zero(t, timings.Extract)
+
+	gatherer.Client.CloseIdleConnections()
+	m, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.tgz", t.TempDir(), gogather.WithExtract(true))
+	assert.NoError(t, err)
+	timings = m.(http.HTTPMetadata).Timings()
+	assert.Positive(t, timings.Connect)
+	assert.Positive(t, timings.Extract)
+}
+
+// TestHTTPGatherer_Gather_CreateParents tests that missing parent directories are only created when allowed.
+func TestHTTPGatherer_Gather_CreateParents(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	destination := filepath.Join(t.TempDir(), "a", "b", "foo.txt")
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination, gogather.WithCreateParents(false))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoFileExists(t, destination)
+
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination)
+	assert.NoError(t, err)
+	assert.FileExists(t, destination)
+}
+
+// TestHTTPGatherer_Gather_Symlink tests that downloads are not written through a link that
+// already exists at the destination, even when the link target does not exist yet.
+func TestHTTPGatherer_Gather_Symlink(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "evil")
+	}))
+	defer mockServer.Close()
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	link := filepath.Join(dir, "foo.txt")
+	assert.NoError(t, os.Symlink(secret, link))
+	gatherer := NewHTTPGatherer()
+
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", link)
+	assert.ErrorIs(t, err, gogather.ErrDestinationIsSymlink)
+	assert.NoFileExists(t, secret)
+
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", link, gogather.WithSafeDestination(false))
+	assert.NoError(t, err)
+	content, err := os.ReadFile(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "evil", string(content))
+}
+
+// TestHTTPGatherer_Gather_BufferSize tests that downloads are copied with the buffer size and
+// that invalid sizes are refused before downloading.
+func TestHTTPGatherer_Gather_BufferSize(t *testing.T) {
+	data := strings.Repeat("x", 1<<20)
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, data)
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+
+	destination := filepath.Join(t.TempDir(), "foo.txt")
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination, gogather.WithBufferSize(1<<20))
+	assert.NoError(t, err)
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(content))
+
+	destination = filepath.Join(t.TempDir(), "foo.txt")
+	_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination, gogather.WithBufferSize(16))
+	assert.ErrorContains(t, err, "buffer size of 16 bytes is not between")
+	assert.NoFileExists(t, destination)
+}
+
+// TestHTTPGatherer_Gather_KeepPartialOnError tests that the output of a failed download is
+// renamed rather than removed when asked to.
+func TestHTTPGatherer_Gather_KeepPartialOnError(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		// Flushing leaves the size unknown, so that it is only exceeded while writing
+		fmt.Fprint(w, strings.Repeat("x", 1024))
+		w.(h.Flusher).Flush()
+		fmt.Fprint(w, strings.Repeat("x", 1024))
+	}))
+	defer mockServer.Close()
+	gatherer := NewHTTPGatherer()
+	checksum := gogather.WithChecksum("sha256:0000000000000000000000000000000000000000000000000000000000000000")
+
+	testCases := []struct {
+		name string
+		opts []gogather.Option
+	}{
+		{name: "checksum mismatch", opts: []gogather.Option{checksum}},
+		{name: "too large", opts: []gogather.Option{gogather.WithMaxBytes(1536)}},
+	}
+
+	for _, tc := range testCases {
+		destination := filepath.Join(t.TempDir(), "foo.txt")
+		_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination, tc.opts...)
+		assert.Error(t, err, tc.name)
+		assert.NoFileExists(t, destination, tc.name)
+		assert.NoFileExists(t, destination+gogather.PartialSuffix, tc.name)
+
+		_, err = gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", destination, append(tc.opts, gogather.WithKeepPartialOnError(true))...)
+		assert.ErrorContains(t, err, "partial output kept at "+destination+gogather.PartialSuffix, tc.name)
+		assert.NoFileExists(t, destination, tc.name)
+		assert.FileExists(t, destination+gogather.PartialSuffix, tc.name)
+	}
+}
+
+// TestHTTPGatherer_Gather_MaxRedirectBodyRead tests that redirects with large bodies are
+// followed without reading the bodies.
+func TestHTTPGatherer_Gather_MaxRedirectBodyRead(t *testing.T) {
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Path == "/redirect" {
+			w.Header().Set("Location", "/foo.txt")
+			w.WriteHeader(h.StatusFound)
+			_, _ = w.Write(bytes.Repeat([]byte("x"), 1<<20))
+			return
+		}
+		fmt.Fprint(w, "hello")
+	}))
+	defer server.Close()
+
+	destination := filepath.Join(t.TempDir(), "foo.txt")
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), server.URL+"/redirect", destination, gogather.WithMaxRedirectBodyRead(0))
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+// TestHTTPGatherer_Gather_ContentLengthRequired tests that responses without a content length,
+// or truncated ones, are refused when a content length is required.
+func TestHTTPGatherer_Gather_ContentLengthRequired(t *testing.T) {
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		switch r.URL.Path {
+		case "/chunked.txt":
+			_, _ = w.Write([]byte("hello"))
+			w.(h.Flusher).Flush()
+		case "/truncated.txt":
+			conn, _, err := w.(h.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\nhello"))
+			conn.Close()
+		default:
+			assert.Equal(t, "identity", r.Header.Get("Accept-Encoding"))
+			_, _ = w.Write([]byte("hello"))
+		}
+	}))
+	defer server.Close()
+	gatherer := NewHTTPGatherer()
+
+	_, err := gatherer.Gather(context.Background(), server.URL+"/chunked.txt", filepath.Join(t.TempDir(), "chunked.txt"), gogather.WithContentLengthRequired(true))
+	assert.ErrorIs(t, err, gogather.ErrContentLengthMissing)
+
+	destination := filepath.Join(t.TempDir(), "truncated.txt")
+	_, err = gatherer.Gather(context.Background(), server.URL+"/truncated.txt", destination, gogather.WithContentLengthRequired(true))
+	assert.ErrorIs(t, err, gogather.ErrContentLengthMismatch)
+	assert.NoFileExists(t, destination)
+
+	destination = filepath.Join(t.TempDir(), "foo.txt")
+	m, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination, gogather.WithContentLengthRequired(true))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), m.(http.HTTPMetadata).ContentLength)
+}
@@ -0,0 +1,123 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// ManifestContentType is the content type identifying a manifest when WithManifest is set.
+const ManifestContentType = "application/vnd.go-gather.manifest+json"
+
+// Manifest lists files to gather with their checksums. It is a JSON document such as:
+//
+//	{
+//	  "files": [
+//	    {"path": "policy/main.rego", "sha256": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"},
+//	    {"path": "data/data.json", "url": "https://cdn.example.com/data.json", "sha256": "..."}
+//	  ]
+//	}
+//
+// Each file is written to its path relative to the destination directory. Paths must be
+// relative and may not leave the destination. The url defaults to the path, and relative URLs
+// are resolved against the URL of the manifest. The sha256 checksum is required.
+type Manifest struct {
+	Files []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file listed in a Manifest.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	URL    string `json:"url,omitempty"`
+	SHA256 string `json:"sha256"`
+}
+
+// isManifest reports whether the response for src is a manifest to gather.
+func isManifest(o *gogather.Options, src *url.URL, resp *http.Response) bool {
+	if o.ManifestURL != "" && o.ManifestURL == src.String() {
+		return true
+	}
+	if !o.Manifest {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == ManifestContentType
+}
+
+// gatherManifest downloads the files listed by the manifest read from r, served from src,
+// into the destination directory, verifying each against its checksum.
+func (h *HTTPGatherer) gatherManifest(ctx context.Context, src *url.URL, r io.Reader, destination string, opts []gogather.Option) (*httpMetadata.HTTPIndexMetadata, error) {
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+
+	// Validate every entry before downloading anything
+	links := make([]*url.URL, len(manifest.Files))
+	for i, f := range manifest.Files {
+		clean := path.Clean(f.Path)
+		if f.Path == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, fmt.Errorf("invalid manifest path: %q", f.Path)
+		}
+		if f.SHA256 == "" {
+			return nil, fmt.Errorf("manifest file %s has no sha256 checksum", f.Path)
+		}
+		ref := f.URL
+		if ref == "" {
+			ref = (&url.URL{Path: clean}).String()
+		}
+		link, err := src.Parse(ref)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest URL for %s: %w", f.Path, err)
+		}
+		links[i] = link
+		manifest.Files[i].Path = clean
+	}
+
+	m := &httpMetadata.HTTPIndexMetadata{Destination: destination}
+	for i, f := range manifest.Files {
+		// The files are gathered as plain files, verified against the manifest checksum
+		fileOpts := append(opts[:len(opts):len(opts)], gogather.WithManifest(false), gogather.WithManifestURL(""), gogather.WithChecksum("sha256:"+f.SHA256))
+		o := gogather.NewOptions(fileOpts...)
+
+		fileDestination := filepath.Join(destination, filepath.FromSlash(f.Path))
+		if err := validateDestination(o, fileDestination); err != nil {
+			return nil, err
+		}
+
+		fm, err := h.download(ctx, o, fileOpts, links[i], destination, path.Base(f.Path), fileDestination)
+		if err != nil {
+			return nil, fmt.Errorf("error gathering %s: %w", f.Path, err)
+		}
+		file := fm.(httpMetadata.HTTPMetadata)
+		file.RelativePath = relativePath(destination, file.Destination)
+		m.Files = append(m.Files, file)
+	}
+	return m, nil
+}
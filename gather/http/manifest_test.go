@@ -0,0 +1,134 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	h "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// sha256Hex returns the hex encoded SHA-256 checksum of s.
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// newManifestServer returns a server serving the manifest at /manifest.json with the given
+// content type, along with the files it lists.
+func newManifestServer(t *testing.T, contentType string, manifest Manifest) *httptest.Server {
+	mux := h.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w h.ResponseWriter, r *h.Request) {
+		w.Header().Set("Content-Type", contentType)
+		assert.NoError(t, json.NewEncoder(w).Encode(manifest))
+	})
+	mux.HandleFunc("/a.txt", func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "aaa")
+	})
+	mux.HandleFunc("/blobs/1234", func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "bbb")
+	})
+	return httptest.NewServer(mux)
+}
+
+// TestHTTPGatherer_Gather_Manifest tests that the files listed by a manifest are downloaded and verified.
+func TestHTTPGatherer_Gather_Manifest(t *testing.T) {
+	server := newManifestServer(t, ManifestContentType, Manifest{Files: []ManifestFile{
+		{Path: "a.txt", SHA256: sha256Hex("aaa")},
+		{Path: "sub/b", URL: "/blobs/1234", SHA256: sha256Hex("bbb")},
+	}})
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "out")
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), server.URL+"/manifest.json", destination, gogather.WithManifest(true))
+	assert.NoError(t, err)
+	assert.Len(t, m.(*http.HTTPIndexMetadata).Files, 2)
+	assert.Equal(t, []string{"a.txt", "sub/b"}, m.(*http.HTTPIndexMetadata).Paths())
+
+	content, err := os.ReadFile(filepath.Join(destination, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "aaa", string(content))
+	content, err = os.ReadFile(filepath.Join(destination, "sub", "b"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bbb", string(content))
+}
+
+// TestHTTPGatherer_Gather_ManifestURL tests that an explicit manifest URL is gathered whatever its content type.
+func TestHTTPGatherer_Gather_ManifestURL(t *testing.T) {
+	server := newManifestServer(t, "application/json", Manifest{Files: []ManifestFile{
+		{Path: "a.txt", SHA256: sha256Hex("aaa")},
+	}})
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "out")
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), server.URL+"/manifest.json", destination, gogather.WithManifest(true))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "manifest.json"))
+
+	destination = filepath.Join(t.TempDir(), "out")
+	_, err = gatherer.Gather(context.Background(), server.URL+"/manifest.json", destination, gogather.WithManifestURL(server.URL+"/manifest.json"))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(destination, "a.txt"))
+}
+
+// TestHTTPGatherer_Gather_ManifestChecksumMismatch tests that a file not matching its checksum is rejected and removed.
+func TestHTTPGatherer_Gather_ManifestChecksumMismatch(t *testing.T) {
+	server := newManifestServer(t, ManifestContentType, Manifest{Files: []ManifestFile{
+		{Path: "a.txt", SHA256: sha256Hex("not aaa")},
+	}})
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "out")
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), server.URL+"/manifest.json", destination, gogather.WithManifest(true))
+	assert.ErrorIs(t, err, gogather.ErrChecksumMismatch)
+	assert.NoFileExists(t, filepath.Join(destination, "a.txt"))
+}
+
+// TestHTTPGatherer_Gather_ManifestInvalid tests that unsafe and incomplete entries are rejected before downloading.
+func TestHTTPGatherer_Gather_ManifestInvalid(t *testing.T) {
+	testCases := []struct {
+		file     ManifestFile
+		expected string
+	}{
+		{file: ManifestFile{Path: "../escape.txt", SHA256: sha256Hex("aaa")}, expected: "invalid manifest path"},
+		{file: ManifestFile{Path: "/etc/passwd", SHA256: sha256Hex("aaa")}, expected: "invalid manifest path"},
+		{file: ManifestFile{Path: "a.txt"}, expected: "has no sha256 checksum"},
+	}
+
+	for _, tc := range testCases {
+		server := newManifestServer(t, ManifestContentType, Manifest{Files: []ManifestFile{tc.file}})
+		gatherer := NewHTTPGatherer()
+		_, err := gatherer.Gather(context.Background(), server.URL+"/manifest.json", t.TempDir(), gogather.WithManifest(true))
+		assert.ErrorContains(t, err, tc.expected)
+		server.Close()
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// pages reads the bodies of the pages of a paginated source one after another. Each page is
+// read in full, so that the pagination function of the options can find the URL of the next
+// page in it, and the next page is only requested once the current one has been read.
+type pages struct {
+	ctx  context.Context
+	o    *gogather.Options
+	c    *http.Client
+	resp *http.Response
+	data []byte
+	page *bytes.Reader
+	seen map[string]bool
+}
+
+// newPages returns the pages starting with resp, the response to the first page, whose body
+// is read on the first read.
+func newPages(ctx context.Context, o *gogather.Options, c *http.Client, resp *http.Response) *pages {
+	return &pages{ctx: ctx, o: o, c: c, resp: resp, seen: map[string]bool{resp.Request.URL.String(): true}}
+}
+
+func (p *pages) Read(b []byte) (int, error) {
+	if p.page == nil {
+		if err := p.read(p.resp); err != nil {
+			return 0, err
+		}
+	}
+	for {
+		n, err := p.page.Read(b)
+		if err != io.EOF || p.resp == nil {
+			return n, err
+		}
+		if err := p.next(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// next requests the page following the current one, if any.
+func (p *pages) next() error {
+	next, err := p.o.Paginate(p.resp, p.data)
+	if err != nil {
+		return fmt.Errorf("error finding the next page: %w", err)
+	}
+	if next == "" {
+		p.resp = nil
+		return nil
+	}
+
+	u, err := p.resp.Request.URL.Parse(next)
+	if err != nil {
+		return fmt.Errorf("error parsing next page URL: %w", err)
+	}
+	if p.seen[u.String()] {
+		return fmt.Errorf("pagination loops back to %s", u)
+	}
+	p.seen[u.String()] = true
+	if err := p.o.CheckScheme(u.Scheme); err != nil {
+		return err
+	}
+	if err := p.o.CheckHost(p.ctx, u.Host); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(p.ctx, "GET", u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	for key, values := range requestHeaderFor(p.ctx, u) {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", "Go-Gather")
+	requireContentLength(p.o, req)
+
+	traceRequest(p.o, req)
+	resp, err := p.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("error downloading page: %w", p.o.ResponseError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response code error for %s: %d", u, resp.StatusCode)
+	}
+	if err := checkContentLength(p.o, resp); err != nil {
+		return err
+	}
+	return p.read(resp)
+}
+
+// read makes resp the current page.
+func (p *pages) read(resp *http.Response) error {
+	data, err := io.ReadAll(p.o.LimitReader(resp.Body))
+	if err != nil {
+		return fmt.Errorf("error reading page: %w", err)
+	}
+	p.resp, p.data, p.page = resp, data, bytes.NewReader(data)
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	h "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// TestHTTPGatherer_Gather_Paginate tests that the pages of a paginated source are written to
+// the destination one after another.
+func TestHTTPGatherer_Gather_Paginate(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		switch page := r.URL.Query().Get("page"); page {
+		case "", "2":
+			next := "3"
+			if page == "" {
+				next = "2"
+			}
+			w.Header().Set("Link", fmt.Sprintf(`</items.json?page=%s>; rel="next"`, next))
+			fmt.Fprintf(w, "page %s\n", page)
+		case "3":
+			fmt.Fprint(w, "page 3\n")
+		default:
+			w.WriteHeader(h.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+	destination := filepath.Join(t.TempDir(), "items.json")
+
+	m, err := NewHTTPGatherer().Gather(context.Background(), mockServer.URL+"/items.json", destination, gogather.WithPaginate(gogather.NextLink))
+	assert.NoError(t, err)
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "page \npage 2\npage 3\n", string(content))
+	assert.Equal(t, int64(len(content)), m.(httpMetadata.HTTPMetadata).BytesWritten)
+	assert.Equal(t, int64(-1), m.(httpMetadata.HTTPMetadata).ContentLength)
+}
+
+// TestHTTPGatherer_Gather_PaginateField tests that the next page can be read from the body of
+// each page.
+func TestHTTPGatherer_Gather_PaginateField(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"items": [2], "next": null}`)
+			return
+		}
+		fmt.Fprint(w, `{"items": [1], "next": "?page=2"}`)
+	}))
+	defer mockServer.Close()
+	destination := filepath.Join(t.TempDir(), "items.json")
+
+	_, err := NewHTTPGatherer().Gather(context.Background(), mockServer.URL+"/items.json", destination, gogather.WithPaginate(gogather.NextField("next")))
+	assert.NoError(t, err)
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"items": [1], "next": "?page=2"}{"items": [2], "next": null}`, string(content))
+}
+
+// TestHTTPGatherer_Gather_PaginateErrors tests that failing pages, loops and pages beyond the
+// maximum size fail the gather.
+func TestHTTPGatherer_Gather_PaginateErrors(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		switch r.URL.Path {
+		case "/missing.json":
+			w.Header().Set("Link", `</gone.json>; rel="next"`)
+		case "/gone.json":
+			w.WriteHeader(h.StatusNotFound)
+			return
+		case "/loop.json":
+			w.Header().Set("Link", `</loop.json>; rel="next"`)
+		case "/large.json":
+			w.Header().Set("Link", `</large.json?page=2>; rel="next"`)
+		}
+		fmt.Fprint(w, "0123456789")
+	}))
+	defer mockServer.Close()
+
+	testCases := []struct {
+		name        string
+		path        string
+		opts        []gogather.Option
+		expectedErr error
+		expectedMsg string
+	}{
+		{name: "missing page", path: "/missing.json", expectedMsg: "response code error for " + mockServer.URL + "/gone.json: 404"},
+		{name: "loop", path: "/loop.json", expectedMsg: "pagination loops back to " + mockServer.URL + "/loop.json"},
+		{name: "too large", path: "/large.json", opts: []gogather.Option{gogather.WithMaxBytes(15)}, expectedErr: gogather.ErrTooLarge},
+		{name: "host not allowed", path: "/missing.json", opts: []gogather.Option{gogather.WithPaginate(func(*h.Response, []byte) (string, error) {
+			return "https://example.com/items.json", nil
+		}), gogather.WithAllowedHosts([]string{"127.0.0.1"})}, expectedErr: gogather.ErrHostNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		opts := append([]gogather.Option{gogather.WithPaginate(gogather.NextLink)}, tc.opts...)
+		_, err := NewHTTPGatherer().Gather(context.Background(), mockServer.URL+tc.path, filepath.Join(t.TempDir(), "items.json"), opts...)
+		if tc.expectedErr != nil {
+			assert.ErrorIs(t, err, tc.expectedErr, tc.name)
+		} else {
+			assert.ErrorContains(t, err, tc.expectedMsg, tc.name)
+		}
+	}
+}
+
+// TestHTTPGatherer_Gather_PaginateHeader tests that the header of a release asset is only sent
+// with the following pages on the host of the first page.
+func TestHTTPGatherer_Gather_PaginateHeader(t *testing.T) {
+	authorizations := map[string]string{}
+	other := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		authorizations[r.URL.Path] = r.Header.Get("Authorization")
+		fmt.Fprint(w, "other\n")
+	}))
+	defer other.Close()
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		authorizations[r.URL.Path] = r.Header.Get("Authorization")
+		if r.URL.Path == "/items.json" {
+			w.Header().Set("Link", `</more.json>; rel="next"`)
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/other.json>; rel="next"`, other.URL))
+		}
+		fmt.Fprint(w, "page\n")
+	}))
+	defer mockServer.Close()
+
+	ctx := withRequestHeader(context.Background(), mockServer.URL+"/items.json", h.Header{"Authorization": {"Bearer token"}})
+	_, err := NewHTTPGatherer().Gather(ctx, mockServer.URL+"/items.json", filepath.Join(t.TempDir(), "items.json"), gogather.WithPaginate(gogather.NextLink))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"/items.json": "Bearer token", "/more.json": "Bearer token", "/other.json": ""}, authorizations)
+}
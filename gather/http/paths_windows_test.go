@@ -0,0 +1,53 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package http
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// TestHTTPGatherer_Gather_AutoIndexPaths tests that listings report forward slash paths on
+// Windows while the files are written with the native separator.
+func TestHTTPGatherer_Gather_AutoIndexPaths(t *testing.T) {
+	server := newIndexServer()
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "out")
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), server.URL+"/files/", destination, gogather.WithHTTPAutoIndex(true))
+	assert.NoError(t, err)
+
+	im := m.(*http.HTTPIndexMetadata)
+	assert.Equal(t, []string{"a.txt", "sub/b c.txt"}, im.Paths())
+	for _, f := range im.Files {
+		assert.False(t, strings.Contains(f.RelativePath, `\`), f.RelativePath)
+	}
+	assert.True(t, strings.HasSuffix(im.Files[1].Destination, `\sub\b c.txt`), im.Files[1].Destination)
+	_, err = os.Stat(filepath.Join(destination, "sub", "b c.txt"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,68 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// preflight validates src with a HEAD request when WithPreflightHEAD is set, and returns the
+// strong ETag of the file, if any, to send with If-Match when downloading it.
+func (h *HTTPGatherer) preflight(ctx context.Context, o *gogather.Options, c *http.Client, src *url.URL) (string, error) {
+	if !o.PreflightHEAD {
+		return "", nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", src.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Go-Gather")
+
+	traceRequest(o, req)
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error validating file: %w", o.ResponseError(err))
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone, http.StatusRequestEntityTooLarge:
+		return "", fmt.Errorf("response code error: %d", resp.StatusCode)
+	default:
+		// Servers answer 405 when they do not support HEAD, and some sign URLs for GET only
+		o.Log(ctx, slog.LevelDebug, "skipping the preflight HEAD request", "source", src.String(), "status", resp.StatusCode)
+		return "", nil
+	}
+
+	if err := o.CheckSize(resp.ContentLength); err != nil {
+		return "", err
+	}
+	etag := resp.Header.Get("ETag")
+	if strings.HasPrefix(etag, "W/") {
+		return "", nil
+	}
+	return etag, nil
+}
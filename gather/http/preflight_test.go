@@ -0,0 +1,98 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	h "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// TestHTTPGatherer_Gather_PreflightHEAD tests that the HEAD request validates the source before it is downloaded.
+func TestHTTPGatherer_Gather_PreflightHEAD(t *testing.T) {
+	testCases := []struct {
+		name        string
+		headStatus  int
+		opts        []gogather.Option
+		expectedErr error
+		expectedMsg string
+		downloaded  bool
+	}{
+		{name: "ok", headStatus: h.StatusOK, downloaded: true},
+		{name: "not found", headStatus: h.StatusNotFound, expectedMsg: "response code error: 404"},
+		{name: "entity too large", headStatus: h.StatusRequestEntityTooLarge, expectedMsg: "response code error: 413"},
+		{name: "too large", headStatus: h.StatusOK, opts: []gogather.Option{gogather.WithMaxBytes(5)}, expectedErr: gogather.ErrTooLarge},
+		{name: "HEAD not supported", headStatus: h.StatusMethodNotAllowed, downloaded: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gets atomic.Int32
+			mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				if r.Method == "HEAD" {
+					w.Header().Set("Content-Length", "11")
+					w.WriteHeader(tc.headStatus)
+					return
+				}
+				gets.Add(1)
+				fmt.Fprint(w, "hello world")
+			}))
+			defer mockServer.Close()
+
+			opts := append([]gogather.Option{gogather.WithPreflightHEAD(true)}, tc.opts...)
+			gatherer := NewHTTPGatherer()
+			_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"), opts...)
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.expectedMsg != "":
+				assert.ErrorContains(t, err, tc.expectedMsg)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.downloaded, gets.Load() == 1)
+		})
+	}
+}
+
+// TestHTTPGatherer_Gather_PreflightHEADIfMatch tests that the download fails if the file changed after the HEAD request.
+func TestHTTPGatherer_Gather_PreflightHEADIfMatch(t *testing.T) {
+	var version atomic.Int32
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version.Add(1))
+		if match := r.Header.Get("If-Match"); match != "" && match != etag {
+			w.WriteHeader(h.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, "hello world")
+	}))
+	defer mockServer.Close()
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"), gogather.WithPreflightHEAD(true))
+	assert.ErrorContains(t, err, "response code error: 412")
+}
@@ -0,0 +1,93 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// rangeSupport caches the result of the range probes, keyed by the scheme and host probed.
+var rangeSupport sync.Map
+
+// probeRange reports whether the server of src supports range requests, probing it with a
+// request for its first byte, if asked to. Only definite results are cached per host: partial
+// content means support, and the whole file without an Accept-Ranges header means none. A
+// probe that fails or gets any other response is not cached and reports no support.
+func (h *HTTPGatherer) probeRange(ctx context.Context, o *gogather.Options, c *http.Client, src *url.URL) bool {
+	if !o.RangeProbe {
+		return false
+	}
+	key := src.Scheme + "://" + src.Host
+	if supported, ok := rangeSupport.Load(key); ok {
+		return supported.(bool)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", src.String(), nil)
+	if err != nil {
+		return false
+	}
+	for key, values := range requestHeader(ctx) {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", "Go-Gather")
+	req.Header.Set("Range", "bytes=0-0")
+
+	traceRequest(o, req)
+	resp, err := c.Do(req)
+	if err != nil {
+		o.Log(ctx, slog.LevelDebug, "range probe failed", "source", src.String(), "error", err)
+		return false
+	}
+	resp.Body.Close()
+
+	// Servers without range support send the whole file instead of partial content
+	var supported bool
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		start, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != 0 {
+			o.Log(ctx, slog.LevelDebug, "range probe got an invalid range", "source", src.String(), "range", resp.Header.Get("Content-Range"))
+			return false
+		}
+		supported = true
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("Accept-Ranges") == "":
+		supported = false
+	default:
+		o.Log(ctx, slog.LevelDebug, "range probe was inconclusive", "source", src.String(), "status", resp.StatusCode)
+		return false
+	}
+	rangeSupport.Store(key, supported)
+	o.Log(ctx, slog.LevelDebug, "probed range support", "host", src.Host, "supported", supported)
+	return supported
+}
+
+// withRangeSupport returns m with the range support found by the range probe.
+func withRangeSupport(m metadata.Metadata, supported bool) metadata.Metadata {
+	if hm, ok := m.(httpMetadata.HTTPMetadata); ok {
+		hm.RangeSupported = supported
+		return hm
+	}
+	return m
+}
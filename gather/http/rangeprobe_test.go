@@ -0,0 +1,98 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	h "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// TestHTTPGatherer_Gather_RangeProbe tests that the range support of a server is probed once
+// per host and reported in the metadata.
+func TestHTTPGatherer_Gather_RangeProbe(t *testing.T) {
+	server, ranges := newRangeServer(t, "hello world")
+	gatherer := NewHTTPGatherer()
+
+	for i := 0; i < 2; i++ {
+		m, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"), gogather.WithRangeProbe(true))
+		assert.NoError(t, err)
+		assert.True(t, m.(http.HTTPMetadata).SupportsRange())
+	}
+	assert.Equal(t, []string{"bytes=0-0", "", ""}, *ranges)
+
+	// Without the probe, nothing is reported
+	m, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"))
+	assert.NoError(t, err)
+	assert.False(t, m.(http.HTTPMetadata).SupportsRange())
+}
+
+// TestHTTPGatherer_Gather_RangeProbeUnsupported tests that a partial download from a server
+// without range support is restarted from scratch.
+func TestHTTPGatherer_Gather_RangeProbeUnsupported(t *testing.T) {
+	var ranges []string
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		_, _ = w.Write([]byte("hello world"))
+	}))
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "foo.txt")
+	assert.NoError(t, os.WriteFile(destination, []byte("hello "), 0600))
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination, gogather.WithHTTPResume(true), gogather.WithRangeProbe(true))
+	assert.NoError(t, err)
+	assert.False(t, m.(http.HTTPMetadata).SupportsRange())
+	assert.Equal(t, h.StatusOK, m.(http.HTTPMetadata).StatusCode)
+	assert.Equal(t, []string{"bytes=0-0", ""}, ranges)
+
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+}
+
+// TestHTTPGatherer_Gather_RangeProbeInconclusive tests that a probe without a definite answer,
+// such as an error response, is not cached, so that the next gather probes again.
+func TestHTTPGatherer_Gather_RangeProbeInconclusive(t *testing.T) {
+	var ranges []string
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			w.WriteHeader(h.StatusServiceUnavailable)
+			return
+		}
+		h.ServeContent(w, r, "foo.txt", rangeModTime, strings.NewReader("hello world"))
+	}))
+	defer server.Close()
+	gatherer := NewHTTPGatherer()
+
+	for _, supported := range []bool{false, true, true} {
+		m, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", filepath.Join(t.TempDir(), "foo.txt"), gogather.WithRangeProbe(true))
+		assert.NoError(t, err)
+		assert.Equal(t, supported, m.(http.HTTPMetadata).SupportsRange())
+	}
+	assert.Equal(t, []string{"bytes=0-0", "", "bytes=0-0", "", ""}, ranges)
+}
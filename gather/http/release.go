@@ -0,0 +1,260 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// githubAPIURL and gitlabAPIURL are the base URLs of the GitHub and GitLab APIs, which tests
+// point to a local server.
+var (
+	githubAPIURL = "https://api.github.com"
+	gitlabAPIURL = "https://gitlab.com/api/v4"
+)
+
+// release is a release asset resolved with the API of its host.
+type release struct {
+	// tag is the tag of the release.
+	tag string
+	// assetID is the ID of the asset.
+	assetID int64
+	// name is the name of the asset.
+	name string
+	// url is the URL the asset is downloaded from.
+	url string
+	// header is sent with the download request.
+	header http.Header
+}
+
+// metadata adds the release tag and asset ID to the metadata of the downloaded asset.
+func (r *release) metadata(m metadata.Metadata) metadata.Metadata {
+	if hm, ok := m.(httpMetadata.HTTPMetadata); ok {
+		hm.ReleaseTag = r.tag
+		hm.ReleaseAssetID = r.assetID
+		return hm
+	}
+	return m
+}
+
+// requestHeaderKey is the context key of the header sent with download requests.
+type requestHeaderKey struct{}
+
+// requestHeaderValue is the header sent with download requests, along with the host of the
+// download URL it is meant for.
+type requestHeaderValue struct {
+	host   string
+	header http.Header
+}
+
+// withRequestHeader returns a context sending header with the download requests of rawURL
+// made with it.
+func withRequestHeader(ctx context.Context, rawURL string, header http.Header) context.Context {
+	var host string
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+	return context.WithValue(ctx, requestHeaderKey{}, requestHeaderValue{host: host, header: header})
+}
+
+// requestHeader returns the header set with withRequestHeader, if any.
+func requestHeader(ctx context.Context) http.Header {
+	v, _ := ctx.Value(requestHeaderKey{}).(requestHeaderValue)
+	return v.header
+}
+
+// requestHeaderFor returns the header set with withRequestHeader for a request of u other
+// than the download itself, or nil when u is on another host than the download, so that
+// credentials such as the token of a release asset are not sent to another server.
+func requestHeaderFor(ctx context.Context, u *url.URL) http.Header {
+	v, _ := ctx.Value(requestHeaderKey{}).(requestHeaderValue)
+	if u.Host != v.host {
+		return nil
+	}
+	return v.header
+}
+
+// githubRelease is the part of a release of the GitHub API that is used.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		ID                 int64  `json:"id"`
+		Name               string `json:"name"`
+		URL                string `json:"url"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// gitlabRelease is the part of a release of the GitLab API that is used.
+type gitlabRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  struct {
+		Links []struct {
+			ID             int64  `json:"id"`
+			Name           string `json:"name"`
+			URL            string `json:"url"`
+			DirectAssetURL string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+// resolveRelease looks the release of the asset up with the API of its host, and returns the
+// asset whose name matches the asset name or pattern.
+func (h *HTTPGatherer) resolveRelease(ctx context.Context, o *gogather.Options, asset gogather.ReleaseAsset) (*release, error) {
+	var names []string
+	var found []*release
+	match := func(r *release) error {
+		ok, err := path.Match(asset.Name, r.name)
+		if err != nil {
+			return fmt.Errorf("invalid asset pattern %q: %w", asset.Name, err)
+		}
+		names = append(names, r.name)
+		if ok {
+			found = append(found, r)
+		}
+		return nil
+	}
+
+	switch asset.Host {
+	case "github.com":
+		endpoint := githubAPIURL + "/repos/" + asset.Project + "/releases/latest"
+		if asset.Tag != "" {
+			endpoint = githubAPIURL + "/repos/" + asset.Project + "/releases/tags/" + url.PathEscape(asset.Tag)
+		}
+		header := http.Header{"Accept": {"application/vnd.github+json"}}
+		if o.GitToken != "" {
+			header.Set("Authorization", "Bearer "+o.GitToken)
+		}
+		var gr githubRelease
+		if err := h.getRelease(ctx, o, endpoint, header, &gr); err != nil {
+			return nil, err
+		}
+		for _, a := range gr.Assets {
+			// Without a token, the browser download URL is not subject to API rate limits
+			r := &release{tag: gr.TagName, assetID: a.ID, name: a.Name, url: a.BrowserDownloadURL}
+			if o.GitToken != "" {
+				r.url = a.URL
+				r.header = http.Header{"Accept": {"application/octet-stream"}, "Authorization": header["Authorization"]}
+			}
+			if err := match(r); err != nil {
+				return nil, err
+			}
+		}
+	case "gitlab.com":
+		endpoint := gitlabAPIURL + "/projects/" + url.PathEscape(asset.Project) + "/releases/permalink/latest"
+		if asset.Tag != "" {
+			endpoint = gitlabAPIURL + "/projects/" + url.PathEscape(asset.Project) + "/releases/" + url.PathEscape(asset.Tag)
+		}
+		header := http.Header{}
+		if o.GitToken != "" {
+			header.Set("PRIVATE-TOKEN", o.GitToken)
+		}
+		var gr gitlabRelease
+		if err := h.getRelease(ctx, o, endpoint, header, &gr); err != nil {
+			return nil, err
+		}
+		for _, l := range gr.Assets.Links {
+			r := &release{tag: gr.TagName, assetID: l.ID, name: l.Name, url: l.DirectAssetURL}
+			if r.url == "" {
+				r.url = l.URL
+			}
+			if err := match(r); err != nil {
+				return nil, err
+			}
+		}
+		// Asset links may point anywhere, so the token is only sent to the API host
+		if len(found) == 1 && o.GitToken != "" && sameHost(found[0].url, gitlabAPIURL) {
+			found[0].header = header
+		}
+	default:
+		return nil, fmt.Errorf("unsupported release host: %s", asset.Host)
+	}
+
+	switch len(found) {
+	case 0:
+		tag := asset.Tag
+		if tag == "" {
+			tag = "latest"
+		}
+		return nil, fmt.Errorf("no asset of release %s of %s matches %s, the assets are: %s", tag, asset.Project, asset.Name, strings.Join(names, ", "))
+	case 1:
+		return found[0], nil
+	default:
+		matching := make([]string, 0, len(found))
+		for _, r := range found {
+			matching = append(matching, r.name)
+		}
+		return nil, fmt.Errorf("several assets of %s match %s: %s", asset.Project, asset.Name, strings.Join(matching, ", "))
+	}
+}
+
+// getRelease gets the release at the API endpoint and decodes it into v.
+func (h *HTTPGatherer) getRelease(ctx context.Context, o *gogather.Options, endpoint string, header http.Header, v any) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("error parsing release URL: %w", err)
+	}
+	if err := o.CheckScheme(u.Scheme); err != nil {
+		return err
+	}
+	if err := o.CheckHost(ctx, u.Host); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header = header.Clone()
+	req.Header.Set("User-Agent", "Go-Gather")
+
+	c := h.client(o)
+	defer h.closeIdle(o, c)
+	traceRequest(o, req)
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("error getting release: %w", o.ResponseError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error getting release: response code error: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(o.LimitReader(resp.Body)).Decode(v); err != nil {
+		return fmt.Errorf("error decoding release: %w", err)
+	}
+	return nil
+}
+
+// sameHost reports whether the URLs a and b have the same host.
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	return err == nil && ua.Host == ub.Host
+}
@@ -0,0 +1,146 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	h "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// newReleaseServer returns a server answering like the GitHub and GitLab APIs for the
+// v1.2.3 release of org/repo, which is also the latest release, and serving its assets.
+// Asset downloads through the GitHub API require the token.
+func newReleaseServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	mux := h.NewServeMux()
+	githubRelease := func(w h.ResponseWriter, r *h.Request) {
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"tag_name": "v1.2.3",
+			"assets": []map[string]any{
+				{"id": 1, "name": "tool-linux.tar.gz", "url": server.URL + "/repos/org/repo/releases/assets/1", "browser_download_url": server.URL + "/download/tool-linux.tar.gz"},
+				{"id": 2, "name": "tool-darwin.tar.gz", "url": server.URL + "/repos/org/repo/releases/assets/2", "browser_download_url": server.URL + "/download/tool-darwin.tar.gz"},
+			},
+		}))
+	}
+	mux.HandleFunc("/repos/org/repo/releases/tags/v1.2.3", githubRelease)
+	mux.HandleFunc("/repos/org/repo/releases/latest", githubRelease)
+	mux.HandleFunc("/repos/org/repo/releases/assets/1", func(w h.ResponseWriter, r *h.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Accept") != "application/octet-stream" {
+			h.Error(w, "not found", h.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "linux tool")
+	})
+	mux.HandleFunc("/download/", func(w h.ResponseWriter, r *h.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		fmt.Fprint(w, "public "+filepath.Base(r.URL.Path))
+	})
+	mux.HandleFunc("/projects/group%2Fproject/releases/v1.2.3", func(w h.ResponseWriter, r *h.Request) {
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"tag_name": "v1.2.3",
+			"assets": map[string]any{"links": []map[string]any{
+				{"id": 7, "name": "asset.zip", "url": server.URL + "/other", "direct_asset_url": server.URL + "/gitlab/asset.zip"},
+			}},
+		}))
+	})
+	mux.HandleFunc("/gitlab/asset.zip", func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "gitlab asset "+r.Header.Get("PRIVATE-TOKEN"))
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+// TestHTTPGatherer_Gather_Release tests that GitHub and GitLab release assets are resolved and downloaded.
+func TestHTTPGatherer_Gather_Release(t *testing.T) {
+	server := newReleaseServer(t)
+	defer server.Close()
+	defer func(github, gitlab string) { githubAPIURL, gitlabAPIURL = github, gitlab }(githubAPIURL, gitlabAPIURL)
+	githubAPIURL, gitlabAPIURL = server.URL, server.URL
+
+	testCases := []struct {
+		name    string
+		source  string
+		opts    []gogather.Option
+		file    string
+		content string
+		assetID int64
+	}{
+		{name: "tag", source: "github.com/org/repo/releases/download/v1.2.3/tool-darwin.tar.gz", file: "tool-darwin.tar.gz", content: "public tool-darwin.tar.gz", assetID: 2},
+		{name: "latest pattern", source: "https://github.com/org/repo/releases/latest/download/tool-linux*", file: "tool-linux.tar.gz", content: "public tool-linux.tar.gz", assetID: 1},
+		{name: "token", source: "github.com/org/repo/releases/download/v1.2.3/tool-linux.tar.gz", opts: []gogather.Option{gogather.WithGitToken("secret")}, file: "tool-linux.tar.gz", content: "linux tool", assetID: 1},
+		{name: "gitlab", source: "gitlab.com/group/project/-/releases/v1.2.3/downloads/asset.zip", opts: []gogather.Option{gogather.WithGitToken("secret")}, file: "asset.zip", content: "gitlab asset secret", assetID: 7},
+	}
+
+	gatherer := NewHTTPGatherer()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			m, err := gatherer.Gather(context.Background(), tc.source, dir, tc.opts...)
+			assert.NoError(t, err)
+
+			hm, ok := m.(http.HTTPMetadata)
+			if !ok {
+				t.Fatalf("unexpected metadata type: %T", m)
+			}
+			assert.Equal(t, "v1.2.3", hm.ReleaseTag)
+			assert.Equal(t, tc.assetID, hm.ReleaseAssetID)
+			content, err := os.ReadFile(filepath.Join(dir, tc.file))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.content, string(content))
+		})
+	}
+}
+
+// TestHTTPGatherer_Gather_ReleaseNoMatch tests that patterns matching no or several assets are refused.
+func TestHTTPGatherer_Gather_ReleaseNoMatch(t *testing.T) {
+	server := newReleaseServer(t)
+	defer server.Close()
+	defer func(github string) { githubAPIURL = github }(githubAPIURL)
+	githubAPIURL = server.URL
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), "github.com/org/repo/releases/latest/download/tool-windows.zip", t.TempDir())
+	assert.ErrorContains(t, err, "no asset of release latest of org/repo matches tool-windows.zip")
+	_, err = gatherer.Gather(context.Background(), "github.com/org/repo/releases/latest/download/tool-*", t.TempDir())
+	assert.ErrorContains(t, err, "several assets of org/repo match tool-*")
+	_, err = gatherer.Gather(context.Background(), "github.com/org/repo/releases/download/v9.9.9/tool-linux.tar.gz", t.TempDir())
+	assert.ErrorContains(t, err, "response code error: 404")
+}
+
+// TestHTTPGatherer_DestinationName_Release tests that release assets are named after the asset.
+func TestHTTPGatherer_DestinationName_Release(t *testing.T) {
+	server := newReleaseServer(t)
+	defer server.Close()
+	defer func(github string) { githubAPIURL = github }(githubAPIURL)
+	githubAPIURL = server.URL
+
+	gatherer := NewHTTPGatherer()
+	name, err := gatherer.DestinationName(context.Background(), "github.com/org/repo/releases/latest/download/*-darwin.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, "tool-darwin.tar.gz", name)
+}
@@ -0,0 +1,218 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	httpMetadata "github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// resumeOffset returns the size of the partial file at destination the download of the file
+// called name is resumed from, or 0 when the download starts from scratch, along with the
+// modification time of the partial file, sent in the If-Range header.
+func resumeOffset(o *gogather.Options, name, destination string) (int64, time.Time) {
+	if !o.HTTPResume || o.Paginate != nil || o.Destination != nil || o.Decompress || o.SniffCompression || gunzipFile(o, name) || o.Extract || o.HashOnly != "" || name == "" {
+		return 0, time.Time{}
+	}
+	info, err := os.Stat(localPath(destination))
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, time.Time{}
+	}
+	return info.Size(), info.ModTime()
+}
+
+// lastModified returns the time of the Last-Modified header of resp when downloads are
+// resumed, or the zero time. The downloaded file is given this modification time, so that
+// the server can tell with the If-Range header of a later resume whether the remote file
+// changed since the partial file was written.
+func lastModified(o *gogather.Options, resp *http.Response) time.Time {
+	if !o.HTTPResume {
+		return time.Time{}
+	}
+	modTime, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return modTime
+}
+
+// setModTime sets the modification time of the file at path to modTime, unless it is zero.
+// A file without it is not resumed, since its own modification time matches no Last-Modified
+// header.
+func setModTime(path string, modTime time.Time) {
+	if !modTime.IsZero() {
+		_ = os.Chtimes(path, modTime, modTime)
+	}
+}
+
+// appendPartial appends the body of a partial content response to the partial file at
+// destination. The partial file is hashed first, so that the whole file is verified against
+// the checksum.
+func (h *HTTPGatherer) appendPartial(o *gogather.Options, resp *http.Response, body io.Reader, offset int64, destination string, verifier *gogather.ChecksumVerifier, sig *signature) (metadata.Metadata, error) {
+	start, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+	if start != offset {
+		return nil, fmt.Errorf("server resumed the download at byte %d instead of %d", start, offset)
+	}
+
+	path := localPath(destination)
+	if err := o.CheckSymlink(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_APPEND, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error opening partial file: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(io.Discard, verifier.Reader(f)); err != nil {
+		return nil, fmt.Errorf("error reading partial file: %w", err)
+	}
+	_, err = io.Copy(f, o.BufferReader(body))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	// The partial file is kept with the time of the remote file, even when the download is
+	// interrupted again
+	setModTime(path, lastModified(o, resp))
+	if err != nil {
+		return nil, fmt.Errorf("error saving file: %w", err)
+	}
+
+	// Verify the checksum and signature, if any, of the whole file before transforming it
+	if err := verifier.Verify(); err != nil {
+		return nil, o.DiscardPartial(path, err)
+	}
+	if err := sig.Verify(path); err != nil {
+		return nil, o.DiscardPartial(path, err)
+	}
+	if err := o.TransformFile(path); err != nil {
+		return nil, o.DiscardPartial(path, err)
+	}
+	o.RecordChecksum(path)
+	return fileMetadata(resp, destination)
+}
+
+// rangeNotSatisfiable handles the rejection of the range of a resumed download. The partial
+// file is kept as the complete file if it matches the checksum. Otherwise, for example because
+// the remote file shrank or there is no checksum to tell, it is removed and the download
+// restarted from scratch. A partial file kept as complete is not transformed, since it may be
+// the result of an earlier, complete gather, but it is verified against the signature, if any.
+func (h *HTTPGatherer) rangeNotSatisfiable(ctx context.Context, o *gogather.Options, opts []gogather.Option, src *url.URL, root, name, destination string, resp *http.Response, offset int64, sig *signature) (metadata.Metadata, error) {
+	complete, err := partialComplete(o, destination)
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		if err := sig.Verify(localPath(destination)); err != nil {
+			return nil, o.DiscardPartial(localPath(destination), err)
+		}
+		o.Log(ctx, slog.LevelInfo, "partial download is already complete", "source", src.String(), "destination", destination)
+		return fileMetadata(resp, destination)
+	}
+
+	o.Log(ctx, slog.LevelInfo, "partial download cannot be resumed, restarting", "source", src.String(), "destination", destination)
+	if err := os.Remove(localPath(destination)); err != nil {
+		return nil, fmt.Errorf("error removing partial file: %w", err)
+	}
+	return h.download(ctx, o, opts, src, root, name, destination)
+}
+
+// partialComplete reports whether the partial file at destination is the complete file,
+// according to the checksum. Without a checksum it is not taken as complete, since a file of
+// the same size may still differ from the remote one.
+func partialComplete(o *gogather.Options, destination string) (bool, error) {
+	if o.Checksum == "" {
+		return false, nil
+	}
+	err := o.VerifyFile(localPath(destination))
+	if err != nil && !errors.Is(err, gogather.ErrChecksumMismatch) {
+		return false, fmt.Errorf("error verifying partial file: %w", err)
+	}
+	return err == nil, nil
+}
+
+// parseContentRange parses a Content-Range header of the form "bytes start-end/total" or
+// "bytes */total". The start is -1 for unsatisfied ranges and the total is -1 when unknown.
+func parseContentRange(header string) (start, total int64, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	rng, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+
+	start, total = -1, -1
+	if rng != "*" {
+		first, _, ok := strings.Cut(rng, "-")
+		if !ok {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+		}
+		if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", header, err)
+		}
+	}
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", header, err)
+		}
+	}
+	return start, total, nil
+}
+
+// fileMetadata returns the metadata of the file at destination, gathered with resp.
+func fileMetadata(resp *http.Response, destination string) (metadata.Metadata, error) {
+	f, err := os.Open(filepath.Clean(localPath(destination)))
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error getting file info: %w", err)
+	}
+	detectedType, _, err := gogather.DetectContentType(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return httpMetadata.HTTPMetadata{
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		Destination:   destination,
+		Headers:       resp.Header,
+		ContentType:   resp.Header.Get("Content-Type"),
+		DetectedType:  detectedType,
+		BytesWritten:  info.Size(),
+	}, nil
+}
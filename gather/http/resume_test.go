@@ -0,0 +1,214 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	h "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata/http"
+)
+
+// rangeModTime is the Last-Modified time of the content served by newRangeServer.
+var rangeModTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// newRangeServer returns a server serving content last modified at rangeModTime with range
+// support, and records the Range header of every request.
+func newRangeServer(t *testing.T, content string) (*httptest.Server, *[]string) {
+	var ranges []string
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		h.ServeContent(w, r, "foo.txt", rangeModTime, strings.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, &ranges
+}
+
+// writePartial writes a partial download of content to destination, as written by a gather
+// of the file served by newRangeServer.
+func writePartial(t *testing.T, destination, content string) {
+	assert.NoError(t, os.WriteFile(destination, []byte(content), 0600))
+	assert.NoError(t, os.Chtimes(destination, rangeModTime, rangeModTime))
+}
+
+// TestHTTPGatherer_Gather_Resume tests that a partial download is completed with a range request.
+func TestHTTPGatherer_Gather_Resume(t *testing.T) {
+	server, ranges := newRangeServer(t, "hello world")
+	destination := filepath.Join(t.TempDir(), "foo.txt")
+	writePartial(t, destination, "hello ")
+
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination,
+		gogather.WithHTTPResume(true), gogather.WithChecksum(sha256Hex("hello world")))
+	assert.NoError(t, err)
+	assert.Equal(t, h.StatusPartialContent, m.(http.HTTPMetadata).StatusCode)
+	assert.Equal(t, []string{"bytes=6-"}, *ranges)
+
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+	info, err := os.Stat(destination)
+	assert.NoError(t, err)
+	assert.True(t, rangeModTime.Equal(info.ModTime()))
+}
+
+// TestHTTPGatherer_Gather_ResumeChanged tests that a partial download of a remote file that
+// changed since is replaced by the whole file.
+func TestHTTPGatherer_Gather_ResumeChanged(t *testing.T) {
+	testCases := []struct {
+		name    string
+		modTime time.Time
+	}{
+		{name: "older partial", modTime: rangeModTime.Add(-time.Hour)},
+		{name: "partial without remote time", modTime: time.Now()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, ranges := newRangeServer(t, "hello world")
+			destination := filepath.Join(t.TempDir(), "foo.txt")
+			assert.NoError(t, os.WriteFile(destination, []byte("HELLO "), 0600))
+			assert.NoError(t, os.Chtimes(destination, tc.modTime, tc.modTime))
+
+			gatherer := NewHTTPGatherer()
+			m, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination, gogather.WithHTTPResume(true))
+			assert.NoError(t, err)
+			assert.Equal(t, h.StatusOK, m.(http.HTTPMetadata).StatusCode)
+			assert.Equal(t, []string{"bytes=6-"}, *ranges)
+
+			content, err := os.ReadFile(destination)
+			assert.NoError(t, err)
+			assert.Equal(t, "hello world", string(content))
+		})
+	}
+}
+
+// TestHTTPGatherer_Gather_ResumeRangeNotSatisfiable tests the handling of a rejected range.
+func TestHTTPGatherer_Gather_ResumeRangeNotSatisfiable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		partial  string
+		opts     []gogather.Option
+		requests []string
+	}{
+		{name: "complete without checksum", partial: "hello world", requests: []string{"bytes=11-", ""}},
+		{name: "complete with checksum", partial: "hello world", opts: []gogather.Option{gogather.WithChecksum(sha256Hex("hello world"))}, requests: []string{"bytes=11-"}},
+		{name: "remote shrank", partial: "hello world, again", requests: []string{"bytes=18-", ""}},
+		{name: "checksum mismatch", partial: "hello WORLD", opts: []gogather.Option{gogather.WithChecksum(sha256Hex("hello world"))}, requests: []string{"bytes=11-", ""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, ranges := newRangeServer(t, "hello world")
+			destination := filepath.Join(t.TempDir(), "foo.txt")
+			writePartial(t, destination, tc.partial)
+
+			gatherer := NewHTTPGatherer()
+			_, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination, append(tc.opts, gogather.WithHTTPResume(true))...)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.requests, *ranges)
+
+			content, err := os.ReadFile(destination)
+			assert.NoError(t, err)
+			assert.Equal(t, "hello world", string(content))
+		})
+	}
+}
+
+// TestHTTPGatherer_Gather_ResumeIgnored tests that the partial file is replaced when the server ignores the range.
+func TestHTTPGatherer_Gather_ResumeIgnored(t *testing.T) {
+	server := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = w.Write([]byte("hello world"))
+	}))
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "foo.txt")
+	assert.NoError(t, os.WriteFile(destination, []byte("stale"), 0600))
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination, gogather.WithHTTPResume(true))
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+}
+
+// TestParseContentRange tests the parsing of Content-Range headers.
+func TestParseContentRange(t *testing.T) {
+	testCases := []struct {
+		header string
+		start  int64
+		total  int64
+		err    bool
+	}{
+		{header: "bytes 6-10/11", start: 6, total: 11},
+		{header: "bytes */11", start: -1, total: 11},
+		{header: "bytes 0-99/*", start: 0, total: -1},
+		{header: "items 0-1/2", err: true},
+		{header: "bytes 6/11", err: true},
+		{header: "", err: true},
+	}
+
+	for _, tc := range testCases {
+		start, total, err := parseContentRange(tc.header)
+		if tc.err {
+			assert.Error(t, err, tc.header)
+			continue
+		}
+		assert.NoError(t, err, tc.header)
+		assert.Equal(t, tc.start, start, tc.header)
+		assert.Equal(t, tc.total, total, tc.header)
+	}
+}
+
+// TestHTTPGatherer_Gather_ResumeChecksumCache tests that a complete file recorded in the
+// checksum cache by its download is not hashed again when the download is resumed.
+func TestHTTPGatherer_Gather_ResumeChecksumCache(t *testing.T) {
+	server, ranges := newRangeServer(t, "hello world")
+	dir := t.TempDir()
+	destination := filepath.Join(dir, "foo.txt")
+	opts := []gogather.Option{
+		gogather.WithHTTPResume(true),
+		gogather.WithChecksum("sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"),
+		gogather.WithChecksumCache(filepath.Join(dir, "cache")),
+	}
+
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination, opts...)
+	assert.NoError(t, err)
+	info, err := os.Stat(destination)
+	assert.NoError(t, err)
+
+	// Rewriting the file with the same size and time goes unnoticed, proving it is not hashed
+	assert.NoError(t, os.WriteFile(destination, []byte("HELLO WORLD"), 0600))
+	assert.NoError(t, os.Chtimes(destination, info.ModTime(), info.ModTime()))
+	_, err = gatherer.Gather(context.Background(), server.URL+"/foo.txt", destination, opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "bytes=11-"}, *ranges)
+	content, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO WORLD", string(content))
+}
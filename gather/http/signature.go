@@ -0,0 +1,257 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/blake2b"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// maxSignatureSize is the largest detached signature that is downloaded.
+const maxSignatureSize = 64 << 10
+
+// minisignUntrustedComment starts the first line of minisign keys and signatures.
+const minisignUntrustedComment = "untrusted comment:"
+
+// signature is the detached signature gathered files are verified with. A nil signature
+// verifies any file.
+type signature struct {
+	url    string
+	verify func(signed io.Reader) error
+}
+
+// fetchSignature downloads the detached signature set with WithSignatureFromURL, resolved
+// against src, and parses it along with the public key. It returns nil if no signature is set.
+func (h *HTTPGatherer) fetchSignature(ctx context.Context, o *gogather.Options, c *http.Client, src *url.URL) (*signature, error) {
+	if o.SignatureURL == "" {
+		return nil, nil
+	}
+	switch {
+	case o.Extract, o.Decompress, o.SniffCompression, gunzipFile(o, urlFileName(src)):
+		return nil, errors.New("signatures cannot be verified when extracting or decompressing")
+	case o.HashOnly != "":
+		return nil, errors.New("signatures cannot be verified in hash-only mode")
+	case o.Destination != nil:
+		return nil, errors.New("signatures cannot be verified with a custom destination")
+	case o.Manifest, o.ManifestURL != "", o.HTTPAutoIndex:
+		return nil, errors.New("signatures cannot be verified for manifests or directory listings")
+	}
+
+	sigURL, err := src.Parse(o.SignatureURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing signature URL: %w", err)
+	}
+	if err := o.CheckScheme(sigURL.Scheme); err != nil {
+		return nil, err
+	}
+	if err := o.CheckHost(ctx, sigURL.Host); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", sigURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	for key, values := range requestHeaderFor(ctx, sigURL) {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", "Go-Gather")
+
+	traceRequest(o, req)
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading signature: %w", o.ResponseError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response code error for %s: %d", sigURL, resp.StatusCode)
+	}
+	sig, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading signature: %w", err)
+	}
+	if len(sig) > maxSignatureSize {
+		return nil, fmt.Errorf("signature %s is larger than %d bytes", sigURL, maxSignatureSize)
+	}
+
+	verify, err := parseSignature(sig, o.SignaturePublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &signature{url: sigURL.String(), verify: verify}, nil
+}
+
+// Verify returns an error wrapping ErrSignatureInvalid if the file at path does not match the signature.
+func (s *signature) Verify(path string) error {
+	if s == nil {
+		return nil
+	}
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("error opening file to verify: %w", err)
+	}
+	defer f.Close()
+	if err := s.verify(f); err != nil {
+		return fmt.Errorf("%w: %s does not match %s: %v", gogather.ErrSignatureInvalid, path, s.url, err)
+	}
+	return nil
+}
+
+// parseSignature returns a function verifying signed data against the minisign or GPG
+// detached signature sig, made with the private key of publicKey.
+func parseSignature(sig, publicKey []byte) (func(signed io.Reader) error, error) {
+	if bytes.HasPrefix(sig, []byte(minisignUntrustedComment)) {
+		return parseMinisign(sig, publicKey)
+	}
+	return parseGPG(sig, publicKey)
+}
+
+// parseGPG returns a function verifying signed data against the armored or binary GPG
+// signature sig, made by one of the keys in the armored or binary key ring publicKey.
+func parseGPG(sig, publicKey []byte) (func(signed io.Reader) error, error) {
+	var keyring openpgp.EntityList
+	var err error
+	if bytes.Contains(publicKey, []byte("-----BEGIN PGP")) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(publicKey))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(publicKey))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading GPG public key: %w", err)
+	}
+
+	armored := bytes.Contains(sig, []byte("-----BEGIN PGP SIGNATURE"))
+	return func(signed io.Reader) error {
+		var err error
+		if armored {
+			_, err = openpgp.CheckArmoredDetachedSignature(keyring, signed, bytes.NewReader(sig), nil)
+		} else {
+			_, err = openpgp.CheckDetachedSignature(keyring, signed, bytes.NewReader(sig), nil)
+		}
+		return err
+	}, nil
+}
+
+// parseMinisign returns a function verifying signed data against the minisign signature sig,
+// made with the private key of the minisign public key publicKey. Both legacy signatures of
+// the data and signatures of its BLAKE2b-512 hash are supported.
+func parseMinisign(sig, publicKey []byte) (func(signed io.Reader) error, error) {
+	key, err := minisignLine(publicKey, 42)
+	if err != nil {
+		return nil, fmt.Errorf("error reading minisign public key: %w", err)
+	}
+	if string(key[:2]) != "Ed" {
+		return nil, errors.New("error reading minisign public key: not an Ed25519 key")
+	}
+	keyID, pub := key[2:10], ed25519.PublicKey(key[10:])
+
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return nil, fmt.Errorf("%w: malformed minisign signature", gogather.ErrSignatureInvalid)
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sigBytes) != 74 {
+		return nil, fmt.Errorf("%w: malformed minisign signature", gogather.ErrSignatureInvalid)
+	}
+	trustedComment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return nil, fmt.Errorf("%w: minisign signature without a trusted comment", gogather.ErrSignatureInvalid)
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: malformed minisign signature", gogather.ErrSignatureInvalid)
+	}
+
+	algorithm, sigKeyID, dataSig := string(sigBytes[:2]), sigBytes[2:10], sigBytes[10:]
+	if !bytes.Equal(sigKeyID, keyID) {
+		return nil, fmt.Errorf("%w: signed with the minisign key %X instead of %X", gogather.ErrSignatureInvalid, reverse(sigKeyID), reverse(keyID))
+	}
+	if algorithm != "Ed" && algorithm != "ED" {
+		return nil, fmt.Errorf("%w: unsupported minisign algorithm %q", gogather.ErrSignatureInvalid, algorithm)
+	}
+
+	return func(signed io.Reader) error {
+		var message []byte
+		if algorithm == "ED" {
+			hash, err := blake2b.New512(nil)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(hash, signed); err != nil {
+				return fmt.Errorf("error reading file: %w", err)
+			}
+			message = hash.Sum(nil)
+		} else {
+			if message, err = io.ReadAll(signed); err != nil {
+				return fmt.Errorf("error reading file: %w", err)
+			}
+		}
+		if !ed25519.Verify(pub, message, dataSig) {
+			return errors.New("minisign signature verification failed")
+		}
+		if !ed25519.Verify(pub, append(append([]byte{}, dataSig...), trustedComment...), globalSig) {
+			return errors.New("minisign trusted comment verification failed")
+		}
+		return nil
+	}, nil
+}
+
+// minisignLine decodes the base64 line of a minisign key, skipping its untrusted comment,
+// and checks that it is size bytes long.
+func minisignLine(data []byte, size int) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, minisignUntrustedComment) {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, err
+		}
+		if len(decoded) != size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", size, len(decoded))
+		}
+		return decoded, nil
+	}
+	return nil, errors.New("no key found")
+}
+
+// reverse returns a reversed copy of b, as minisign key IDs are little-endian.
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
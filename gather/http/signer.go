@@ -0,0 +1,58 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	gogather "github.com/enterprise-contract/go-gather"
+)
+
+// signingTransport signs every request with sign before sending it with next. Signing at the
+// transport, rather than where requests are built, also covers the requests of redirects.
+type signingTransport struct {
+	next http.RoundTripper
+	sign gogather.RequestSigner
+}
+
+// RoundTrip signs a copy of req, so that the request of the caller is left as it is, and sends it.
+func (t signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	if err := t.sign(signed); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+	return t.next.RoundTrip(signed)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport.
+func (t signingTransport) CloseIdleConnections() {
+	if ci, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// unwrapSigning returns the transport rt signs requests for, or rt if it does not sign them.
+func unwrapSigning(rt http.RoundTripper) http.RoundTripper {
+	if t, ok := rt.(signingTransport); ok {
+		return t.next
+	}
+	return rt
+}
@@ -0,0 +1,83 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	h "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// TestHTTPGatherer_Gather_RequestSigner tests that every request, including the redirect, is signed.
+func TestHTTPGatherer_Gather_RequestSigner(t *testing.T) {
+	var signed atomic.Int32
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.Header.Get("Authorization") != "signed "+r.URL.Path {
+			w.WriteHeader(h.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/foo.bar" {
+			h.Redirect(w, r, "/other.bar", h.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "Hello, World!")
+	}))
+	defer mockServer.Close()
+
+	sign := func(req *h.Request) error {
+		signed.Add(1)
+		req.Header.Set("Authorization", "signed "+req.URL.Path)
+		return nil
+	}
+
+	dir := t.TempDir()
+	gatherer := NewHTTPGatherer()
+	m, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.bar", filepath.Join(dir, "out.txt"), gogather.WithRequestSigner(sign))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), signed.Load())
+	assert.Equal(t, metadata.AuthRequestSigner, m.AuthMethod())
+
+	content, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, World!", string(content))
+}
+
+// TestHTTPGatherer_Gather_RequestSignerError tests that an error of the signer fails the gather.
+func TestHTTPGatherer_Gather_RequestSignerError(t *testing.T) {
+	mockServer := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	}))
+	defer mockServer.Close()
+
+	errNoCredentials := errors.New("no credentials")
+	gatherer := NewHTTPGatherer()
+	_, err := gatherer.Gather(context.Background(), mockServer.URL+"/foo.bar", t.TempDir(), gogather.WithRequestSigner(func(*h.Request) error {
+		return errNoCredentials
+	}))
+	assert.ErrorIs(t, err, errNoCredentials)
+}
@@ -0,0 +1,252 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// serviceAccountDir holds the credentials mounted into pods for their service account.
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Config describes how to reach and authenticate to a Kubernetes API server.
+type Config struct {
+	// Server is the URL of the API server, e.g. https://10.0.0.1:443.
+	Server string
+	// Token is a bearer token, if any.
+	Token string
+	// CAData is the PEM encoded certificate authority of the server, if any.
+	CAData []byte
+	// CertData and KeyData are the PEM encoded client certificate and key, if any.
+	CertData []byte
+	KeyData  []byte
+	// Insecure skips the verification of the server certificate.
+	Insecure bool
+}
+
+// LoadConfig returns the in-cluster configuration when running in a pod, and otherwise the
+// current context of the kubeconfig file named by $KUBECONFIG or ~/.kube/config.
+func LoadConfig() (*Config, error) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return InClusterConfig()
+	}
+	return KubeconfigConfig()
+}
+
+// InClusterConfig returns the configuration of the service account of the current pod.
+func InClusterConfig() (*Config, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return &Config{
+		Server: "https://" + host + ":" + port,
+		Token:  strings.TrimSpace(string(token)),
+		CAData: ca,
+	}, nil
+}
+
+// kubeconfig is the subset of the kubeconfig file format used by KubeconfigConfig.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// KubeconfigConfig returns the configuration of the current context of the kubeconfig file
+// named by $KUBECONFIG, or ~/.kube/config. Only the first file of $KUBECONFIG is read, and
+// only token and client certificate credentials are supported; exec and auth provider
+// plugins are not.
+func KubeconfigConfig() (*Config, error) {
+	path := strings.Split(os.Getenv("KUBECONFIG"), string(os.PathListSeparator))[0]
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate kubeconfig: %w", err)
+		}
+		path = filepath.Join(home, ".kube", "config")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+
+	// Relative file references are relative to the kubeconfig file
+	dir := filepath.Dir(path)
+	readFile := func(name string) ([]byte, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		return os.ReadFile(name)
+	}
+
+	c := &Config{}
+	for _, ctx := range kc.Contexts {
+		if ctx.Name != kc.CurrentContext {
+			continue
+		}
+		for _, cluster := range kc.Clusters {
+			if cluster.Name != ctx.Context.Cluster {
+				continue
+			}
+			c.Server = cluster.Cluster.Server
+			c.Insecure = cluster.Cluster.InsecureSkipTLSVerify
+			if c.CAData, err = inlineOrFile(cluster.Cluster.CertificateAuthorityData, cluster.Cluster.CertificateAuthority, readFile); err != nil {
+				return nil, fmt.Errorf("failed to read certificate authority: %w", err)
+			}
+		}
+		for _, user := range kc.Users {
+			if user.Name != ctx.Context.User {
+				continue
+			}
+			c.Token = user.User.Token
+			if c.Token == "" && user.User.TokenFile != "" {
+				token, err := readFile(user.User.TokenFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read token file: %w", err)
+				}
+				c.Token = strings.TrimSpace(string(token))
+			}
+			if c.CertData, err = inlineOrFile(user.User.ClientCertificateData, user.User.ClientCertificate, readFile); err != nil {
+				return nil, fmt.Errorf("failed to read client certificate: %w", err)
+			}
+			if c.KeyData, err = inlineOrFile(user.User.ClientKeyData, user.User.ClientKey, readFile); err != nil {
+				return nil, fmt.Errorf("failed to read client key: %w", err)
+			}
+		}
+	}
+	if c.Server == "" {
+		return nil, fmt.Errorf("no cluster found for context %q in %s", kc.CurrentContext, path)
+	}
+	return c, nil
+}
+
+// inlineOrFile returns the base64 decoded data, or the contents of the file if there is no data.
+func inlineOrFile(data, file string, readFile func(string) ([]byte, error)) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return readFile(file)
+	}
+	return nil, nil
+}
+
+// client returns an http.Client configured for c, using the transport of the options as a base.
+// The transports shared with WithClientCache are not used, since the TLS settings of c are
+// specific to the cluster.
+func (c *Config) client(o *gogather.Options) (*http.Client, error) {
+	base := o.NewTransport()
+	if o.HTTPTransport != nil {
+		t, ok := o.HTTPTransport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("the kubernetes gatherer requires an *http.Transport")
+		}
+		base = t
+	}
+	t := base.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.InsecureSkipVerify = c.Insecure // #nosec G402 -- only when the kubeconfig asks for it
+
+	if len(c.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.CAData) {
+			return nil, fmt.Errorf("failed to parse certificate authority")
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	if len(c.CertData) > 0 {
+		cert, err := tls.X509KeyPair(c.CertData, c.KeyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Transport:     t,
+		CheckRedirect: o.CheckRedirect,
+	}, nil
+}
+
+// authMethod returns how requests made with c under o authenticate with the API server: with
+// the bearer token, if any, and otherwise with the client certificate of c or of the options.
+func (c *Config) authMethod(o *gogather.Options) string {
+	switch {
+	case c.Token != "":
+		return metadata.AuthBearer
+	case len(c.CertData) > 0 || len(o.ClientCertificates) > 0:
+		return metadata.AuthClientCertificate
+	default:
+		return metadata.AuthAnonymous
+	}
+}
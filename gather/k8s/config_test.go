@@ -0,0 +1,121 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+func TestKubeconfigConfig(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("from-file\n"), 0600))
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+    certificate-authority-data: Y2EtZGF0YQ==
+contexts:
+- name: prod
+  context: {cluster: prod, user: admin}
+- name: dev
+  context: {cluster: dev, user: developer}
+users:
+- name: admin
+  user: {token: admin-token}
+- name: developer
+  user: {tokenFile: token}
+`), 0600))
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	c, err := KubeconfigConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{Server: "https://dev.example.com:6443", Token: "from-file", CAData: []byte("ca-data")}, c)
+}
+
+func TestKubeconfigConfig_NoContext(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, os.WriteFile(kubeconfig, []byte("current-context: missing\n"), 0600))
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	_, err := KubeconfigConfig()
+	assert.ErrorContains(t, err, `no cluster found for context "missing"`)
+}
+
+func TestInClusterConfig(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("pod-token\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("ca"), 0600))
+	defer func(d string) { serviceAccountDir = d }(serviceAccountDir)
+	serviceAccountDir = dir
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+
+	c, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{Server: "https://10.0.0.1:443", Token: "pod-token", CAData: []byte("ca")}, c)
+}
+
+// TestConfig_AuthMethod tests that the token wins over client certificates.
+func TestConfig_AuthMethod(t *testing.T) {
+	testCases := []struct {
+		config Config
+		opts   []gogather.Option
+		want   string
+	}{
+		{want: metadata.AuthAnonymous},
+		{config: Config{Token: "t", CertData: []byte("cert")}, want: metadata.AuthBearer},
+		{config: Config{CertData: []byte("cert")}, want: metadata.AuthClientCertificate},
+		{opts: []gogather.Option{gogather.WithClientCert(tls.Certificate{})}, want: metadata.AuthClientCertificate},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, tc.config.authMethod(gogather.NewOptions(tc.opts...)))
+	}
+}
+
+// TestConfig_Client tests that the client is built on a dedicated transport, including with
+// the client cache, and that a custom transport must be an *http.Transport.
+func TestConfig_Client(t *testing.T) {
+	c := &Config{Server: "https://dev.example.com:6443", Insecure: true}
+
+	client, err := c.client(gogather.NewOptions(gogather.WithClientCache(time.Minute), gogather.WithMaxConnsPerHost(2)))
+	assert.NoError(t, err)
+	transport, ok := client.Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, 2, transport.MaxConnsPerHost)
+	assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+
+	_, err = c.client(gogather.NewOptions(gogather.WithHTTPTransport(http.NewFileTransport(http.Dir(".")))))
+	assert.ErrorContains(t, err, "requires an *http.Transport")
+}
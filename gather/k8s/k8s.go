@@ -0,0 +1,303 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package k8s provides methods for gathering ConfigMaps and Secrets from Kubernetes.
+// This package implements the Gatherer interface for k8s:// URIs of the form
+//
+//	k8s://<namespace>/configmap/<name>
+//	k8s://<namespace>/secret/<name>
+//
+// Every data key of the object is written as a file in the destination directory.
+// A single key is selected with a //<key> subpath, e.g. k8s://default/configmap/settings//config.yaml.
+//
+// The objects are read through the Kubernetes API with the in-cluster service account when
+// running in a pod, and with the current context of the kubeconfig file otherwise.
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	k8sMetadata "github.com/enterprise-contract/go-gather/metadata/k8s"
+)
+
+// K8sGatherer is a struct that implements the Gatherer interface
+// and provides methods for gathering ConfigMaps and Secrets.
+type K8sGatherer struct {
+	// Config, when set, is used instead of the in-cluster or kubeconfig configuration.
+	Config *Config
+}
+
+// source is a parsed k8s:// URI.
+type source struct {
+	namespace string
+	kind      string
+	name      string
+	key       string
+}
+
+// object holds the fields of a ConfigMap or Secret used by the gatherer.
+// The API encodes []byte values in base64, which encoding/json decodes.
+type object struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Data       map[string]json.RawMessage `json:"data"`
+	BinaryData map[string][]byte          `json:"binaryData"`
+}
+
+// Gather reads the ConfigMap or Secret named by source and writes its data keys to destination.
+func (k *K8sGatherer) Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (m metadata.Metadata, err error) {
+	o := gogather.NewOptions(opts...)
+	o.Emit(gogather.Event{Phase: gogather.EventStarted, Source: source})
+	defer func() { o.Emit(gogather.Event{Phase: gogather.EventDone, Source: source, Err: err}) }()
+	if err := o.CheckDestination(); err != nil {
+		return nil, err
+	}
+	sw := metadata.NewStopwatch()
+	ctx = sw.WithContext(ctx)
+
+	src, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := k.Config
+	if cfg == nil {
+		if cfg, err = LoadConfig(); err != nil {
+			return nil, fmt.Errorf("failed to load kubernetes configuration: %w", err)
+		}
+	}
+
+	obj, err := fetch(ctx, o, cfg, src)
+	if err != nil {
+		return nil, err
+	}
+	data, err := obj.values(src.kind)
+	if err != nil {
+		return nil, err
+	}
+
+	// Select a single key, if asked to
+	if src.key != "" {
+		value, ok := data[src.key]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in %s %s/%s", src.key, src.kind, src.namespace, src.name)
+		}
+		data = map[string][]byte{src.key: value}
+		if strings.HasSuffix(destination, "/") || filepath.Ext(destination) == "" {
+			destination = filepath.Join(destination, src.key)
+		}
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := destination
+		if src.key == "" {
+			path = filepath.Join(destination, key)
+		}
+		if err := write(o, src.kind, path, data[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	return k8sMetadata.K8sMetadata{
+		Namespace:       src.namespace,
+		Kind:            src.kind,
+		Name:            src.name,
+		ResourceVersion: obj.Metadata.ResourceVersion,
+		Path:            destination,
+		Keys:            keys,
+		Auth:            cfg.authMethod(o),
+		Timing:          sw.Timings(),
+	}, nil
+}
+
+// parseSource parses a k8s://<namespace>/<kind>/<name>[//<key>] URI.
+func parseSource(rawURL string) (source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return source{}, fmt.Errorf("failed to parse source URI: %w", err)
+	}
+	if u.Scheme != "k8s" {
+		return source{}, fmt.Errorf("unsupported source scheme: %s", u.Scheme)
+	}
+
+	p, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "//")
+	kind, name, ok := strings.Cut(p, "/")
+	if u.Host == "" || !ok || name == "" || strings.Contains(name, "/") {
+		return source{}, fmt.Errorf("invalid kubernetes URI %s: expected k8s://<namespace>/<configmap|secret>/<name>", rawURL)
+	}
+	if kind != "configmap" && kind != "secret" {
+		return source{}, fmt.Errorf("unsupported kubernetes kind %s: expected configmap or secret", kind)
+	}
+	if key != "" && !validKey(key) {
+		return source{}, fmt.Errorf("invalid key %q", key)
+	}
+	return source{namespace: u.Host, kind: kind, name: name, key: key}, nil
+}
+
+// fetch reads the object named by src from the API server.
+func fetch(ctx context.Context, o *gogather.Options, cfg *Config, src source) (*object, error) {
+	server, err := url.Parse(cfg.Server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server URL: %w", err)
+	}
+	if err := o.CheckScheme(server.Scheme); err != nil {
+		return nil, err
+	}
+	if err := o.CheckHost(ctx, server.Host); err != nil {
+		return nil, err
+	}
+
+	client, err := cfg.client(o)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloseIdleConnections()
+
+	endpoint := server.JoinPath("api", "v1", "namespaces", src.namespace, src.kind+"s", src.name)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Go-Gather")
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s %s/%s: %w", src.kind, src.namespace, src.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(body, &status) == nil && status.Message != "" {
+			return nil, fmt.Errorf("response code error: %d: %s", resp.StatusCode, status.Message)
+		}
+		return nil, fmt.Errorf("response code error: %d", resp.StatusCode)
+	}
+
+	obj := &object{}
+	if err := json.NewDecoder(resp.Body).Decode(obj); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", src.kind, err)
+	}
+	return obj, nil
+}
+
+// values returns the data keys of the object. ConfigMap data is plain text, while Secret
+// data, like ConfigMap binaryData, is base64 encoded.
+func (obj *object) values(kind string) (map[string][]byte, error) {
+	data := make(map[string][]byte, len(obj.Data)+len(obj.BinaryData))
+	for key, raw := range obj.Data {
+		if kind == "secret" {
+			var value []byte
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("error decoding key %s: %w", key, err)
+			}
+			data[key] = value
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("error decoding key %s: %w", key, err)
+		}
+		data[key] = []byte(value)
+	}
+	for key, value := range obj.BinaryData {
+		data[key] = value
+	}
+
+	for key := range data {
+		if !validKey(key) {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+	}
+	return data, nil
+}
+
+// validKey reports whether key is a valid ConfigMap or Secret key, which also makes it a
+// safe file name.
+func validKey(key string) bool {
+	if key == "" || key == "." || key == ".." {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// write writes a data key to path. Secret data is only readable by the owner, whatever the
+// file permission of the options, including when path already exists, since os.WriteFile
+// only sets the permission of the files it creates.
+func write(o *gogather.Options, kind, path string, value []byte) error {
+	if err := o.ValidateDestination(path); err != nil {
+		return err
+	}
+	if o.Destination != nil {
+		if _, err := o.WriteDestination(path, bytes.NewReader(value)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		return nil
+	}
+
+	perm := o.FilePermission()
+	if kind == "secret" {
+		perm = o.PreservedPermission(0600)
+	}
+	if err := o.MkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := o.CheckSymlink(path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, value, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if kind == "secret" {
+		if err := os.Chmod(path, perm); err != nil {
+			return fmt.Errorf("failed to change the permission of %s: %w", path, err)
+		}
+	} else if err := o.Chmod(path, perm); err != nil {
+		return err
+	}
+	return o.TransformFile(path)
+}
@@ -0,0 +1,143 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	k8sMetadata "github.com/enterprise-contract/go-gather/metadata/k8s"
+)
+
+// newAPIServer returns a fake API server serving a ConfigMap and a Secret in the default namespace.
+func newAPIServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/configmaps/settings":
+			fmt.Fprint(w, `{"metadata":{"resourceVersion":"7"},"data":{"config.yaml":"a: 1\n","mode":"strict"},"binaryData":{"logo.png":"iVBORw=="}}`)
+		case "/api/v1/namespaces/default/secrets/credentials":
+			fmt.Fprint(w, `{"metadata":{"resourceVersion":"9"},"data":{"password":"aHVudGVyMg=="}}`)
+		case "/api/v1/namespaces/default/configmaps/evil":
+			fmt.Fprint(w, `{"data":{"..":"x"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","message":"configmaps \"missing\" not found"}`)
+		}
+	}))
+}
+
+func TestK8sGatherer_Gather_ConfigMap(t *testing.T) {
+	server := newAPIServer(t)
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "settings")
+
+	gatherer := &K8sGatherer{Config: &Config{Server: server.URL, Token: "s3cr3t"}}
+	m, err := gatherer.Gather(context.Background(), "k8s://default/configmap/settings", destination)
+	assert.NoError(t, err)
+
+	// The timings vary between runs, only check that the request was timed
+	km, ok := m.(k8sMetadata.K8sMetadata)
+	assert.True(t, ok)
+	assert.Positive(t, km.Timings().Total())
+	km.Timing = metadata.Timings{}
+	assert.Equal(t, k8sMetadata.K8sMetadata{
+		Namespace:       "default",
+		Kind:            "configmap",
+		Name:            "settings",
+		ResourceVersion: "7",
+		Path:            destination,
+		Keys:            []string{"config.yaml", "logo.png", "mode"},
+		Auth:            metadata.AuthBearer,
+	}, km)
+
+	content, err := os.ReadFile(filepath.Join(destination, "config.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+	content, err = os.ReadFile(filepath.Join(destination, "logo.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, content)
+}
+
+func TestK8sGatherer_Gather_SecretKey(t *testing.T) {
+	server := newAPIServer(t)
+	defer server.Close()
+	destination := t.TempDir()
+
+	// An existing, readable file is made private as well
+	path := filepath.Join(destination, "password")
+	assert.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	gatherer := &K8sGatherer{Config: &Config{Server: server.URL, Token: "s3cr3t"}}
+	_, err := gatherer.Gather(context.Background(), "k8s://default/secret/credentials//password", destination)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", string(content))
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestK8sGatherer_Gather_Errors(t *testing.T) {
+	server := newAPIServer(t)
+	defer server.Close()
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{source: "k8s://default/configmap/missing", expected: `response code error: 404: configmaps "missing" not found`},
+		{source: "k8s://default/configmap/settings//absent", expected: "key absent not found"},
+		{source: "k8s://default/configmap/evil", expected: `invalid key ".."`},
+		{source: "k8s://default/deployment/app", expected: "unsupported kubernetes kind"},
+		{source: "k8s://default/configmap", expected: "invalid kubernetes URI"},
+		{source: "k8s://default/secret/credentials//../x", expected: "invalid key"},
+	}
+
+	gatherer := &K8sGatherer{Config: &Config{Server: server.URL, Token: "s3cr3t"}}
+	for _, tc := range testCases {
+		_, err := gatherer.Gather(context.Background(), tc.source, t.TempDir())
+		assert.ErrorContains(t, err, tc.expected, tc.source)
+	}
+}
+
+func TestK8sGatherer_Gather_HostNotAllowed(t *testing.T) {
+	gatherer := &K8sGatherer{Config: &Config{Server: "https://kubernetes.example.com"}}
+
+	_, err := gatherer.Gather(context.Background(), "k8s://default/configmap/settings", t.TempDir(), gogather.WithDeniedHosts([]string{"kubernetes.example.com"}))
+
+	assert.ErrorIs(t, err, gogather.ErrHostNotAllowed)
+}
+
+func TestK8sGatherer_Gather_InsecureTransport(t *testing.T) {
+	gatherer := &K8sGatherer{Config: &Config{Server: "http://kubernetes.example.com"}}
+
+	_, err := gatherer.Gather(context.Background(), "k8s://default/configmap/settings", t.TempDir(), gogather.WithInsecureHTTPAllowed(false))
+
+	assert.ErrorIs(t, err, gogather.ErrInsecureTransport)
+}
@@ -0,0 +1,65 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"sync"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// Middleware wraps a Gatherer to handle cross-cutting concerns, such as metrics, logging or
+// authentication, around its Gather calls. It may time the call, change the source or the
+// options passed on, or return without calling the wrapped Gatherer.
+type Middleware func(Gatherer) Gatherer
+
+// GathererFunc is a function used as a Gatherer, typically by a Middleware.
+type GathererFunc func(ctx context.Context, source, destination string, opts ...gogather.Option) (metadata.Metadata, error)
+
+// Gather calls f.
+func (f GathererFunc) Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
+	return f(ctx, source, destination, opts...)
+}
+
+var (
+	middlewaresMu sync.RWMutex
+	middlewares   []Middleware
+)
+
+// Use wraps the gatherers of this package with the middlewares for the gathers started from
+// then on by Gather, GatherAsync and GatherFromFS, and the functions built on them. The
+// middlewares run in the order they are given, after those of earlier calls: the first one
+// receives the Gather call and passes it on to the next one, and the last one to the
+// gatherer. The wrapped gatherers are still used to suggest destination names and are closed
+// by Close.
+func Use(mws ...Middleware) {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+	middlewares = append(middlewares, mws...)
+}
+
+// wrap returns gatherer wrapped with the middlewares of Use.
+func wrap(gatherer Gatherer) Gatherer {
+	middlewaresMu.RLock()
+	defer middlewaresMu.RUnlock()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		gatherer = middlewares[i](gatherer)
+	}
+	return gatherer
+}
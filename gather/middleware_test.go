@@ -0,0 +1,77 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// TestUse tests that the middlewares wrap the gatherers in order, and that they may pass the
+// call on or return without calling the gatherer.
+func TestUse(t *testing.T) {
+	defer func(mws []Middleware) { middlewares = mws }(middlewares)
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Gatherer) Gatherer {
+			return GathererFunc(func(ctx context.Context, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
+				calls = append(calls, name)
+				return next.Gather(ctx, source, destination, opts...)
+			})
+		}
+	}
+	Use(record("first"), record("second"))
+	Use(record("third"))
+
+	source := filepath.Join(t.TempDir(), "foo.txt")
+	if err := os.WriteFile(source, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Gather(context.Background(), source, "file://"+filepath.Join(t.TempDir(), "foo.txt")); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if expected := []string{"first", "second", "third"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected the middlewares to run as %v, but got %v", expected, calls)
+	}
+
+	// A middleware may refuse the gather
+	errRefused := errors.New("refused")
+	Use(func(Gatherer) Gatherer {
+		return GathererFunc(func(ctx context.Context, source, destination string, opts ...gogather.Option) (metadata.Metadata, error) {
+			return nil, errRefused
+		})
+	})
+	destination := filepath.Join(t.TempDir(), "foo.txt")
+	handle, err := GatherAsync(context.Background(), source, destination)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if _, err := handle.Wait(); !errors.Is(err, errRefused) {
+		t.Errorf("expected the middleware error, but got: %v", err)
+	}
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be gathered, but got: %v", err)
+	}
+}
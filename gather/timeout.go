@@ -0,0 +1,52 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is the initial timeout of gathers whose context has no deadline.
+const DefaultTimeout = time.Hour
+
+// defaultTimeout is the timeout set with SetDefaultTimeout.
+var defaultTimeout atomic.Int64
+
+func init() {
+	defaultTimeout.Store(int64(DefaultTimeout))
+}
+
+// SetDefaultTimeout sets the timeout applied to gathers whose context has no deadline, such
+// as context.Background(), so that a stalled server cannot make them hang forever. Contexts
+// with a deadline are left as they are, whether it is shorter or longer. The timeout applies
+// to each source of GatherAll and the other functions gathering several sources, and starts
+// with DefaultTimeout; a timeout of zero or less disables it. It is safe for concurrent use.
+func SetDefaultTimeout(d time.Duration) {
+	defaultTimeout.Store(int64(d))
+}
+
+// withDefaultTimeout returns ctx with the default timeout applied, unless it has a deadline
+// or the default timeout is disabled, and the function releasing its resources.
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	d := time.Duration(defaultTimeout.Load())
+	if _, ok := ctx.Deadline(); ok || d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
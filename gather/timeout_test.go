@@ -0,0 +1,91 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stalledServer returns a server that does not respond until the test ends.
+func stalledServer(t *testing.T) *httptest.Server {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server
+}
+
+// TestGather_DefaultTimeout tests that gathers without a deadline time out after the default timeout.
+func TestGather_DefaultTimeout(t *testing.T) {
+	SetDefaultTimeout(100 * time.Millisecond)
+	defer SetDefaultTimeout(DefaultTimeout)
+
+	server := stalledServer(t)
+	dst := filepath.Join(t.TempDir(), "foo.txt")
+
+	start := time.Now()
+	_, err := Gather(context.Background(), server.URL+"/foo.txt", dst)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, but got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the gather to time out after the default timeout, but it took %s", elapsed)
+	}
+
+	handle, err := GatherAsync(context.Background(), server.URL+"/foo.txt", dst)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if _, err := handle.Wait(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded from GatherAsync, but got: %v", err)
+	}
+}
+
+// TestGather_DefaultTimeoutDeadline tests that the deadline of the context is kept.
+func TestGather_DefaultTimeoutDeadline(t *testing.T) {
+	SetDefaultTimeout(100 * time.Millisecond)
+	defer SetDefaultTimeout(DefaultTimeout)
+
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	got, stop := withDefaultTimeout(ctx)
+	defer stop()
+	if d, ok := got.Deadline(); !ok || !d.Equal(deadline) {
+		t.Errorf("expected the deadline %s to be kept, but got: %s", deadline, d)
+	}
+
+	SetDefaultTimeout(0)
+	got, stop = withDefaultTimeout(context.Background())
+	defer stop()
+	if d, ok := got.Deadline(); ok {
+		t.Errorf("expected no deadline with the default timeout disabled, but got: %s", d)
+	}
+}
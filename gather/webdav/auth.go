@@ -0,0 +1,241 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webdav
+
+import (
+	"crypto/md5" // #nosec G501 -- MD5 is the default algorithm of HTTP digest authentication
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// credentials are the user and password of a source. They are sent with basic or digest
+// authentication, as the server asks for with its first 401 response, and then sent with
+// every request.
+type credentials struct {
+	user     string
+	password string
+
+	mu sync.Mutex
+	// basic is set once the server asked for basic authentication.
+	basic bool
+	// digest is the last digest challenge of the server, if any.
+	digest *digestChallenge
+	// nc counts the requests sent with the nonce of the digest challenge.
+	nc int
+}
+
+// digestChallenge holds the parameters of a Digest WWW-Authenticate challenge.
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+// authorize sets the Authorization header of req for the authentication scheme the server
+// asked for, if any yet.
+func (c *credentials) authorize(req *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.digest != nil:
+		c.nc++
+		cnonce, err := newCnonce()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", c.digest.authorization(c.user, c.password, req.Method, req.URL.RequestURI(), c.nc, cnonce))
+	case c.basic:
+		req.SetBasicAuth(c.user, c.password)
+	}
+	return nil
+}
+
+// method returns the authentication scheme the credentials were sent with, or an empty
+// string if the server never asked for them.
+func (c *credentials) method() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.digest != nil:
+		return metadata.AuthDigest
+	case c.basic:
+		return metadata.AuthBasic
+	default:
+		return ""
+	}
+}
+
+// challenge records the authentication scheme asked for by resp, a 401 response, and returns
+// false if none is supported or resp only repeats the challenge already answered, meaning
+// that the credentials were refused.
+func (c *credentials) challenge(resp *http.Response) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var basic bool
+	for _, value := range resp.Header.Values("WWW-Authenticate") {
+		scheme, params, _ := strings.Cut(strings.TrimSpace(value), " ")
+		switch {
+		case strings.EqualFold(scheme, "Digest"):
+			d := parseDigestChallenge(params)
+			if d == nil {
+				continue
+			}
+			// A stale nonce is renewed, while the same nonce means the response was refused
+			if c.digest != nil && c.digest.nonce == d.nonce {
+				return false
+			}
+			c.digest, c.nc, c.basic = d, 0, false
+			return true
+		case strings.EqualFold(scheme, "Basic"):
+			basic = true
+		}
+	}
+	if !basic || c.basic {
+		return false
+	}
+	c.basic = true
+	return true
+}
+
+// parseDigestChallenge parses the parameters of a Digest challenge, and returns nil for
+// challenges with an unsupported algorithm or quality of protection.
+func parseDigestChallenge(params string) *digestChallenge {
+	values := parseAuthParams(params)
+	d := &digestChallenge{
+		realm:     values["realm"],
+		nonce:     values["nonce"],
+		opaque:    values["opaque"],
+		algorithm: values["algorithm"],
+	}
+	if d.nonce == "" || d.hash() == nil {
+		return nil
+	}
+	if qop, ok := values["qop"]; ok {
+		for _, q := range strings.Split(qop, ",") {
+			if strings.TrimSpace(q) == "auth" {
+				d.qop = "auth"
+			}
+		}
+		if d.qop == "" {
+			return nil
+		}
+	}
+	return d
+}
+
+// parseAuthParams parses comma separated key=value pairs, whose values may be quoted.
+func parseAuthParams(s string) map[string]string {
+	values := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, ", ")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " ")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value, s = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// hash returns the hash function of the algorithm of the challenge, or nil if it is not
+// supported. MD5 is assumed when no algorithm is given.
+func (d *digestChallenge) hash() func() hash.Hash {
+	switch strings.ToUpper(strings.TrimSuffix(strings.ToLower(d.algorithm), "-sess")) {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+// authorization returns the Authorization header answering the challenge for a request of
+// method to uri, as the nc-th request with the nonce, with the client nonce cnonce.
+func (d *digestChallenge) authorization(user, password, method, uri string, nc int, cnonce string) string {
+	h := func(s string) string {
+		sum := d.hash()()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	count := fmt.Sprintf("%08x", nc)
+	ha1 := h(user + ":" + d.realm + ":" + password)
+	if strings.HasSuffix(strings.ToLower(d.algorithm), "-sess") {
+		ha1 = h(ha1 + ":" + d.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	var response string
+	if d.qop != "" {
+		response = h(strings.Join([]string{ha1, d.nonce, count, cnonce, d.qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + d.nonce + ":" + ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf("username=%q", user),
+		fmt.Sprintf("realm=%q", d.realm),
+		fmt.Sprintf("nonce=%q", d.nonce),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("response=%q", response),
+	}
+	if d.algorithm != "" {
+		fields = append(fields, "algorithm="+d.algorithm)
+	}
+	if d.opaque != "" {
+		fields = append(fields, fmt.Sprintf("opaque=%q", d.opaque))
+	}
+	if d.qop != "" {
+		fields = append(fields, "qop="+d.qop, "nc="+count, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+// newCnonce returns a random client nonce.
+func newCnonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating client nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
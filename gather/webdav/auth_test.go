@@ -0,0 +1,112 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webdav
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDigestChallenge_Authorization tests the digest responses against the examples of
+// RFC 2617 and RFC 7616.
+func TestDigestChallenge_Authorization(t *testing.T) {
+	testCases := []struct {
+		name      string
+		challenge string
+		password  string
+		cnonce    string
+		response  string
+	}{
+		{
+			name:      "RFC 2617",
+			challenge: `realm="testrealm@host.com", qop="auth,auth-int", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`,
+			password:  "Circle Of Life",
+			cnonce:    "0a4f113b",
+			response:  "6629fae49393a05397450978507c4ef1",
+		},
+		{
+			name:      "RFC 7616 MD5",
+			challenge: `realm="http-auth@example.org", qop="auth, auth-int", algorithm=MD5, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+			password:  "Circle of Life",
+			cnonce:    "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+			response:  "8ca523f5e9506fed4657c9700eebdbec",
+		},
+		{
+			name:      "RFC 7616 SHA-256",
+			challenge: `realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+			password:  "Circle of Life",
+			cnonce:    "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+			response:  "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		},
+	}
+
+	for _, tc := range testCases {
+		d := parseDigestChallenge(tc.challenge)
+		if !assert.NotNil(t, d, tc.name) {
+			continue
+		}
+		header := d.authorization("Mufasa", tc.password, "GET", "/dir/index.html", 1, tc.cnonce)
+		params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+		assert.Equal(t, tc.response, params["response"], tc.name)
+		assert.Equal(t, "00000001", params["nc"], tc.name)
+		assert.Equal(t, d.opaque, params["opaque"], tc.name)
+	}
+}
+
+// TestParseDigestChallenge_Unsupported tests that challenges the gatherer cannot answer are
+// ignored.
+func TestParseDigestChallenge_Unsupported(t *testing.T) {
+	for _, challenge := range []string{
+		`realm="dav", nonce="abc", algorithm=SHA-512-256`,
+		`realm="dav", nonce="abc", qop="auth-int"`,
+		`realm="dav"`,
+	} {
+		assert.Nil(t, parseDigestChallenge(challenge), challenge)
+	}
+}
+
+// TestCredentials_Challenge tests that the scheme asked for is used once, so that refused
+// credentials are not sent again.
+func TestCredentials_Challenge(t *testing.T) {
+	resp := func(values ...string) *http.Response {
+		return &http.Response{Header: http.Header{"Www-Authenticate": values}}
+	}
+	c := &credentials{user: "alice", password: "s3cret"}
+
+	assert.True(t, c.challenge(resp(`Basic realm="dav"`)))
+	assert.False(t, c.challenge(resp(`Basic realm="dav"`)))
+	assert.True(t, c.challenge(resp(`Basic realm="dav"`, `Digest realm="dav", nonce="n1"`)))
+	assert.False(t, c.challenge(resp(`Digest realm="dav", nonce="n1"`)))
+	assert.True(t, c.challenge(resp(`Digest realm="dav", nonce="n2", stale=true`)))
+	assert.False(t, c.challenge(resp(`Negotiate`)))
+
+	req, err := http.NewRequest("GET", "https://dav.example.com/docs/a.txt", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, c.authorize(req))
+	params := parseAuthParams(strings.TrimPrefix(req.Header.Get("Authorization"), "Digest "))
+	assert.Equal(t, "n2", params["nonce"])
+	assert.Equal(t, "/docs/a.txt", params["uri"])
+}
+
+// TestParseAuthParams tests that quoted and unquoted values are parsed.
+func TestParseAuthParams(t *testing.T) {
+	params := parseAuthParams(`realm="a \"quoted\", realm", nonce=abc, qop="auth"`)
+	assert.Equal(t, map[string]string{"realm": `a "quoted", realm`, "nonce": "abc", "qop": "auth"}, params)
+}
@@ -0,0 +1,20 @@
+module github.com/enterprise-contract/go-gather/gather/webdav
+
+go 1.22.2
+
+require (
+	github.com/enterprise-contract/go-gather v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
+	github.com/enterprise-contract/go-gather/metadata/webdav v0.0.0-20240523073727-ba2c37023242
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/net v0.25.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/ulikunitz/xz v0.5.12 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,453 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webdav provides methods for gathering files and collections from WebDAV servers.
+// This package implements the Gatherer interface for the schemes
+//
+//	webdav://<host>/<path>     served over http
+//	webdavs://<host>/<path>    served over https
+//	dav+http://<host>/<path>
+//	dav+https://<host>/<path>
+//
+// A file is downloaded to the destination, and the members of a collection are listed with
+// PROPFIND requests and downloaded below the destination directory, descending into the
+// collections they contain. The user and password of the URL, if any, are sent with basic or
+// digest authentication, whichever the server asks for.
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	webdavMetadata "github.com/enterprise-contract/go-gather/metadata/webdav"
+)
+
+// WebDAVGatherer is a struct that implements the Gatherer interface
+// and provides methods for gathering from WebDAV servers.
+type WebDAVGatherer struct {
+	// Client is the HTTP client requests are sent with. Its transport is built from the
+	// options when it has none.
+	Client http.Client
+}
+
+// schemes maps the schemes of WebDAV sources to the schemes they are served over.
+var schemes = map[string]string{
+	"webdav":    "http",
+	"webdavs":   "https",
+	"dav+http":  "http",
+	"dav+https": "https",
+}
+
+// maxMultistatusSize is the largest PROPFIND response read, which bounds the memory used to
+// list a collection.
+const maxMultistatusSize = 32 << 20
+
+// propfindBody asks for the properties of a resource used by the gatherer.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:resourcetype/><D:getetag/><D:getcontentlength/><D:getlastmodified/></D:prop></D:propfind>`
+
+// resource is a file or collection listed by a PROPFIND request.
+type resource struct {
+	url          *url.URL
+	collection   bool
+	etag         string
+	size         int64
+	lastModified time.Time
+}
+
+// multistatus is the body of a 207 Multi-Status response to a PROPFIND request.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ETag          string `xml:"DAV: getetag"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// session holds what the requests of a gather share.
+type session struct {
+	o           *gogather.Options
+	client      *http.Client
+	credentials *credentials
+}
+
+// authMethod returns how the requests of the session authenticated with the server: with the
+// credentials of the source, if the server asked for them, and otherwise with the client
+// certificate of the options, if any.
+func (s *session) authMethod() string {
+	if s.credentials != nil {
+		if method := s.credentials.method(); method != "" {
+			return method
+		}
+	}
+	if len(s.o.ClientCertificates) > 0 {
+		return metadata.AuthClientCertificate
+	}
+	return metadata.AuthAnonymous
+}
+
+// Gather downloads the file or collection named by source to destination. A collection is
+// written below the destination directory, while a file is written to the destination, or
+// below it if it ends with a slash or has no extension.
+func (w *WebDAVGatherer) Gather(ctx context.Context, source, destination string, opts ...gogather.Option) (m metadata.Metadata, err error) {
+	o := gogather.NewOptions(opts...)
+	o.Emit(gogather.Event{Phase: gogather.EventStarted, Source: source})
+	defer func() { o.Emit(gogather.Event{Phase: gogather.EventDone, Source: source, Err: err}) }()
+	if err := o.CheckDestination(); err != nil {
+		return nil, err
+	}
+	sw := metadata.NewStopwatch()
+	ctx = sw.WithContext(ctx)
+
+	src, creds, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.CheckScheme(src.Scheme); err != nil {
+		return nil, err
+	}
+	if err := o.CheckHost(ctx, src.Host); err != nil {
+		return nil, err
+	}
+
+	s := &session{o: o, client: w.client(o), credentials: creds}
+	if o.OwnsTransport(s.client.Transport) && w.Client.Transport == nil {
+		defer s.client.CloseIdleConnections()
+	}
+
+	resources, err := s.propfind(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	self := resources[0]
+
+	wm := &webdavMetadata.WebDAVMetadata{URL: src.String(), ETag: self.etag, Collection: self.collection}
+	if self.collection {
+		wm.Destination = destination
+		if o.Destination == nil {
+			if err := o.MkdirAll(destination); err != nil {
+				return nil, fmt.Errorf("failed to create %s: %w", destination, err)
+			}
+		}
+		if err := s.gatherCollection(ctx, resources, destination, "", wm); err != nil {
+			return nil, err
+		}
+	} else {
+		name := path.Base(src.Path)
+		if strings.HasSuffix(destination, "/") || filepath.Ext(destination) == "" {
+			destination = filepath.Join(destination, name)
+		}
+		wm.Destination = destination
+		file, err := s.download(ctx, self, destination)
+		if err != nil {
+			return nil, err
+		}
+		file.RelativePath = name
+		wm.ETag = file.ETag
+		wm.Files = append(wm.Files, file)
+	}
+	wm.Auth = s.authMethod()
+	wm.Timing = sw.Timings()
+	return wm, nil
+}
+
+// parseSource returns the HTTP(S) URL of a WebDAV source, without the user and password,
+// and the credentials given by them, if any.
+func parseSource(source string) (*url.URL, *credentials, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse source URI: %w", err)
+	}
+	scheme, ok := schemes[strings.ToLower(u.Scheme)]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported source scheme: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, nil, fmt.Errorf("invalid WebDAV URI %s: missing host", source)
+	}
+	u.Scheme = scheme
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	var creds *credentials
+	if u.User != nil {
+		password, _ := u.User.Password()
+		creds = &credentials{user: u.User.Username(), password: password}
+		u.User = nil
+	}
+	return u, creds, nil
+}
+
+// gatherCollection downloads the members of the collection listed in resources, the
+// collection itself first, to dir, recording them in m with their paths prefixed with rel.
+// Members left out by WithInclude and WithExclude are skipped.
+func (s *session) gatherCollection(ctx context.Context, resources []resource, dir, rel string, m *webdavMetadata.WebDAVMetadata) error {
+	collection := resources[0].url
+	for _, r := range resources[1:] {
+		name, ok := memberName(collection, r.url)
+		if !ok {
+			continue
+		}
+		memberRel := path.Join(rel, name)
+		if err := s.o.CheckDirDepth(memberRel); err != nil {
+			return err
+		}
+		included, err := s.o.Included(memberRel, r.collection)
+		if err != nil {
+			return err
+		}
+		if !included {
+			continue
+		}
+		memberPath := filepath.Join(dir, name)
+
+		if r.collection {
+			members, err := s.propfind(ctx, r.url)
+			if err != nil {
+				return err
+			}
+			if s.o.Destination == nil {
+				if err := s.o.CheckSymlink(memberPath); err != nil {
+					return err
+				}
+				// With include patterns, directories are only created for the files they hold
+				if len(s.o.Include) == 0 {
+					if err := s.o.MkdirAll(memberPath); err != nil {
+						return fmt.Errorf("failed to create %s: %w", memberPath, err)
+					}
+				}
+			}
+			if err := s.gatherCollection(ctx, members, memberPath, memberRel, m); err != nil {
+				return err
+			}
+			continue
+		}
+
+		file, err := s.download(ctx, r, memberPath)
+		if err != nil {
+			return err
+		}
+		file.RelativePath = memberRel
+		m.Files = append(m.Files, file)
+	}
+	return nil
+}
+
+// memberName returns the name of member if it is a direct member of collection on the same
+// server, and false otherwise, such as for links to other servers.
+func memberName(collection, member *url.URL) (string, bool) {
+	if member.Host != collection.Host || member.Scheme != collection.Scheme {
+		return "", false
+	}
+	parent := strings.TrimSuffix(collection.Path, "/")
+	p := strings.TrimSuffix(member.Path, "/")
+	name := path.Base(p)
+	if path.Dir(p) != path.Clean("/"+parent) || name == "." || name == ".." || name == "/" || !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	return name, true
+}
+
+// propfind lists the resource at u and, for a collection, its members. The resource itself
+// comes first.
+func (s *session) propfind(ctx context.Context, u *url.URL) ([]resource, error) {
+	stop := metadata.StopwatchFrom(ctx).Time(metadata.PhaseResolve)
+	defer stop()
+	resp, err := s.do(ctx, "PROPFIND", u, []byte(propfindBody), http.Header{
+		"Depth":        {"1"},
+		"Content-Type": {"application/xml; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("error listing %s: response code error: %d", u, resp.StatusCode)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxMultistatusSize)).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("error parsing the listing of %s: %w", u, err)
+	}
+
+	// Hrefs are relative to the URL that was served, which may have been redirected to
+	base := resp.Request.URL
+	var self *resource
+	var members []resource
+	for _, response := range ms.Responses {
+		href, err := base.Parse(strings.TrimSpace(response.Href))
+		if err != nil {
+			continue
+		}
+		r := resource{url: href, size: -1}
+		for _, ps := range response.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") && ps.Status != "" {
+				continue
+			}
+			if ps.Prop.ResourceType.Collection != nil {
+				r.collection = true
+			}
+			if ps.Prop.ETag != "" {
+				r.etag = ps.Prop.ETag
+			}
+			if n, err := strconv.ParseInt(strings.TrimSpace(ps.Prop.ContentLength), 10, 64); err == nil {
+				r.size = n
+			}
+			if t, err := http.ParseTime(strings.TrimSpace(ps.Prop.LastModified)); err == nil {
+				r.lastModified = t
+			}
+		}
+		if self == nil && strings.TrimSuffix(href.Path, "/") == strings.TrimSuffix(base.Path, "/") {
+			r.url = base
+			self = &r
+			continue
+		}
+		members = append(members, r)
+	}
+	if self == nil {
+		return nil, fmt.Errorf("error listing %s: the response does not describe the resource", u)
+	}
+	if self.collection && !strings.HasSuffix(self.url.Path, "/") {
+		collection := *self.url
+		collection.Path += "/"
+		self.url = &collection
+	}
+	return append([]resource{*self}, members...), nil
+}
+
+// download writes the file r to dst and returns its description.
+func (s *session) download(ctx context.Context, r resource, dst string) (webdavMetadata.WebDAVFile, error) {
+	o := s.o
+	file := webdavMetadata.WebDAVFile{Destination: dst, ETag: r.etag, LastModified: r.lastModified}
+	if err := o.ValidateDestination(dst); err != nil {
+		return file, err
+	}
+
+	resp, err := s.do(ctx, "GET", r.url, nil, nil)
+	if err != nil {
+		return file, fmt.Errorf("error downloading %s: %w", r.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return file, fmt.Errorf("error downloading %s: response code error: %d", r.url, resp.StatusCode)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		file.ETag = etag
+	}
+	if err := o.CheckSize(resp.ContentLength); err != nil {
+		return file, err
+	}
+	body, finish := o.ProgressReader(o.LimitReader(resp.Body), r.url.String(), resp.ContentLength)
+	defer finish()
+
+	if o.Destination != nil {
+		file.Size, err = o.WriteDestination(dst, body)
+		return file, err
+	}
+
+	if err := o.MkdirAll(filepath.Dir(dst)); err != nil {
+		return file, fmt.Errorf("failed to create %s: %w", filepath.Dir(dst), err)
+	}
+	if err := o.CheckSymlink(dst); err != nil {
+		return file, err
+	}
+	f, err := os.OpenFile(filepath.Clean(dst), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, o.FilePermission())
+	if err != nil {
+		return file, fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	file.Size, err = io.Copy(f, o.BufferReader(body))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return file, o.DiscardPartial(dst, fmt.Errorf("failed to write %s: %w", dst, err))
+	}
+	if err := o.ChmodFile(dst); err != nil {
+		return file, o.DiscardPartial(dst, err)
+	}
+	return file, nil
+}
+
+// do sends a request with the credentials of the session, answering the authentication
+// challenge of the server, if any, once.
+func (s *session) do(ctx context.Context, method string, u *url.URL, body []byte, header http.Header) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("User-Agent", "Go-Gather")
+		if s.credentials != nil {
+			if err := s.credentials.authorize(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, s.o.ResponseError(err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || s.credentials == nil || attempt > 0 || !s.credentials.challenge(resp) {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+	}
+}
+
+// client returns a copy of the gatherer's http.Client configured according to o.
+func (w *WebDAVGatherer) client(o *gogather.Options) *http.Client {
+	c := w.Client
+	if c.Transport == nil {
+		c.Transport = o.Transport()
+	}
+	checkRedirect := c.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if checkRedirect != nil {
+			if err := checkRedirect(req, via); err != nil {
+				return err
+			}
+		}
+		return o.CheckRedirect(req, via)
+	}
+	return &c
+}
@@ -0,0 +1,246 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/webdav"
+
+	gogather "github.com/enterprise-contract/go-gather"
+	"github.com/enterprise-contract/go-gather/metadata"
+	webdavMetadata "github.com/enterprise-contract/go-gather/metadata/webdav"
+)
+
+// newServer starts a WebDAV server holding the given files, behind the handler returned by
+// wrap, if any.
+func newServer(t *testing.T, files map[string]string, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+	fs := webdav.NewMemFS()
+	ctx := context.Background()
+	for name, content := range files {
+		dir := "/"
+		for _, part := range strings.Split(filepath.ToSlash(filepath.Dir(name)), "/") {
+			if part == "." {
+				continue
+			}
+			dir += part + "/"
+			if err := fs.Mkdir(ctx, dir, 0755); err != nil && !os.IsExist(err) {
+				t.Fatal(err)
+			}
+		}
+		f, err := fs.OpenFile(ctx, "/"+name, os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var handler http.Handler = &webdav.Handler{FileSystem: fs, LockSystem: webdav.NewMemLS()}
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// davURL returns the webdav:// URL of path on server.
+func davURL(server *httptest.Server, path string) string {
+	return strings.Replace(server.URL, "http://", "webdav://", 1) + path
+}
+
+// TestWebDAVGatherer_Gather_File tests that a single file is downloaded with its etag.
+func TestWebDAVGatherer_Gather_File(t *testing.T) {
+	server := newServer(t, map[string]string{"docs/report.txt": "hello"}, nil)
+	gatherer := &WebDAVGatherer{}
+
+	dir := t.TempDir()
+	m, err := gatherer.Gather(context.Background(), davURL(server, "/docs/report.txt"), dir+"/")
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	wm := m.(*webdavMetadata.WebDAVMetadata)
+	assert.Equal(t, server.URL+"/docs/report.txt", wm.URL)
+	assert.False(t, wm.Collection)
+	assert.NotEmpty(t, wm.ETag)
+	assert.Equal(t, filepath.Join(dir, "report.txt"), wm.Destination)
+	assert.Equal(t, []string{"report.txt"}, wm.Paths())
+	assert.Equal(t, int64(5), wm.Files[0].Size)
+	assert.Equal(t, metadata.AuthAnonymous, wm.AuthMethod())
+}
+
+// TestWebDAVGatherer_Gather_Collection tests that collections are downloaded recursively.
+func TestWebDAVGatherer_Gather_Collection(t *testing.T) {
+	server := newServer(t, map[string]string{
+		"docs/a.txt":         "a",
+		"docs/sub/b.txt":     "b",
+		"docs/sub/deep/c.md": "c",
+		"other.txt":          "other",
+	}, nil)
+	gatherer := &WebDAVGatherer{}
+
+	for _, source := range []string{davURL(server, "/docs"), davURL(server, "/docs/")} {
+		destination := filepath.Join(t.TempDir(), "docs")
+		m, err := gatherer.Gather(context.Background(), source, destination)
+		assert.NoError(t, err, source)
+
+		for name, expected := range map[string]string{"a.txt": "a", "sub/b.txt": "b", "sub/deep/c.md": "c"} {
+			content, err := os.ReadFile(filepath.Join(destination, filepath.FromSlash(name)))
+			assert.NoError(t, err, name)
+			assert.Equal(t, expected, string(content), name)
+		}
+		assert.NoFileExists(t, filepath.Join(destination, "other.txt"))
+
+		wm := m.(*webdavMetadata.WebDAVMetadata)
+		assert.True(t, wm.Collection)
+		assert.ElementsMatch(t, []string{"a.txt", "sub/b.txt", "sub/deep/c.md"}, wm.Paths())
+		for _, f := range wm.Files {
+			assert.NotEmpty(t, f.ETag, f.RelativePath)
+		}
+	}
+
+	_, err := gatherer.Gather(context.Background(), davURL(server, "/docs"), filepath.Join(t.TempDir(), "docs"), gogather.WithMaxDirDepth(2))
+	assert.ErrorIs(t, err, gogather.ErrMaxDepthExceeded)
+}
+
+// TestWebDAVGatherer_Gather_IncludeExclude tests that only the included members of a collection
+// are downloaded, excludes winning.
+func TestWebDAVGatherer_Gather_IncludeExclude(t *testing.T) {
+	server := newServer(t, map[string]string{
+		"docs/a.txt":         "a",
+		"docs/sub/b.txt":     "b",
+		"docs/sub/deep/c.md": "c",
+		"docs/skip/d.md":     "d",
+	}, nil)
+	gatherer := &WebDAVGatherer{}
+
+	destination := filepath.Join(t.TempDir(), "docs")
+	m, err := gatherer.Gather(context.Background(), davURL(server, "/docs"), destination,
+		gogather.WithInclude([]string{"*.md"}), gogather.WithExclude([]string{"skip"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sub/deep/c.md"}, m.(*webdavMetadata.WebDAVMetadata).Paths())
+	assert.FileExists(t, filepath.Join(destination, "sub", "deep", "c.md"))
+	assert.NoFileExists(t, filepath.Join(destination, "a.txt"))
+	assert.NoDirExists(t, filepath.Join(destination, "skip"))
+}
+
+// TestWebDAVGatherer_Gather_BasicAuth tests that the credentials of the URL are sent with basic
+// authentication when the server asks for it.
+func TestWebDAVGatherer_Gather_BasicAuth(t *testing.T) {
+	server := newServer(t, map[string]string{"docs/a.txt": "a"}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, password, ok := r.BasicAuth(); !ok || user != "alice" || password != "s3cret" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="dav"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	gatherer := &WebDAVGatherer{}
+	source := strings.Replace(server.URL, "http://", "webdav://alice:s3cret@", 1) + "/docs"
+
+	m, err := gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "docs"))
+	assert.NoError(t, err)
+	assert.NotContains(t, m.(*webdavMetadata.WebDAVMetadata).URL, "s3cret")
+	assert.Equal(t, metadata.AuthBasic, m.AuthMethod())
+
+	_, err = gatherer.Gather(context.Background(), strings.Replace(source, "s3cret", "wrong", 1), filepath.Join(t.TempDir(), "docs"))
+	assert.ErrorContains(t, err, "401")
+}
+
+// TestWebDAVGatherer_Gather_DigestAuth tests that the credentials of the URL are sent with
+// digest authentication when the server asks for it.
+func TestWebDAVGatherer_Gather_DigestAuth(t *testing.T) {
+	challenge := &digestChallenge{realm: "dav", nonce: "abc123", algorithm: "SHA-256", qop: "auth"}
+	server := newServer(t, map[string]string{"docs/a.txt": "a", "docs/b.txt": "b"}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			params := parseAuthParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+			// The response depends on the request count, which is taken from the header
+			var nc int
+			_, _ = fmt.Sscanf(params["nc"], "%x", &nc)
+			expected := parseAuthParams(strings.TrimPrefix(challenge.authorization("alice", "s3cret", r.Method, params["uri"], nc, params["cnonce"]), "Digest "))["response"]
+			if params["username"] != "alice" || params["uri"] != r.URL.RequestURI() || params["response"] != expected {
+				w.Header().Set("WWW-Authenticate", `Digest realm="dav", nonce="abc123", algorithm=SHA-256, qop="auth"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	gatherer := &WebDAVGatherer{}
+	source := strings.Replace(server.URL, "http://", "webdav://alice:s3cret@", 1) + "/docs"
+
+	m, err := gatherer.Gather(context.Background(), source, filepath.Join(t.TempDir(), "docs"))
+	assert.NoError(t, err)
+	assert.Len(t, m.(*webdavMetadata.WebDAVMetadata).Files, 2)
+	assert.Equal(t, metadata.AuthDigest, m.AuthMethod())
+}
+
+// TestWebDAVGatherer_Gather_Error tests that invalid sources and missing resources are refused.
+func TestWebDAVGatherer_Gather_Error(t *testing.T) {
+	server := newServer(t, map[string]string{"docs/a.txt": "a"}, nil)
+	gatherer := &WebDAVGatherer{}
+
+	for _, source := range []string{"https://example.com/docs", "webdav:///docs", davURL(server, "/missing")} {
+		_, err := gatherer.Gather(context.Background(), source, t.TempDir())
+		assert.Error(t, err, source)
+	}
+
+	_, err := gatherer.Gather(context.Background(), davURL(server, "/docs"), t.TempDir(), gogather.WithInsecureHTTPAllowed(false))
+	assert.ErrorIs(t, err, gogather.ErrInsecureTransport)
+}
+
+// TestParseSource tests that the schemes are mapped to HTTP(S) and the credentials removed.
+func TestParseSource(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+		user     string
+	}{
+		{source: "webdav://dav.example.com/docs", expected: "http://dav.example.com/docs"},
+		{source: "webdavs://alice:pw@dav.example.com:8443/docs/", expected: "https://dav.example.com:8443/docs/", user: "alice"},
+		{source: "dav+http://dav.example.com", expected: "http://dav.example.com/"},
+		{source: "dav+https://dav.example.com/a%20b", expected: "https://dav.example.com/a%20b"},
+	}
+
+	for _, tc := range testCases {
+		u, creds, err := parseSource(tc.source)
+		assert.NoError(t, err, tc.source)
+		assert.Equal(t, tc.expected, u.String(), tc.source)
+		if tc.user == "" {
+			assert.Nil(t, creds, tc.source)
+		} else {
+			assert.Equal(t, tc.user, creds.user, tc.source)
+		}
+	}
+}
@@ -1,3 +1,9 @@
 module github.com/enterprise-contract/go-gather
 
 go 1.21.9
+
+require (
+	github.com/klauspost/compress v1.17.9
+	github.com/ulikunitz/xz v0.5.12
+	golang.org/x/sys v0.20.0
+)
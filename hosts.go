@@ -0,0 +1,146 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ErrHostNotAllowed is returned when a gatherer refuses to contact a host.
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// ErrInsecureTransport is returned when a gatherer refuses to use an unencrypted protocol.
+var ErrInsecureTransport = errors.New("insecure transport")
+
+// LookupIPAddr resolves host names for the private network check.
+var LookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// CheckHost returns an error wrapping ErrHostNotAllowed if the host may not be contacted.
+// The host may include a port. When BlockPrivateNetworks is set, the host is resolved,
+// through the resolver and host mapping of the options, and refused if any of its addresses
+// is private.
+func (o *Options) CheckHost(ctx context.Context, host string) error {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	// The trailing dot of a fully qualified name names the same host
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+
+	for _, pattern := range o.DeniedHosts {
+		if matchHost(pattern, host) {
+			return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+		}
+	}
+
+	if len(o.AllowedHosts) > 0 {
+		allowed := false
+		for _, pattern := range o.AllowedHosts {
+			if matchHost(pattern, host) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s is not in the allowed hosts", ErrHostNotAllowed, host)
+		}
+	}
+
+	if !o.BlockPrivateNetworks {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIP(host, ip)
+	}
+
+	addrs, err := o.lookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve host %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := checkIP(host, addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckScheme returns an error wrapping ErrInsecureTransport if the URL scheme is one of the
+// unencrypted protocols, http and git, and WithInsecureHTTPAllowed(false) is set.
+func (o *Options) CheckScheme(scheme string) error {
+	if !o.DenyInsecureTransport {
+		return nil
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "git":
+		return fmt.Errorf("%w: %s:// is not encrypted", ErrInsecureTransport, strings.ToLower(scheme))
+	}
+	return nil
+}
+
+// CheckRedirect is an http.Client CheckRedirect function that applies CheckScheme and
+// CheckHost to every redirect target. With WithMaxRedirectBodyRead, it also discards the
+// body of the redirect response, reading at most the configured number of bytes.
+func (o *Options) CheckRedirect(req *http.Request, via []*http.Request) error {
+	o.discardRedirectBody(req.Response)
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if err := o.CheckScheme(req.URL.Scheme); err != nil {
+		return err
+	}
+	if err := o.CheckHost(req.Context(), req.URL.Host); err != nil {
+		return err
+	}
+	o.Log(req.Context(), LevelTrace, "following redirect", "url", req.URL.String(), "redirects", len(via))
+	return nil
+}
+
+// discardRedirectBody reads at most MaxRedirectBodyRead bytes of the body of the redirect
+// response resp and closes it. A body read to the end leaves the connection to be reused,
+// while closing a body that was not is cheaper than reading it for a new connection.
+func (o *Options) discardRedirectBody(resp *http.Response) {
+	if o.MaxRedirectBodyRead == nil || resp == nil || resp.Body == nil {
+		return
+	}
+	if *o.MaxRedirectBodyRead > 0 {
+		_, _ = io.CopyN(io.Discard, resp.Body, *o.MaxRedirectBodyRead)
+	}
+	_ = resp.Body.Close()
+}
+
+// matchHost reports whether host matches the shell-style pattern.
+func matchHost(pattern, host string) bool {
+	ok, err := path.Match(strings.ToLower(pattern), host)
+	return err == nil && ok
+}
+
+// checkIP returns an error wrapping ErrHostNotAllowed if ip is not a public address.
+func checkIP(host string, ip net.IP) error {
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s resolves to a private address", ErrHostNotAllowed, host)
+	}
+	return nil
+}
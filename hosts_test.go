@@ -0,0 +1,151 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestCheckHost tests the CheckHost method with allowed and denied hosts.
+func TestCheckHost(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []Option
+		host    string
+		allowed bool
+	}{
+		{name: "no restrictions", host: "example.com", allowed: true},
+		{name: "allowed", opts: []Option{WithAllowedHosts([]string{"example.com"})}, host: "example.com", allowed: true},
+		{name: "allowed with port", opts: []Option{WithAllowedHosts([]string{"example.com"})}, host: "example.com:8443", allowed: true},
+		{name: "allowed wildcard", opts: []Option{WithAllowedHosts([]string{"*.example.com"})}, host: "api.Example.com", allowed: true},
+		{name: "not allowed", opts: []Option{WithAllowedHosts([]string{"*.example.com"})}, host: "example.org", allowed: false},
+		{name: "denied", opts: []Option{WithDeniedHosts([]string{"evil.*"})}, host: "evil.com", allowed: false},
+		{name: "denied trailing dot", opts: []Option{WithDeniedHosts([]string{"*.internal.example.com"})}, host: "db.internal.example.com.", allowed: false},
+		{name: "denied trailing dot with port", opts: []Option{WithDeniedHosts([]string{"metadata.google.internal"})}, host: "metadata.google.internal.:80", allowed: false},
+		{name: "allowed trailing dot", opts: []Option{WithAllowedHosts([]string{"*.example.com"})}, host: "api.example.com.", allowed: true},
+		{name: "denied wins", opts: []Option{WithAllowedHosts([]string{"*"}), WithDeniedHosts([]string{"evil.com"})}, host: "evil.com", allowed: false},
+		{name: "private ip", opts: []Option{WithBlockPrivateNetworks(true)}, host: "10.0.0.1", allowed: false},
+		{name: "loopback ip", opts: []Option{WithBlockPrivateNetworks(true)}, host: "[::1]:80", allowed: false},
+		{name: "link-local ip", opts: []Option{WithBlockPrivateNetworks(true)}, host: "169.254.169.254", allowed: false},
+		{name: "public ip", opts: []Option{WithBlockPrivateNetworks(true)}, host: "8.8.8.8", allowed: true},
+	}
+
+	for _, tc := range testCases {
+		err := NewOptions(tc.opts...).CheckHost(context.Background(), tc.host)
+		if tc.allowed && err != nil {
+			t.Errorf("%s: expected %s to be allowed, but got: %v", tc.name, tc.host, err)
+		}
+		if !tc.allowed && !errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("%s: expected ErrHostNotAllowed for %s, but got: %v", tc.name, tc.host, err)
+		}
+	}
+}
+
+// TestCheckHost_ResolvesPrivate tests that host names resolving to private addresses are refused.
+func TestCheckHost_ResolvesPrivate(t *testing.T) {
+	LookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("192.168.1.10")}}, nil
+	}
+	defer func() { LookupIPAddr = net.DefaultResolver.LookupIPAddr }()
+
+	err := NewOptions(WithBlockPrivateNetworks(true)).CheckHost(context.Background(), "internal.example.com")
+	if !errors.Is(err, ErrHostNotAllowed) {
+		t.Errorf("Expected ErrHostNotAllowed, but got: %v", err)
+	}
+}
+
+// TestCheckScheme tests that unencrypted protocols are refused when insecure transports are not allowed.
+func TestCheckScheme(t *testing.T) {
+	testCases := []struct {
+		scheme  string
+		allowed bool
+		refused bool
+	}{
+		{scheme: "http", allowed: true},
+		{scheme: "http", refused: true},
+		{scheme: "HTTP", refused: true},
+		{scheme: "git", refused: true},
+		{scheme: "https"},
+		{scheme: "ssh"},
+		{scheme: "file"},
+	}
+
+	for _, tc := range testCases {
+		err := NewOptions(WithInsecureHTTPAllowed(tc.allowed)).CheckScheme(tc.scheme)
+		if tc.refused != errors.Is(err, ErrInsecureTransport) {
+			t.Errorf("Unexpected error for %s (allowed %t): %v", tc.scheme, tc.allowed, err)
+		}
+	}
+	if err := NewOptions().CheckScheme("http"); err != nil {
+		t.Errorf("Expected http to be allowed by default, but got: %v", err)
+	}
+}
+
+// redirectBody is the body of a redirect response, recording how much of it was read.
+type redirectBody struct {
+	io.Reader
+	read   int64
+	closed bool
+}
+
+func (b *redirectBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *redirectBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// TestCheckRedirect_MaxRedirectBodyRead tests that at most the configured number of bytes of
+// a redirect body are read before it is closed.
+func TestCheckRedirect_MaxRedirectBodyRead(t *testing.T) {
+	testCases := []struct {
+		opts   []Option
+		read   int64
+		closed bool
+	}{
+		{read: 0, closed: false},
+		{opts: []Option{WithMaxRedirectBodyRead(0)}, read: 0, closed: true},
+		{opts: []Option{WithMaxRedirectBodyRead(512)}, read: 512, closed: true},
+		{opts: []Option{WithMaxRedirectBodyRead(1 << 20)}, read: 4096, closed: true},
+	}
+
+	for _, tc := range testCases {
+		body := &redirectBody{Reader: strings.NewReader(strings.Repeat("x", 4096))}
+		req, err := http.NewRequest("GET", "https://example.com/final", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		req.Response = &http.Response{StatusCode: http.StatusFound, Body: body}
+
+		if err := NewOptions(tc.opts...).CheckRedirect(req, []*http.Request{{}}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if body.read != tc.read || body.closed != tc.closed {
+			t.Errorf("Expected %d bytes read and closed %t, but got %d bytes read and closed %t", tc.read, tc.closed, body.read, body.closed)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrIdleTimeout is returned when no data is read for longer than the idle timeout.
+var ErrIdleTimeout = errors.New("idle timeout")
+
+// IdleTimeoutReader returns a reader that calls cancel when no bytes are read from r for
+// longer than the idle timeout, and a function that stops the timer once reading is done.
+// Cancelling is expected to unblock a pending read, for example by cancelling the context of
+// the request r belongs to; the error of that read is then reported as ErrIdleTimeout.
+// Without an idle timeout r is returned as is.
+func (o *Options) IdleTimeoutReader(r io.Reader, cancel context.CancelFunc) (io.Reader, func()) {
+	if o.IdleTimeout <= 0 {
+		return r, func() {}
+	}
+	ir := &idleReader{r: r, timeout: o.IdleTimeout}
+	ir.timer = time.AfterFunc(o.IdleTimeout, func() {
+		ir.expired.Store(true)
+		cancel()
+	})
+	return ir, func() { ir.timer.Stop() }
+}
+
+// idleReader resets its timer on every successful read.
+type idleReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+func (ir *idleReader) Read(p []byte) (int, error) {
+	n, err := ir.r.Read(p)
+	if ir.expired.Load() {
+		return n, fmt.Errorf("%w: no data received for %s", ErrIdleTimeout, ir.timeout)
+	}
+	if n > 0 {
+		ir.timer.Reset(ir.timeout)
+	}
+	return n, err
+}
+
+// IdleTimeoutWait calls cancel when the response headers do not arrive within the idle
+// timeout, a wait IdleTimeoutReader does not cover since it only starts with the body. The
+// returned function stops the timer once the response arrived, or the request failed, and
+// returns ErrIdleTimeout if it expired. Without an idle timeout it does nothing.
+func (o *Options) IdleTimeoutWait(cancel context.CancelFunc) func() error {
+	if o.IdleTimeout <= 0 {
+		return func() error { return nil }
+	}
+	expired := make(chan struct{})
+	timer := time.AfterFunc(o.IdleTimeout, func() {
+		close(expired)
+		cancel()
+	})
+	return func() error {
+		if timer.Stop() {
+			return nil
+		}
+		<-expired
+		return fmt.Errorf("%w: no response received for %s", ErrIdleTimeout, o.IdleTimeout)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// slowReader returns one byte, then blocks until it is cancelled.
+type slowReader struct {
+	sent      bool
+	cancelled chan struct{}
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if !r.sent {
+		r.sent = true
+		p[0] = 'a'
+		return 1, nil
+	}
+	<-r.cancelled
+	return 0, context.Canceled
+}
+
+// TestIdleTimeoutReader tests that a stalled reader is cancelled and reported as idle.
+func TestIdleTimeoutReader(t *testing.T) {
+	r := &slowReader{cancelled: make(chan struct{})}
+	o := NewOptions(WithIdleTimeout(50 * time.Millisecond))
+
+	ir, stop := o.IdleTimeoutReader(r, func() { close(r.cancelled) })
+	defer stop()
+
+	data, err := io.ReadAll(ir)
+	if !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("Expected ErrIdleTimeout, but got: %v", err)
+	}
+	if string(data) != "a" {
+		t.Errorf("Expected the data read before the timeout, but got: %q", data)
+	}
+}
+
+// TestIdleTimeoutReader_Disabled tests that the reader is not wrapped without an idle timeout.
+func TestIdleTimeoutReader_Disabled(t *testing.T) {
+	r := &slowReader{}
+	ir, stop := NewOptions().IdleTimeoutReader(r, func() {})
+	defer stop()
+
+	if ir != io.Reader(r) {
+		t.Errorf("Expected the reader to be returned as is")
+	}
+}
+
+// TestIdleTimeoutWait tests that a wait longer than the idle timeout is cancelled and reported
+// as idle, and that a shorter one is not.
+func TestIdleTimeoutWait(t *testing.T) {
+	o := NewOptions(WithIdleTimeout(20 * time.Millisecond))
+
+	cancelled := make(chan struct{})
+	wait := o.IdleTimeoutWait(func() { close(cancelled) })
+	<-cancelled
+	if err := wait(); !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("Expected ErrIdleTimeout, but got: %v", err)
+	}
+
+	wait = o.IdleTimeoutWait(func() { t.Error("Expected no cancellation") })
+	if err := wait(); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if err := NewOptions().IdleTimeoutWait(func() {})(); err != nil {
+		t.Errorf("Expected no error without an idle timeout, but got: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Included reports whether the entry at rel, its path relative to the destination of a recursive
+// gather, is gathered under the patterns of WithInclude and WithExclude. Both slashes and the
+// separator of the platform are accepted. A file is gathered unless it matches an exclude
+// pattern, and, when include patterns are set, only if it matches one of them: excludes win. A
+// directory, with dir set, is only left out when it matches an exclude pattern, as files below
+// it may still be included. An error wrapping path.ErrBadPattern is returned for invalid patterns.
+func (o *Options) Included(rel string, dir bool) (bool, error) {
+	if !o.Selecting() {
+		return true, nil
+	}
+	clean := strings.Trim(path.Clean(filepath.ToSlash(rel)), "/")
+	if clean == "." || clean == "" {
+		return true, nil
+	}
+	segments := strings.Split(clean, "/")
+
+	excluded, err := matchAny(o.Exclude, segments, dir)
+	if err != nil || excluded {
+		return false, err
+	}
+	if dir || len(o.Include) == 0 {
+		return true, nil
+	}
+	return matchAny(o.Include, segments, false)
+}
+
+// Selecting reports whether patterns are set with WithInclude or WithExclude.
+func (o *Options) Selecting() bool {
+	return len(o.Include) > 0 || len(o.Exclude) > 0
+}
+
+// FilterTree removes the files below root that are not included under the patterns of
+// WithInclude and WithExclude, and the directories left empty, for gatherers that cannot
+// leave the files out as they write them, such as git checkouts. Git metadata directories
+// are kept.
+func (o *Options) FilterTree(root string) error {
+	if !o.Selecting() {
+		return nil
+	}
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		included, err := o.Included(rel, d.IsDir())
+		if err != nil {
+			return err
+		}
+		switch {
+		case !included:
+			if err := os.RemoveAll(p); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", p, err)
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		case d.IsDir():
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Directories are walked parents first, so they are removed children first.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if hasEntries(dirs[i]) {
+			continue
+		}
+		if err := os.Remove(dirs[i]); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dirs[i], err)
+		}
+	}
+	return nil
+}
+
+// hasEntries reports whether the directory dir has entries.
+func hasEntries(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// matchAny reports whether one of patterns matches the path made of segments, or one of the
+// directories it lies in.
+func matchAny(patterns []string, segments []string, dir bool) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := matchPattern(pattern, segments, dir)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// matchPattern reports whether the gitignore-style pattern matches the path made of segments,
+// or one of the directories it lies in. A pattern with a slash at its start or in its middle
+// is matched against the whole path, and otherwise against every name in the path; "**"
+// matches any number of directories, and a trailing slash only matches directories. Negated
+// patterns are not supported.
+func matchPattern(pattern string, segments []string, dir bool) (bool, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return false, fmt.Errorf("%w: empty pattern", path.ErrBadPattern)
+	}
+
+	parts := strings.Split(pattern, "/")
+	if !anchored {
+		parts = append([]string{"**"}, parts...)
+	}
+	for n := 1; n <= len(segments); n++ {
+		if n == len(segments) && dirOnly && !dir {
+			break
+		}
+		matched, err := matchSegments(parts, segments[:n])
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchSegments reports whether the pattern parts match segments, each part matching one
+// segment, except "**", which matches any number of them.
+func matchSegments(parts, segments []string) (bool, error) {
+	if len(parts) == 0 {
+		return len(segments) == 0, nil
+	}
+	if parts[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			matched, err := matchSegments(parts[1:], segments[i:])
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+	if len(segments) == 0 {
+		return false, nil
+	}
+	matched, err := path.Match(parts[0], segments[0])
+	if err != nil || !matched {
+		return false, err
+	}
+	return matchSegments(parts[1:], segments[1:])
+}
@@ -0,0 +1,120 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// TestIncluded tests the gitignore-style matching of include and exclude patterns.
+func TestIncluded(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []Option
+		rel      string
+		dir      bool
+		included bool
+	}{
+		{name: "no patterns", rel: "a/b.txt", included: true},
+		{name: "name at any depth", opts: []Option{WithInclude([]string{"*.yaml"})}, rel: "deploy/app.yaml", included: true},
+		{name: "name not matched", opts: []Option{WithInclude([]string{"*.yaml"})}, rel: "deploy/app.json", included: false},
+		{name: "anchored", opts: []Option{WithInclude([]string{"/app.yaml"})}, rel: "deploy/app.yaml", included: false},
+		{name: "anchored at root", opts: []Option{WithInclude([]string{"/app.yaml"})}, rel: "app.yaml", included: true},
+		{name: "middle slash", opts: []Option{WithInclude([]string{"policy/*.rego"})}, rel: "policy/main.rego", included: true},
+		{name: "middle slash nested", opts: []Option{WithInclude([]string{"policy/*.rego"})}, rel: "lib/policy/main.rego", included: false},
+		{name: "double star", opts: []Option{WithInclude([]string{"policy/**/*.rego"})}, rel: "policy/a/b/main.rego", included: true},
+		{name: "double star no dirs", opts: []Option{WithInclude([]string{"policy/**/*.rego"})}, rel: "policy/main.rego", included: true},
+		{name: "directory", opts: []Option{WithInclude([]string{"docs"})}, rel: "docs/guide/index.md", included: true},
+		{name: "directory only", opts: []Option{WithInclude([]string{"docs/"})}, rel: "docs/index.md", included: true},
+		{name: "directory only file", opts: []Option{WithInclude([]string{"docs/"})}, rel: "docs", included: false},
+		{name: "excluded", opts: []Option{WithExclude([]string{"*_test.go"})}, rel: "pkg/a_test.go", included: false},
+		{name: "not excluded", opts: []Option{WithExclude([]string{"*_test.go"})}, rel: "pkg/a.go", included: true},
+		{name: "excluded directory", opts: []Option{WithExclude([]string{"vendor/"})}, rel: "vendor/x/y.go", included: false},
+		{name: "exclude wins", opts: []Option{WithInclude([]string{"*.go"}), WithExclude([]string{"*_test.go"})}, rel: "a_test.go", included: false},
+		{name: "included directory", opts: []Option{WithInclude([]string{"*.go"})}, rel: "pkg", dir: true, included: true},
+		{name: "excluded directory entry", opts: []Option{WithExclude([]string{"node_modules"})}, rel: "web/node_modules", dir: true, included: false},
+		{name: "platform separator", opts: []Option{WithInclude([]string{"/docs/*.md"})}, rel: filepath.Join("docs", "a.md"), included: true},
+	}
+
+	for _, tc := range testCases {
+		included, err := NewOptions(tc.opts...).Included(tc.rel, tc.dir)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if included != tc.included {
+			t.Errorf("%s: expected included %t for %s, but got %t", tc.name, tc.included, tc.rel, included)
+		}
+	}
+}
+
+// TestIncluded_BadPattern tests that invalid patterns are reported.
+func TestIncluded_BadPattern(t *testing.T) {
+	for _, pattern := range []string{"[", "/"} {
+		_, err := NewOptions(WithInclude([]string{pattern})).Included("a", false)
+		if !errors.Is(err, path.ErrBadPattern) {
+			t.Errorf("Expected path.ErrBadPattern for %q, but got: %v", pattern, err)
+		}
+	}
+}
+
+// TestFilterTree tests that the files left out and the directories left empty are removed.
+func TestFilterTree(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"README.md", "docs/a.md", "src/main.go", "src/main_test.go", ".git/config"} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0600); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	o := NewOptions(WithInclude([]string{"*.go", "/README.md"}), WithExclude([]string{"*_test.go"}))
+	if err := o.FilterTree(root); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Strings(files)
+	expected := []string{".", ".git", ".git/config", "README.md", "src", "src/main.go"}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %v, but got %v", expected, files)
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Errorf("Expected %v, but got %v", expected, files)
+			break
+		}
+	}
+}
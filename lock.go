@@ -0,0 +1,86 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrLockTimeout is returned when the lock file set with WithLockFile is not acquired within
+// the timeout set with WithLockTimeout.
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// errLocked is returned by tryLock when the file is locked by another process or gather.
+var errLocked = errors.New("locked")
+
+// lockPollInterval is how often a lock held by another gather is tried again, which tests
+// shorten.
+var lockPollInterval = 50 * time.Millisecond
+
+// AcquireLock acquires the advisory lock on the file set with WithLockFile, creating the
+// file if needed, and returns the function releasing it. It waits for the lock until the
+// timeout set with WithLockTimeout, and returns an error wrapping ErrLockTimeout once it
+// expires, or until ctx is done. Without a lock file it returns right away. The lock file is
+// left in place once released.
+func (o *Options) AcquireLock(ctx context.Context) (func(), error) {
+	if o.LockFile == "" {
+		return func() {}, nil
+	}
+
+	if err := o.MkdirParents(o.LockFile); err != nil {
+		return nil, fmt.Errorf("failed to create lock file directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Clean(o.LockFile), os.O_RDWR|os.O_CREATE, o.FilePermission())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	var expired <-chan time.Time
+	if o.LockTimeout > 0 {
+		timer := time.NewTimer(o.LockTimeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return func() {
+				_ = unlock(f)
+				_ = f.Close()
+			}, nil
+		}
+		if !errors.Is(err, errLocked) {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", o.LockFile, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", o.LockFile, ctx.Err())
+		case <-expired:
+			_ = f.Close()
+			return nil, fmt.Errorf("%w: %s is still locked after %s", ErrLockTimeout, o.LockFile, o.LockTimeout)
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
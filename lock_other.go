@@ -0,0 +1,35 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || windows)
+
+package gogather
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// tryLock returns an error, as file locks are not supported on this platform.
+func tryLock(f *os.File) error {
+	return fmt.Errorf("file locks are not supported on %s", runtime.GOOS)
+}
+
+// unlock does nothing, as no lock can be taken.
+func unlock(f *os.File) error {
+	return nil
+}
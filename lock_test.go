@@ -0,0 +1,67 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestAcquireLock tests that a lock file is held by one gather at a time.
+func TestAcquireLock(t *testing.T) {
+	lockPollInterval = time.Millisecond
+	defer func() { lockPollInterval = 50 * time.Millisecond }()
+
+	lockFile := filepath.Join(t.TempDir(), "cache", ".lock")
+	o := NewOptions(WithLockFile(lockFile), WithLockTimeout(10*time.Millisecond))
+
+	release, err := o.AcquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := o.AcquireLock(context.Background()); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected ErrLockTimeout, but got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected to wait for the timeout, but gave up after %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewOptions(WithLockFile(lockFile)).AcquireLock(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but got: %v", err)
+	}
+
+	// The lock is acquired once released, even by a gather already waiting for it
+	time.AfterFunc(10*time.Millisecond, release)
+	release, err = NewOptions(WithLockFile(lockFile), WithLockTimeout(5*time.Second)).AcquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	release()
+
+	release, err = NewOptions().AcquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error without a lock file: %v", err)
+	}
+	release()
+}
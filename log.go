@@ -0,0 +1,37 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LevelTrace is the level of the most detailed logs, such as every request and redirect.
+const LevelTrace = slog.LevelDebug - 4
+
+// Log logs msg with the key-value pairs of args at level to the logger of WithLogger.
+// Without a logger, or below the level set with WithLogLevel, nothing is logged.
+func (o *Options) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if o.Logger == nil {
+		return
+	}
+	if o.LogLevel != nil && level < o.LogLevel.Level() {
+		return
+	}
+	o.Logger.Log(ctx, level, msg, args...)
+}
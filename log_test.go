@@ -0,0 +1,62 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestLog tests that messages are logged to the logger, if any.
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	o := NewOptions(WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	o.Log(context.Background(), slog.LevelInfo, "gathered", "source", "foo")
+	if !strings.Contains(buf.String(), `msg=gathered source=foo`) {
+		t.Errorf("Expected the message to be logged, but got: %q", buf.String())
+	}
+
+	// Without a logger, logging does nothing
+	NewOptions().Log(context.Background(), slog.LevelInfo, "gathered")
+}
+
+// TestLog_Level tests that messages below the level of WithLogLevel are not logged.
+func TestLog_Level(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelTrace})
+	o := NewOptions(WithLogger(slog.New(handler)), WithLogLevel(slog.LevelWarn))
+
+	o.Log(context.Background(), slog.LevelInfo, "milestone")
+	o.Log(context.Background(), LevelTrace, "request")
+	o.Log(context.Background(), slog.LevelWarn, "warning")
+	if strings.Contains(buf.String(), "milestone") || strings.Contains(buf.String(), "request") {
+		t.Errorf("Expected messages below the level to be dropped, but got: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "msg=warning") {
+		t.Errorf("Expected the warning to be logged, but got: %q", buf.String())
+	}
+
+	buf.Reset()
+	NewOptions(WithLogger(slog.New(handler)), WithLogLevel(LevelTrace)).Log(context.Background(), LevelTrace, "request")
+	if !strings.Contains(buf.String(), "msg=request") {
+		t.Errorf("Expected the trace message to be logged, but got: %q", buf.String())
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metadata
+
+// The authentication methods reported by Metadata.AuthMethod.
+const (
+	// AuthAnonymous is reported when no credentials were sent, as for local files.
+	AuthAnonymous = "anonymous"
+	// AuthBasic is reported for a user and password sent with basic authentication, such as
+	// those given in the URL of the source.
+	AuthBasic = "basic"
+	// AuthDigest is reported for a user and password sent with digest authentication.
+	AuthDigest = "digest"
+	// AuthBearer is reported for a token, such as the one set with WithGitToken or the token
+	// of a kubeconfig or service account.
+	AuthBearer = "bearer"
+	// AuthClientCertificate is reported for a TLS client certificate.
+	AuthClientCertificate = "client-certificate"
+	// AuthRequestSigner is reported for requests signed with WithRequestSigner.
+	AuthRequestSigner = "request-signer"
+	// AuthSSHKey is reported for SSH connections authenticated with the keys of the user.
+	AuthSSHKey = "ssh-key"
+	// AuthSSHAgent is reported for SSH connections authenticated through the SSH agent.
+	AuthSSHAgent = "ssh-agent"
+	// AuthCredentialHelper is reported when the git binary was left to find credentials with
+	// the credential helpers it is configured with.
+	AuthCredentialHelper = "credential-helper"
+)
@@ -23,7 +23,8 @@
 // The FileMetadata and DirectoryMetadata types both have a Get method,
 // which returns a map containing the metadata information.
 // The Get method can be used to obtain a description
-// of the metadata in a structured format.
+// of the metadata in a structured format. Encoded with json.Marshal, the metadata is wrapped
+// in a metadata.Envelope of type "file" or "directory".
 //
 // Example usage:
 //
@@ -41,35 +42,117 @@
 package file
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// The types of the metadata in the JSON envelopes of metadata.UnmarshalMetadata.
+const (
+	FileType      = "file"
+	DirectoryType = "directory"
 )
 
+func init() {
+	metadata.RegisterType(FileType, func(data []byte) (metadata.Metadata, error) {
+		m := &FileMetadata{}
+		return m, json.Unmarshal(data, m)
+	})
+	metadata.RegisterType(DirectoryType, func(data []byte) (metadata.Metadata, error) {
+		m := &DirectoryMetadata{}
+		return m, json.Unmarshal(data, m)
+	})
+}
+
 type FileMetadata struct {
-	Size      int64
-	Path      string
-	Timestamp time.Time
-	SHA       string
+	Size      int64     `json:"size"`
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+	SHA       string    `json:"sha"`
+	// DetectedType is the content type detected from the file contents.
+	DetectedType string `json:"detectedType"`
+	// Auth is how the gather authenticated with the source, one of the metadata.Auth constants.
+	Auth string `json:"auth"`
+	// Timing is the time the gather spent in each of its phases.
+	Timing metadata.Timings `json:"timing"`
 }
 
 type DirectoryMetadata struct {
-	Size      int64
-	Path      string
-	Timestamp time.Time
+	Size      int64     `json:"size"`
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+	// Auth is how the gather authenticated with the source, one of the metadata.Auth constants.
+	Auth string `json:"auth"`
+	// Timing is the time the gather spent in each of its phases.
+	Timing metadata.Timings `json:"timing"`
 }
 
 func (m *FileMetadata) Get() map[string]any {
 	return map[string]any{
-		"size":      m.Size,
-		"path":      m.Path,
-		"timestamp": m.Timestamp,
-		"sha":       m.SHA,
+		"size":         m.Size,
+		"path":         m.Path,
+		"timestamp":    m.Timestamp,
+		"sha":          m.SHA,
+		"detectedType": m.DetectedType,
+		"auth":         m.Auth,
 	}
 }
 
+// MarshalJSON encodes the metadata in an envelope of type "file".
+func (m FileMetadata) MarshalJSON() ([]byte, error) {
+	type fields FileMetadata
+	return metadata.MarshalEnvelope(FileType, fields(m))
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "file".
+func (m *FileMetadata) UnmarshalJSON(data []byte) error {
+	type fields FileMetadata
+	return metadata.UnmarshalEnvelope(data, FileType, (*fields)(m))
+}
+
+// DetectedContentType returns the content type detected from the file contents.
+func (m *FileMetadata) DetectedContentType() string {
+	return m.DetectedType
+}
+
+// Timings returns the time the gather spent in each of its phases.
+func (m *FileMetadata) Timings() metadata.Timings {
+	return m.Timing
+}
+
+// AuthMethod returns how the gather authenticated with the source.
+func (m *FileMetadata) AuthMethod() string {
+	return m.Auth
+}
+
 func (m *DirectoryMetadata) Get() map[string]any {
 	return map[string]any{
 		"size":      m.Size,
 		"path":      m.Path,
 		"timestamp": m.Timestamp,
+		"auth":      m.Auth,
 	}
 }
+
+// Timings returns the time the gather spent in each of its phases.
+func (m *DirectoryMetadata) Timings() metadata.Timings {
+	return m.Timing
+}
+
+// AuthMethod returns how the gather authenticated with the source.
+func (m *DirectoryMetadata) AuthMethod() string {
+	return m.Auth
+}
+
+// MarshalJSON encodes the metadata in an envelope of type "directory".
+func (m DirectoryMetadata) MarshalJSON() ([]byte, error) {
+	type fields DirectoryMetadata
+	return metadata.MarshalEnvelope(DirectoryType, fields(m))
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "directory".
+func (m *DirectoryMetadata) UnmarshalJSON(data []byte) error {
+	type fields DirectoryMetadata
+	return metadata.UnmarshalEnvelope(data, DirectoryType, (*fields)(m))
+}
@@ -16,18 +16,24 @@
 package file
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/enterprise-contract/go-gather/metadata"
 )
 
 func TestFileMetadata_Get(t *testing.T) {
 	testTime := time.Now()
 	// Create a FileMetadata instance
 	m := &FileMetadata{
-		Size:      int64(100),
-		Path:      "/path/to/file",
-		Timestamp: testTime,
-		SHA:       "ef4e93945f5b3d481abe655d6ce3870132994c0bd5840e312d7ac97cde021050",
+		Size:         int64(100),
+		Path:         "/path/to/file",
+		Timestamp:    testTime,
+		SHA:          "ef4e93945f5b3d481abe655d6ce3870132994c0bd5840e312d7ac97cde021050",
+		DetectedType: "text/plain; charset=utf-8",
+		Auth:         metadata.AuthAnonymous,
 	}
 
 	// Call the Get method
@@ -35,10 +41,12 @@ func TestFileMetadata_Get(t *testing.T) {
 
 	// Assert the expected values
 	expected := map[string]interface{}{
-		"size":      int64(100),
-		"path":      "/path/to/file",
-		"timestamp": testTime,
-		"sha":       "ef4e93945f5b3d481abe655d6ce3870132994c0bd5840e312d7ac97cde021050",
+		"size":         int64(100),
+		"path":         "/path/to/file",
+		"timestamp":    testTime,
+		"sha":          "ef4e93945f5b3d481abe655d6ce3870132994c0bd5840e312d7ac97cde021050",
+		"detectedType": "text/plain; charset=utf-8",
+		"auth":         "anonymous",
 	}
 
 	if len(result) != len(expected) {
@@ -59,6 +67,7 @@ func TestDirectoryMetadata_Get(t *testing.T) {
 		Size:      int64(100),
 		Path:      "/path/to/dir/",
 		Timestamp: testTime,
+		Auth:      metadata.AuthAnonymous,
 	}
 
 	// Call the Get method
@@ -69,6 +78,7 @@ func TestDirectoryMetadata_Get(t *testing.T) {
 		"size":      int64(100),
 		"path":      "/path/to/dir/",
 		"timestamp": testTime,
+		"auth":      "anonymous",
 	}
 
 	if len(result) != len(expected) {
@@ -81,3 +91,35 @@ func TestDirectoryMetadata_Get(t *testing.T) {
 		}
 	}
 }
+
+// TestFileMetadata_JSON tests that file and directory metadata survive a JSON round trip.
+func TestFileMetadata_JSON(t *testing.T) {
+	timestamp := time.Date(2024, 5, 23, 7, 37, 27, 0, time.UTC)
+	testCases := []metadata.Metadata{
+		&FileMetadata{Size: 11, Path: "/tmp/foo.txt", Timestamp: timestamp, SHA: "abc", DetectedType: "text/plain", Timing: metadata.Timings{Transfer: time.Second}},
+		&DirectoryMetadata{Size: 22, Path: "/tmp/foo", Timestamp: timestamp},
+	}
+
+	for _, m := range testCases {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		decoded, err := metadata.UnmarshalMetadata(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("expected %#v, but got %#v", m, decoded)
+		}
+	}
+
+	data, err := json.Marshal(FileMetadata{Size: 11, Path: "/tmp/foo.txt", Timestamp: timestamp})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"type":"file","metadata":{"size":11,"path":"/tmp/foo.txt","timestamp":"2024-05-23T07:37:27Z","sha":"","detectedType":"","auth":"","timing":{"resolve":0,"connect":0,"transfer":0,"extract":0}}}`
+	if string(data) != expected {
+		t.Errorf("expected %s, but got %s", expected, data)
+	}
+}
@@ -36,26 +36,76 @@
 package git
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/enterprise-contract/go-gather/metadata"
 )
 
+// GitType is the type of the metadata in the JSON envelopes of metadata.UnmarshalMetadata.
+const GitType = "git"
+
+func init() {
+	metadata.RegisterType(GitType, func(data []byte) (metadata.Metadata, error) {
+		m := &GitMetadata{}
+		return m, json.Unmarshal(data, m)
+	})
+}
+
 // GitMetadata is a struct that represents the metadata of a git repository.
-// It has fields for size, path, timestamp, and commits.
+// It has fields for size, path, timestamp, commits, and the checked out ref and commit SHA.
 type GitMetadata struct {
-	Size      int64
-	Path      string
-	Timestamp time.Time
-	Commits   []object.Commit
+	Size      int64           `json:"size"`
+	Path      string          `json:"path"`
+	Timestamp time.Time       `json:"timestamp"`
+	Commits   []object.Commit `json:"-"`
+	// Ref is the concrete reference that was checked out, e.g. refs/tags/v1.2.3.
+	Ref string `json:"ref"`
+	// SHA is the hash of the checked out commit.
+	SHA string `json:"sha"`
+	// Method is how the files were retrieved: "checkout" for a full clone, "archive" for a
+	// subdirectory exported with git archive, "tree" for a subdirectory and "blob" for a
+	// single file read from the fetched objects, "diff" for the files changed since a ref, and
+	// "archive-api" for a snapshot downloaded from the GitHub or GitLab archive endpoint, and
+	// "bundle" for a git bundle written at Path.
+	Method string `json:"method"`
+	// Changed lists the slash separated paths of the files written because they were added,
+	// modified or renamed since the ref given with WithGitSince.
+	Changed []string `json:"changed"`
+	// Deleted lists the slash separated paths of the files removed or renamed away since the
+	// ref given with WithGitSince, which callers may remove from earlier output.
+	Deleted []string `json:"deleted"`
+	// Refs lists the refs included in a bundle written with WithGitBundle.
+	Refs []string `json:"refs"`
+	// Filter is the partial clone filter the repository was cloned with, if any.
+	Filter string `json:"filter"`
+	// Auth is how the gather authenticated with the source, one of the metadata.Auth constants.
+	Auth string `json:"auth"`
+	// PullRequest is the number of the GitHub pull request or GitLab merge request whose head
+	// was checked out, if any.
+	PullRequest int `json:"pullRequest"`
+	// Timing is the time the gather spent in each of its phases.
+	Timing metadata.Timings `json:"timing"`
 }
 
 func (m GitMetadata) Get() map[string]any {
 	return map[string]any{
-		"size":      m.Size,
-		"path":      m.Path,
-		"timestamp": m.Timestamp,
-		"commits":   m.Commits,
+		"size":        m.Size,
+		"path":        m.Path,
+		"timestamp":   m.Timestamp,
+		"commits":     m.Commits,
+		"ref":         m.Ref,
+		"sha":         m.SHA,
+		"method":      m.Method,
+		"changed":     m.Changed,
+		"deleted":     m.Deleted,
+		"refs":        m.Refs,
+		"filter":      m.Filter,
+		"auth":        m.Auth,
+		"pullRequest": m.PullRequest,
 	}
 }
 
@@ -66,3 +116,89 @@ func (m GitMetadata) GetHashes() []string {
 	}
 	return hashes
 }
+
+// gitFields is the JSON structure of the fields of GitMetadata, with the commits encoded
+// without their storage.
+type gitFields struct {
+	fields
+	Commits []commitFields `json:"commits"`
+}
+
+// fields is GitMetadata without its JSON methods.
+type fields GitMetadata
+
+// commitFields is the JSON structure of a commit.
+type commitFields struct {
+	Hash         string          `json:"hash"`
+	Author       signatureFields `json:"author"`
+	Committer    signatureFields `json:"committer"`
+	PGPSignature string          `json:"pgpSignature"`
+	Message      string          `json:"message"`
+	TreeHash     string          `json:"treeHash"`
+	ParentHashes []string        `json:"parentHashes"`
+}
+
+// signatureFields is the JSON structure of the author or committer of a commit.
+type signatureFields struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	When  time.Time `json:"when"`
+}
+
+// MarshalJSON encodes the metadata in an envelope of type "git".
+func (m GitMetadata) MarshalJSON() ([]byte, error) {
+	commits := make([]commitFields, 0, len(m.Commits))
+	for _, c := range m.Commits {
+		parents := make([]string, 0, len(c.ParentHashes))
+		for _, h := range c.ParentHashes {
+			parents = append(parents, h.String())
+		}
+		commits = append(commits, commitFields{
+			Hash:         c.Hash.String(),
+			Author:       signatureFields(c.Author),
+			Committer:    signatureFields(c.Committer),
+			PGPSignature: c.PGPSignature,
+			Message:      c.Message,
+			TreeHash:     c.TreeHash.String(),
+			ParentHashes: parents,
+		})
+	}
+	return metadata.MarshalEnvelope(GitType, gitFields{fields: fields(m), Commits: commits})
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "git". The decoded commits
+// have no storage, so that their trees, parents and files cannot be read.
+func (m *GitMetadata) UnmarshalJSON(data []byte) error {
+	var v gitFields
+	if err := metadata.UnmarshalEnvelope(data, GitType, &v); err != nil {
+		return err
+	}
+	*m = GitMetadata(v.fields)
+	m.Commits = nil
+	for _, c := range v.Commits {
+		parents := make([]plumbing.Hash, 0, len(c.ParentHashes))
+		for _, h := range c.ParentHashes {
+			parents = append(parents, plumbing.NewHash(h))
+		}
+		m.Commits = append(m.Commits, object.Commit{
+			Hash:         plumbing.NewHash(c.Hash),
+			Author:       object.Signature(c.Author),
+			Committer:    object.Signature(c.Committer),
+			PGPSignature: c.PGPSignature,
+			Message:      c.Message,
+			TreeHash:     plumbing.NewHash(c.TreeHash),
+			ParentHashes: parents,
+		})
+	}
+	return nil
+}
+
+// Timings returns the time the gather spent in each of its phases.
+func (m GitMetadata) Timings() metadata.Timings {
+	return m.Timing
+}
+
+// AuthMethod returns how the gather authenticated with the source.
+func (m GitMetadata) AuthMethod() string {
+	return m.Auth
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
@@ -8,6 +9,8 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/enterprise-contract/go-gather/metadata"
 )
 
 func TestGitMetadata_GetHashes(t *testing.T) {
@@ -35,13 +38,27 @@ func TestGitMetadata_Get(t *testing.T) {
 			{Hash: plumbing.ComputeHash(plumbing.AnyObject, []byte("hash2"))},
 			{Hash: plumbing.ComputeHash(plumbing.AnyObject, []byte("hash3"))},
 		},
+		Ref:     "refs/tags/v1.2.3",
+		SHA:     "fc771c3730239d59dd35e5e0e1b527a78201d5fb",
+		Method:  "checkout",
+		Deleted: []string{"old.txt"},
+		Auth:    "ssh-agent",
 	}
 
 	expectedResult := map[string]any{
-		"size":      int64(100),
-		"path":      "/path/to/repo",
-		"timestamp": metadata.Timestamp,
-		"commits":   metadata.Commits,
+		"size":        int64(100),
+		"path":        "/path/to/repo",
+		"timestamp":   metadata.Timestamp,
+		"commits":     metadata.Commits,
+		"ref":         "refs/tags/v1.2.3",
+		"sha":         "fc771c3730239d59dd35e5e0e1b527a78201d5fb",
+		"method":      "checkout",
+		"changed":     []string(nil),
+		"deleted":     []string{"old.txt"},
+		"refs":        []string(nil),
+		"filter":      "",
+		"auth":        "ssh-agent",
+		"pullRequest": 0,
 	}
 
 	defer os.RemoveAll(metadata.Path)
@@ -49,3 +66,34 @@ func TestGitMetadata_Get(t *testing.T) {
 
 	assert.Equal(t, expectedResult, result)
 }
+
+// TestGitMetadata_JSON tests that the metadata survives a JSON round trip, commits included.
+func TestGitMetadata_JSON(t *testing.T) {
+	when := time.Date(2024, 5, 23, 7, 37, 27, 0, time.UTC)
+	m := GitMetadata{
+		Size:      100,
+		Path:      "/path/to/repo",
+		Timestamp: when,
+		Commits: []object.Commit{{
+			Hash:         plumbing.ComputeHash(plumbing.CommitObject, []byte("commit")),
+			Author:       object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: when},
+			Committer:    object.Signature{Name: "John Doe", Email: "john@example.com", When: when},
+			Message:      "Initial commit\n",
+			TreeHash:     plumbing.ComputeHash(plumbing.TreeObject, []byte("tree")),
+			ParentHashes: []plumbing.Hash{plumbing.ComputeHash(plumbing.CommitObject, []byte("parent"))},
+		}},
+		Ref:     "refs/heads/main",
+		SHA:     plumbing.ComputeHash(plumbing.CommitObject, []byte("commit")).String(),
+		Method:  "checkout",
+		Changed: []string{"a.txt"},
+		Timing:  metadata.Timings{Connect: time.Millisecond},
+	}
+
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"hash":"`+m.SHA+`"`)
+
+	decoded, err := metadata.UnmarshalMetadata(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &m, decoded)
+}
@@ -2,7 +2,10 @@ module github.com/enterprise-contract/go-gather/metadata/git
 
 go 1.21.9
 
-require github.com/go-git/go-git/v5 v5.12.0
+require (
+	github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
+	github.com/go-git/go-git/v5 v5.12.0
+)
 
 require (
 	github.com/ProtonMail/go-crypto v1.0.0 // indirect
@@ -1,3 +1,5 @@
 module github.com/enterprise-contract/go-gather/metadata/http
 
 go 1.22.2
+
+require github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
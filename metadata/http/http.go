@@ -16,18 +16,169 @@
 
 package http
 
+import (
+	"encoding/json"
+
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// The types of the metadata in the JSON envelopes of metadata.UnmarshalMetadata.
+const (
+	HTTPType      = "http"
+	HTTPIndexType = "http-index"
+)
+
+func init() {
+	metadata.RegisterType(HTTPType, func(data []byte) (metadata.Metadata, error) {
+		var m HTTPMetadata
+		return m, json.Unmarshal(data, &m)
+	})
+	metadata.RegisterType(HTTPIndexType, func(data []byte) (metadata.Metadata, error) {
+		m := &HTTPIndexMetadata{}
+		return m, json.Unmarshal(data, m)
+	})
+}
+
 type HTTPMetadata struct {
-	StatusCode    int
-	ContentLength int64
-	Destination   string
-	Headers       map[string][]string
+	StatusCode    int                 `json:"statusCode"`
+	ContentLength int64               `json:"contentLength"`
+	Destination   string              `json:"destination"`
+	Headers       map[string][]string `json:"headers"`
+	// ContentType is the content type reported by the server, if any.
+	ContentType string `json:"contentType"`
+	// DetectedType is the content type detected from the downloaded bytes.
+	DetectedType string `json:"detectedType"`
+	// BytesWritten is the size of the gathered file once it was written, of the archive for
+	// extracted archives, or of the data hashed in hash-only mode.
+	BytesWritten int64 `json:"size"`
+	// Checksum is the checksum of the data as "algorithm:hex", computed in hash-only mode.
+	Checksum string `json:"checksum"`
+	// RelativePath is the path of a file gathered from a directory listing or manifest
+	// relative to its destination, with forward slashes on all platforms.
+	RelativePath string `json:"relativePath"`
+	// ReleaseTag is the tag of the release of a gathered GitHub or GitLab release asset.
+	ReleaseTag string `json:"releaseTag"`
+	// ReleaseAssetID is the ID of a gathered GitHub or GitLab release asset.
+	ReleaseAssetID int64 `json:"releaseAssetID"`
+	// RangeSupported is whether the server answered the range probe of WithRangeProbe with
+	// partial content.
+	RangeSupported bool `json:"rangeSupported"`
+	// Auth is how the gather authenticated with the source, one of the metadata.Auth constants.
+	Auth string `json:"auth"`
+	// Timing is the time the gather spent in each of its phases.
+	Timing metadata.Timings `json:"timing"`
 }
 
 func (m HTTPMetadata) Get() map[string]any {
 	return map[string]any{
-		"statusCode":    m.StatusCode,
-		"contentLength": m.ContentLength,
-		"destination":   m.Destination,
-		"headers":       m.Headers,
+		"statusCode":     m.StatusCode,
+		"contentLength":  m.ContentLength,
+		"destination":    m.Destination,
+		"headers":        m.Headers,
+		"contentType":    m.ContentType,
+		"detectedType":   m.DetectedType,
+		"size":           m.BytesWritten,
+		"checksum":       m.Checksum,
+		"relativePath":   m.RelativePath,
+		"releaseTag":     m.ReleaseTag,
+		"releaseAssetID": m.ReleaseAssetID,
+		"rangeSupported": m.RangeSupported,
+		"auth":           m.Auth,
+	}
+}
+
+// MarshalJSON encodes the metadata in an envelope of type "http".
+func (m HTTPMetadata) MarshalJSON() ([]byte, error) {
+	type fields HTTPMetadata
+	return metadata.MarshalEnvelope(HTTPType, fields(m))
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "http".
+func (m *HTTPMetadata) UnmarshalJSON(data []byte) error {
+	type fields HTTPMetadata
+	return metadata.UnmarshalEnvelope(data, HTTPType, (*fields)(m))
+}
+
+// Size returns the number of bytes written for the gathered file, which is known even when
+// the server did not send a Content-Length.
+func (m HTTPMetadata) Size() int64 {
+	return m.BytesWritten
+}
+
+// DeclaredSize returns the Content-Length the server announced before the download, or -1
+// if it was unknown.
+func (m HTTPMetadata) DeclaredSize() int64 {
+	return m.ContentLength
+}
+
+// DetectedContentType returns the content type detected from the downloaded bytes.
+func (m HTTPMetadata) DetectedContentType() string {
+	return m.DetectedType
+}
+
+// SupportsRange reports whether the server supports range requests, as found by the probe of
+// WithRangeProbe. It is false when the probe was not enabled.
+func (m HTTPMetadata) SupportsRange() bool {
+	return m.RangeSupported
+}
+
+// Timings returns the time the gather spent in each of its phases.
+func (m HTTPMetadata) Timings() metadata.Timings {
+	return m.Timing
+}
+
+// AuthMethod returns how the gather authenticated with the source.
+func (m HTTPMetadata) AuthMethod() string {
+	return m.Auth
+}
+
+// HTTPIndexMetadata describes the files gathered from a directory listing.
+type HTTPIndexMetadata struct {
+	Destination string         `json:"destination"`
+	Files       []HTTPMetadata `json:"files"`
+	// Auth is how the gather authenticated with the source, one of the metadata.Auth constants.
+	Auth string `json:"auth"`
+	// Timing is the time the gather of the whole listing spent in each of its phases.
+	Timing metadata.Timings `json:"timing"`
+}
+
+func (m HTTPIndexMetadata) Get() map[string]any {
+	return map[string]any{
+		"destination": m.Destination,
+		"files":       m.Files,
+		"auth":        m.Auth,
 	}
 }
+
+// MarshalJSON encodes the metadata in an envelope of type "http-index", with the files in
+// envelopes of type "http".
+func (m HTTPIndexMetadata) MarshalJSON() ([]byte, error) {
+	type fields HTTPIndexMetadata
+	return metadata.MarshalEnvelope(HTTPIndexType, fields(m))
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "http-index".
+func (m *HTTPIndexMetadata) UnmarshalJSON(data []byte) error {
+	type fields HTTPIndexMetadata
+	return metadata.UnmarshalEnvelope(data, HTTPIndexType, (*fields)(m))
+}
+
+// Paths returns the paths of the gathered files relative to the destination, with forward
+// slashes on all platforms, so that listings compare equal across operating systems.
+func (m HTTPIndexMetadata) Paths() []string {
+	paths := make([]string, 0, len(m.Files))
+	for _, f := range m.Files {
+		paths = append(paths, f.RelativePath)
+	}
+	return paths
+}
+
+// Timings returns the time the gather of the whole listing spent in each of its phases.
+func (m HTTPIndexMetadata) Timings() metadata.Timings {
+	return m.Timing
+}
+
+// AuthMethod returns how the gather authenticated with the source.
+func (m HTTPIndexMetadata) AuthMethod() string {
+	return m.Auth
+}
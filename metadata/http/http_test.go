@@ -1,17 +1,29 @@
 package http
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
+
+	"github.com/enterprise-contract/go-gather/metadata"
 )
 
 func TestHTTPMetadata_Get(t *testing.T) {
 	// Create a sample HTTPMetadata instance
 	metadata := HTTPMetadata{
-		StatusCode:    200,
-		ContentLength: 1024,
-		Destination:   "https://example.com",
-		Headers:       map[string][]string{"Content-Type": {"text/plain"}},
+		StatusCode:     200,
+		ContentLength:  1024,
+		Destination:    "https://example.com",
+		Headers:        map[string][]string{"Content-Type": {"text/plain"}},
+		ContentType:    "text/plain",
+		DetectedType:   "text/plain; charset=utf-8",
+		BytesWritten:   1000,
+		Checksum:       "sha256:abcd",
+		RelativePath:   "sub/file.txt",
+		ReleaseTag:     "v1.2.3",
+		ReleaseAssetID: 42,
+		RangeSupported: true,
+		Auth:           "bearer",
 	}
 
 	// Call the Get method
@@ -19,13 +31,110 @@ func TestHTTPMetadata_Get(t *testing.T) {
 
 	// Verify the expected values
 	expected := map[string]interface{}{
-		"statusCode":    200,
-		"contentLength": int64(1024),
-		"destination":   "https://example.com",
-		"headers":       map[string][]string{"Content-Type": {"text/plain"}},
+		"statusCode":     200,
+		"contentLength":  int64(1024),
+		"destination":    "https://example.com",
+		"headers":        map[string][]string{"Content-Type": {"text/plain"}},
+		"contentType":    "text/plain",
+		"detectedType":   "text/plain; charset=utf-8",
+		"size":           int64(1000),
+		"checksum":       "sha256:abcd",
+		"relativePath":   "sub/file.txt",
+		"releaseTag":     "v1.2.3",
+		"releaseAssetID": int64(42),
+		"rangeSupported": true,
+		"auth":           "bearer",
 	}
 
 	if !reflect.DeepEqual(result, expected) {
 		t.Errorf("unexpected result: got %v, want %v", result, expected)
 	}
 }
+
+func TestHTTPMetadata_Size(t *testing.T) {
+	metadata := HTTPMetadata{ContentLength: -1, BytesWritten: 11}
+
+	if metadata.Size() != 11 {
+		t.Errorf("unexpected size: got %d, want %d", metadata.Size(), 11)
+	}
+	if metadata.DeclaredSize() != -1 {
+		t.Errorf("unexpected declared size: got %d, want %d", metadata.DeclaredSize(), -1)
+	}
+}
+
+func TestHTTPMetadata_SupportsRange(t *testing.T) {
+	if (HTTPMetadata{}).SupportsRange() || !(HTTPMetadata{RangeSupported: true}).SupportsRange() {
+		t.Errorf("unexpected range support")
+	}
+}
+
+func TestHTTPMetadata_DetectedContentType(t *testing.T) {
+	metadata := HTTPMetadata{DetectedType: "application/x-gzip"}
+
+	if metadata.DetectedContentType() != "application/x-gzip" {
+		t.Errorf("unexpected detected content type: got %s, want %s", metadata.DetectedContentType(), "application/x-gzip")
+	}
+}
+
+func TestHTTPIndexMetadata_Get(t *testing.T) {
+	files := []HTTPMetadata{{StatusCode: 200, Destination: "/tmp/out/a.txt"}}
+	metadata := HTTPIndexMetadata{Destination: "/tmp/out", Files: files, Auth: "basic"}
+
+	expected := map[string]interface{}{
+		"destination": "/tmp/out",
+		"files":       files,
+		"auth":        "basic",
+	}
+
+	if !reflect.DeepEqual(metadata.Get(), expected) {
+		t.Errorf("unexpected result: got %v, want %v", metadata.Get(), expected)
+	}
+}
+
+func TestHTTPIndexMetadata_Paths(t *testing.T) {
+	metadata := HTTPIndexMetadata{Files: []HTTPMetadata{{RelativePath: "a.txt"}, {RelativePath: "sub/b.txt"}}}
+
+	expected := []string{"a.txt", "sub/b.txt"}
+	if !reflect.DeepEqual(metadata.Paths(), expected) {
+		t.Errorf("unexpected result: got %v, want %v", metadata.Paths(), expected)
+	}
+}
+
+// TestHTTPIndexMetadata_JSON tests that the metadata of a listing and its files survives a JSON round trip.
+func TestHTTPIndexMetadata_JSON(t *testing.T) {
+	m := HTTPIndexMetadata{
+		Destination: "/tmp/index",
+		Files: []HTTPMetadata{
+			{StatusCode: 200, ContentLength: 11, Destination: "/tmp/index/a.txt", Headers: map[string][]string{"Etag": {`"abc"`}}, BytesWritten: 11, RelativePath: "a.txt"},
+			{StatusCode: 200, ContentLength: -1, Destination: "/tmp/index/b/c.txt", ReleaseAssetID: 42, RelativePath: "b/c.txt"},
+		},
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := metadata.UnmarshalMetadata(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, &m) {
+		t.Errorf("unexpected result: got %#v, want %#v", decoded, &m)
+	}
+
+	decoded, err = metadata.UnmarshalMetadata(mustMarshal(t, m.Files[0]))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, m.Files[0]) {
+		t.Errorf("unexpected result: got %#v, want %#v", decoded, m.Files[0])
+	}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return data
+}
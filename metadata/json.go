@@ -0,0 +1,95 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownType is returned by UnmarshalMetadata for metadata whose type is not registered.
+var ErrUnknownType = errors.New("unknown metadata type")
+
+// Envelope is the JSON structure all metadata types are encoded in: the type of the metadata,
+// such as "file" or "git", and its fields. For example, a file is encoded as
+//
+//	{"type":"file","metadata":{"size":11,"path":"/tmp/foo.txt",...}}
+type Envelope struct {
+	Type     string          `json:"type"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+var (
+	typesMu sync.RWMutex
+	// types maps the registered metadata types to the functions decoding their envelopes.
+	types = map[string]func(data []byte) (Metadata, error){}
+)
+
+// RegisterType registers decode as the function decoding the envelopes, encoded as JSON, of
+// metadata of type typ for UnmarshalMetadata. The metadata packages register their types when
+// they are imported.
+func RegisterType(typ string, decode func(data []byte) (Metadata, error)) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	types[typ] = decode
+}
+
+// MarshalEnvelope returns the envelope of metadata of type typ whose fields are v, encoded as
+// JSON. It is used to implement json.Marshaler by the metadata types.
+func MarshalEnvelope(typ string, v any) ([]byte, error) {
+	fields, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s metadata: %w", typ, err)
+	}
+	return json.Marshal(Envelope{Type: typ, Metadata: fields})
+}
+
+// UnmarshalEnvelope decodes the fields of the envelope data, of metadata of type typ, into v.
+// It is used to implement json.Unmarshaler by the metadata types.
+func UnmarshalEnvelope(data []byte, typ string, v any) error {
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	if e.Type != typ {
+		return fmt.Errorf("expected %s metadata, but got %q", typ, e.Type)
+	}
+	if err := json.Unmarshal(e.Metadata, v); err != nil {
+		return fmt.Errorf("failed to decode %s metadata: %w", typ, err)
+	}
+	return nil
+}
+
+// UnmarshalMetadata decodes metadata encoded with json.Marshal, returning it as the type the
+// gatherers return, such as *file.FileMetadata or http.HTTPMetadata. The package of the type must
+// be imported, which it is when the gather package is. An error wrapping ErrUnknownType is
+// returned for other types.
+func UnmarshalMetadata(data []byte) (Metadata, error) {
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	typesMu.RLock()
+	decode, ok := types[e.Type]
+	typesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, e.Type)
+	}
+	return decode(data)
+}
@@ -0,0 +1,80 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// testMetadata is metadata of a type registered for the tests.
+type testMetadata struct {
+	Name string `json:"name"`
+}
+
+func (m testMetadata) Get() map[string]any {
+	return map[string]any{"name": m.Name}
+}
+
+func (m testMetadata) AuthMethod() string {
+	return AuthAnonymous
+}
+
+func (m testMetadata) MarshalJSON() ([]byte, error) {
+	type fields testMetadata
+	return MarshalEnvelope("test", fields(m))
+}
+
+func (m *testMetadata) UnmarshalJSON(data []byte) error {
+	type fields testMetadata
+	return UnmarshalEnvelope(data, "test", (*fields)(m))
+}
+
+// TestUnmarshalMetadata tests that metadata is encoded in an envelope and decoded as its registered type.
+func TestUnmarshalMetadata(t *testing.T) {
+	RegisterType("test", func(data []byte) (Metadata, error) {
+		var m testMetadata
+		return m, json.Unmarshal(data, &m)
+	})
+
+	data, err := json.Marshal(testMetadata{Name: "foo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"type":"test","metadata":{"name":"foo"}}`; string(data) != expected {
+		t.Errorf("expected %s, but got %s", expected, data)
+	}
+
+	m, err := UnmarshalMetadata(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m != (testMetadata{Name: "foo"}) {
+		t.Errorf("expected the metadata to be decoded, but got %#v", m)
+	}
+
+	if _, err := UnmarshalMetadata([]byte(`{"type":"other","metadata":{}}`)); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType, but got: %v", err)
+	}
+	if _, err := UnmarshalMetadata([]byte(`not json`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+	if err := json.Unmarshal([]byte(`{"type":"other","metadata":{}}`), &testMetadata{}); err == nil {
+		t.Error("expected an error for an envelope of another type")
+	}
+}
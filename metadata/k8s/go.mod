@@ -0,0 +1,5 @@
+module github.com/enterprise-contract/go-gather/metadata/k8s
+
+go 1.21.9
+
+require github.com/enterprise-contract/go-gather/metadata v0.0.0-20240523073727-ba2c37023242
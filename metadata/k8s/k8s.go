@@ -0,0 +1,99 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package k8s provides the metadata structure for objects gathered from Kubernetes.
+//
+// The K8sMetadata type has a Get method, which returns a map containing the metadata information.
+//
+// Example usage:
+//
+//	m := k8s.K8sMetadata{
+//	    Namespace: "default",
+//	    Kind:      "configmap",
+//	    Name:      "settings",
+//	    Path:      "/path/to/settings",
+//	    Keys:      []string{"config.yaml"},
+//	}
+//	fmt.Println(m.Get())
+package k8s
+
+import (
+	"encoding/json"
+
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// K8sType is the type of the metadata in the JSON envelopes of metadata.UnmarshalMetadata.
+const K8sType = "k8s"
+
+func init() {
+	metadata.RegisterType(K8sType, func(data []byte) (metadata.Metadata, error) {
+		var m K8sMetadata
+		return m, json.Unmarshal(data, &m)
+	})
+}
+
+// K8sMetadata describes a ConfigMap or Secret gathered from Kubernetes.
+type K8sMetadata struct {
+	Namespace string `json:"namespace"`
+	// Kind is either "configmap" or "secret".
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// ResourceVersion is the version of the object that was read.
+	ResourceVersion string `json:"resourceVersion"`
+	// Path is the destination the keys were written to.
+	Path string `json:"path"`
+	// Keys lists the data keys that were written, in order.
+	Keys []string `json:"keys"`
+	// Auth is how the gather authenticated with the source, one of the metadata.Auth constants.
+	Auth string `json:"auth"`
+	// Timing is the time the gather spent in each of its phases.
+	Timing metadata.Timings `json:"timing"`
+}
+
+func (m K8sMetadata) Get() map[string]any {
+	return map[string]any{
+		"namespace":       m.Namespace,
+		"kind":            m.Kind,
+		"name":            m.Name,
+		"resourceVersion": m.ResourceVersion,
+		"path":            m.Path,
+		"keys":            m.Keys,
+		"auth":            m.Auth,
+	}
+}
+
+// MarshalJSON encodes the metadata in an envelope of type "k8s".
+func (m K8sMetadata) MarshalJSON() ([]byte, error) {
+	type fields K8sMetadata
+	return metadata.MarshalEnvelope(K8sType, fields(m))
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "k8s".
+func (m *K8sMetadata) UnmarshalJSON(data []byte) error {
+	type fields K8sMetadata
+	return metadata.UnmarshalEnvelope(data, K8sType, (*fields)(m))
+}
+
+// Timings returns the time the gather spent in each of its phases.
+func (m K8sMetadata) Timings() metadata.Timings {
+	return m.Timing
+}
+
+// AuthMethod returns how the gather authenticated with the source.
+func (m K8sMetadata) AuthMethod() string {
+	return m.Auth
+}
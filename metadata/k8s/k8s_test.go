@@ -0,0 +1,67 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+func TestK8sMetadata_Get(t *testing.T) {
+	m := K8sMetadata{
+		Namespace:       "default",
+		Kind:            "secret",
+		Name:            "credentials",
+		ResourceVersion: "42",
+		Path:            "/tmp/credentials",
+		Keys:            []string{"password", "username"},
+		Auth:            "bearer",
+	}
+
+	expected := map[string]any{
+		"namespace":       "default",
+		"kind":            "secret",
+		"name":            "credentials",
+		"resourceVersion": "42",
+		"path":            "/tmp/credentials",
+		"keys":            []string{"password", "username"},
+		"auth":            "bearer",
+	}
+
+	if !reflect.DeepEqual(m.Get(), expected) {
+		t.Errorf("unexpected result: got %v, want %v", m.Get(), expected)
+	}
+}
+
+// TestK8sMetadata_JSON tests that the metadata survives a JSON round trip.
+func TestK8sMetadata_JSON(t *testing.T) {
+	m := K8sMetadata{Namespace: "default", Kind: "configmap", Name: "settings", ResourceVersion: "42", Path: "/tmp/settings", Keys: []string{"config.yaml"}}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := metadata.UnmarshalMetadata(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, m) {
+		t.Errorf("unexpected result: got %#v, want %#v", decoded, m)
+	}
+}
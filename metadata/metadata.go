@@ -23,4 +23,13 @@ package metadata
 // Metadata is an interface that all metadata types will satisfy.
 type Metadata interface {
 	Get() map[string]any // Example method; adjust according to actual use cases.
+	// AuthMethod returns how the gather authenticated with the source, one of the Auth
+	// constants, such as AuthAnonymous. The credentials themselves are never recorded.
+	AuthMethod() string
+}
+
+// ContentTypeDetector is implemented by metadata of gathered files whose
+// content type was detected from the downloaded bytes.
+type ContentTypeDetector interface {
+	DetectedContentType() string
 }
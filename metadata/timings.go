@@ -0,0 +1,179 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metadata
+
+import (
+	"context"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is the time a gather spent in each of its phases, measured on a best-effort basis.
+// Phases that do not apply to a gatherer are zero. Encoded as JSON, the times are in nanoseconds.
+type Timings struct {
+	// Resolve is the time spent resolving the source, such as DNS lookups and finding the
+	// release or ref to fetch.
+	Resolve time.Duration `json:"resolve"`
+	// Connect is the time spent establishing connections, including TLS handshakes.
+	Connect time.Duration `json:"connect"`
+	// Transfer is the time spent transferring and writing the data, which is the rest of the gather.
+	Transfer time.Duration `json:"transfer"`
+	// Extract is the time spent extracting archives. Archives are extracted while they are
+	// read, so this includes the transfer of their data.
+	Extract time.Duration `json:"extract"`
+}
+
+// Total returns the time spent in all phases.
+func (t Timings) Total() time.Duration {
+	return t.Resolve + t.Connect + t.Transfer + t.Extract
+}
+
+// Timed is implemented by metadata that records the Timings of the gather that produced it.
+type Timed interface {
+	Timings() Timings
+}
+
+// Phase is a phase of a gather timed by a Stopwatch. The transfer is not timed explicitly,
+// it is whatever time is not spent in the other phases.
+type Phase int
+
+const (
+	PhaseResolve Phase = iota
+	PhaseConnect
+	PhaseExtract
+)
+
+// Stopwatch measures the Timings of a gather. It is safe for concurrent use, and the methods
+// of a nil Stopwatch do nothing, so that gatherers can time phases unconditionally.
+type Stopwatch struct {
+	mu      sync.Mutex
+	start   time.Time
+	running int
+	timings Timings
+}
+
+// NewStopwatch returns a Stopwatch started now.
+func NewStopwatch() *Stopwatch {
+	return &Stopwatch{start: time.Now()}
+}
+
+type stopwatchKey struct{}
+
+// WithContext returns a copy of ctx that carries the Stopwatch and records the DNS lookups
+// and connections of the HTTP requests made with it.
+func (s *Stopwatch) WithContext(ctx context.Context) context.Context {
+	if s == nil || ctx == nil {
+		return ctx
+	}
+	return httptrace.WithClientTrace(context.WithValue(ctx, stopwatchKey{}, s), s.clientTrace())
+}
+
+// StopwatchFrom returns the Stopwatch carried by ctx, or nil if there is none.
+func StopwatchFrom(ctx context.Context) *Stopwatch {
+	s, _ := ctx.Value(stopwatchKey{}).(*Stopwatch)
+	return s
+}
+
+// Time starts timing phase and returns the function that stops it. Requests made while a
+// phase is timed count towards that phase only.
+func (s *Stopwatch) Time(phase Phase) (stop func()) {
+	if s == nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	s.running++
+	s.mu.Unlock()
+	start := time.Now()
+	return func() {
+		s.add(phase, time.Since(start))
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}
+}
+
+// Timings returns the time spent in each phase so far, with the rest of the time since the
+// Stopwatch was started as the transfer.
+func (s *Stopwatch) Timings() Timings {
+	if s == nil {
+		return Timings{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.timings
+	t.Transfer = time.Since(s.start) - t.Resolve - t.Connect - t.Extract
+	if t.Transfer < 0 {
+		t.Transfer = 0
+	}
+	return t
+}
+
+func (s *Stopwatch) add(phase Phase, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch phase {
+	case PhaseResolve:
+		s.timings.Resolve += d
+	case PhaseConnect:
+		s.timings.Connect += d
+	case PhaseExtract:
+		s.timings.Extract += d
+	}
+}
+
+// clientTrace returns the hooks recording DNS lookups as resolving and the rest of getting a
+// new connection as connecting, unless another phase is being timed.
+func (s *Stopwatch) clientTrace() *httptrace.ClientTrace {
+	var mu sync.Mutex
+	var getConn, dnsStart time.Time
+	var dns time.Duration
+	counted := func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.running == 0
+	}
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			mu.Lock()
+			defer mu.Unlock()
+			getConn, dns = time.Now(), 0
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			d := time.Since(dnsStart)
+			dns += d
+			mu.Unlock()
+			if counted() {
+				s.add(PhaseResolve, d)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			mu.Lock()
+			d := time.Since(getConn) - dns
+			mu.Unlock()
+			if !info.Reused && counted() {
+				s.add(PhaseConnect, d)
+			}
+		},
+	}
+}
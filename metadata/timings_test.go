@@ -0,0 +1,82 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestStopwatch tests that timed phases are recorded and the rest of the time counts as the transfer.
+func TestStopwatch(t *testing.T) {
+	s := NewStopwatch()
+	stop := s.Time(PhaseExtract)
+	time.Sleep(10 * time.Millisecond)
+	stop()
+	time.Sleep(5 * time.Millisecond)
+
+	timings := s.Timings()
+	if timings.Extract < 10*time.Millisecond {
+		t.Errorf("Expected the extraction to take at least 10ms, but got %s", timings.Extract)
+	}
+	if timings.Transfer < 5*time.Millisecond {
+		t.Errorf("Expected the transfer to take at least 5ms, but got %s", timings.Transfer)
+	}
+	if timings.Resolve != 0 || timings.Connect != 0 {
+		t.Errorf("Expected no resolving or connecting, but got %+v", timings)
+	}
+}
+
+// TestStopwatch_Nil tests that a nil Stopwatch does nothing.
+func TestStopwatch_Nil(t *testing.T) {
+	var s *Stopwatch
+	s.Time(PhaseResolve)()
+	if s.Timings() != (Timings{}) {
+		t.Errorf("Expected no timings, but got %+v", s.Timings())
+	}
+	if StopwatchFrom(context.Background()) != nil {
+		t.Errorf("Expected no stopwatch in an empty context")
+	}
+}
+
+// TestStopwatch_WithContext tests that new connections of requests made with the context are timed.
+func TestStopwatch_WithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	s := NewStopwatch()
+	ctx := s.WithContext(context.Background())
+	if StopwatchFrom(ctx) != s {
+		t.Fatalf("Expected the context to carry the stopwatch")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if s.Timings().Connect <= 0 {
+		t.Errorf("Expected the connection to be timed, but got %+v", s.Timings())
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webdav provides the metadata structure for resources gathered from WebDAV servers.
+//
+// The WebDAVMetadata type has a Get method, which returns a map containing the metadata information.
+//
+// Example usage:
+//
+//	m := &webdav.WebDAVMetadata{
+//	    URL:         "https://dav.example.com/docs/",
+//	    Destination: "/path/to/docs",
+//	    Collection:  true,
+//	    Files:       []webdav.WebDAVFile{{RelativePath: "report.pdf", ETag: `"5e1f"`, Size: 1024}},
+//	}
+//	fmt.Println(m.Get())
+package webdav
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+// WebDAVType is the type of the metadata in the JSON envelopes of metadata.UnmarshalMetadata.
+const WebDAVType = "webdav"
+
+func init() {
+	metadata.RegisterType(WebDAVType, func(data []byte) (metadata.Metadata, error) {
+		m := &WebDAVMetadata{}
+		return m, json.Unmarshal(data, m)
+	})
+}
+
+// WebDAVMetadata describes a file or collection gathered from a WebDAV server.
+type WebDAVMetadata struct {
+	// URL is the URL of the resource, without credentials.
+	URL string `json:"url"`
+	// Destination is the file or directory the resource was written to.
+	Destination string `json:"destination"`
+	// ETag is the entity tag the server reported for the resource, if any.
+	ETag string `json:"etag"`
+	// Collection is true when the resource is a collection, whose members are in Files.
+	Collection bool `json:"collection"`
+	// Files lists the files written, in order.
+	Files []WebDAVFile `json:"files"`
+	// Auth is how the gather authenticated with the source, one of the metadata.Auth constants.
+	Auth string `json:"auth"`
+	// Timing is the time the gather spent in each of its phases.
+	Timing metadata.Timings `json:"timing"`
+}
+
+// WebDAVFile describes a file written by a WebDAV gather.
+type WebDAVFile struct {
+	// RelativePath is the path of the file below the gathered collection, with forward
+	// slashes, or its name for a single file.
+	RelativePath string `json:"relativePath"`
+	// Destination is the path the file was written to.
+	Destination string `json:"destination"`
+	// ETag is the entity tag the server reported for the file, if any.
+	ETag string `json:"etag"`
+	// Size is the number of bytes written.
+	Size int64 `json:"size"`
+	// LastModified is the modification time the server reported for the file, if any.
+	LastModified time.Time `json:"lastModified"`
+}
+
+func (m WebDAVMetadata) Get() map[string]any {
+	return map[string]any{
+		"url":         m.URL,
+		"destination": m.Destination,
+		"etag":        m.ETag,
+		"collection":  m.Collection,
+		"files":       m.Files,
+		"auth":        m.Auth,
+	}
+}
+
+// MarshalJSON encodes the metadata in an envelope of type "webdav".
+func (m WebDAVMetadata) MarshalJSON() ([]byte, error) {
+	type fields WebDAVMetadata
+	return metadata.MarshalEnvelope(WebDAVType, fields(m))
+}
+
+// UnmarshalJSON decodes the metadata from an envelope of type "webdav".
+func (m *WebDAVMetadata) UnmarshalJSON(data []byte) error {
+	type fields WebDAVMetadata
+	return metadata.UnmarshalEnvelope(data, WebDAVType, (*fields)(m))
+}
+
+// Paths returns the paths of the gathered files relative to the destination, with forward
+// slashes, in order.
+func (m WebDAVMetadata) Paths() []string {
+	paths := make([]string, 0, len(m.Files))
+	for _, f := range m.Files {
+		paths = append(paths, f.RelativePath)
+	}
+	return paths
+}
+
+// Timings returns the time the gather spent in each of its phases.
+func (m WebDAVMetadata) Timings() metadata.Timings {
+	return m.Timing
+}
+
+// AuthMethod returns how the gather authenticated with the source.
+func (m WebDAVMetadata) AuthMethod() string {
+	return m.Auth
+}
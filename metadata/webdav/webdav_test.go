@@ -0,0 +1,76 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webdav
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/enterprise-contract/go-gather/metadata"
+)
+
+func TestWebDAVMetadata_Get(t *testing.T) {
+	files := []WebDAVFile{{RelativePath: "a/report.pdf", Destination: "/tmp/docs/a/report.pdf", ETag: `"5e1f"`, Size: 1024}}
+	m := WebDAVMetadata{
+		URL:         "https://dav.example.com/docs/",
+		Destination: "/tmp/docs",
+		ETag:        `"d1"`,
+		Collection:  true,
+		Files:       files,
+		Auth:        "digest",
+	}
+
+	expected := map[string]any{
+		"url":         "https://dav.example.com/docs/",
+		"destination": "/tmp/docs",
+		"etag":        `"d1"`,
+		"collection":  true,
+		"files":       files,
+		"auth":        "digest",
+	}
+
+	if !reflect.DeepEqual(m.Get(), expected) {
+		t.Errorf("unexpected result: got %v, want %v", m.Get(), expected)
+	}
+	if !reflect.DeepEqual(m.Paths(), []string{"a/report.pdf"}) {
+		t.Errorf("unexpected paths: %v", m.Paths())
+	}
+}
+
+// TestWebDAVMetadata_JSON tests that the metadata survives a JSON round trip.
+func TestWebDAVMetadata_JSON(t *testing.T) {
+	m := &WebDAVMetadata{
+		URL:         "https://dav.example.com/docs/report.pdf",
+		Destination: "/tmp/report.pdf",
+		ETag:        `"5e1f"`,
+		Files:       []WebDAVFile{{RelativePath: "report.pdf", Destination: "/tmp/report.pdf", ETag: `"5e1f"`, Size: 1024, LastModified: time.Date(2024, 5, 23, 7, 37, 27, 0, time.UTC)}},
+		Timing:      metadata.Timings{Connect: time.Millisecond},
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := metadata.UnmarshalMetadata(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, m) {
+		t.Errorf("unexpected result: got %#v, want %#v", decoded, m)
+	}
+}
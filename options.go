@@ -0,0 +1,1268 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Option configures a single gather operation.
+type Option func(*Options)
+
+// Options holds the settings consulted by the gatherers.
+// The zero value keeps the default behaviour of every gatherer.
+type Options struct {
+	// AllowedHosts, when not empty, lists the only hosts that may be contacted.
+	AllowedHosts []string
+	// DeniedHosts lists hosts that must never be contacted.
+	DeniedHosts []string
+	// BlockPrivateNetworks refuses connections to private, loopback and link-local addresses.
+	BlockPrivateNetworks bool
+
+	// TransformFunc, when set, is applied to every gathered file.
+	TransformFunc TransformFunc
+
+	// Destination, when set, receives the gathered files instead of the local filesystem.
+	Destination Destination
+
+	// DisableHTTP2 restricts the transport to HTTP/1.1.
+	DisableHTTP2 bool
+
+	// GitRefResolver resolves git refs as "latest" or a semver constraint against the repository tags.
+	GitRefResolver bool
+
+	// DefaultType, when set, classifies ambiguous two segment sources such as "foo/bar".
+	DefaultType *URIType
+
+	// HTTPAutoIndex downloads the files of HTML directory listings recursively.
+	HTTPAutoIndex bool
+
+	// IdleTimeout aborts downloads that receive no data for longer than the duration.
+	IdleTimeout time.Duration
+
+	// GitConfigRewrites applies the insteadOf rules of the git configuration to remote URLs.
+	GitConfigRewrites bool
+
+	// MaxConnsPerHost limits the number of connections to each host.
+	MaxConnsPerHost int
+	// HTTPTransport, when set, is used for HTTP requests instead of a transport built from the options.
+	HTTPTransport http.RoundTripper
+
+	// Decompress decompresses gathered files that are compressed with a single-file format.
+	Decompress bool
+
+	// Checksum is the expected checksum of the gathered file, as "algorithm:hex".
+	Checksum string
+	// Manifest gathers the files listed by sources served as manifests.
+	Manifest bool
+	// ManifestURL is a source that is always gathered as a manifest.
+	ManifestURL string
+
+	// StripGitDir removes the .git directory of cloned repositories.
+	StripGitDir bool
+
+	// DestinationValidator, when set, is called with every destination before it is written.
+	DestinationValidator func(destination string) error
+
+	// GitShallowSince, when not zero, limits cloned history to commits after the time.
+	GitShallowSince time.Time
+
+	// Events, when set, receives events describing the progress of the gather.
+	Events chan<- Event
+
+	// GitRefs lists the refs fetched by the git gatherer in a single fetch.
+	GitRefs []string
+	// GitRemoteName is the name of the remote of cloned repositories, "origin" by default.
+	GitRemoteName string
+	// GitMirror makes the git gatherer create a bare mirror instead of checking out a worktree.
+	GitMirror bool
+
+	// FilePerm, when not zero, is the permission of created files.
+	FilePerm os.FileMode
+	// DirPerm, when not zero, is the permission of created directories.
+	DirPerm os.FileMode
+	// Umask, when set, is cleared from the permission of created files and directories.
+	Umask *os.FileMode
+
+	// Logger, when set, receives the decisions the gatherers make along the way.
+	Logger *slog.Logger
+
+	// GitResume resumes interrupted clones found at the destination.
+	GitResume bool
+
+	// ProgressWriter, when set, receives a textual progress bar of the downloads.
+	ProgressWriter io.Writer
+
+	// HTTPResume resumes downloads from the partial file found at the destination.
+	HTTPResume bool
+
+	// RootCAs, when set, replaces the system roots trusted for TLS connections.
+	RootCAs *x509.CertPool
+	// ClientCertificates are presented to servers that request a client certificate.
+	ClientCertificates []tls.Certificate
+	// InsecureSkipTLSVerify disables the verification of server certificates.
+	InsecureSkipTLSVerify bool
+
+	// MaxBytes, when positive, is the largest size of a gathered file.
+	MaxBytes int64
+	// PreflightHEAD sends a HEAD request to validate http sources before downloading them.
+	PreflightHEAD bool
+
+	// IfExists is the policy applied to destinations of GatherInto that already exist.
+	IfExists IfExists
+
+	// Extract extracts gathered tar archives into the destination directory.
+	Extract bool
+
+	// DenyInsecureTransport refuses sources and redirects using the unencrypted http and git protocols.
+	DenyInsecureTransport bool
+
+	// HashOnly, when set, is the algorithm of the checksum computed instead of saving the data.
+	HashOnly string
+
+	// ExpectContentTypes are the content types http responses may have, if any are set.
+	ExpectContentTypes []string
+
+	// AtomicDir makes recursive gathers replace the destination directory only on success.
+	AtomicDir bool
+
+	// GitAutoDepth makes the git gatherer clone tags without history.
+	GitAutoDepth bool
+
+	// GitToken authenticates requests to the GitHub and GitLab APIs.
+	GitToken string
+
+	// ClientCacheTTL, when positive, is how long dedicated transports are shared per host.
+	ClientCacheTTL time.Duration
+
+	// GitSince, when set, makes the git gatherer write only the files changed since the ref.
+	GitSince string
+
+	// MaxResponseHeaderBytes, when positive, is the largest size of the headers of a response.
+	MaxResponseHeaderBytes int64
+
+	// LogLevel, when set, is the lowest level logged to Logger.
+	LogLevel slog.Leveler
+
+	// NoCreateParents requires the parent directory of a destination to exist.
+	NoCreateParents bool
+
+	// ProgressInterval, when positive, is the minimum time between two progress events.
+	ProgressInterval time.Duration
+
+	// GitExtraArgs are configuration arguments passed to the git commands run by the git gatherer.
+	GitExtraArgs []string
+
+	// UnsafeDestination allows files to be written through existing symbolic links at their
+	// destination paths.
+	UnsafeDestination bool
+
+	// Paginate returns the URL of the page following each page of an HTTP source.
+	Paginate PaginateFunc
+
+	// BufferSize is the size of the buffer data is copied with, or zero for the default.
+	BufferSize int
+
+	// KeepPartialOnError keeps the partial output of a failed gather for inspection.
+	KeepPartialOnError bool
+
+	// GitBranch is the branch the git gatherer checks out.
+	GitBranch string
+
+	// GitTag is the tag the git gatherer checks out.
+	GitTag string
+
+	// GitCommit is the commit hash the git gatherer checks out.
+	GitCommit string
+
+	// ChecksumCache is the directory recording the files known to match their checksum.
+	ChecksumCache string
+
+	// ForceType, when set, is the type of every source instead of the classified one.
+	ForceType *URIType
+
+	// PinnedCertFingerprints are the SHA-256 fingerprints of the certificates trusted for TLS connections.
+	PinnedCertFingerprints []string
+
+	// SignatureURL is the URL of the detached signature gathered files are verified with.
+	SignatureURL string
+	// SignaturePublicKey is the GPG or minisign public key the signature is verified with.
+	SignaturePublicKey []byte
+
+	// MaxRedirectBodyRead, when set, is the largest number of bytes read from the body of a redirect.
+	MaxRedirectBodyRead *int64
+
+	// GitUseArchiveAPI downloads GitHub and GitLab repositories as tarballs instead of cloning them.
+	GitUseArchiveAPI bool
+
+	// URLTemplate maps package URLs to the sources to gather.
+	URLTemplate string
+
+	// Executable makes the http and file gatherers set the executable bits of gathered files.
+	Executable bool
+
+	// DecompressedName, when not empty, is the name of the file written by WithDecompress.
+	DecompressedName string
+	// KeepCompressionExtension keeps the file name of decompressed files as is.
+	KeepCompressionExtension bool
+
+	// LockFile, when not empty, is the file locked for the duration of a gather.
+	LockFile string
+	// LockTimeout is how long a gather waits for the lock file, until the context is done if zero.
+	LockTimeout time.Duration
+
+	// LockInput, when not empty, is the git lock file pinning branches to the commits recorded in it.
+	LockInput string
+	// LockOutput, when not empty, is the git lock file the checked out commits of branches are recorded in.
+	LockOutput string
+
+	// MaxDirDepth is the deepest an entry may lie below the destination, zero for the default and negative for no limit.
+	MaxDirDepth int
+
+	// StripComponents is the number of leading path components removed from extracted archive entries.
+	StripComponents int
+
+	// Resolver, when set, resolves the host names connected to by the transport.
+	Resolver *net.Resolver
+	// HostMapping maps host names, optionally with a port, to the addresses dialed instead.
+	HostMapping map[string]string
+
+	// ContentLengthRequired refuses responses without a Content-Length or with a body of another size.
+	ContentLengthRequired bool
+
+	// GitMetadataOnly makes the git gatherer resolve the commit without cloning the repository.
+	GitMetadataOnly bool
+
+	// SniffCompression decompresses gathered files whose magic bytes show they are compressed.
+	SniffCompression bool
+
+	// GitSingleBranch makes the git gatherer fetch only the requested branch or tag.
+	GitSingleBranch bool
+
+	// RangeProbe makes the http gatherer find whether servers support range requests.
+	RangeProbe bool
+
+	// GunzipSingleFile decompresses downloaded single files named *.gz compressed with gzip.
+	GunzipSingleFile bool
+
+	// GitBundle makes the git gatherer write a git bundle file instead of a worktree.
+	GitBundle bool
+
+	// DefaultDestinationDir is the directory destinations derived for an empty destination are
+	// rooted in, instead of the current working directory.
+	DefaultDestinationDir string
+
+	// HTTPTrailerChecksum verifies downloads against the checksum trailers of the response.
+	HTTPTrailerChecksum bool
+
+	// GitFilter is the partial clone filter the git gatherer clones with.
+	GitFilter string
+
+	// CASLayout makes gather.Gather store gathered content in a content-addressed layout.
+	CASLayout bool
+
+	// RequestSigner signs every request the http gatherer sends.
+	RequestSigner RequestSigner
+
+	// Include holds the gitignore-style patterns of the files recursive gathers write.
+	Include []string
+	// Exclude holds the gitignore-style patterns of the files recursive gathers leave out.
+	Exclude []string
+}
+
+// NewOptions returns the Options resulting from applying opts in order.
+func NewOptions(opts ...Option) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithAllowedHosts restricts the hosts that may be contacted to the given list.
+// Entries may contain shell-style wildcards, e.g. "*.example.com".
+func WithAllowedHosts(hosts []string) Option {
+	return func(o *Options) {
+		o.AllowedHosts = hosts
+	}
+}
+
+// WithDeniedHosts prevents the given hosts from being contacted.
+// Entries may contain shell-style wildcards and take precedence over WithAllowedHosts.
+func WithDeniedHosts(hosts []string) Option {
+	return func(o *Options) {
+		o.DeniedHosts = hosts
+	}
+}
+
+// WithBlockPrivateNetworks refuses to contact hosts that resolve to private,
+// loopback or link-local addresses.
+func WithBlockPrivateNetworks(block bool) Option {
+	return func(o *Options) {
+		o.BlockPrivateNetworks = block
+	}
+}
+
+// WithTransform applies fn to every gathered file after it has been written to the destination.
+// If fn returns an error other than ErrSkipTransform, the gather is aborted and the written
+// output is removed. A destination that already existed is not removed: the files of a
+// gathered directory are only replaced once fn has transformed every one of them, so they are
+// left as gathered, untransformed, rather than partly transformed.
+func WithTransform(fn TransformFunc) Option {
+	return func(o *Options) {
+		o.TransformFunc = fn
+	}
+}
+
+// WithDestination writes the gathered files to d instead of the local filesystem.
+// The destination argument of Gather is then interpreted as a path within d.
+// Gatherers that need a local working copy, such as the git gatherer, return
+// ErrDestinationNotSupported, and WithTransform cannot be combined with it.
+func WithDestination(d Destination) Option {
+	return func(o *Options) {
+		o.Destination = d
+	}
+}
+
+// WithHTTP2Disabled restricts HTTP requests to HTTP/1.1, as an escape hatch for servers
+// with a broken HTTP/2 implementation. It only applies when the gatherer builds its own
+// transport; an http.Client supplied with a custom Transport is used as is.
+func WithHTTP2Disabled(disabled bool) Option {
+	return func(o *Options) {
+		o.DisableHTTP2 = disabled
+	}
+}
+
+// WithGitRefResolver makes the git gatherer treat the ref of a source as "latest" or a
+// semver constraint, such as "^1.2", and check out the highest tag satisfying it.
+// Tags that are not semver versions are ignored, and "latest" skips pre-releases.
+func WithGitRefResolver(enabled bool) Option {
+	return func(o *Options) {
+		o.GitRefResolver = enabled
+	}
+}
+
+// WithDefaultType classifies ambiguous two segment sources, such as "foo/bar", as t instead
+// of as Git URIs. Only GitURI and FileURI are honoured; other types keep the default precedence.
+// Sources ending with ".git" are always Git URIs.
+func WithDefaultType(t URIType) Option {
+	return func(o *Options) {
+		o.DefaultType = &t
+	}
+}
+
+// WithHTTPAutoIndex makes the http gatherer treat sources whose path ends with a slash as
+// Apache or nginx autoindex pages. The files linked from the page are downloaded into the
+// destination directory and subdirectories are descended into. Only links below the listed
+// directory are followed, so parent directory and sorting links are ignored. Relative links
+// are resolved against the URL the listing was redirected to, or its <base href> tag when it
+// points below the listed directory on the same host.
+func WithHTTPAutoIndex(enabled bool) Option {
+	return func(o *Options) {
+		o.HTTPAutoIndex = enabled
+	}
+}
+
+// WithIdleTimeout aborts a download with ErrIdleTimeout when no bytes are received for longer
+// than d, whether waiting for the response headers or reading the body. Unlike an overall
+// deadline it catches stalled connections without limiting the duration of large downloads.
+// It applies to the http gatherer.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.IdleTimeout = d
+	}
+}
+
+// WithGitConfigRewrites makes the git gatherer apply the url.<base>.insteadOf rules of the
+// system and global git configuration to the remote URL before cloning, as native git does.
+// Host restrictions are checked against the rewritten URL.
+func WithGitConfigRewrites(enabled bool) Option {
+	return func(o *Options) {
+		o.GitConfigRewrites = enabled
+	}
+}
+
+// WithMaxConnsPerHost limits the number of concurrent connections to each host to n.
+// GatherAll shares a single transport across its gathers, so the limit applies to the whole
+// run rather than to each source. Like WithHTTP2Disabled, it only applies when the gatherer
+// builds its own transport.
+func WithMaxConnsPerHost(n int) Option {
+	return func(o *Options) {
+		o.MaxConnsPerHost = n
+	}
+}
+
+// WithHTTPTransport makes the gatherers send HTTP requests through rt, so that several
+// gathers can share its connections and limits. The transport is used as is, so the
+// transport related options are not applied to it.
+func WithHTTPTransport(rt http.RoundTripper) Option {
+	return func(o *Options) {
+		o.HTTPTransport = rt
+	}
+}
+
+// WithDecompress decompresses a gathered file compressed with gzip, bzip2, xz or zstd and
+// writes the decompressed content, dropping the compression extension from the file name.
+// The format is detected from the magic bytes, and gathering a file in any other format
+// fails with ErrUnknownCompression, whatever its extension. A name without an extension of
+// the detected format, such as "data.bin" holding gzip data, is kept as is. Use
+// WithDecompressedName or WithKeepCompressionExtension to choose the name instead. Unlike
+// archive extraction, it handles a single file. It applies to the http and file gatherers;
+// directories are copied as is.
+func WithDecompress(enabled bool) Option {
+	return func(o *Options) {
+		o.Decompress = enabled
+	}
+}
+
+// WithChecksum verifies the downloaded file against checksum, given as "sha256:<hex>" or
+// "sha512:<hex>"; a bare hex value is taken as SHA-256. The checksum covers the bytes
+// received, before any decompression or transform. On a mismatch the gather fails with
+// ErrChecksumMismatch and the written file is removed. It applies to the http gatherer.
+func WithChecksum(checksum string) Option {
+	return func(o *Options) {
+		o.Checksum = checksum
+	}
+}
+
+// WithManifest makes the http gatherer treat sources served with the manifest content type,
+// application/vnd.go-gather.manifest+json, as manifests: every file listed is downloaded into
+// the destination directory and verified against its checksum. See the http package for the
+// manifest schema.
+func WithManifest(enabled bool) Option {
+	return func(o *Options) {
+		o.Manifest = enabled
+	}
+}
+
+// WithManifestURL makes the http gatherer treat the source u as a manifest whatever its
+// content type, as with WithManifest.
+func WithManifestURL(u string) Option {
+	return func(o *Options) {
+		o.ManifestURL = u
+	}
+}
+
+// WithKeepGitDir controls whether the git gatherer keeps the .git directory of a clone,
+// which it does by default. Without it the destination is a clean snapshot of the files,
+// but later fetches, pulls or other git commands in the destination are no longer possible.
+// Gathering a subdirectory or a single file never produces a .git directory.
+func WithKeepGitDir(keep bool) Option {
+	return func(o *Options) {
+		o.StripGitDir = !keep
+	}
+}
+
+// WithDestinationValidator calls fn with every destination before it is written, in addition
+// to the checks of the gatherer, so that callers can enforce their own rules, e.g. that
+// destinations are below a workspace directory or are not symlinks. The error returned by
+// fn aborts the gather and is returned as is.
+func WithDestinationValidator(fn func(destination string) error) Option {
+	return func(o *Options) {
+		o.DestinationValidator = fn
+	}
+}
+
+// WithGitShallowSince makes the git gatherer fetch only the history committed after t, like
+// git clone --shallow-since. The history is deepened in steps until it reaches t and then cut
+// at the oldest commits after t, so the commits of the metadata and of the clone all date
+// from after t. Since git cannot limit history by depth and date at once, combining it with
+// a depth in the source URL fails.
+func WithGitShallowSince(t time.Time) Option {
+	return func(o *Options) {
+		o.GitShallowSince = t
+	}
+}
+
+// WithEventChannel sends events describing the progress of the gather, such as its start,
+// the bytes read and its end, to ch. Events are sent without blocking and dropped when ch is
+// full, so a slow consumer never stalls the gather; give ch a buffer to keep them. The
+// channel is not closed by the gatherers. See Event for the events sent by each gatherer.
+func WithEventChannel(ch chan<- Event) Option {
+	return func(o *Options) {
+		o.Events = ch
+	}
+}
+
+// WithGitRefs makes the git gatherer fetch all of refs in a single fetch and check out the
+// first one, or check out nothing when combined with WithGitMirror. Refs are full names, such
+// as "refs/tags/v1.0.0", or branch names. Branches other than the first are kept as
+// remote-tracking branches. It cannot be combined with a ref or a subdirectory in the source.
+func WithGitRefs(refs []string) Option {
+	return func(o *Options) {
+		o.GitRefs = refs
+	}
+}
+
+// WithGitRemoteName names the remote of repositories cloned by the git gatherer name instead
+// of "origin".
+func WithGitRemoteName(name string) Option {
+	return func(o *Options) {
+		o.GitRemoteName = name
+	}
+}
+
+// WithGitMirror makes the git gatherer create a bare repository mirroring the refs of the
+// remote, like git clone --mirror, without a worktree. Combined with WithGitRefs only the
+// listed refs are mirrored. It cannot be combined with a subdirectory in the source.
+func WithGitMirror(mirror bool) Option {
+	return func(o *Options) {
+		o.GitMirror = mirror
+	}
+}
+
+// WithFilePerm gives the files created by the gatherers the permission perm instead of 0644.
+// Files whose mode is carried over from the source, such as executables in git repositories,
+// keep their mode. Like WithUmask, it is applied explicitly and not subject to the umask of
+// the process.
+func WithFilePerm(perm os.FileMode) Option {
+	return func(o *Options) {
+		o.FilePerm = perm
+	}
+}
+
+// WithDirPerm gives the directories created by the gatherers the permission perm instead of
+// 0755. Directories that already exist are left as is.
+func WithDirPerm(perm os.FileMode) Option {
+	return func(o *Options) {
+		o.DirPerm = perm
+	}
+}
+
+// WithUmask clears the bits of mask from the permission of the files and directories created
+// by the gatherers, instead of the umask of the process. It is applied on top of WithFilePerm
+// and WithDirPerm, and to modes carried over from the source, such as the executable bit of
+// files in git repositories: with a mask of 077, an executable is created as 0700. For example,
+// WithFilePerm(0664) with WithUmask(002) keeps files group-writable in a shared directory.
+func WithUmask(mask os.FileMode) Option {
+	return func(o *Options) {
+		o.Umask = &mask
+	}
+}
+
+// WithLogger makes the gatherers log to l the decisions they make along the way, such as
+// falling back to a fresh clone when an interrupted one cannot be resumed.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithGitResume makes the git gatherer resume an interrupted clone of the same remote found
+// at the destination, fetching into it and checking out the ref instead of cloning from
+// scratch. Objects already fetched are not fetched again. A repository of the same remote
+// that cannot be resumed is removed and cloned again, which is logged; a repository of
+// another remote is never removed. It does not apply to subdirectories or WithGitRefs.
+func WithGitResume(resume bool) Option {
+	return func(o *Options) {
+		o.GitResume = resume
+	}
+}
+
+// WithProgressWriter draws the progress of downloads to w, such as os.Stderr, for command line
+// users. The http and file gatherers draw a bar for each file, or a spinner when the size is
+// unknown, updated in place with carriage returns and finished with a newline. The git
+// gatherer writes the progress reported by the remote, as git clone does.
+func WithProgressWriter(w io.Writer) Option {
+	return func(o *Options) {
+		o.ProgressWriter = w
+	}
+}
+
+// WithHTTPResume makes the http gatherer resume a download from the partial file found at the
+// destination, requesting only the remaining bytes with a Range header. Any file found at the
+// destination is taken as a partial download, so it may be replaced. Downloaded files are
+// given the Last-Modified time of the server, which a resume sends in an If-Range header, so
+// that the server sends the whole file instead when the remote file changed. Servers that
+// ignore the range send the whole file, which replaces the partial one. When the server
+// rejects the range, the partial file is kept as the complete file if it matches the checksum
+// of WithChecksum, and the download restarts from scratch otherwise. Files from servers that
+// send no Last-Modified header are downloaded again. Downloads to a custom destination or with
+// WithDecompress are not resumed.
+func WithHTTPResume(enabled bool) Option {
+	return func(o *Options) {
+		o.HTTPResume = enabled
+	}
+}
+
+// WithRootCAs makes TLS connections trust the certificate authorities in pool instead of the
+// system roots, for servers with certificates issued by a private CA. It applies to the http
+// and git gatherers when they build their own transport, as WithHTTP2Disabled does.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(o *Options) {
+		o.RootCAs = pool
+	}
+}
+
+// WithClientCert presents cert to servers that request a client certificate over TLS. It can
+// be given several times to offer more than one certificate. Load a certificate and its key
+// with tls.LoadX509KeyPair.
+func WithClientCert(cert tls.Certificate) Option {
+	return func(o *Options) {
+		o.ClientCertificates = append(o.ClientCertificates, cert)
+	}
+}
+
+// WithInsecureSkipTLSVerify disables the verification of server certificates, so any server
+// can impersonate the remote. Prefer WithRootCAs, and only use it against test servers.
+func WithInsecureSkipTLSVerify(skip bool) Option {
+	return func(o *Options) {
+		o.InsecureSkipTLSVerify = skip
+	}
+}
+
+// WithMaxBytes makes the http gatherer refuse files larger than n bytes with ErrTooLarge. The
+// size announced by the server is checked before the body is read, and the download stops as
+// soon as more than n bytes are received, in case the size is unknown or wrong. The limit
+// applies to the downloaded bytes, before any decompression. Manifests, directory listings and
+// release descriptions are read under the same limit.
+func WithMaxBytes(n int64) Option {
+	return func(o *Options) {
+		o.MaxBytes = n
+	}
+}
+
+// WithPreflightHEAD makes the http gatherer send a HEAD request before downloading a file,
+// failing early when the server reports that it does not exist, responds with 413 Request
+// Entity Too Large, or announces a size above WithMaxBytes. The strong ETag returned, if any,
+// is sent with If-Match, so the download fails if the file changed after it was validated.
+// Servers that do not support HEAD, answering 405 Method Not Allowed or any other unexpected
+// status, are not validated and the file is downloaded as usual.
+func WithPreflightHEAD(enabled bool) Option {
+	return func(o *Options) {
+		o.PreflightHEAD = enabled
+	}
+}
+
+// WithIfExists sets the policy applied by GatherInto to the destination of a subpath that
+// already exists: fail, which is the default, skip the subpath or overwrite the destination.
+func WithIfExists(policy IfExists) Option {
+	return func(o *Options) {
+		o.IfExists = policy
+	}
+}
+
+// WithExtract makes the http and file gatherers extract a gathered tar archive into the
+// destination, which is then the directory receiving its entries rather than the file name
+// of the archive. Archives compressed with gzip, bzip2, xz or zstd, such as ".tar.gz" or
+// ".tzst" files, are decompressed first. Gathering anything else fails with ErrNotArchive,
+// and entries escaping the destination fail with ErrUnsafeArchive. It takes precedence over
+// WithDecompress, and a checksum set with WithChecksum applies to the archive.
+func WithExtract(enabled bool) Option {
+	return func(o *Options) {
+		o.Extract = enabled
+	}
+}
+
+// WithInsecureHTTPAllowed, when allowed is false, makes the gatherers refuse http:// and git://
+// sources, redirects to http:// and Kubernetes API servers reached over http:// with
+// ErrInsecureTransport, so that TLS can be enforced centrally. Plaintext protocols are
+// allowed by default.
+func WithInsecureHTTPAllowed(allowed bool) Option {
+	return func(o *Options) {
+		o.DenyInsecureTransport = !allowed
+	}
+}
+
+// WithHashOnly makes the http gatherer compute the checksum of the source with algorithm,
+// "sha256" or "sha512", while streaming it, and discard the data instead of writing it. The
+// destination is ignored, and the checksum is returned in the metadata as "algorithm:hex",
+// for example to check a published checksum against the live artifact. A checksum set with
+// WithChecksum is still verified.
+func WithHashOnly(algorithm string) Option {
+	return func(o *Options) {
+		o.HashOnly = algorithm
+	}
+}
+
+// WithExpectContentType makes the http gatherer check the Content-Type of the response
+// against contentTypes, which may use wildcards such as "application/*", and fail with
+// ErrUnexpectedContentType if it does not match any of them. This catches HTML error,
+// login and captive portal pages served with a 200 status instead of the expected file.
+func WithExpectContentType(contentTypes []string) Option {
+	return func(o *Options) {
+		o.ExpectContentTypes = contentTypes
+	}
+}
+
+// WithAtomicDir makes recursive gathers, of local directories, git repositories and
+// extracted archives, write into a temporary sibling of the destination directory that is
+// renamed into place once the gather succeeded, replacing the previous directory, and removed
+// otherwise. Consumers never see a partially updated directory. The previous and the new
+// directory both exist until the rename, so the disk space needed is that of both, and the
+// parent directory must be writable.
+func WithAtomicDir(atomic bool) Option {
+	return func(o *Options) {
+		o.AtomicDir = atomic
+	}
+}
+
+// WithGitAutoDepth makes the git gatherer pick the clone depth from the requested ref: a ref
+// naming a tag, rather than a branch, or a tag resolved with WithGitRefResolver is cloned
+// with a depth of 1, as a pinned tag needs no history, while branches and sources without a
+// ref are cloned with their full history. A depth given in the source, such as "?depth=10",
+// or a date given with WithGitShallowSince overrides the heuristic.
+func WithGitAutoDepth(enabled bool) Option {
+	return func(o *Options) {
+		o.GitAutoDepth = enabled
+	}
+}
+
+// WithGitToken sets the token authenticating the http gatherer with the GitHub and GitLab
+// APIs when resolving release assets, so that assets of private repositories can be gathered
+// and rate limits are raised. The token is sent to the API host only.
+func WithGitToken(token string) Option {
+	return func(o *Options) {
+		o.GitToken = token
+	}
+}
+
+// WithClientCache makes gathers in the same process share the dedicated transports that
+// options such as WithRootCAs, WithClientCert or WithBlockPrivateNetworks require, per host,
+// for ttl after their creation, so that repeated gathers from the same host reuse connections
+// and TLS sessions. Transports are only shared between gathers with the same transport
+// settings and credentials. Gathers that need no dedicated transport already share
+// http.DefaultTransport. The k8s gatherer, whose TLS settings come from the cluster
+// configuration, does not share its transports.
+func WithClientCache(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.ClientCacheTTL = ttl
+	}
+}
+
+// WithGitSince makes the git gatherer write only the files that changed between ref and the
+// checked out ref, for incremental builds. ref may be a branch, tag or commit. Files that were
+// removed or renamed away are reported in the Deleted field of the git metadata, so that the
+// caller can remove them from earlier output. The history is fetched in full, so the option
+// cannot be combined with a depth or WithGitShallowSince.
+func WithGitSince(ref string) Option {
+	return func(o *Options) {
+		o.GitSince = ref
+	}
+}
+
+// WithMaxResponseHeaderBytes makes HTTP requests fail with ErrResponseTooLarge when the
+// headers of a response exceed n bytes, guarding against servers sending huge headers. Bodies
+// are limited separately, with WithMaxBytes.
+func WithMaxResponseHeaderBytes(n int64) Option {
+	return func(o *Options) {
+		o.MaxResponseHeaderBytes = n
+	}
+}
+
+// WithLogLevel makes the gatherers log only at level and above, from slog.LevelError down to
+// LevelTrace: slog.LevelInfo logs the milestones of a gather, slog.LevelDebug the decisions made
+// along the way, and LevelTrace every request and redirect as well. It is used together with
+// WithLogger, whose handler must accept the level too. Without it, every message is passed to
+// the logger, which filters them by its own level.
+func WithLogLevel(level slog.Level) Option {
+	return func(o *Options) {
+		o.LogLevel = level
+	}
+}
+
+// WithCreateParents controls whether the file and http gatherers create the missing parent
+// directories of a destination, with the permission of WithDirPerm, which they do by default.
+// With WithCreateParents(false) a destination whose parent directory does not exist is
+// refused with an error wrapping fs.ErrNotExist.
+func WithCreateParents(create bool) Option {
+	return func(o *Options) {
+		o.NoCreateParents = !create
+	}
+}
+
+// WithProgressInterval sends progress events at most once per interval d, plus a final event
+// with the bytes read once the data is read, so that fast downloads do not flood the consumer
+// of WithEventChannel. The progress bar of WithProgressWriter is always redrawn at most ten
+// times per second.
+func WithProgressInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.ProgressInterval = d
+	}
+}
+
+// WithGitExtraArgs passes git configuration settings to the git binary the git gatherer runs,
+// given as "-c key=value", "--config key=value" or "--config=key=value", for example
+// "-c", "http.postBuffer=524288000". Clones are made with go-git, so the settings only apply
+// to the git commands used to export subdirectories with git archive. Only settings that tune
+// the transfer are allowed: http.postBuffer, http.lowSpeedLimit, http.lowSpeedTime,
+// http.maxRequests, pack.*, core.compression, core.bigFileThreshold, protocol.version,
+// transfer.fsckObjects and fetch.fsckObjects. Anything else, such as --upload-pack,
+// core.sshCommand or http.sslVerify, fails the gather with git.ErrGitArgNotAllowed. The
+// arguments are passed without a shell, so values are used verbatim and need no escaping.
+func WithGitExtraArgs(args []string) Option {
+	return func(o *Options) {
+		o.GitExtraArgs = args
+	}
+}
+
+// WithSafeDestination controls whether gathers refuse to write a file through an existing
+// symbolic link at its destination path, which could otherwise make a gather overwrite the
+// file the link points to. It is enabled by default and applies to every file written while
+// copying directories or extracting archives as well; such writes fail with
+// ErrDestinationIsSymlink. Links created by an archive are subject to the same check, so an
+// archive may not write a file over one of its own links.
+func WithSafeDestination(safe bool) Option {
+	return func(o *Options) {
+		o.UnsafeDestination = !safe
+	}
+}
+
+// WithPaginate makes the http gatherer follow the pages of a paginated HTTP source and write
+// all of them, one after another, to the destination, as if they were a single file. next is
+// given each page in turn and returns the URL of the following page, or an empty string once
+// the last page is reached; NextLink follows Link headers and NextField a field of JSON pages.
+// Each page is held in memory until it is written. The maximum size set with WithMaxBytes and
+// the checksum set with WithChecksum apply to the concatenated pages, and resuming downloads
+// is not supported.
+func WithPaginate(next PaginateFunc) Option {
+	return func(o *Options) {
+		o.Paginate = next
+	}
+}
+
+// WithBufferSize sets the size, in bytes, of the buffer the http and file gatherers copy data
+// with, instead of the default of 32 KiB. Larger buffers mean fewer, larger reads and writes,
+// which can improve the throughput of large downloads over fast, high-latency links, while
+// smaller buffers save memory when gathering many small files concurrently. The size must be
+// between MinBufferSize (4 KiB) and MaxBufferSize (64 MiB), or the gather fails. Setting a size
+// disables the zero-copy transfers the operating system can make between local files.
+func WithBufferSize(n int) Option {
+	return func(o *Options) {
+		o.BufferSize = n
+	}
+}
+
+// WithKeepPartialOnError makes the http and file gatherers keep the partial output of a failed
+// gather for inspection rather than removing it: a file or directory written to dest is renamed
+// to "<dest>.partial", replacing any previous one, and the returned error gives its path. It
+// applies to downloads that fail while being written, downloads that do not match the checksum
+// set with WithChecksum, failed extractions and failed transforms. Partial downloads left for
+// WithHTTPResume to resume are kept in place, as before.
+func WithKeepPartialOnError(keep bool) Option {
+	return func(o *Options) {
+		o.KeepPartialOnError = keep
+	}
+}
+
+// WithGitBranch makes the git gatherer check out the branch called name, as a local branch
+// tracking the one of the remote. WithGitBranch, WithGitTag and WithGitCommit are spelled out
+// alternatives to the ref of the URL: only one of them may be set, and not along with a
+// "?ref=" query parameter or WithGitRefs, or the gather fails.
+func WithGitBranch(name string) Option {
+	return func(o *Options) {
+		o.GitBranch = name
+	}
+}
+
+// WithGitTag makes the git gatherer check out the tag called name, leaving HEAD detached at
+// the tag. See WithGitBranch for the options it cannot be combined with.
+func WithGitTag(name string) Option {
+	return func(o *Options) {
+		o.GitTag = name
+	}
+}
+
+// WithGitCommit makes the git gatherer check out the commit with the full or abbreviated hash
+// sha, leaving HEAD detached at it. The commit must be reachable from a branch or tag of the
+// remote, and within the depth of the clone if one is set; mirrors cannot check out a commit.
+// See WithGitBranch for the options it cannot be combined with.
+func WithGitCommit(sha string) Option {
+	return func(o *Options) {
+		o.GitCommit = sha
+	}
+}
+
+// WithChecksumCache keeps a cache of verified checksums in dir, so that a large file that
+// already matched the checksum of WithChecksum is not hashed again, for example when a
+// resumed http download finds the file complete. Each file is recorded with its size and
+// modification time, and hashed again once either changes. Files are recorded once verified
+// while being downloaded or by Options.VerifyFile, unless a transform rewrote them.
+func WithChecksumCache(dir string) Option {
+	return func(o *Options) {
+		o.ChecksumCache = dir
+	}
+}
+
+// WithForceType makes Gather route every source to the gatherer of t instead of classifying
+// it, as an escape hatch for inputs that ClassifyURI gets wrong. The source still has to be
+// parseable by that gatherer: HTTPURI requires an http:// or https:// URL with a host, K8sURI
+// the k8s:// scheme, and FileURI a path or file:// URL. Unknown cannot be forced.
+func WithForceType(t URIType) Option {
+	return func(o *Options) {
+		o.ForceType = &t
+	}
+}
+
+// WithPinnedCertFingerprints makes TLS connections of the http and git gatherers fail with
+// ErrCertPinMismatch unless the server presents a certificate whose fingerprint is one of
+// fingerprints, in addition to the usual verification, so that a compromised certificate
+// authority cannot impersonate known hosts. A fingerprint is the SHA-256 digest of the DER
+// encoding of the certificate, normally the leaf, in hex. Colons and upper case are accepted,
+// as printed by "openssl x509 -noout -fingerprint -sha256". Pinning several fingerprints
+// allows certificates to be rotated.
+func WithPinnedCertFingerprints(fingerprints []string) Option {
+	return func(o *Options) {
+		o.PinnedCertFingerprints = fingerprints
+	}
+}
+
+// WithSignatureFromURL makes the http gatherer download the detached signature at sigURL,
+// which may be relative to the source URL such as "file.tar.gz.sig", and verify the gathered
+// file against it with publicKey before finalizing it. A file that does not match is removed
+// and ErrSignatureInvalid returned. GPG signatures, armored as .asc or binary as .sig, are
+// verified with an armored or binary public key. Minisign signatures, whose first line is an
+// untrusted comment, are verified with a minisign public key, either the key file or its
+// base64 line, and their trusted comment is verified as well. Signatures cannot be verified
+// together with WithExtract, WithDecompress, WithHashOnly, WithDestination, WithManifest or
+// WithHTTPAutoIndex.
+func WithSignatureFromURL(sigURL string, publicKey []byte) Option {
+	return func(o *Options) {
+		o.SignatureURL = sigURL
+		o.SignaturePublicKey = publicKey
+	}
+}
+
+// WithMaxRedirectBodyRead caps the number of bytes read and discarded from the body of a
+// redirect response before the redirect is followed, so that misconfigured servers sending
+// large bodies along with a redirect do not waste bandwidth. A body that is not read to the
+// end within n bytes is closed, and its connection is not reused; with n zero, redirect bodies
+// are not read at all. Without the option, net/http reads up to 2 KiB of bodies of unknown
+// or small size. It applies to the http and git gatherers.
+func WithMaxRedirectBodyRead(n int64) Option {
+	return func(o *Options) {
+		o.MaxRedirectBodyRead = &n
+	}
+}
+
+// WithGitUseArchiveAPI makes the git gatherer download the tarball of the ref from the archive
+// endpoint of GitHub or GitLab, such as https://github.com/org/repo/archive/refs/tags/v1.tar.gz,
+// and extract it instead of cloning repositories hosted there. This needs no git and is much
+// faster for a snapshot at a tag, but writes no .git directory and gathers no history. The
+// default branch of GitLab repositories, commits that have no tarball, remotes on other hosts,
+// git refs, mirrors and WithGitShallowSince are cloned as before. GitHub redirects the download
+// to codeload.github.com, which WithAllowedHosts must allow. With WithGitToken, the token
+// authenticates the download.
+func WithGitUseArchiveAPI(enabled bool) Option {
+	return func(o *Options) {
+		o.GitUseArchiveAPI = enabled
+	}
+}
+
+// WithURLTemplate makes Gather map package URLs, such as "pkg:maven/org.example/lib@1.2.3",
+// to the source produced by the text/template tmpl, so that any package registry can be
+// gathered from. The template is executed with the PackageURL parsed by ParsePackageURL, whose
+// fields are available as {{.Type}}, {{.Namespace}}, {{.Name}}, {{.Version}}, {{.Subpath}}
+// and {{.Qualifiers.key}}, and with the functions "replace OLD NEW", "lower", "pathescape"
+// and "queryescape". For example, Maven Central artifacts are gathered with
+//
+//	https://repo1.maven.org/maven2/{{.Namespace | replace "." "/"}}/{{.Name}}/{{.Version}}/{{.Name}}-{{.Version}}.jar
+//
+// Use {{if eq .Type "npm"}} to map several package types. Other sources are gathered as is.
+func WithURLTemplate(tmpl string) Option {
+	return func(o *Options) {
+		o.URLTemplate = tmpl
+	}
+}
+
+// WithExecutable makes the http and file gatherers set the executable bits of the gathered
+// file before returning, for downloading binaries without a separate chmod. The bits are set
+// where the file is readable: 0644 becomes 0755 and, with WithFilePerm(0640), 0750. Without
+// WithFilePerm and WithUmask, the permission the file was created with, and so the umask of
+// the process, is kept. It does not apply to directories or extracted archives.
+func WithExecutable(executable bool) Option {
+	return func(o *Options) {
+		o.Executable = executable
+	}
+}
+
+// WithDecompressedName writes the file decompressed with WithDecompress as name, in the
+// directory of the destination, instead of the destination name without its compression
+// extension. The name must not contain a path separator.
+func WithDecompressedName(name string) Option {
+	return func(o *Options) {
+		o.DecompressedName = name
+	}
+}
+
+// WithKeepCompressionExtension keeps the name of the file decompressed with WithDecompress as
+// is, so that "foo.txt.gz" is decompressed into "foo.txt.gz". By default, the compression
+// extension is dropped and a shorthand such as ".tgz" becomes ".tar". WithDecompressedName
+// takes precedence.
+func WithKeepCompressionExtension(keep bool) Option {
+	return func(o *Options) {
+		o.KeepCompressionExtension = keep
+	}
+}
+
+// WithLockFile makes gathers hold an advisory lock on the file at path, created if missing,
+// while they write to their destination, so that processes gathering into the same
+// destination, such as CI jobs sharing a cache directory, take turns instead of corrupting each
+// other's output. Only gathers using the same lock file are serialized, and the lock is not
+// enforced on other writers. The sources of GatherAll and the other functions gathering several
+// sources are gathered under a single lock. See WithLockTimeout to bound the wait.
+func WithLockFile(path string) Option {
+	return func(o *Options) {
+		o.LockFile = path
+	}
+}
+
+// WithLockTimeout makes gathers give up waiting for the lock file set with WithLockFile after
+// d, failing with ErrLockTimeout. By default they wait until their context is done.
+func WithLockTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.LockTimeout = d
+	}
+}
+
+// WithLockInput makes the git gatherer check out the commit recorded for the source in the
+// lock file at path, written by WithLockOutput, instead of the tip of its branch, so that a
+// gather tracking a branch can be reproduced. Sources missing from the file, or a missing
+// file, are gathered from their branch. Tags and commits are already pinned and are not
+// looked up. Unlike WithLockFile, the file records commits and is not locked.
+func WithLockInput(path string) Option {
+	return func(o *Options) {
+		o.LockInput = path
+	}
+}
+
+// WithLockOutput makes the git gatherer record the commit it checked out for a branch in the
+// lock file at path, keyed by source, alongside the entries of other sources already in it.
+// Pass the same path to WithLockInput to keep later gathers pinned to the recorded commits,
+// and leave it out to move them to the tip of their branch. Unlike WithLockFile, the file
+// records commits and is not locked.
+func WithLockOutput(path string) Option {
+	return func(o *Options) {
+		o.LockOutput = path
+	}
+}
+
+// WithMaxDirDepth makes recursive gathers, such as directory copies, archive extraction and git
+// checkouts, fail with ErrMaxDepthExceeded when an entry would lie more than n path elements
+// below the destination, so that "a/b/c" has a depth of 3. This protects against pathological
+// sources and path length limits, such as MAX_PATH on Windows. Zero restores the default of
+// DefaultMaxDirDepth and a negative n removes the limit.
+func WithMaxDirDepth(n int) Option {
+	return func(o *Options) {
+		o.MaxDirDepth = n
+	}
+}
+
+// WithStripComponents makes archives extracted with WithExtract lose the first n components of
+// the paths of their entries, like the --strip-components option of tar, so that the entries
+// of a tarball wrapped in "repo-1.2.3/" are extracted from "repo-1.2.3/src/main.go" to
+// "src/main.go". The targets of hard links are stripped alike. Directories left without a path,
+// such as "repo-1.2.3/" itself, are skipped, and any other such entry fails the extraction with
+// ErrStripComponents.
+func WithStripComponents(n int) Option {
+	return func(o *Options) {
+		o.StripComponents = n
+	}
+}
+
+// WithResolver makes the http and git gatherers resolve host names with r instead of the
+// default resolver, such as one querying a specific DNS server through its Dial function.
+// It applies when the gatherer builds its own transport, as WithHTTP2Disabled does, and to
+// the private network check of WithBlockPrivateNetworks. Git over SSH is not covered.
+func WithResolver(r *net.Resolver) Option {
+	return func(o *Options) {
+		o.Resolver = r
+	}
+}
+
+// WithHostMapping makes the http and git gatherers connect to the address mapped to a host
+// instead of resolving it, like an entry of /etc/hosts, for testing and split-horizon DNS.
+// Keys are host names, or host names and ports such as "example.com:443", which take
+// precedence. Values are IP addresses or host names, with a port replacing the one of the URL
+// if given. The requests, and the verification of TLS certificates, still use the original
+// host name. It applies when the gatherer builds its own transport, as WithHTTP2Disabled does.
+func WithHostMapping(mapping map[string]string) Option {
+	return func(o *Options) {
+		o.HostMapping = mapping
+	}
+}
+
+// WithContentLengthRequired makes the http gatherer fail with ErrContentLengthMissing when a
+// response has no Content-Length header, and with ErrContentLengthMismatch when its body is not
+// exactly as long as declared, which catches truncated transfers when no checksum is
+// available. Responses are then requested without a content encoding, as the transport drops
+// the length of the responses it decompresses, unless an Accept-Encoding header is given.
+func WithContentLengthRequired(required bool) Option {
+	return func(o *Options) {
+		o.ContentLengthRequired = required
+	}
+}
+
+// WithGitMetadataOnly makes the git gatherer resolve the ref of the source with the refs the
+// remote advertises, as git ls-remote does, and return the metadata of its commit without
+// checking out the repository or writing to the destination. The commit is fetched into
+// memory without its history, for its author, committer and message. Of the refs of
+// WithGitRefs, a single one can be resolved.
+func WithGitMetadataOnly(enabled bool) Option {
+	return func(o *Options) {
+		o.GitMetadataOnly = enabled
+	}
+}
+
+// WithSniffCompression makes the http and file gatherers decide from the magic bytes alone
+// whether a gathered file is compressed, whatever its extension: a "data.json" file holding
+// gzip data is decompressed, as with WithDecompress, while a "data.json.gz" file that is not
+// compressed is written as is rather than failing with ErrUnknownCompression. A warning is
+// logged when the extension and the content disagree. The decompressed file is named as with
+// WithDecompress.
+func WithSniffCompression(enabled bool) Option {
+	return func(o *Options) {
+		o.SniffCompression = enabled
+	}
+}
+
+// WithGitSingleBranch makes the git gatherer clone only the requested branch or tag, as
+// git clone --single-branch does, or the branch HEAD of the remote points to when none is
+// requested, instead of the refs of every branch. It combines with a depth or a shallow
+// since date to limit the history of that branch. The clone is configured to fetch that
+// branch alone, so later fetches in it do not see other branches unless its remote is
+// reconfigured, and a commit or since ref must be reachable from it. It cannot be combined
+// with WithGitRefs or WithGitMirror.
+func WithGitSingleBranch(enabled bool) Option {
+	return func(o *Options) {
+		o.GitSingleBranch = enabled
+	}
+}
+
+// WithRangeProbe makes the http gatherer send a "Range: bytes=0-0" request before a download
+// to find whether the server supports range requests, reported by the SupportsRange method of
+// the metadata. A definite result is cached per host for the life of the process, while
+// failed probes and error responses are tried again by the next gather. A resumed download,
+// see WithHTTPResume, then starts from scratch rather than sending a range to a server that
+// does not support it.
+func WithRangeProbe(enabled bool) Option {
+	return func(o *Options) {
+		o.RangeProbe = enabled
+	}
+}
+
+// WithGunzipSingleFile makes the http gatherer decompress a file whose name, from the URL or
+// the Content-Disposition header, ends with ".gz" but not ".tar.gz", such as "file.json.gz",
+// and that is compressed with gzip, writing it as "file.json". This is distinct from the
+// Content-Encoding of the response, which the transport decodes. Such a file is decompressed
+// even with WithExtract, which still extracts tar archives, and written as is, with a
+// warning, if it is not compressed after all. The file is named as with WithDecompress.
+func WithGunzipSingleFile(enabled bool) Option {
+	return func(o *Options) {
+		o.GunzipSingleFile = enabled
+	}
+}
+
+// WithGitBundle makes the git gatherer write the fetched history to a git bundle file at the
+// destination instead of checking out a worktree, for moving a repository across an air gap
+// and cloning from it. The bundle is named after the repository, with a ".bundle" extension,
+// when the destination is a directory. It holds the requested ref, or the refs of WithGitRefs,
+// or else all branches and tags, or all refs with WithGitMirror, and HEAD. A history limited
+// by a depth or WithGitShallowSince lists the parents of its oldest commits as prerequisites,
+// which a repository must have to fetch from the bundle, so that such a bundle cannot be
+// cloned from. It cannot be combined with a subdirectory in the source or WithGitSince.
+func WithGitBundle(enabled bool) Option {
+	return func(o *Options) {
+		o.GitBundle = enabled
+	}
+}
+
+// WithDefaultDestinationDir roots the destinations that gather.Gather derives for an empty
+// destination in dir instead of the current working directory.
+func WithDefaultDestinationDir(dir string) Option {
+	return func(o *Options) {
+		o.DefaultDestinationDir = dir
+	}
+}
+
+// WithHTTPTrailerChecksum makes the http gatherer verify a download against the checksum the
+// server sends in an X-Checksum-Sha256 or X-Checksum-Sha512 trailer, hex encoded, after the
+// body, failing with ErrChecksumMismatch if it does not match. Downloads without such a
+// trailer are not checked, and resumed downloads and paginated sources are never checked
+// against trailers. It complements WithChecksum, which is verified as well.
+func WithHTTPTrailerChecksum(enabled bool) Option {
+	return func(o *Options) {
+		o.HTTPTrailerChecksum = enabled
+	}
+}
+
+// WithGitFilter makes the git gatherer clone partially, asking the remote to leave out the
+// objects filter matches, such as "blob:none" for all blobs, "blob:limit=1m" for blobs of
+// at least a megabyte, "tree:0" for all trees, or "object:type=blob". The objects left out
+// are fetched as they are needed, by the checkout for instance, so that cloning the history
+// of a large repository is much faster. Filtered clones are made with the git binary, which
+// must be installed, and from a remote that supports filters; other remotes send all objects.
+// Other specs are refused. The filter is reported in the metadata. It cannot be combined
+// with a subdirectory in the source, WithGitRefs, WithGitSince, WithGitShallowSince,
+// WithGitBundle or WithGitUseArchiveAPI.
+func WithGitFilter(spec string) Option {
+	return func(o *Options) {
+		o.GitFilter = spec
+	}
+}
+
+// WithCASLayout makes gather.Gather treat the destination as a content-addressed store, for
+// build caches shared across sources: the content of every gathered file is stored at
+// destination/sha256/<the first two hex digits of its hash>/<its hash>, see gather.CASPath,
+// and the checksums are returned in the metadata. Content the store already has is not
+// written again. File names, directories, symbolic links and the .git directory of a clone
+// are not kept in the store. It cannot be combined with WithDestination.
+func WithCASLayout(enabled bool) Option {
+	return func(o *Options) {
+		o.CASLayout = enabled
+	}
+}
+
+// WithRequestSigner makes the http gatherer sign every request it sends with signer, just
+// before it is sent, for sources that authenticate signed requests, such as APIs behind AWS
+// Signature Version 4, see NewSigV4Signer. Redirects and the requests for further pages are
+// signed separately. signer may change the headers of the request, but not its body; an error
+// it returns fails the request.
+func WithRequestSigner(signer RequestSigner) Option {
+	return func(o *Options) {
+		o.RequestSigner = signer
+	}
+}
+
+// WithInclude makes recursive gathers, such as git clones, directory copies, extracted
+// archives, autoindex listings and WebDAV collections, only write the files matching one of
+// the gitignore-style patterns, such as "*.yaml", "/policy/**/*.rego" or "docs/": a pattern
+// without a slash, other than a trailing one, matches a name at any depth, one with a slash
+// matches the path from the root of the gather, "**" matches any number of directories and a
+// trailing slash matches directories only, including every file below them. Excludes win over
+// includes, see WithExclude and Options.Included. Negated patterns are not supported.
+func WithInclude(patterns []string) Option {
+	return func(o *Options) {
+		o.Include = patterns
+	}
+}
+
+// WithExclude makes recursive gathers leave out the files matching one of the gitignore-style
+// patterns, and every file in the directories they match, written as for WithInclude. A file
+// matching both an include and an exclude pattern is left out.
+func WithExclude(patterns []string) Option {
+	return func(o *Options) {
+		o.Exclude = patterns
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PaginateFunc returns the URL of the page following the response resp, whose body is page,
+// or an empty string if resp is the last page. Relative URLs are resolved against the URL of
+// the response.
+type PaginateFunc func(resp *http.Response, page []byte) (string, error)
+
+// NextLink is a PaginateFunc following the link with the "next" relation of the Link header
+// of each page, as returned by the GitHub and GitLab APIs among others.
+func NextLink(resp *http.Response, _ []byte) (string, error) {
+	for _, value := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				key, rel, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, r := range strings.Fields(strings.Trim(strings.TrimSpace(rel), `"`)) {
+					if strings.EqualFold(r, "next") {
+						return strings.Trim(target, "<>"), nil
+					}
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// NextField returns a PaginateFunc reading the URL of the next page from a field of the JSON
+// object of each page, given as a dotted path such as "links.next". A missing, null or empty
+// field ends the pagination.
+func NextField(path string) PaginateFunc {
+	return func(_ *http.Response, page []byte) (string, error) {
+		var value any
+		if err := json.Unmarshal(page, &value); err != nil {
+			return "", fmt.Errorf("failed to parse page: %w", err)
+		}
+		for _, key := range strings.Split(path, ".") {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return "", nil
+			}
+			value = object[key]
+		}
+		switch next := value.(type) {
+		case nil:
+			return "", nil
+		case string:
+			return next, nil
+		default:
+			return "", fmt.Errorf("field %s of page is not a string", path)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestNextLink tests that the link with the next relation is found in Link headers.
+func TestNextLink(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header []string
+		want   string
+	}{
+		{name: "no header"},
+		{name: "next", header: []string{`<https://api.example.com/items?page=2>; rel="next"`}, want: "https://api.example.com/items?page=2"},
+		{name: "several links", header: []string{`<https://api.example.com/items?page=1>; rel="prev", <https://api.example.com/items?page=3>; rel="next", <https://api.example.com/items?page=9>; rel="last"`}, want: "https://api.example.com/items?page=3"},
+		{name: "several headers", header: []string{`</items?page=1>; rel="first"`, `</items?page=2>; rel=next`}, want: "/items?page=2"},
+		{name: "several relations", header: []string{`</items?page=2>; title="more"; rel="Next alternate"`}, want: "/items?page=2"},
+		{name: "last page", header: []string{`</items?page=1>; rel="first", </items?page=1>; rel="prev"`}},
+		{name: "malformed", header: []string{`/items?page=2; rel="next"`}},
+	}
+
+	for _, tc := range testCases {
+		resp := &http.Response{Header: http.Header{"Link": tc.header}}
+		next, err := NextLink(resp, nil)
+		if err != nil || next != tc.want {
+			t.Errorf("%s: expected %q, but got %q, %v", tc.name, tc.want, next, err)
+		}
+	}
+}
+
+// TestNextField tests that the next page URL is read from a field of JSON pages.
+func TestNextField(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		page    string
+		want    string
+		wantErr bool
+	}{
+		{name: "top-level field", path: "next", page: `{"items": [], "next": "/items?page=2"}`, want: "/items?page=2"},
+		{name: "nested field", path: "links.next", page: `{"links": {"next": "/items?page=2"}}`, want: "/items?page=2"},
+		{name: "null field", path: "links.next", page: `{"links": {"next": null}}`},
+		{name: "missing field", path: "links.next", page: `{"items": []}`},
+		{name: "not an object", path: "links.next", page: `{"links": "none"}`},
+		{name: "not a string", path: "next", page: `{"next": 2}`, wantErr: true},
+		{name: "not JSON", path: "next", page: `<html></html>`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		next, err := NextField(tc.path)(&http.Response{}, []byte(tc.page))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if next != tc.want {
+			t.Errorf("%s: expected %q, but got %q", tc.name, tc.want, next)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"fmt"
+	"os"
+)
+
+// PartialSuffix is appended to the path of the partial output kept by WithKeepPartialOnError.
+const PartialSuffix = ".partial"
+
+// DiscardPartial disposes of the output at path of a gather that failed with err, and returns
+// the error to report. The output, a file or a directory, is removed, unless partial output
+// is kept with WithKeepPartialOnError: it is then renamed to path with PartialSuffix, replacing
+// any previous partial output, and the returned error names it.
+func (o *Options) DiscardPartial(path string, err error) error {
+	return o.discardPartial(path, path+PartialSuffix, err)
+}
+
+// discardPartial is DiscardPartial with the output at path kept as partial.
+func (o *Options) discardPartial(path, partial string, err error) error {
+	if !o.KeepPartialOnError {
+		_ = os.RemoveAll(path)
+		return err
+	}
+	if _, statErr := os.Lstat(path); statErr != nil {
+		return err
+	}
+	_ = os.RemoveAll(partial)
+	if renameErr := os.Rename(path, partial); renameErr != nil {
+		partial = path
+	}
+	return fmt.Errorf("%w (partial output kept at %s)", err, partial)
+}
@@ -0,0 +1,88 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDiscardPartial tests that partial output is removed by default and renamed when kept.
+func TestDiscardPartial(t *testing.T) {
+	errFailed := errors.New("failed")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo.txt")
+
+	if err := os.WriteFile(path, []byte("partial"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewOptions().DiscardPartial(path, errFailed); err != errFailed {
+		t.Errorf("Expected the error as is, but got: %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial file to be removed, but got: %v", err)
+	}
+
+	for _, content := range []string{"old partial", "partial"} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		err := NewOptions(WithKeepPartialOnError(true)).DiscardPartial(path, errFailed)
+		if !errors.Is(err, errFailed) || !strings.Contains(err.Error(), path+PartialSuffix) {
+			t.Errorf("Expected the error to name the partial file, but got: %v", err)
+		}
+	}
+	if content, err := os.ReadFile(path + PartialSuffix); err != nil || string(content) != "partial" {
+		t.Errorf("Expected the latest partial file to be kept, but got: %q, %v", content, err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial file to be moved, but got: %v", err)
+	}
+
+	// Nothing is named when there is no output
+	err := NewOptions(WithKeepPartialOnError(true)).DiscardPartial(filepath.Join(dir, "missing.txt"), errFailed)
+	if err != errFailed {
+		t.Errorf("Expected the error as is, but got: %v", err)
+	}
+}
+
+// TestStageDir_KeepPartial tests that the staging directory of a failed gather is kept as the
+// partial output of the destination directory.
+func TestStageDir_KeepPartial(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	staging, finish, err := NewOptions(WithAtomicDir(true), WithKeepPartialOnError(true)).StageDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, "foo.txt"), []byte("partial"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = finish(errors.New("failed"))
+	if err == nil || !strings.Contains(err.Error(), dir+PartialSuffix) {
+		t.Errorf("Expected the error to name the partial directory, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir+PartialSuffix, "foo.txt")); err != nil {
+		t.Errorf("Expected the partial directory to be kept, but got: %v", err)
+	}
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Errorf("Expected no staging directory to be left, but got: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// defaultFilePerm is the permission of created files without WithFilePerm.
+	defaultFilePerm os.FileMode = 0644
+	// defaultDirPerm is the permission of created directories without WithDirPerm.
+	defaultDirPerm os.FileMode = 0755
+)
+
+// FilePermission returns the permission of created files that have no mode of their own:
+// the permission of WithFilePerm, 0644 by default, with the umask applied.
+func (o *Options) FilePermission() os.FileMode {
+	perm := defaultFilePerm
+	if o.FilePerm != 0 {
+		perm = o.FilePerm
+	}
+	return o.PreservedPermission(perm)
+}
+
+// DirPermission returns the permission of created directories: the permission of
+// WithDirPerm, 0755 by default, with the umask applied.
+func (o *Options) DirPermission() os.FileMode {
+	perm := defaultDirPerm
+	if o.DirPerm != 0 {
+		perm = o.DirPerm
+	}
+	return o.PreservedPermission(perm)
+}
+
+// PreservedPermission returns perm, a permission carried over from the source, with the
+// umask applied.
+func (o *Options) PreservedPermission(perm os.FileMode) os.FileMode {
+	if o.Umask != nil {
+		perm &^= *o.Umask
+	}
+	return perm.Perm()
+}
+
+// setsPermissions reports whether the options set the permissions of created files and
+// directories, which are then changed explicitly so that the umask of the process does not
+// apply on top.
+func (o *Options) setsPermissions() bool {
+	return o.FilePerm != 0 || o.DirPerm != 0 || o.Umask != nil
+}
+
+// Chmod changes the permission of path to perm if the options set permissions.
+func (o *Options) Chmod(path string, perm os.FileMode) error {
+	if !o.setsPermissions() {
+		return nil
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		return fmt.Errorf("failed to change the permission of %s: %w", path, err)
+	}
+	return nil
+}
+
+// ChmodFile changes the permission of the gathered file path to FilePermission, if the
+// options set permissions. With WithExecutable, the executable bits are added where the file
+// is readable, to the permission the file was created with if the options set none.
+func (o *Options) ChmodFile(path string) error {
+	if !o.Executable {
+		return o.Chmod(path, o.FilePermission())
+	}
+	perm := o.FilePermission()
+	if !o.setsPermissions() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to get the permission of %s: %w", path, err)
+		}
+		perm = info.Mode().Perm()
+	}
+	perm = o.PreservedPermission(perm | (perm&0444)>>2)
+	if err := os.Chmod(path, perm); err != nil {
+		return fmt.Errorf("failed to change the permission of %s: %w", path, err)
+	}
+	return nil
+}
+
+// MkdirAll creates the directory path along with any missing parents, giving the directories
+// it creates the permission of DirPermission.
+func (o *Options) MkdirAll(path string) error {
+	if !o.setsPermissions() {
+		return os.MkdirAll(path, defaultDirPerm)
+	}
+
+	// Find the directories that are missing, so that only those are changed
+	var missing []string
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		missing = append(missing, dir)
+	}
+
+	if err := os.MkdirAll(path, o.DirPermission()); err != nil {
+		return err
+	}
+	for _, dir := range missing {
+		if err := o.Chmod(dir, o.DirPermission()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MkdirParents creates the missing parent directories of path, like MkdirAll. With
+// WithCreateParents(false), it only checks that the parent directory exists instead.
+func (o *Options) MkdirParents(path string) error {
+	dir := filepath.Dir(path)
+	if !o.NoCreateParents {
+		return o.MkdirAll(dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("parent directory of %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("parent of %s is not a directory", path)
+	}
+	return nil
+}
+
+// ChmodTree changes the permissions of the files and directories below root, if the options
+// set permissions. Executable files keep their executable bits, as preserved permissions, and
+// symlinks are left as is.
+func (o *Options) ChmodTree(root string) error {
+	if !o.setsPermissions() {
+		return nil
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			return nil
+		case d.IsDir():
+			return o.Chmod(path, o.DirPermission())
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm()&0111 != 0 {
+			return o.Chmod(path, o.PreservedPermission(0755))
+		}
+		return o.Chmod(path, o.FilePermission())
+	})
+}
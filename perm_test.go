@@ -0,0 +1,157 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPermissions tests the effective permissions for combinations of permissions and masks.
+func TestPermissions(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      []Option
+		file      os.FileMode
+		dir       os.FileMode
+		preserved os.FileMode
+	}{
+		{name: "defaults", file: 0644, dir: 0755, preserved: 0755},
+		{name: "umask 077", opts: []Option{WithUmask(077)}, file: 0600, dir: 0700, preserved: 0700},
+		{name: "umask 002", opts: []Option{WithFilePerm(0666), WithDirPerm(0777), WithUmask(002)}, file: 0664, dir: 0775, preserved: 0755},
+		{name: "umask 0", opts: []Option{WithFilePerm(0660), WithUmask(0)}, file: 0660, dir: 0755, preserved: 0755},
+	}
+
+	for _, tc := range testCases {
+		o := NewOptions(tc.opts...)
+		if perm := o.FilePermission(); perm != tc.file {
+			t.Errorf("%s: expected file permission %o, but got %o", tc.name, tc.file, perm)
+		}
+		if perm := o.DirPermission(); perm != tc.dir {
+			t.Errorf("%s: expected directory permission %o, but got %o", tc.name, tc.dir, perm)
+		}
+		if perm := o.PreservedPermission(0755); perm != tc.preserved {
+			t.Errorf("%s: expected preserved permission %o, but got %o", tc.name, tc.preserved, perm)
+		}
+	}
+}
+
+// TestMkdirAllChmodTree tests that created directories and the files of a tree get the configured permissions.
+func TestMkdirAllChmodTree(t *testing.T) {
+	root := t.TempDir()
+	o := NewOptions(WithFilePerm(0664), WithUmask(002))
+
+	dir := filepath.Join(root, "a", "b")
+	if err := o.MkdirAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tool"), nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.ChmodTree(filepath.Join(root, "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]os.FileMode{"a": 0755, "a/b": 0755, "a/b/file": 0664, "a/b/tool": 0755}
+	for path, perm := range expected {
+		info, err := os.Stat(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != perm {
+			t.Errorf("expected %s to have permission %o, but got %o", path, perm, info.Mode().Perm())
+		}
+	}
+
+	// Without permission options nothing is changed
+	if err := NewOptions().Chmod(filepath.Join(dir, "file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, "file")); info.Mode().Perm() != 0664 {
+		t.Errorf("expected the permission to be left as is, but got %o", info.Mode().Perm())
+	}
+}
+
+// TestMkdirParents tests that missing parent directories are created unless WithCreateParents(false) is set.
+func TestMkdirParents(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a", "b", "file.txt")
+
+	if err := NewOptions(WithCreateParents(false)).MkdirParents(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, but got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a")); !os.IsNotExist(err) {
+		t.Errorf("expected no directory to be created, but got: %v", err)
+	}
+
+	if err := NewOptions(WithDirPerm(0700)).MkdirParents(path); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(root, "a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("expected the directory to have permission 700, but got %o", info.Mode().Perm())
+	}
+
+	if err := NewOptions(WithCreateParents(false)).MkdirParents(path); err != nil {
+		t.Errorf("expected an existing parent to be accepted, but got: %v", err)
+	}
+}
+
+// TestChmodFile_Executable tests that executable bits are added where gathered files are readable.
+func TestChmodFile_Executable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []Option
+		created  os.FileMode
+		expected os.FileMode
+	}{
+		{name: "not executable", opts: []Option{WithFilePerm(0640)}, created: 0600, expected: 0640},
+		{name: "created permission", opts: []Option{WithExecutable(true)}, created: 0644, expected: 0755},
+		{name: "created private", opts: []Option{WithExecutable(true)}, created: 0600, expected: 0700},
+		{name: "file permission", opts: []Option{WithExecutable(true), WithFilePerm(0640)}, created: 0600, expected: 0750},
+		{name: "umask", opts: []Option{WithExecutable(true), WithFilePerm(0666), WithUmask(013)}, created: 0600, expected: 0764},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), "tool")
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, tc.created); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewOptions(tc.opts...).ChmodFile(path); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != tc.expected {
+			t.Errorf("%s: expected permission %o, but got %o", tc.name, tc.expected, info.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// progressBarWidth is the number of characters of the bar between the brackets.
+	progressBarWidth = 30
+	// progressInterval is the minimum time between two renderings of a progress bar.
+	progressInterval = 100 * time.Millisecond
+)
+
+// progressSpinner holds the frames of the spinner drawn when the total size is unknown.
+var progressSpinner = []string{"|", "/", "-", "\\"}
+
+// progressBar draws the progress of a download on a single line, redrawn in place with
+// carriage returns. A nil progressBar draws nothing.
+type progressBar struct {
+	w        io.Writer
+	name     string
+	total    int64
+	current  int64
+	frame    int
+	last     time.Time
+	finished bool
+}
+
+// update records that n bytes have been read and redraws the bar, at most every progressInterval.
+func (b *progressBar) update(n int64) {
+	if b == nil {
+		return
+	}
+	b.current = n
+	if time.Since(b.last) < progressInterval {
+		return
+	}
+	b.last = time.Now()
+	b.frame++
+	b.draw()
+}
+
+// finish draws the final state of the bar and ends its line.
+func (b *progressBar) finish() {
+	if b == nil || b.finished {
+		return
+	}
+	b.finished = true
+	b.draw()
+	fmt.Fprintln(b.w)
+}
+
+func (b *progressBar) draw() {
+	if b.total <= 0 {
+		spinner := progressSpinner[b.frame%len(progressSpinner)]
+		if b.finished {
+			spinner = " "
+		}
+		fmt.Fprintf(b.w, "\r%s %s %s", b.name, spinner, formatBytes(b.current))
+		return
+	}
+	done := int(b.current * progressBarWidth / b.total)
+	if done > progressBarWidth {
+		done = progressBarWidth
+	}
+	fmt.Fprintf(b.w, "\r%s [%s%s] %3d%% %s/%s", b.name, strings.Repeat("=", done), strings.Repeat(" ", progressBarWidth-done),
+		b.current*100/b.total, formatBytes(b.current), formatBytes(b.total))
+}
+
+// formatBytes formats a number of bytes with a binary unit, such as "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,71 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestProgressWriter tests that the progress bar is drawn in place and finished with a newline.
+func TestProgressWriter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		total    int64
+		expected string
+	}{
+		{name: "known size", total: 2048, expected: "\rfoo.txt [==============================] 100% 2.0 KiB/2.0 KiB\n"},
+		{name: "unknown size", total: -1, expected: "\rfoo.txt   2.0 KiB\n"},
+	}
+
+	for _, tc := range testCases {
+		var out bytes.Buffer
+		o := NewOptions(WithProgressWriter(&out))
+
+		r, finish := o.ProgressReader(strings.NewReader(strings.Repeat("x", 2048)), "foo.txt", tc.total)
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			t.Fatal(err)
+		}
+		finish()
+		finish()
+
+		if !strings.HasSuffix(out.String(), tc.expected) {
+			t.Errorf("%s: expected the output to end with %q, but got %q", tc.name, tc.expected, out.String())
+		}
+		if strings.Count(out.String(), "\n") != 1 {
+			t.Errorf("%s: expected a single newline, but got %q", tc.name, out.String())
+		}
+	}
+}
+
+// TestFormatBytes tests the formatting of byte counts.
+func TestFormatBytes(t *testing.T) {
+	testCases := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+	}
+	for n, expected := range testCases {
+		if s := formatBytes(n); s != expected {
+			t.Errorf("Expected %d to be formatted as %q, but got %q", n, expected, s)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// PackageURL holds the coordinates of a package URL, or purl, such as
+// "pkg:maven/org.example/lib@1.2.3?type=jar#docs".
+type PackageURL struct {
+	// Type is the package type, such as "maven", "npm" or "pypi", in lower case.
+	Type string
+	// Namespace is the slash separated namespace, such as the Maven group ID or the npm scope,
+	// or empty.
+	Namespace string
+	// Name is the name of the package.
+	Name string
+	// Version is the version of the package, or empty.
+	Version string
+	// Qualifiers are the key-value pairs of the query string, with keys in lower case.
+	Qualifiers map[string]string
+	// Subpath is the slash separated path inside the package, or empty.
+	Subpath string
+}
+
+// ParsePackageURL parses a package URL of the form
+//
+//	pkg:type/namespace/name@version?qualifiers#subpath
+//
+// where the namespace, version, qualifiers and subpath are optional. The components are
+// percent-decoded, so that an npm scope is given as "pkg:npm/%40scope/name@1.0.0".
+func ParsePackageURL(s string) (PackageURL, error) {
+	rest, ok := strings.CutPrefix(s, "pkg:")
+	if !ok {
+		return PackageURL{}, fmt.Errorf("package URL %q does not start with pkg:", s)
+	}
+	var p PackageURL
+
+	rest, subpath, _ := strings.Cut(rest, "#")
+	if subpath != "" {
+		decoded, err := decodeSegments(strings.Trim(subpath, "/"))
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid subpath of package URL %q: %w", s, err)
+		}
+		p.Subpath = decoded
+	}
+
+	rest, query, _ := strings.Cut(rest, "?")
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid qualifiers of package URL %q: %w", s, err)
+		}
+		p.Qualifiers = make(map[string]string, len(values))
+		for key, value := range values {
+			p.Qualifiers[strings.ToLower(key)] = value[0]
+		}
+	}
+
+	rest = strings.Trim(rest, "/")
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		version, err := url.PathUnescape(rest[i+1:])
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid version of package URL %q: %w", s, err)
+		}
+		p.Version, rest = version, rest[:i]
+	}
+
+	segments := strings.Split(rest, "/")
+	if len(segments) < 2 || segments[0] == "" || segments[len(segments)-1] == "" {
+		return PackageURL{}, fmt.Errorf("package URL %q has no type or name", s)
+	}
+	p.Type = strings.ToLower(segments[0])
+	name, err := url.PathUnescape(segments[len(segments)-1])
+	if err != nil {
+		return PackageURL{}, fmt.Errorf("invalid name of package URL %q: %w", s, err)
+	}
+	p.Name = name
+	if p.Namespace, err = decodeSegments(strings.Join(segments[1:len(segments)-1], "/")); err != nil {
+		return PackageURL{}, fmt.Errorf("invalid namespace of package URL %q: %w", s, err)
+	}
+	return p, nil
+}
+
+// decodeSegments percent-decodes each segment of the slash separated path.
+func decodeSegments(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", err
+		}
+		segments[i] = decoded
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// urlTemplateFuncs are the functions available to the templates of WithURLTemplate.
+var urlTemplateFuncs = template.FuncMap{
+	"replace":     func(from, to, s string) string { return strings.ReplaceAll(s, from, to) },
+	"lower":       strings.ToLower,
+	"pathescape":  url.PathEscape,
+	"queryescape": url.QueryEscape,
+}
+
+// ResolvePackageURL returns the URL that the template set with WithURLTemplate maps the
+// package URL source to. Other sources, and every source without a template, are returned
+// as is.
+func (o *Options) ResolvePackageURL(source string) (string, error) {
+	if o.URLTemplate == "" || !strings.HasPrefix(source, "pkg:") {
+		return source, nil
+	}
+	p, err := ParsePackageURL(source)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New("url").Funcs(urlTemplateFuncs).Option("missingkey=error").Parse(o.URLTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, p); err != nil {
+		return "", fmt.Errorf("error expanding URL template for %s: %w", source, err)
+	}
+	resolved := strings.TrimSpace(b.String())
+	if resolved == "" {
+		return "", fmt.Errorf("the URL template maps %s to an empty source", source)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,114 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParsePackageURL tests that the coordinates of package URLs are parsed and decoded.
+func TestParsePackageURL(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected PackageURL
+		err      bool
+	}{
+		{
+			input:    "pkg:maven/org.example/lib@1.2.3",
+			expected: PackageURL{Type: "maven", Namespace: "org.example", Name: "lib", Version: "1.2.3"},
+		},
+		{
+			input:    "pkg:npm/%40scope/name@1.0.0",
+			expected: PackageURL{Type: "npm", Namespace: "@scope", Name: "name", Version: "1.0.0"},
+		},
+		{
+			input:    "pkg:PyPI/requests",
+			expected: PackageURL{Type: "pypi", Name: "requests"},
+		},
+		{
+			input:    "pkg:golang/github.com/org/repo@v1.0.0?Type=module#sub/dir",
+			expected: PackageURL{Type: "golang", Namespace: "github.com/org", Name: "repo", Version: "v1.0.0", Qualifiers: map[string]string{"type": "module"}, Subpath: "sub/dir"},
+		},
+		{
+			input:    "pkg:maven/org.example/lib@1.2.3%2Bbuild?classifier=sources&type=jar",
+			expected: PackageURL{Type: "maven", Namespace: "org.example", Name: "lib", Version: "1.2.3+build", Qualifiers: map[string]string{"classifier": "sources", "type": "jar"}},
+		},
+		{input: "maven/org.example/lib@1.2.3", err: true},
+		{input: "pkg:maven", err: true},
+		{input: "pkg:maven/", err: true},
+		{input: "pkg:npm/%zz@1.0.0", err: true},
+	}
+
+	for _, tc := range testCases {
+		actual, err := ParsePackageURL(tc.input)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Expected an error for %s, but got %+v", tc.input, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", tc.input, err)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("Expected ParsePackageURL(%s) to return %+v, but got %+v", tc.input, tc.expected, actual)
+		}
+	}
+}
+
+// TestResolvePackageURL tests that package URLs are mapped with the URL template.
+func TestResolvePackageURL(t *testing.T) {
+	maven := `https://repo1.maven.org/maven2/{{.Namespace | replace "." "/"}}/{{.Name}}/{{.Version}}/{{.Name}}-{{.Version}}.jar`
+	testCases := []struct {
+		input    string
+		template string
+		expected string
+		err      string
+	}{
+		{input: "pkg:maven/org.example/lib@1.2.3", template: maven, expected: "https://repo1.maven.org/maven2/org/example/lib/1.2.3/lib-1.2.3.jar"},
+		{input: "https://example.com/file.txt", template: maven, expected: "https://example.com/file.txt"},
+		{input: "pkg:maven/org.example/lib@1.2.3", expected: "pkg:maven/org.example/lib@1.2.3"},
+		{
+			input:    "pkg:npm/%40scope/name@1.0.0",
+			template: `{{if eq .Type "npm"}}https://registry.npmjs.org/{{.Namespace}}/{{.Name}}/-/{{.Name}}-{{.Version}}.tgz{{end}}`,
+			expected: "https://registry.npmjs.org/@scope/name/-/name-1.0.0.tgz",
+		},
+		{input: "pkg:generic/tool@1.0?arch=amd64", template: "https://example.com/{{.Name}}-{{.Qualifiers.arch}}", expected: "https://example.com/tool-amd64"},
+		{input: "pkg:generic/tool@1.0", template: "https://example.com/{{.Name}}-{{.Qualifiers.arch}}", err: "error expanding URL template"},
+		{input: "pkg:pypi/requests", template: `{{if eq .Type "npm"}}https://example.com{{end}}`, err: "empty source"},
+		{input: "pkg:maven/org.example/lib@1.2.3", template: "{{.Name", err: "invalid URL template"},
+		{input: "pkg:maven", template: maven, err: "has no type or name"},
+	}
+
+	for _, tc := range testCases {
+		actual, err := NewOptions(WithURLTemplate(tc.template)).ResolvePackageURL(tc.input)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected an error containing %q for %s, but got: %v", tc.err, tc.input, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", tc.input, err)
+		}
+		if actual != tc.expected {
+			t.Errorf("Expected %s to resolve to %s, but got %s", tc.input, tc.expected, actual)
+		}
+	}
+}
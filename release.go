@@ -0,0 +1,57 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"regexp"
+)
+
+// ReleaseAsset names an asset of a GitHub or GitLab release.
+type ReleaseAsset struct {
+	// Host is the host of the repository, github.com or gitlab.com.
+	Host string
+	// Project is the path of the repository, such as "org/repo".
+	Project string
+	// Tag is the tag of the release, or empty for the latest release.
+	Tag string
+	// Name is the name of the asset, which may be a glob pattern such as "tool-*-linux.tar.gz".
+	Name string
+}
+
+// githubReleasePattern matches the download URLs of GitHub release assets, with or without
+// the https:// scheme.
+var githubReleasePattern = regexp.MustCompile(`^(?:https://)?(github\.com)/([^/]+/[^/]+)/releases/(?:latest/download|download/([^/]+))/([^/]+)$`)
+
+// gitlabReleasePattern matches the permalinks of GitLab release assets, with or without the
+// https:// scheme.
+var gitlabReleasePattern = regexp.MustCompile(`^(?:https://)?(gitlab\.com)/([^/]+(?:/[^/]+)+)/-/releases/(?:permalink/latest|([^/]+))/downloads/([^/]+)$`)
+
+// ParseReleaseAsset parses a release asset source, which is the download URL of a GitHub
+// release asset, such as "github.com/org/repo/releases/download/v1.2.3/asset.tar.gz", or the
+// permalink of a GitLab release asset, such as
+// "gitlab.com/group/project/-/releases/v1.2.3/downloads/asset.tar.gz". The latest release is
+// named as "github.com/org/repo/releases/latest/download/asset.tar.gz" and
+// "gitlab.com/group/project/-/releases/permalink/latest/downloads/asset.tar.gz", and the
+// asset name may be a glob pattern.
+func ParseReleaseAsset(source string) (ReleaseAsset, bool) {
+	for _, pattern := range []*regexp.Regexp{githubReleasePattern, gitlabReleasePattern} {
+		if m := pattern.FindStringSubmatch(source); m != nil {
+			return ReleaseAsset{Host: m[1], Project: m[2], Tag: m[3], Name: m[4]}, true
+		}
+	}
+	return ReleaseAsset{}, false
+}
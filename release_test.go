@@ -0,0 +1,62 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"testing"
+)
+
+// TestParseReleaseAsset tests the parsing of GitHub and GitLab release asset sources.
+func TestParseReleaseAsset(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected ReleaseAsset
+		ok       bool
+	}{
+		{
+			source:   "github.com/org/repo/releases/download/v1.2.3/asset.tar.gz",
+			expected: ReleaseAsset{Host: "github.com", Project: "org/repo", Tag: "v1.2.3", Name: "asset.tar.gz"},
+			ok:       true,
+		},
+		{
+			source:   "https://github.com/org/repo/releases/latest/download/tool-*-linux.tar.gz",
+			expected: ReleaseAsset{Host: "github.com", Project: "org/repo", Name: "tool-*-linux.tar.gz"},
+			ok:       true,
+		},
+		{
+			source:   "gitlab.com/group/sub/project/-/releases/v1.2.3/downloads/asset.zip",
+			expected: ReleaseAsset{Host: "gitlab.com", Project: "group/sub/project", Tag: "v1.2.3", Name: "asset.zip"},
+			ok:       true,
+		},
+		{
+			source:   "https://gitlab.com/group/project/-/releases/permalink/latest/downloads/asset.zip",
+			expected: ReleaseAsset{Host: "gitlab.com", Project: "group/project", Name: "asset.zip"},
+			ok:       true,
+		},
+		{source: "github.com/org/repo"},
+		{source: "github.com/org/repo/releases/tag/v1.2.3"},
+		{source: "http://github.com/org/repo/releases/download/v1.2.3/asset.tar.gz"},
+		{source: "https://example.com/org/repo/releases/download/v1.2.3/asset.tar.gz"},
+	}
+
+	for _, tc := range testCases {
+		actual, ok := ParseReleaseAsset(tc.source)
+		if ok != tc.ok || actual != tc.expected {
+			t.Errorf("ParseReleaseAsset(%s): expected %+v, %t, but got %+v, %t", tc.source, tc.expected, tc.ok, actual, ok)
+		}
+	}
+}
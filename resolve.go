@@ -0,0 +1,85 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// mapAddress returns the address to dial for address, a host and port, applying the host
+// mapping of the options.
+func (o *Options) mapAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || len(o.HostMapping) == 0 {
+		return address
+	}
+	target, ok := o.mappedHost(host, port)
+	if !ok {
+		return address
+	}
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return target
+	}
+	return net.JoinHostPort(strings.Trim(target, "[]"), port)
+}
+
+// mappedHost returns the address mapped to host and port, or else to host alone. Host names
+// are matched regardless of case.
+func (o *Options) mappedHost(host, port string) (string, bool) {
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	var byHost string
+	found := false
+	for key, target := range o.HostMapping {
+		key = strings.ToLower(key)
+		if port != "" && key == strings.ToLower(net.JoinHostPort(host, port)) {
+			return target, true
+		}
+		if strings.Trim(key, "[]") == host {
+			byHost, found = target, true
+		}
+	}
+	return byHost, found
+}
+
+// dialContext returns a dial function connecting with dialer to the addresses mapped by the
+// options.
+func (o *Options) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, o.mapAddress(address))
+	}
+}
+
+// lookupIPAddr resolves host with the resolver of the options, if any, after applying the host
+// mapping, so that private network checks see the addresses actually dialed.
+func (o *Options) lookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if target, ok := o.mappedHost(host, ""); ok {
+		if h, _, err := net.SplitHostPort(target); err == nil {
+			target = h
+		}
+		target = strings.Trim(target, "[]")
+		if ip := net.ParseIP(target); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		}
+		host = target
+	}
+	if o.Resolver != nil {
+		return o.Resolver.LookupIPAddr(ctx, host)
+	}
+	return LookupIPAddr(ctx, host)
+}
@@ -0,0 +1,122 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMapAddress tests that addresses are mapped by host and port, then by host alone.
+func TestMapAddress(t *testing.T) {
+	o := NewOptions(WithHostMapping(map[string]string{
+		"Example.com":      "10.0.0.1",
+		"example.com:8443": "10.0.0.2:443",
+		"mirror.test":      "[::1]:8080",
+		"[::2]":            "::3",
+	}))
+	testCases := []struct {
+		address  string
+		expected string
+	}{
+		{address: "example.com:443", expected: "10.0.0.1:443"},
+		{address: "EXAMPLE.COM:80", expected: "10.0.0.1:80"},
+		{address: "example.com:8443", expected: "10.0.0.2:443"},
+		{address: "mirror.test:443", expected: "[::1]:8080"},
+		{address: "[::2]:443", expected: "[::3]:443"},
+		{address: "example.org:443", expected: "example.org:443"},
+	}
+
+	for _, tc := range testCases {
+		if actual := o.mapAddress(tc.address); actual != tc.expected {
+			t.Errorf("Expected %s to be mapped to %s, but got %s", tc.address, tc.expected, actual)
+		}
+	}
+}
+
+// TestTransport_HostMapping tests that connections go to the mapped address while the
+// certificate of the server is verified against the host name of the URL.
+func TestTransport_HostMapping(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	address := server.Listener.Addr().String()
+
+	// The certificate of the test server is valid for example.com, but not for example.org
+	testCases := []struct {
+		url     string
+		success bool
+	}{
+		{url: "https://example.com/", success: true},
+		{url: "https://example.org/"},
+	}
+
+	for _, tc := range testCases {
+		transport := NewOptions(WithRootCAs(pool), WithHostMapping(map[string]string{"example.com": address, "example.org": address})).Transport()
+		resp, err := (&http.Client{Transport: transport}).Get(tc.url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if tc.success && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.url, err)
+		}
+		if !tc.success && err == nil {
+			t.Errorf("%s: expected the certificate to be refused", tc.url)
+		}
+	}
+}
+
+// TestTransport_Resolver tests that host names are resolved with the resolver of the options.
+func TestTransport_Resolver(t *testing.T) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("custom resolver")
+		},
+	}
+
+	resp, err := (&http.Client{Transport: NewOptions(WithResolver(resolver)).Transport()}).Get("http://unresolvable.example.com/")
+	if err == nil {
+		resp.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "custom resolver") {
+		t.Errorf("Expected the custom resolver to be used, but got: %v", err)
+	}
+}
+
+// TestCheckHost_HostMapping tests that the private network check applies to mapped addresses.
+func TestCheckHost_HostMapping(t *testing.T) {
+	LookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("8.8.8.8")}}, nil
+	}
+	defer func() { LookupIPAddr = net.DefaultResolver.LookupIPAddr }()
+
+	o := NewOptions(WithBlockPrivateNetworks(true), WithHostMapping(map[string]string{"example.com": "10.0.0.1:8443"}))
+	if err := o.CheckHost(context.Background(), "example.com:443"); !errors.Is(err, ErrHostNotAllowed) {
+		t.Errorf("Expected ErrHostNotAllowed, but got: %v", err)
+	}
+	if err := o.CheckHost(context.Background(), "example.org"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -31,11 +31,13 @@ package file
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // FileSaver handles saving data to local filesystem paths.
@@ -43,19 +45,7 @@ type FileSaver struct{}
 
 // Save implements the Saver interface for file destinations.
 func (fs *FileSaver) Save(ctx context.Context, data io.Reader, destination string) error {
-
-	dst, err := url.Parse(destination)
-	if err != nil {
-		return fmt.Errorf("failed to parse destination URI: %w", err)
-	}
-
-	// Ensure the destination directory exists.
-	if err := os.MkdirAll(filepath.Dir(dst.Path), 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
-	}
-
-	// Create the destination file.
-	f, err := os.Create(dst.Path)
+	f, err := fs.Create(destination)
 	if err != nil {
 		return err
 	}
@@ -68,3 +58,25 @@ func (fs *FileSaver) Save(ctx context.Context, data io.Reader, destination strin
 	}
 	return nil
 }
+
+// Create creates the file at destination for writing, along with any missing parent directories.
+// It allows a FileSaver to be used where a writable destination is expected.
+// The path of a file:// URL is percent-decoded, plain paths are used as is.
+func (fs *FileSaver) Create(destination string) (io.WriteCloser, error) {
+	path := destination
+	dst, err := url.Parse(destination)
+	if err != nil && (strings.HasPrefix(destination, "file:") || !errors.As(err, new(url.EscapeError))) {
+		return nil, fmt.Errorf("failed to parse destination URI: %w", err)
+	}
+	if err == nil && dst.Scheme == "file" {
+		path = dst.Path
+	}
+
+	// Ensure the destination directory exists.
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	// Create the destination file.
+	return os.Create(path)
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -146,3 +147,52 @@ func TestFileSaver_CopyError(t *testing.T) {
 		os.RemoveAll(destination)
 	})
 }
+
+// TestFileSaver_Create tests the Create method of the FileSaver type.
+func TestFileSaver_Create(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "sub", "test.txt")
+
+	fs := &FileSaver{}
+	w, err := fs.Create("file://" + destination)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if _, err := w.Write([]byte("test data")); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close file: %v", err)
+	}
+
+	savedData, err := os.ReadFile(destination)
+	if err != nil {
+		t.Fatalf("failed to read saved file: %v", err)
+	}
+	if string(savedData) != "test data" {
+		t.Errorf("unexpected saved data: got %s, want %s", savedData, "test data")
+	}
+}
+
+// TestFileSaver_SpecialCharacters tests that file:// URLs are percent-decoded and plain paths are used as is.
+func TestFileSaver_SpecialCharacters(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		destination string
+		path        string
+	}{
+		{destination: "file://" + dir + "/my%20folder/notes%23draft%3F.txt", path: filepath.Join(dir, "my folder", "notes#draft?.txt")},
+		{destination: filepath.Join(dir, "plain folder", "notes#draft?.txt"), path: filepath.Join(dir, "plain folder", "notes#draft?.txt")},
+		{destination: filepath.Join(dir, "100%.txt"), path: filepath.Join(dir, "100%.txt")},
+	}
+
+	fs := &FileSaver{}
+	for _, tc := range testCases {
+		if err := fs.Save(context.Background(), bytes.NewReader([]byte("data")), tc.destination); err != nil {
+			t.Errorf("failed to save %s: %v", tc.destination, err)
+			continue
+		}
+		if data, err := os.ReadFile(tc.path); err != nil || string(data) != "data" {
+			t.Errorf("expected %s to be saved to %s, but got %q, %v", tc.destination, tc.path, data, err)
+		}
+	}
+}
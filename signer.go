@@ -0,0 +1,199 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RequestSigner signs req before it is sent, typically by setting its authorization headers.
+type RequestSigner func(req *http.Request) error
+
+// sigV4Algorithm is the algorithm of AWS Signature Version 4 signatures.
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// unsignedPayload is the payload hash of requests whose body cannot be read twice.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// sigV4Now returns the time requests are signed at, it is replaced in tests.
+var sigV4Now = time.Now
+
+// NewSigV4Signer returns a RequestSigner signing requests with AWS Signature Version 4, for
+// service, such as "s3" or "execute-api", in region, with the given credentials. sessionToken
+// is only needed for temporary credentials and may be empty. The host, the content type and
+// the X-Amz-* headers are signed. The body is hashed when it can be read again through
+// GetBody, as for the requests of the http gatherer, and is left unsigned otherwise.
+func NewSigV4Signer(accessKeyID, secretAccessKey, sessionToken, region, service string) RequestSigner {
+	return func(req *http.Request) error {
+		payload, err := payloadHash(req)
+		if err != nil {
+			return fmt.Errorf("failed to hash the request body: %w", err)
+		}
+
+		now := sigV4Now().UTC()
+		req.Header.Del("Authorization")
+		req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+		if sessionToken != "" {
+			req.Header.Set("X-Amz-Security-Token", sessionToken)
+		}
+		if service == "s3" {
+			req.Header.Set("X-Amz-Content-Sha256", payload)
+		}
+
+		headers, signedHeaders := canonicalHeaders(req)
+		canonicalRequest := strings.Join([]string{
+			req.Method,
+			canonicalURI(req.URL, service),
+			canonicalQuery(req.URL),
+			headers,
+			signedHeaders,
+			payload,
+		}, "\n")
+
+		date := now.Format("20060102")
+		scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+		stringToSign := strings.Join([]string{sigV4Algorithm, now.Format("20060102T150405Z"), scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+		key := []byte("AWS4" + secretAccessKey)
+		for _, part := range []string{date, region, service, "aws4_request"} {
+			key = hmacSHA256(key, part)
+		}
+		signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+		req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", sigV4Algorithm, accessKeyID, scope, signedHeaders, signature))
+		return nil
+	}
+}
+
+// payloadHash returns the hex SHA-256 hash of the body of req, or unsignedPayload when the
+// body cannot be read without consuming it.
+func payloadHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return sha256Hex(nil), nil
+	}
+	if req.GetBody == nil {
+		return unsignedPayload, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalURI returns the URI-encoded path of u. The path is encoded twice for all services
+// but S3, as the signature version requires.
+func canonicalURI(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query of u with its parameters sorted and URI-encoded.
+func canonicalQuery(u *url.URL) string {
+	var params []string
+	for key, values := range u.Query() {
+		for _, value := range values {
+			params = append(params, sigV4Escape(key)+"="+sigV4Escape(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// canonicalHeaders returns the canonical headers of req, each on its own line, and the list
+// of their names.
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, vs := range req.Header {
+		name = strings.ToLower(name)
+		if name != "content-type" && !strings.HasPrefix(name, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, len(vs))
+		for i, v := range vs {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// sigV4Escape URI-encodes s, leaving only the unreserved characters of RFC 3986 as they are.
+func sigV4Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sha256Hex returns the hex SHA-256 hash of data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
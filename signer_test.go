@@ -0,0 +1,78 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewSigV4Signer tests the signer against requests of the AWS Signature Version 4 test suite.
+func TestNewSigV4Signer(t *testing.T) {
+	sigV4Now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+	defer func() { sigV4Now = time.Now }()
+
+	testCases := []struct {
+		name      string
+		url       string
+		signature string
+	}{
+		{name: "get-vanilla", url: "https://example.amazonaws.com/", signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{name: "get-vanilla-query-order-key-case", url: "https://example.amazonaws.com/?Param2=value2&Param1=value1", signature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+
+	sign := NewSigV4Signer("AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "", "us-east-1", "service")
+	for _, tc := range testCases {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := sign(req); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + tc.signature
+		if got := req.Header.Get("Authorization"); got != expected {
+			t.Errorf("%s: expected Authorization %q, but got %q", tc.name, expected, got)
+		}
+		if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+			t.Errorf("%s: unexpected X-Amz-Date %q", tc.name, got)
+		}
+	}
+}
+
+// TestNewSigV4Signer_S3 tests that the session token and, for S3, the payload hash are signed.
+func TestNewSigV4Signer_S3(t *testing.T) {
+	req, err := http.NewRequest("PUT", "https://bucket.s3.amazonaws.com/key", strings.NewReader("content"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := NewSigV4Signer("id", "secret", "token", "eu-west-1", "s3")(req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != sha256Hex([]byte("content")) {
+		t.Errorf("Unexpected X-Amz-Content-Sha256 %q", got)
+	}
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("Unexpected X-Amz-Security-Token %q", got)
+	}
+	if got := req.Header.Get("Authorization"); !strings.Contains(got, "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Unexpected Authorization %q", got)
+	}
+}
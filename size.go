@@ -0,0 +1,140 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrTooLarge is returned when a source is larger than the size set with WithMaxBytes.
+var ErrTooLarge = errors.New("source too large")
+
+// ErrResponseTooLarge is returned when the headers of a response exceed the size set with
+// WithMaxResponseHeaderBytes.
+var ErrResponseTooLarge = errors.New("response headers too large")
+
+// ErrContentLengthMissing is returned when a response has no Content-Length header and
+// WithContentLengthRequired is set.
+var ErrContentLengthMissing = errors.New("content length missing")
+
+// ErrContentLengthMismatch is returned when the size of a response body differs from its
+// Content-Length header and WithContentLengthRequired is set, such as for a truncated transfer.
+var ErrContentLengthMismatch = errors.New("content length mismatch")
+
+// ResponseError returns err, the error of an HTTP request, marked with ErrResponseTooLarge
+// if the transport aborted the response because its headers exceeded the limit. The
+// transports only report this in the error message, for HTTP/1 and HTTP/2 alike.
+func (o *Options) ResponseError(err error) error {
+	if err == nil || o.MaxResponseHeaderBytes <= 0 {
+		return err
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "server response headers exceeded") || strings.Contains(msg, "response header list larger than") {
+		return fmt.Errorf("%w: more than %d bytes: %v", ErrResponseTooLarge, o.MaxResponseHeaderBytes, err)
+	}
+	return err
+}
+
+// CheckSize returns ErrTooLarge if size, as announced by a server, exceeds the maximum size.
+// Unknown sizes, given as a negative size, and gathers without a maximum always pass.
+func (o *Options) CheckSize(size int64) error {
+	if o.MaxBytes > 0 && size > o.MaxBytes {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrTooLarge, size, o.MaxBytes)
+	}
+	return nil
+}
+
+// LimitReader returns a reader that fails with ErrTooLarge once more than the maximum size
+// is read from r, so that sources of unknown or misreported size are caught as well.
+// Without a maximum r is returned as is.
+func (o *Options) LimitReader(r io.Reader) io.Reader {
+	if o.MaxBytes <= 0 {
+		return r
+	}
+	return &limitReader{r: r, max: o.MaxBytes, remaining: o.MaxBytes}
+}
+
+// limitReader reads at most one byte past the limit, to tell a source of exactly the
+// maximum size from a larger one.
+type limitReader struct {
+	r         io.Reader
+	max       int64
+	remaining int64
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	if lr.remaining < 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, lr.max)
+	}
+	if int64(len(p)) > lr.remaining+1 {
+		p = p[:lr.remaining+1]
+	}
+	n, err := lr.r.Read(p)
+	lr.remaining -= int64(n)
+	if lr.remaining < 0 {
+		return n + int(lr.remaining), fmt.Errorf("%w: more than %d bytes", ErrTooLarge, lr.max)
+	}
+	return n, err
+}
+
+// CheckContentLength returns ErrContentLengthMissing if length, the content length of a
+// response, is unknown, given as a negative length, and a content length is required.
+func (o *Options) CheckContentLength(length int64) error {
+	if o.ContentLengthRequired && length < 0 {
+		return fmt.Errorf("%w: the server did not send a Content-Length header", ErrContentLengthMissing)
+	}
+	return nil
+}
+
+// LengthReader returns a reader that fails with ErrContentLengthMismatch if r, a response
+// body, does not hold exactly length bytes, when a content length is required. Otherwise, or
+// for an unknown length, r is returned as is.
+func (o *Options) LengthReader(r io.Reader, length int64) io.Reader {
+	if !o.ContentLengthRequired || length < 0 {
+		return r
+	}
+	return &lengthReader{r: r, length: length}
+}
+
+// lengthReader counts the bytes read, reading at most one byte past the length, to tell a
+// body of exactly the declared length from a longer one.
+type lengthReader struct {
+	r      io.Reader
+	length int64
+	read   int64
+}
+
+func (lr *lengthReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > lr.length-lr.read+1 {
+		p = p[:lr.length-lr.read+1]
+	}
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.length {
+		return n - int(lr.read-lr.length), fmt.Errorf("%w: more than the %d bytes declared", ErrContentLengthMismatch, lr.length)
+	}
+	if err == io.EOF && lr.read < lr.length {
+		return n, fmt.Errorf("%w: received %d of the %d bytes declared", ErrContentLengthMismatch, lr.read, lr.length)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return n, fmt.Errorf("%w: received %d of the %d bytes declared: %w", ErrContentLengthMismatch, lr.read, lr.length, err)
+	}
+	return n, err
+}
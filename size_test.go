@@ -0,0 +1,134 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestCheckSize tests that sizes above the maximum are refused.
+func TestCheckSize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		max      int64
+		size     int64
+		tooLarge bool
+	}{
+		{name: "no maximum", size: 1 << 40},
+		{name: "unknown size", max: 10, size: -1},
+		{name: "at the maximum", max: 10, size: 10},
+		{name: "above the maximum", max: 10, size: 11, tooLarge: true},
+	}
+
+	for _, tc := range testCases {
+		err := NewOptions(WithMaxBytes(tc.max)).CheckSize(tc.size)
+		if tc.tooLarge != errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+// TestLimitReader tests that reading past the maximum fails with ErrTooLarge.
+func TestLimitReader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		max      int64
+		content  string
+		tooLarge bool
+	}{
+		{name: "no maximum", content: "hello world"},
+		{name: "at the maximum", max: 11, content: "hello world"},
+		{name: "above the maximum", max: 5, content: "hello world", tooLarge: true},
+	}
+
+	for _, tc := range testCases {
+		data, err := io.ReadAll(NewOptions(WithMaxBytes(tc.max)).LimitReader(strings.NewReader(tc.content)))
+		if tc.tooLarge != errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !tc.tooLarge && string(data) != tc.content {
+			t.Errorf("%s: expected %q, but got %q", tc.name, tc.content, data)
+		}
+		if tc.tooLarge && int64(len(data)) > tc.max {
+			t.Errorf("%s: expected at most %d bytes, but got %d", tc.name, tc.max, len(data))
+		}
+	}
+}
+
+// TestCheckContentLength tests that unknown content lengths are refused when they are required.
+func TestCheckContentLength(t *testing.T) {
+	if err := NewOptions().CheckContentLength(-1); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := NewOptions(WithContentLengthRequired(true)).CheckContentLength(0); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := NewOptions(WithContentLengthRequired(true)).CheckContentLength(-1); !errors.Is(err, ErrContentLengthMissing) {
+		t.Errorf("Expected ErrContentLengthMissing, but got: %v", err)
+	}
+}
+
+// TestLengthReader tests that bodies shorter or longer than declared fail with
+// ErrContentLengthMismatch when a content length is required.
+func TestLengthReader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		required bool
+		length   int64
+		content  string
+		mismatch bool
+	}{
+		{name: "not required", length: 5, content: "hello world"},
+		{name: "unknown length", required: true, length: -1, content: "hello world"},
+		{name: "exact", required: true, length: 11, content: "hello world"},
+		{name: "empty", required: true, length: 0, content: ""},
+		{name: "truncated", required: true, length: 20, content: "hello world", mismatch: true},
+		{name: "longer", required: true, length: 5, content: "hello world", mismatch: true},
+	}
+
+	for _, tc := range testCases {
+		o := NewOptions(WithContentLengthRequired(tc.required))
+		data, err := io.ReadAll(o.LengthReader(strings.NewReader(tc.content), tc.length))
+		if tc.mismatch != errors.Is(err, ErrContentLengthMismatch) {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !tc.mismatch && string(data) != tc.content {
+			t.Errorf("%s: expected %q, but got %q", tc.name, tc.content, data)
+		}
+		if tc.mismatch && tc.length >= 0 && int64(len(data)) > tc.length {
+			t.Errorf("%s: expected at most %d bytes, but got %d", tc.name, tc.length, len(data))
+		}
+	}
+}
+
+// TestResponseError tests that aborted responses with too large headers are marked with ErrResponseTooLarge.
+func TestResponseError(t *testing.T) {
+	headerErr := errors.New("net/http: server response headers exceeded 1024 bytes; aborted")
+	o := NewOptions(WithMaxResponseHeaderBytes(1024))
+	if err := o.ResponseError(headerErr); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected ErrResponseTooLarge, but got: %v", err)
+	}
+	if err := o.ResponseError(io.ErrUnexpectedEOF); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected other errors to be returned as is, but got: %v", err)
+	}
+	if err := NewOptions().ResponseError(headerErr); errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected no ErrResponseTooLarge without a limit, but got: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrSkipTransform can be returned by a TransformFunc to leave a file unchanged,
+// for example when the file is binary.
+var ErrSkipTransform = errors.New("skip transform")
+
+// TransformFunc receives the path and content of a gathered file and returns the
+// content to store in its place.
+type TransformFunc func(path string, content []byte) ([]byte, error)
+
+// TransformFile applies the transform function, if any, to the file at path. The transformed
+// content is written to a temporary file next to it first, which then replaces it.
+func (o *Options) TransformFile(path string) error {
+	if o.TransformFunc == nil {
+		return nil
+	}
+	staged, err := o.stageTransform(path)
+	if err != nil || staged == "" {
+		return err
+	}
+	if err := os.Rename(staged, path); err != nil {
+		_ = os.Remove(staged)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// TransformTree applies the transform function, if any, to every regular file below root.
+// Git metadata directories are skipped. Every file is transformed before any is replaced, so
+// that a failing transform leaves the files below root as they were rather than partly
+// transformed.
+func (o *Options) TransformTree(root string) error {
+	if o.TransformFunc == nil {
+		return nil
+	}
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the transformed content of every file next to it, then replace the files
+	staged := make(map[string]string, len(paths))
+	defer func() {
+		for _, s := range staged {
+			_ = os.Remove(s)
+		}
+	}()
+	for _, path := range paths {
+		s, err := o.stageTransform(path)
+		if err != nil {
+			return err
+		}
+		if s != "" {
+			staged[path] = s
+		}
+	}
+	for _, path := range paths {
+		s, ok := staged[path]
+		if !ok {
+			continue
+		}
+		if err := os.Rename(s, path); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		delete(staged, path)
+	}
+	return nil
+}
+
+// stageTransform writes the transformed content of the file at path to a temporary file in
+// the same directory, with the permission of the file, and returns its path, or an empty
+// path if the transform function skips the file.
+func (o *Options) stageTransform(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	transformed, err := o.TransformFunc(path, content)
+	if errors.Is(err, ErrSkipTransform) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to transform %s: %w", path, err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".transform-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	_, err = f.Write(transformed)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), info.Mode().Perm())
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,123 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTransformTree tests that the transform function is applied to every file except skipped ones.
+func TestTransformTree(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":        "hello\r\nworld\r\n",
+		"sub/b.txt":    "foo\r\n",
+		"c.bin":        "\x00\r\n",
+		".git/HEAD":    "ref: refs/heads/main\r\n",
+		"sub/.git/foo": "bar\r\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := NewOptions(WithTransform(func(path string, content []byte) ([]byte, error) {
+		if filepath.Ext(path) == ".bin" {
+			return nil, ErrSkipTransform
+		}
+		return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")), nil
+	}))
+	if err := o.TransformTree(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"a.txt":        "hello\nworld\n",
+		"sub/b.txt":    "foo\n",
+		"c.bin":        "\x00\r\n",
+		".git/HEAD":    "ref: refs/heads/main\r\n",
+		"sub/.git/foo": "bar\r\n",
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != content {
+			t.Errorf("Expected %s to contain %q, but got %q", name, content, actual)
+		}
+	}
+}
+
+// TestTransformFile_Error tests that errors from the transform function are returned.
+func TestTransformFile_Error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("test"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedErr := errors.New("transform failed")
+	o := NewOptions(WithTransform(func(path string, content []byte) ([]byte, error) {
+		return nil, expectedErr
+	}))
+	if err := o.TransformFile(path); !errors.Is(err, expectedErr) {
+		t.Errorf("Expected %v, but got: %v", expectedErr, err)
+	}
+}
+
+// TestTransformTree_Error tests that no file is replaced when the transform of one fails.
+func TestTransformTree_Error(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("hello"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectedErr := errors.New("transform failed")
+	o := NewOptions(WithTransform(func(path string, content []byte) ([]byte, error) {
+		if filepath.Base(path) == "b.txt" {
+			return nil, expectedErr
+		}
+		return bytes.ToUpper(content), nil
+	}))
+	if err := o.TransformTree(dir); !errors.Is(err, expectedErr) {
+		t.Errorf("Expected %v, but got: %v", expectedErr, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected the staged files to be removed, but got %v", entries)
+	}
+	for _, e := range entries {
+		if content, err := os.ReadFile(filepath.Join(dir, e.Name())); err != nil || string(content) != "hello" {
+			t.Errorf("Expected %s to be left unchanged, but got %q (%v)", e.Name(), content, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// ErrCertPinMismatch is returned when none of the certificates presented by a server matches
+// the fingerprints pinned with WithPinnedCertFingerprints.
+var ErrCertPinMismatch = errors.New("certificate pin mismatch")
+
+// Transport returns the http.RoundTripper to use for requests made under o.
+// It returns the transport set with WithHTTPTransport, if any, and otherwise
+// http.DefaultTransport unless an option requires a dedicated transport.
+// When BlockPrivateNetworks is set, the resolved address of every connection is checked,
+// which also covers redirects and DNS answers that change between lookups. Connections go
+// to the addresses of the host mapping, if any, and are resolved with the resolver, if any.
+// With WithClientCache, dedicated transports are shared per host, see cachingTransport.
+func (o *Options) Transport() http.RoundTripper {
+	if o.HTTPTransport != nil {
+		return o.HTTPTransport
+	}
+	if !o.needsTransport() {
+		return http.DefaultTransport
+	}
+	if o.ClientCacheTTL > 0 {
+		return cachingTransport{o: o, settings: o.transportSettings()}
+	}
+	return o.newTransport()
+}
+
+// NewTransport returns a dedicated transport applying the options, for gatherers that
+// configure the transport further, such as with TLS settings of their own, and so cannot use
+// the transports shared with WithClientCache. The transport set with WithHTTPTransport, if
+// any, is not taken into account.
+func (o *Options) NewTransport() *http.Transport {
+	return o.newTransport()
+}
+
+// newTransport builds a dedicated transport applying the options.
+func (o *Options) newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if o.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+
+	if o.MaxResponseHeaderBytes > 0 {
+		t.MaxResponseHeaderBytes = o.MaxResponseHeaderBytes
+	}
+
+	if o.BlockPrivateNetworks || o.Resolver != nil || len(o.HostMapping) > 0 {
+		dialer := &net.Dialer{Resolver: o.Resolver}
+		if o.BlockPrivateNetworks {
+			dialer.Control = func(network, address string, c syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				return checkIP(host, net.ParseIP(host))
+			}
+		}
+		t.DialContext = o.dialContext(dialer)
+	}
+
+	if o.usesTLSConfig() {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if o.RootCAs != nil {
+			t.TLSClientConfig.RootCAs = o.RootCAs
+		}
+		t.TLSClientConfig.Certificates = append(t.TLSClientConfig.Certificates, o.ClientCertificates...)
+		t.TLSClientConfig.InsecureSkipVerify = o.InsecureSkipTLSVerify // #nosec G402 -- only when asked for with WithInsecureSkipTLSVerify
+		if len(o.PinnedCertFingerprints) > 0 {
+			t.TLSClientConfig.VerifyPeerCertificate = o.verifyPinnedCertificate
+		}
+	}
+
+	if o.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto map disables HTTP/2. The cloned TLS config may
+		// already advertise h2 through ALPN, so that is reset as well.
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if t.TLSClientConfig != nil {
+			t.TLSClientConfig.NextProtos = nil
+		}
+	}
+
+	return t
+}
+
+// needsTransport reports whether any of the options requires a dedicated transport.
+func (o *Options) needsTransport() bool {
+	return o.BlockPrivateNetworks || o.Resolver != nil || len(o.HostMapping) > 0 || o.DisableHTTP2 || o.MaxConnsPerHost > 0 || o.MaxResponseHeaderBytes > 0 || o.usesTLSConfig()
+}
+
+// usesTLSConfig reports whether any of the options customizes the TLS configuration.
+func (o *Options) usesTLSConfig() bool {
+	return o.RootCAs != nil || len(o.ClientCertificates) > 0 || o.InsecureSkipTLSVerify || len(o.PinnedCertFingerprints) > 0
+}
+
+// verifyPinnedCertificate fails with ErrCertPinMismatch unless one of the certificates
+// presented by the server has one of the pinned SHA-256 fingerprints. It is called after,
+// and in addition to, the usual verification of the certificate chain.
+func (o *Options) verifyPinnedCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	for _, der := range rawCerts {
+		sum := sha256.Sum256(der)
+		fingerprint := hex.EncodeToString(sum[:])
+		for _, pin := range o.PinnedCertFingerprints {
+			if normalizeFingerprint(pin) == fingerprint {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: no certificate presented by the server matches a pinned fingerprint", ErrCertPinMismatch)
+}
+
+// normalizeFingerprint returns the fingerprint in lower case hex without colons, as in the
+// output of "openssl x509 -noout -fingerprint -sha256".
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// OwnsTransport reports whether rt was built for requests made under o alone, in which
+// case its connections should be closed once those requests are done. Transports shared
+// through the client cache are not.
+func (o *Options) OwnsTransport(rt http.RoundTripper) bool {
+	_, cached := rt.(cachingTransport)
+	return o.HTTPTransport == nil && rt != http.DefaultTransport && !cached
+}
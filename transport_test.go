@@ -0,0 +1,218 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gogather
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTransport_Default tests that the default transport is shared when no option requires a dedicated one.
+func TestTransport_Default(t *testing.T) {
+	if NewOptions().Transport() != http.DefaultTransport {
+		t.Errorf("Expected http.DefaultTransport to be used")
+	}
+}
+
+// TestTransport_HTTP2Disabled tests that WithHTTP2Disabled restricts requests to HTTP/1.1.
+func TestTransport_HTTP2Disabled(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	testCases := []struct {
+		disabled      bool
+		expectedProto int
+	}{
+		{disabled: false, expectedProto: 2},
+		{disabled: true, expectedProto: 1},
+	}
+
+	for _, tc := range testCases {
+		transport := NewOptions(WithHTTP2Disabled(tc.disabled)).Transport().(*http.Transport).Clone()
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = pool
+
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != tc.expectedProto {
+			t.Errorf("Expected HTTP/%d with WithHTTP2Disabled(%t), but got %s", tc.expectedProto, tc.disabled, resp.Proto)
+		}
+	}
+}
+
+// TestTransport_MaxConnsPerHost tests that WithMaxConnsPerHost limits the connections of the transport.
+func TestTransport_MaxConnsPerHost(t *testing.T) {
+	o := NewOptions(WithMaxConnsPerHost(2))
+	transport, ok := o.Transport().(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected a dedicated transport")
+	}
+	if transport.MaxConnsPerHost != 2 {
+		t.Errorf("Expected MaxConnsPerHost to be 2, but got %d", transport.MaxConnsPerHost)
+	}
+	if !o.OwnsTransport(transport) {
+		t.Errorf("Expected the dedicated transport to be owned by the options")
+	}
+}
+
+// TestTransport_Shared tests that a transport set with WithHTTPTransport is used as is.
+func TestTransport_Shared(t *testing.T) {
+	shared := &http.Transport{}
+	o := NewOptions(WithHTTPTransport(shared), WithMaxConnsPerHost(2))
+	if o.Transport() != shared {
+		t.Errorf("Expected the shared transport to be used")
+	}
+	if o.OwnsTransport(shared) {
+		t.Errorf("Expected the shared transport not to be owned by the options")
+	}
+}
+
+// newClientCert returns a self-signed certificate for client authentication.
+func newClientCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// TestTransport_TLS tests that the TLS options configure the verification of the server and the client certificate.
+func TestTransport_TLS(t *testing.T) {
+	clientCert := newClientCert(t)
+	clientCA, err := x509.ParseCertificate(clientCert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPool := x509.NewCertPool()
+	clientPool.AddCert(clientCA)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientPool}
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	testCases := []struct {
+		name    string
+		opts    []Option
+		success bool
+	}{
+		{name: "untrusted server", opts: []Option{WithClientCert(clientCert)}},
+		{name: "no client certificate", opts: []Option{WithRootCAs(pool)}},
+		{name: "root CAs and client certificate", opts: []Option{WithRootCAs(pool), WithClientCert(clientCert)}, success: true},
+		{name: "insecure", opts: []Option{WithInsecureSkipTLSVerify(true), WithClientCert(clientCert)}, success: true},
+	}
+
+	for _, tc := range testCases {
+		o := NewOptions(tc.opts...)
+		transport := o.Transport()
+		if !o.OwnsTransport(transport) {
+			t.Errorf("%s: expected a dedicated transport", tc.name)
+		}
+
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if tc.success && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !tc.success && err == nil {
+			t.Errorf("%s: expected the request to fail", tc.name)
+		}
+	}
+}
+
+// TestTransport_PinnedCertFingerprints tests that connections fail with ErrCertPinMismatch
+// unless the server presents a pinned certificate.
+func TestTransport_PinnedCertFingerprints(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	sum := sha256.Sum256(server.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+	var colons []string
+	for _, b := range sum {
+		colons = append(colons, strings.ToUpper(hex.EncodeToString([]byte{b})))
+	}
+	other := strings.Repeat("ab", sha256.Size)
+
+	testCases := []struct {
+		name    string
+		opts    []Option
+		success bool
+	}{
+		{name: "pinned", opts: []Option{WithRootCAs(pool), WithPinnedCertFingerprints([]string{fingerprint})}, success: true},
+		{name: "openssl format", opts: []Option{WithRootCAs(pool), WithPinnedCertFingerprints([]string{strings.Join(colons, ":")})}, success: true},
+		{name: "one of several", opts: []Option{WithRootCAs(pool), WithPinnedCertFingerprints([]string{other, fingerprint})}, success: true},
+		{name: "mismatch", opts: []Option{WithRootCAs(pool), WithPinnedCertFingerprints([]string{other})}},
+		{name: "mismatch without verification", opts: []Option{WithInsecureSkipTLSVerify(true), WithPinnedCertFingerprints([]string{other})}},
+	}
+
+	for _, tc := range testCases {
+		resp, err := (&http.Client{Transport: NewOptions(tc.opts...).Transport()}).Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if tc.success && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !tc.success && !errors.Is(err, ErrCertPinMismatch) {
+			t.Errorf("%s: expected ErrCertPinMismatch, but got: %v", tc.name, err)
+		}
+	}
+}